@@ -29,6 +29,7 @@ type ABI struct {
 	Constructor Method
 	Methods     map[string]Method
 	Events      map[string]Event
+	Errors      map[string]Error
 }
 
 // JSON returns a parsed ABI interface and error if it failed.
@@ -97,13 +98,15 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) (err error) {
 
 func (abi *ABI) UnmarshalJSON(data []byte) error {
 	var fields []struct {
-		Type      string
-		Name      string
-		Constant  bool
-		Indexed   bool
-		Anonymous bool
-		Inputs    []Argument
-		Outputs   []Argument
+		Type            string
+		Name            string
+		Constant        bool
+		Payable         bool
+		StateMutability string
+		Indexed         bool
+		Anonymous       bool
+		Inputs          []Argument
+		Outputs         []Argument
 	}
 
 	if err := json.Unmarshal(data, &fields); err != nil {
@@ -112,17 +115,24 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 
 	abi.Methods = make(map[string]Method)
 	abi.Events = make(map[string]Event)
+	abi.Errors = make(map[string]Error)
 	for _, field := range fields {
+		// Newer compilers only report payability through the state mutability
+		if field.StateMutability == "payable" {
+			field.Payable = true
+		}
 		switch field.Type {
 		case "constructor":
 			abi.Constructor = Method{
-				Inputs: field.Inputs,
+				Payable: field.Payable,
+				Inputs:  field.Inputs,
 			}
 		// empty defaults to function according to the abi spec
 		case "function", "":
 			abi.Methods[field.Name] = Method{
 				Name:    field.Name,
 				Const:   field.Constant,
+				Payable: field.Payable,
 				Inputs:  field.Inputs,
 				Outputs: field.Outputs,
 			}
@@ -132,6 +142,11 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs,
 			}
+		case "error":
+			abi.Errors[field.Name] = Error{
+				Name:   field.Name,
+				Inputs: field.Inputs,
+			}
 		}
 	}
 
@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
 	"github.com/fairblock/go-fairblock/crypto"
 )
 
@@ -54,14 +55,14 @@ const jsondata2 = `
 ]`
 
 func TestReader(t *testing.T) {
-	Uint256, _ := NewType("uint256")
+	Uint256, _ := NewType("uint256", "", nil)
 	exp := ABI{
 		Methods: map[string]Method{
 			"balance": {
-				"balance", true, nil, nil,
+				"balance", true, false, nil, nil,
 			},
 			"send": {
-				"send", false, []Argument{
+				"send", false, false, []Argument{
 					{"amount", Uint256, false},
 				}, nil,
 			},
@@ -160,8 +161,8 @@ func TestTestSlice(t *testing.T) {
 }
 
 func TestMethodSignature(t *testing.T) {
-	String, _ := NewType("string")
-	m := Method{"foo", false, []Argument{{"bar", String, false}, {"baz", String, false}}, nil}
+	String, _ := NewType("string", "", nil)
+	m := Method{"foo", false, false, []Argument{{"bar", String, false}, {"baz", String, false}}, nil}
 	exp := "foo(string,string)"
 	if m.Sig() != exp {
 		t.Error("signature mismatch", exp, "!=", m.Sig())
@@ -172,8 +173,8 @@ func TestMethodSignature(t *testing.T) {
 		t.Errorf("expected ids to match %x != %x", m.Id(), idexp)
 	}
 
-	uintt, _ := NewType("uint256")
-	m = Method{"foo", false, []Argument{{"bar", uintt, false}}, nil}
+	uintt, _ := NewType("uint256", "", nil)
+	m = Method{"foo", false, false, []Argument{{"bar", uintt, false}}, nil}
 	exp = "foo(uint256)"
 	if m.Sig() != exp {
 		t.Error("signature mismatch", exp, "!=", m.Sig())
@@ -368,8 +369,8 @@ func TestBareEvents(t *testing.T) {
 	{ "type" : "event", "name" : "args", "inputs" : [{ "indexed":false, "name":"arg0", "type":"uint256" }, { "indexed":true, "name":"arg1", "type":"address" }] }
 	]`
 
-	arg0, _ := NewType("uint256")
-	arg1, _ := NewType("address")
+	arg0, _ := NewType("uint256", "", nil)
+	arg1, _ := NewType("address", "", nil)
 
 	expectedEvents := map[string]struct {
 		Anonymous bool
@@ -418,3 +419,65 @@ func TestBareEvents(t *testing.T) {
 		}
 	}
 }
+
+func TestUnpackRevert(t *testing.T) {
+	// Assemble the revert payloads by packing methods with the same signatures
+	const definition = `[
+	{ "type" : "function", "name" : "Error", "inputs" : [ { "name" : "reason", "type" : "string" } ] },
+	{ "type" : "function", "name" : "Panic", "inputs" : [ { "name" : "code", "type" : "uint256" } ] },
+	{ "type" : "function", "name" : "Unauthorized", "inputs" : [ { "name" : "caller", "type" : "address" }, { "name" : "level", "type" : "uint8" } ] },
+	{ "type" : "error", "name" : "Unauthorized", "inputs" : [ { "name" : "caller", "type" : "address" }, { "name" : "level", "type" : "uint8" } ] }
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack := func(name string, args ...interface{}) []byte {
+		data, err := abi.Pack(name, args...)
+		if err != nil {
+			t.Fatalf("failed to pack %s: %v", name, err)
+		}
+		return data
+	}
+	tests := []struct {
+		data   []byte
+		reason string
+		custom bool
+	}{
+		{pack("Error", "insufficient funds"), "insufficient funds", false},
+		{pack("Panic", big.NewInt(0x11)), "panic: arithmetic underflow or overflow (0x11)", false},
+		{pack("Panic", big.NewInt(0x99)), "panic: unknown code 0x99", false},
+		{pack("Unauthorized", common.Address{1}, uint8(2)), "Unauthorized(" + common.Address{1}.Hex() + ", 2)", true},
+	}
+	for i, tt := range tests {
+		reason, err := UnpackRevert(tt.data)
+		if tt.custom {
+			if err == nil {
+				t.Errorf("test %d: custom error decoded without the abi: %q", i, reason)
+			}
+		} else if err != nil || reason != tt.reason {
+			t.Errorf("test %d: reason mismatch: have %q (%v), want %q", i, reason, err, tt.reason)
+		}
+		if reason, err = abi.UnpackRevert(tt.data); err != nil || reason != tt.reason {
+			t.Errorf("test %d: abi reason mismatch: have %q (%v), want %q", i, reason, err, tt.reason)
+		}
+	}
+	if _, err := abi.UnpackRevert([]byte{1, 2, 3, 4}); err == nil {
+		t.Errorf("unknown selector decoded")
+	}
+	if _, err := UnpackRevert(nil); err == nil {
+		t.Errorf("empty payload decoded")
+	}
+	if sig := abi.Errors["Unauthorized"].String(); sig != "error Unauthorized(address caller, uint8 level)" {
+		t.Errorf("error signature mismatch: have %q", sig)
+	}
+	// Reverted errors carry the decoded reason, the error code and the payload
+	data := pack("Error", "insufficient funds")
+	rerr := NewRevertedError(data)
+	if rerr.Error() != "execution reverted: insufficient funds" || rerr.ErrorCode() != 3 || rerr.ErrorData() != hexutil.Encode(data) {
+		t.Errorf("reverted error mismatch: have %q, code %d, data %v", rerr.Error(), rerr.ErrorCode(), rerr.ErrorData())
+	}
+	if rerr := NewRevertedError(nil); rerr.Error() != "execution reverted" || rerr.ErrorData() != "0x" {
+		t.Errorf("empty reverted error mismatch: have %q, data %v", rerr.Error(), rerr.ErrorData())
+	}
+}
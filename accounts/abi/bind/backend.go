@@ -39,8 +39,28 @@ var (
 	// This error is returned by WaitDeployed if contract creation leaves an
 	// empty contract behind.
 	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+
+	// This error is returned when attempting to filter or watch events on a
+	// contract bound without a ContractFilterer.
+	ErrNoFilterer = errors.New("contract is not bound to an event filterer")
 )
 
+// RevertError is returned by contract calls and transactions whose execution
+// was reverted by the EVM, carrying the revert payload and the reason decoded
+// from it, if any.
+type RevertError struct {
+	Reason string // Revert reason, or the custom error and its arguments (empty if undecodable)
+	Data   []byte // Raw revert payload returned by the EVM
+}
+
+// Error implements error, including the decoded reason in the message.
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
 // ContractCaller defines the methods needed to allow operating with contract on a read
 // only basis.
 type ContractCaller interface {
@@ -90,8 +110,21 @@ type ContractTransactor interface {
 	SendTransaction(ctx context.Context, tx *types.Transaction) error
 }
 
+// ContractFilterer defines the methods needed to access log events using one-off
+// queries or continuous event subscriptions.
+type ContractFilterer interface {
+	// FilterLogs executes a log filter operation, blocking during execution and
+	// returning all the results in one batch.
+	FilterLogs(ctx context.Context, query fairblock.FilterQuery) ([]types.Log, error)
+
+	// SubscribeFilterLogs creates a background log filtering operation, returning
+	// a subscription immediately, which can be used to stream the found events.
+	SubscribeFilterLogs(ctx context.Context, query fairblock.FilterQuery, ch chan<- types.Log) (fairblock.Subscription, error)
+}
+
 // ContractBackend defines the methods needed to work with contracts on a read-write basis.
 type ContractBackend interface {
 	ContractCaller
 	ContractTransactor
+	ContractFilterer
 }
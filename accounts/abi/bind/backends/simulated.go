@@ -18,6 +18,7 @@ package backends
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
@@ -25,16 +26,22 @@ import (
 	"time"
 
 	"github.com/fairblock/go-fairblock"
+	"github.com/fairblock/go-fairblock/accounts/abi"
 	"github.com/fairblock/go-fairblock/accounts/abi/bind"
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/common/math"
 	"github.com/fairblock/go-fairblock/consensus/fbcash"
 	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/bloombits"
 	"github.com/fairblock/go-fairblock/core/state"
 	"github.com/fairblock/go-fairblock/core/types"
 	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/fbc/filters"
 	"github.com/fairblock/go-fairblock/fbcdb"
 	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rpc"
 )
 
 // This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
@@ -42,6 +49,11 @@ var _ bind.ContractBackend = (*SimulatedBackend)(nil)
 
 var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
 var errGasEstimationFailed = errors.New("gas required exceeds allowance or always failing transaction")
+var errPendingTransactions = errors.New("SimulatedBackend cannot fork or rewind with pending transactions")
+
+// faucetBalance is the amount of wei the internal faucet account is seeded with
+// in genesis to be able to fund arbitrary accounts after construction.
+var faucetBalance = new(big.Int).Lsh(big.NewInt(1), 255)
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
@@ -53,18 +65,35 @@ type SimulatedBackend struct {
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
 
+	events *filters.EventSystem // Event system for filtering log events live
+	faucet *ecdsa.PrivateKey    // Internal account used to fund accounts after construction
+
 	config *params.ChainConfig
 }
 
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
 // for testing purposes.
 func NewSimulatedBackend(alloc core.GenesisAlloc) *SimulatedBackend {
+	// Seed an internal faucet account next to the requested allocations
+	faucet, _ := crypto.GenerateKey()
+
+	accounts := core.GenesisAlloc{crypto.PubkeyToAddress(faucet.PublicKey): {Balance: faucetBalance}}
+	for addr, account := range alloc {
+		accounts[addr] = account
+	}
 	database, _ := fbcdb.NewMemDatabase()
-	genesis := core.Genesis{Config: params.AllFbcashProtocolChanges, Alloc: alloc}
+	genesis := core.Genesis{Config: params.AllFbcashProtocolChanges, Alloc: accounts}
 	genesis.MustCommit(database)
 	blockchain, _ := core.NewBlockChain(database, genesis.Config, fbcash.NewFaker(), vm.Config{})
-	backend := &SimulatedBackend{database: database, blockchain: blockchain, config: genesis.Config}
-	backend.rollback()
+
+	backend := &SimulatedBackend{
+		database:   database,
+		blockchain: blockchain,
+		faucet:     faucet,
+		config:     genesis.Config,
+	}
+	backend.events = filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false)
+	backend.rollback(blockchain.CurrentBlock())
 	return backend
 }
 
@@ -77,7 +106,82 @@ func (b *SimulatedBackend) Commit() {
 	if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
 		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
 	}
-	b.rollback()
+	// Continue building on the committed block, even if it's on a side chain
+	b.rollback(b.pendingBlock)
+}
+
+// CommitEmpty imports a number of empty blocks on top of the pending block's
+// parent, retaining any pending transactions for the block after them.
+func (b *SimulatedBackend) CommitEmpty(blocks int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parent := b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash())
+	empty, _ := core.GenerateChain(b.config, parent, b.database, blocks, func(int, *core.BlockGen) {})
+	if _, err := b.blockchain.InsertChain(empty); err != nil {
+		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+	}
+	if len(empty) > 0 {
+		parent = empty[len(empty)-1]
+	}
+	b.regenerate(parent, b.pendingBlock.Transactions(), 0)
+}
+
+// Fork sets the pending block to be built on top of an earlier block, allowing
+// to create an alternate chain. The fork becomes canonical as soon as it is
+// longer than the current chain. Any pending transactions need to be either
+// committed or rolled back first.
+func (b *SimulatedBackend) Fork(ctx context.Context, parent common.Hash) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pendingBlock.Transactions()) != 0 {
+		return errPendingTransactions
+	}
+	block := b.blockchain.GetBlockByHash(parent)
+	if block == nil {
+		return errors.New("parent block not found")
+	}
+	b.rollback(block)
+	return nil
+}
+
+// Rewind discards all blocks above the given number, resetting the chain head
+// and the pending block onto it. Any pending transactions need to be either
+// committed or rolled back first.
+func (b *SimulatedBackend) Rewind(number uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pendingBlock.Transactions()) != 0 {
+		return errPendingTransactions
+	}
+	if number > b.blockchain.CurrentBlock().NumberU64() {
+		return fmt.Errorf("cannot rewind forward to block %d", number)
+	}
+	if err := b.blockchain.SetHead(number); err != nil {
+		return err
+	}
+	b.rollback(b.blockchain.CurrentBlock())
+	return nil
+}
+
+// Fund transfers the given amount of wei from an internal faucet account to an
+// arbitrary address. As with any other transaction, the funds are available in
+// the pending state immediately and in the chain state after a Commit.
+func (b *SimulatedBackend) Fund(ctx context.Context, addr common.Address, amount *big.Int) error {
+	// Hold the lock until the transfer is pending, concurrent calls would race
+	// for the same faucet nonce otherwise
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	nonce := b.pendingState.GetNonce(crypto.PubkeyToAddress(b.faucet.PublicKey))
+	tx, err := types.SignTx(types.NewTransaction(nonce, addr, amount, new(big.Int).SetUint64(params.TxGas), new(big.Int), nil), types.HomesteadSigner{}, b.faucet)
+	if err != nil {
+		return err
+	}
+	b.sendTransaction(tx)
+	return nil
 }
 
 // Rollback aborts all pending transactions, reverting to the last committed state.
@@ -85,11 +189,25 @@ func (b *SimulatedBackend) Rollback() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.rollback()
+	b.rollback(b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash()))
 }
 
-func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(int, *core.BlockGen) {})
+// rollback discards the pending block, starting a fresh empty one on top of parent.
+func (b *SimulatedBackend) rollback(parent *types.Block) {
+	b.regenerate(parent, nil, 0)
+}
+
+// regenerate recreates the pending block on top of parent with the given
+// transactions, shifting its timestamp by offset seconds.
+func (b *SimulatedBackend) regenerate(parent *types.Block, txs types.Transactions, offset int64) {
+	blocks, _ := core.GenerateChain(b.config, parent, b.database, 1, func(number int, block *core.BlockGen) {
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+		if offset != 0 {
+			block.OffsetTime(offset)
+		}
+	})
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
 }
@@ -169,7 +287,10 @@ func (b *SimulatedBackend) CallContract(ctx context.Context, call fairblock.Call
 	if err != nil {
 		return nil, err
 	}
-	rval, _, _, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), state)
+	rval, _, failed, err := b.callContract(ctx, call, b.blockchain.CurrentBlock(), state)
+	if err == nil && failed && len(rval) > 0 {
+		return nil, abi.NewRevertedError(rval)
+	}
 	return rval, err
 }
 
@@ -179,7 +300,10 @@ func (b *SimulatedBackend) PendingCallContract(ctx context.Context, call fairblo
 	defer b.mu.Unlock()
 	defer b.pendingState.RevertToSnapshot(b.pendingState.Snapshot())
 
-	rval, _, _, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
+	rval, _, failed, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
+	if err == nil && failed && len(rval) > 0 {
+		return nil, abi.NewRevertedError(rval)
+	}
 	return rval, err
 }
 
@@ -217,23 +341,27 @@ func (b *SimulatedBackend) EstimateGas(ctx context.Context, call fairblock.CallM
 	}
 	cap = hi
 
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	// Create a helper to check if a gas allowance results in an executable transaction,
+	// also returning the revert payload if the execution was reverted
+	executable := func(gas uint64) (bool, []byte) {
 		call.Gas = new(big.Int).SetUint64(gas)
 
 		snapshot := b.pendingState.Snapshot()
-		_, _, failed, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
+		rval, _, failed, err := b.callContract(ctx, call, b.pendingBlock, b.pendingState)
 		b.pendingState.RevertToSnapshot(snapshot)
 
-		if err != nil || failed {
-			return false
+		if err != nil {
+			return false, nil
+		}
+		if failed {
+			return false, rval
 		}
-		return true
+		return true, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -241,7 +369,10 @@ func (b *SimulatedBackend) EstimateGas(ctx context.Context, call fairblock.CallM
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, revert := executable(hi); !ok {
+			if len(revert) > 0 {
+				return nil, abi.NewRevertedError(revert)
+			}
 			return nil, errGasEstimationFailed
 		}
 	}
@@ -282,6 +413,13 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	b.sendTransaction(tx)
+	return nil
+}
+
+// sendTransaction adds a transaction to the pending block. The caller must hold
+// the backend lock.
+func (b *SimulatedBackend) sendTransaction(tx *types.Transaction) {
 	sender, err := types.Sender(types.HomesteadSigner{}, tx)
 	if err != nil {
 		panic(fmt.Errorf("invalid transaction: %v", err))
@@ -291,30 +429,104 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
+	txs := append(b.pendingBlock.Transactions(), tx)
+	b.regenerate(b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash()), txs, 0)
+}
+
+// FilterLogs executes a log filter operation, blocking during execution and
+// returning all the results in one batch.
+func (b *SimulatedBackend) FilterLogs(ctx context.Context, query fairblock.FilterQuery) ([]types.Log, error) {
+	// Initialize unset filter boundaries to run from genesis to chain head
+	from := int64(0)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	to := int64(-1)
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	// Construct and execute the filter
+	filter := filters.New(&filterBackend{b.database, b.blockchain}, from, to, query.Addresses, query.Topics)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs creates a background log filtering operation, returning a
+// subscription immediately, which can be used to stream the found events.
+func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query fairblock.FilterQuery, ch chan<- types.Log) (fairblock.Subscription, error) {
+	// Subscribe to contract events
+	sink := make(chan []*types.Log)
+
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), sink)
+	if err != nil {
+		return nil, err
+	}
+	// Since we're getting logs in batches, we need to flatten them into a plain stream
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
 		}
-		block.AddTx(tx)
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
-	return nil
+	}), nil
+}
+
+// SubscribeNewHead returns an event subscription for a new header imported as
+// the head of the canonical chain.
+func (b *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (fairblock.Subscription, error) {
+	// Subscribe to chain head events
+	sink := make(chan *types.Header)
+	sub := b.events.SubscribeNewHeads(sink)
+
+	// Forward the headers to the user until the subscription is torn down
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-sink:
+				select {
+				case ch <- head:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
 }
 
 // JumpTimeInSeconds adds skip seconds to the clock
 func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
-		}
-		block.OffsetTime(int64(adjustment.Seconds()))
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
-
+	b.regenerate(b.blockchain.GetBlockByHash(b.pendingBlock.ParentHash()), b.pendingBlock.Transactions(), int64(adjustment.Seconds()))
 	return nil
 }
 
@@ -331,3 +543,45 @@ func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callmsg) Gas() *big.Int        { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+
+// filterBackend implements filters.Backend to support filtering for logs without
+// taking bloom-bits acceleration structures into account.
+type filterBackend struct {
+	db fbcdb.Database
+	bc *core.BlockChain
+}
+
+func (fb *filterBackend) ChainDb() fbcdb.Database  { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
+
+func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
+	if block == rpc.LatestBlockNumber {
+		return fb.bc.CurrentHeader(), nil
+	}
+	return fb.bc.GetHeaderByNumber(uint64(block.Int64())), nil
+}
+
+func (fb *filterBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(fb.db, hash, core.GetBlockNumber(fb.db, hash)), nil
+}
+
+func (fb *filterBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return fb.bc.SubscribeChainEvent(ch)
+}
+func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return fb.bc.SubscribeRemovedLogsEvent(ch)
+}
+func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return fb.bc.SubscribeLogsEvent(ch)
+}
+
+func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
+	panic("not supported")
+}
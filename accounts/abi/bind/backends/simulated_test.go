@@ -0,0 +1,160 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+)
+
+// Tests that accounts can be funded after the simulator is constructed.
+func TestSimulatedFund(t *testing.T) {
+	sim := NewSimulatedBackend(nil)
+	addr := common.Address{1}
+
+	if err := sim.Fund(context.Background(), addr, big.NewInt(1000)); err != nil {
+		t.Fatalf("failed to fund account: %v", err)
+	}
+	if err := sim.Fund(context.Background(), addr, big.NewInt(24)); err != nil {
+		t.Fatalf("failed to fund account: %v", err)
+	}
+	sim.Commit()
+
+	if balance, _ := sim.BalanceAt(context.Background(), addr, nil); balance.Cmp(big.NewInt(1024)) != 0 {
+		t.Fatalf("balance mismatch: have %v, want 1024", balance)
+	}
+}
+
+// Tests that concurrent funding doesn't race for the faucet nonce.
+func TestSimulatedFundConcurrent(t *testing.T) {
+	sim := NewSimulatedBackend(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := sim.Fund(context.Background(), common.Address{byte(i + 1)}, big.NewInt(1)); err != nil {
+				t.Errorf("failed to fund account %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	sim.Commit()
+
+	for i := 0; i < 8; i++ {
+		if balance, _ := sim.BalanceAt(context.Background(), common.Address{byte(i + 1)}, nil); balance.Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("account %d: balance mismatch: have %v, want 1", i, balance)
+		}
+	}
+}
+
+// Tests that empty blocks can be mined, keeping pending transactions around.
+func TestSimulatedCommitEmpty(t *testing.T) {
+	sim := NewSimulatedBackend(nil)
+	addr := common.Address{1}
+
+	sim.Fund(context.Background(), addr, big.NewInt(1))
+	sim.CommitEmpty(3)
+
+	if head := sim.blockchain.CurrentBlock(); head.NumberU64() != 3 {
+		t.Fatalf("head number mismatch: have %d, want 3", head.NumberU64())
+	}
+	if balance, _ := sim.BalanceAt(context.Background(), addr, nil); balance.Sign() != 0 {
+		t.Fatalf("pending transfer mined into empty block")
+	}
+	sim.Commit()
+	if balance, _ := sim.BalanceAt(context.Background(), addr, nil); balance.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("balance mismatch after commit: have %v, want 1", balance)
+	}
+}
+
+// Tests that the chain can be rewound, discarding state changes above the new head.
+func TestSimulatedRewind(t *testing.T) {
+	sim := NewSimulatedBackend(nil)
+	addr := common.Address{1}
+
+	sim.CommitEmpty(2)
+	sim.Fund(context.Background(), addr, big.NewInt(1))
+	if err := sim.Rewind(1); err != errPendingTransactions {
+		t.Fatalf("rewind error mismatch: have %v, want %v", err, errPendingTransactions)
+	}
+	sim.Commit()
+
+	if err := sim.Rewind(1); err != nil {
+		t.Fatalf("failed to rewind: %v", err)
+	}
+	if head := sim.blockchain.CurrentBlock(); head.NumberU64() != 1 {
+		t.Fatalf("head number mismatch: have %d, want 1", head.NumberU64())
+	}
+	if balance, _ := sim.BalanceAt(context.Background(), addr, nil); balance.Sign() != 0 {
+		t.Fatalf("rewound transfer still in state")
+	}
+	if err := sim.Rewind(5); err == nil {
+		t.Fatalf("rewound forward")
+	}
+}
+
+// Tests that an alternate chain can be forked off, becoming canonical once longer.
+func TestSimulatedFork(t *testing.T) {
+	sim := NewSimulatedBackend(nil)
+	addr := common.Address{1}
+
+	heads := make(chan *types.Header, 16)
+	sub, err := sim.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe to heads: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Fund an account on the main chain, then fork it off from genesis
+	genesis := sim.blockchain.CurrentBlock()
+	sim.Fund(context.Background(), addr, big.NewInt(1))
+	sim.Commit()
+
+	if err := sim.Fork(context.Background(), genesis.Hash()); err != nil {
+		t.Fatalf("failed to fork: %v", err)
+	}
+	sim.Commit()
+	sim.Commit()
+	if head := sim.blockchain.CurrentBlock(); head.NumberU64() != 2 {
+		t.Fatalf("head number mismatch: have %d, want 2", head.NumberU64())
+	}
+	if balance, _ := sim.BalanceAt(context.Background(), addr, nil); balance.Sign() != 0 {
+		t.Fatalf("funds remained after reorg: %v", balance)
+	}
+	// Ensure the head subscription reported the new canonical head
+	for {
+		select {
+		case head := <-heads:
+			if head.Number.Uint64() == 2 {
+				if head.Hash() != sim.blockchain.CurrentBlock().Hash() {
+					t.Fatalf("head hash mismatch: have %x, want %x", head.Hash(), sim.blockchain.CurrentBlock().Hash())
+				}
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("new head not delivered")
+		}
+	}
+}
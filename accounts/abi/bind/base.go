@@ -25,8 +25,10 @@ import (
 	"github.com/fairblock/go-fairblock"
 	"github.com/fairblock/go-fairblock/accounts/abi"
 	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
 	"github.com/fairblock/go-fairblock/core/types"
 	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/event"
 )
 
 // SignerFn is a signer function callback when a contract requires a method to
@@ -55,6 +57,23 @@ type TransactOpts struct {
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	Start *uint64 // Start of the queried range (nil = latest)
+
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Fairblock network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
@@ -63,16 +82,18 @@ type BoundContract struct {
 	abi        abi.ABI            // Reflect based ABI to access the correct Fairblock methods
 	caller     ContractCaller     // Read interface to interact with the blockchain
 	transactor ContractTransactor // Write interface to interact with the blockchain
+	filterer   ContractFilterer   // Event filtering to interact with the blockchain
 }
 
-// NewBoundContract creates a low level contract interface through which calls
-// and transactions may be made through.
-func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor) *BoundContract {
+// NewBoundContract creates a low level contract interface through which calls,
+// transactions and event filtering may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
 	return &BoundContract{
 		address:    address,
 		abi:        abi,
 		caller:     caller,
 		transactor: transactor,
+		filterer:   filterer,
 	}
 }
 
@@ -80,7 +101,7 @@ func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller
 // deployment address with a Go wrapper.
 func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
 	// Otherwise try to deploy the contract
-	c := NewBoundContract(common.Address{}, abi, backend, backend)
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
 
 	input, err := c.abi.Pack("", params...)
 	if err != nil {
@@ -140,7 +161,7 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 		}
 	}
 	if err != nil {
-		return err
+		return c.revertError(err)
 	}
 	return c.abi.Unpack(result, method, output)
 }
@@ -202,6 +223,9 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 		msg := fairblock.CallMsg{From: opts.From, To: contract, Value: value, Data: input}
 		gasLimit, err = c.transactor.EstimateGas(ensureContext(opts.Context), msg)
 		if err != nil {
+			if rerr, ok := c.revertError(err).(*RevertError); ok {
+				return nil, rerr
+			}
 			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
 		}
 	}
@@ -225,6 +249,138 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	return signedTx, nil
 }
 
+// FilterLogs filters contract logs for past blocks, returning the necessary
+// channels to construct a strongly typed bound iterator on top of them.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	config, err := c.filterQuery(name, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.FromBlock = new(big.Int).SetUint64(opts.Start)
+	if opts.End != nil {
+		config.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	buff, err := c.filterer.FilterLogs(ensureContext(opts.Context), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Feed the results through a channel, mimicking a live subscription
+	logs := make(chan types.Log, 128)
+	sub := event.NewSubscription(func(quit <-chan struct{}) error {
+		for _, log := range buff {
+			select {
+			case logs <- log:
+			case <-quit:
+				return nil
+			}
+		}
+		return nil
+	})
+	return logs, sub, nil
+}
+
+// WatchLogs filters subscribes to contract logs for future blocks, returning a
+// subscription object that can be used to tear down the watcher.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	config, err := c.filterQuery(name, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Start != nil {
+		config.FromBlock = new(big.Int).SetUint64(*opts.Start)
+	}
+	logs := make(chan types.Log, 128)
+	sub, err := c.filterer.SubscribeFilterLogs(ensureContext(opts.Context), config, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, sub, nil
+}
+
+// filterQuery assembles the log filter for the named event of the contract,
+// restricting the indexed fields to the given query values.
+func (c *BoundContract) filterQuery(name string, query [][]interface{}) (fairblock.FilterQuery, error) {
+	if c.filterer == nil {
+		return fairblock.FilterQuery{}, ErrNoFilterer
+	}
+	ev, ok := c.abi.Events[name]
+	if !ok {
+		return fairblock.FilterQuery{}, fmt.Errorf("event '%s' not found", name)
+	}
+	// Prepend the event selector to the query parameters and construct the topic set
+	topics, err := makeTopics(append([][]interface{}{{ev.Id()}}, query...)...)
+	if err != nil {
+		return fairblock.FilterQuery{}, err
+	}
+	return fairblock.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}, nil
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure, the
+// non-indexed fields from the log data and the indexed ones from its topics.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log) error {
+	ev, ok := c.abi.Events[event]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", event)
+	}
+	if len(log.Topics) == 0 || log.Topics[0] != ev.Id() {
+		return fmt.Errorf("log is not a '%s' event", event)
+	}
+	var indexed []abi.Argument
+	for i, arg := range ev.Inputs {
+		if arg.Indexed {
+			if arg.Name == "" {
+				// unnamed arguments are bound to the same fields abigen generates
+				arg.Name = fmt.Sprintf("arg%d", i)
+			}
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(indexed) < len(ev.Inputs) {
+		if err := c.abi.Unpack(out, event, log.Data); err != nil {
+			return err
+		}
+	}
+	return parseTopics(out, indexed, log.Topics[1:])
+}
+
+// revertError converts a backend error carrying a revert payload as its error
+// data into a RevertError, decoding the reason with the contract ABI. Any other
+// error is returned unchanged.
+func (c *BoundContract) revertError(err error) error {
+	de, ok := err.(interface {
+		ErrorData() interface{}
+	})
+	if !ok {
+		return err
+	}
+	var data []byte
+	switch payload := de.ErrorData().(type) {
+	case []byte:
+		data = payload
+	case string:
+		decoded, derr := hexutil.Decode(payload)
+		if derr != nil {
+			return err
+		}
+		data = decoded
+	default:
+		return err
+	}
+	reason, _ := c.abi.UnpackRevert(data)
+	return &RevertError{Reason: reason, Data: data}
+}
+
 func ensureContext(ctx context.Context) context.Context {
 	if ctx == nil {
 		return context.TODO()
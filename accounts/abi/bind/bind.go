@@ -22,11 +22,12 @@ package bind
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
-	"unicode"
 
 	"github.com/fairblock/go-fairblock/accounts/abi"
 	"golang.org/x/tools/imports"
@@ -47,7 +48,10 @@ const (
 // manually maintain hard coded strings that break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct)
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -55,13 +59,12 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 		if err != nil {
 			return "", err
 		}
-		// Strip any whitespace from the JSON ABI
-		strippedABI := strings.Map(func(r rune) rune {
-			if unicode.IsSpace(r) {
-				return -1
-			}
-			return r
-		}, abis[i])
+		// Strip any whitespace from the JSON ABI, keeping it within strings
+		compacted := new(bytes.Buffer)
+		if err := json.Compact(compacted, []byte(abis[i])); err != nil {
+			return "", err
+		}
+		strippedABI := compacted.String()
 
 		// Extract the call and transact methods, and sort them alphabetically
 		var (
@@ -94,6 +97,40 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 				transacts[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original)}
 			}
 		}
+		events := make(map[string]*tmplEvent)
+		for _, original := range evmABI.Events {
+			// Skip anonymous events as they don't support explicit filtering
+			if original.Anonymous {
+				continue
+			}
+			// Normalize the event for capital cases and non-anonymous outputs
+			normalized := original
+			normalized.Name = methodNormalizer[lang](original.Name)
+
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			for j, input := range normalized.Inputs {
+				if input.Name == "" {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+			}
+			// Append the event to the accumulator list
+			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
+		}
+		// Generate the structs of all tuples in a deterministic order
+		bindStructs := func(args []abi.Argument) {
+			for _, arg := range args {
+				bindStructType(arg.Type, structs)
+			}
+		}
+		bindStructs(evmABI.Constructor.Inputs)
+		for _, name := range sortedMethodNames(evmABI) {
+			bindStructs(evmABI.Methods[name].Inputs)
+			bindStructs(evmABI.Methods[name].Outputs)
+		}
+		for _, name := range sortedEventNames(evmABI) {
+			bindStructs(evmABI.Events[name].Inputs)
+		}
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
 			InputABI:    strings.Replace(strippedABI, "\"", "\\\"", -1),
@@ -101,17 +138,27 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			Constructor: evmABI.Constructor,
 			Calls:       calls,
 			Transacts:   transacts,
+			Events:      events,
 		}
 	}
+	if len(structs) > 0 && lang != LangGo {
+		return "", fmt.Errorf("tuples are only supported in Go bindings")
+	}
 	// Generate the contract template data content and render it
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype":     bindType[lang],
+		"bindtype": func(kind abi.Type) string {
+			return bindType[lang](kind, structs)
+		},
+		"bindtopictype": func(kind abi.Type) string {
+			return bindTopicType[lang](kind, structs)
+		},
 		"namedtype":    namedType[lang],
 		"capitalise":   capitalise,
 		"decapitalise": decapitalise,
@@ -134,15 +181,24 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language.
-var bindType = map[Lang]func(kind abi.Type) string{
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
 	LangGo:   bindTypeGo,
 	LangJava: bindTypeJava,
 }
 
 // bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
 // from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. *big.Int).
-func bindTypeGo(kind abi.Type) string {
+// mapped will use an upscaled type (e.g. *big.Int). Tuples are mapped to the
+// structs previously generated for them by bindStructType.
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[structKey(kind)].Name
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindTypeGo(*kind.Elem, structs)
+	case abi.SliceTy:
+		return "[]" + bindTypeGo(*kind.Elem, structs)
+	}
 	stringKind := kind.String()
 
 	switch {
@@ -183,10 +239,74 @@ func bindTypeGo(kind abi.Type) string {
 	}
 }
 
+// bindStructType generates a Go struct for the tuple type kind, and for any
+// tuple nested within it, unless one was already generated for the same tuple.
+// Structs are named after the Solidity struct if the ABI reports it.
+func bindStructType(kind abi.Type, structs map[string]*tmplStruct) {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		bindStructType(*kind.Elem, structs)
+		return
+	case abi.TupleTy:
+	default:
+		return
+	}
+	key := structKey(kind)
+	if _, exist := structs[key]; exist {
+		return
+	}
+	fields := make([]*tmplField, len(kind.TupleElems))
+	for i, elem := range kind.TupleElems {
+		bindStructType(*elem, structs)
+		fields[i] = &tmplField{
+			Type:    bindTypeGo(*elem, structs),
+			Name:    abi.ToCamelCase(kind.TupleRawNames[i]),
+			SolKind: *elem,
+		}
+	}
+	name := kind.TupleRawName
+	for _, s := range structs {
+		if s.Name == name {
+			name = "" // same name, different layout
+		}
+	}
+	if name == "" {
+		name = fmt.Sprintf("Struct%d", len(structs))
+	}
+	structs[key] = &tmplStruct{Name: name, Fields: fields}
+}
+
+// structKey identifies the struct generated for a tuple type.
+func structKey(kind abi.Type) string {
+	return kind.TupleRawName + kind.String()
+}
+
+// sortedMethodNames returns the names of the methods of the ABI in alphabetic
+// order.
+func sortedMethodNames(evmABI abi.ABI) []string {
+	names := make([]string, 0, len(evmABI.Methods))
+	for name := range evmABI.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedEventNames returns the names of the events of the contract in
+// alphabetical order.
+func sortedEventNames(evmABI abi.ABI) []string {
+	names := make([]string, 0, len(evmABI.Events))
+	for name := range evmABI.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
 // from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type) string {
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
 	stringKind := kind.String()
 
 	switch {
@@ -254,6 +374,35 @@ func bindTypeJava(kind abi.Type) string {
 	}
 }
 
+// bindTopicType is a set of type binders that convert Solidity types to some
+// supported programming language topic types.
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   bindTopicTypeGo,
+	LangJava: bindTopicTypeJava,
+}
+
+// bindTopicTypeGo converts a Solidity topic type to a Go one. It is almost the
+// same functionality as for simple types, but dynamic types get converted to
+// hashes, as only those are stored in the log topics.
+func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return "common.Hash"
+	}
+	return bindTypeGo(kind, structs)
+}
+
+// bindTopicTypeJava converts a Solidity topic type to a Java one. It is almost
+// the same functionality as for simple types, but dynamic types get converted
+// to hashes, as only those are stored in the log topics.
+func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return "Hash"
+	}
+	return bindTypeJava(kind, structs)
+}
+
 // namedType is a set of functions that transform language specific types to
 // named versions that my be used inside method names.
 var namedType = map[Lang]func(string, abi.Type) string{
@@ -447,6 +447,170 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that tuples are bound to generated structs, named after the Solidity structs if known
+	{
+		`Tuple`,
+		`
+			contract Tuple {
+				struct T { address x; bytes32[] y; }
+				struct S { uint256 a; T[] ts; }
+
+				function get() constant returns (S s, uint256 n);
+				function set(S s, tuple(uint8 p, bool q)[2][] pairs);
+			}
+		`, `61014f600e60003961014f6000f361014061000f6000396101406000f30000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000500000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000200000000000000000000000000100000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000010200000000000000000000000000000000000000000000000000000000000000`,
+		`[{"constant":true,"inputs":[],"name":"get","outputs":[{"components":[{"name":"a","type":"uint256"},{"components":[{"name":"x","type":"address"},{"name":"y","type":"bytes32[]"}],"internalType":"struct Tuple.T[]","name":"ts","type":"tuple[]"}],"internalType":"struct Tuple.S","name":"s","type":"tuple"},{"name":"n","type":"uint256"}],"payable":false,"type":"function"},{"constant":false,"inputs":[{"components":[{"name":"a","type":"uint256"},{"components":[{"name":"x","type":"address"},{"name":"y","type":"bytes32[]"}],"internalType":"struct Tuple.T[]","name":"ts","type":"tuple[]"}],"internalType":"struct Tuple.S","name":"s","type":"tuple"},{"components":[{"name":"p","type":"uint8"},{"name":"q","type":"bool"}],"name":"pairs","type":"tuple[2][]"}],"name":"set","outputs":[],"payable":false,"type":"function"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy a contract answering every call with a fixed tuple
+			_, _, tuple, err := DeployTuple(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy tuple contract: %v", err)
+			}
+			sim.Commit()
+
+			// Ensure nested tuples are unpacked into the generated structs
+			res, err := tuple.Get(nil)
+			if err != nil {
+				t.Fatalf("Failed to retrieve tuple: %v", err)
+			}
+			want := TupleS{A: big.NewInt(1), Ts: []TupleT{{X: common.Address{1}, Y: [][32]byte{{2}}}}}
+			if !reflect.DeepEqual(res.S, want) || res.N.Cmp(big.NewInt(5)) != 0 {
+				t.Fatalf("Tuple mismatch: have %+v, want %+v and 5", res, want)
+			}
+			// Ensure the generated structs can be packed, anonymous ones included
+			if _, err := tuple.Set(auth, want, [][2]Struct2{{{P: 1, Q: true}, {P: 2}}}); err != nil {
+				t.Fatalf("Failed to send tuple: %v", err)
+			}
+			sim.Commit()
+		`,
+	},
+	// Tests that events are bound to typed filterers and watchers
+	{
+		`Eventer`,
+		`
+			contract Eventer {
+				event SimpleEvent(address indexed addr, bytes32 indexed id, bool indexed flag, uint value);
+				event NodataEvent(uint indexed number, int16 indexed short, uint32 indexed long);
+				event DynamicEvent(string indexed idxStr, bytes indexed idxDat, string str, bytes dat);
+				event UnnamedEvent(uint8 indexed, uint);
+
+				function Eventer() { SimpleEvent(msg.sender, 7, true, 42); }
+				function() { SimpleEvent(msg.sender, 7, true, 42); }
+			}
+		`, `602a60005260016007337f1f097de4289df643bd9c11011cc61367aa12983405c021056e706eb5ba1250c860206000a4603180603b6000396000f3602a60005260016007337f1f097de4289df643bd9c11011cc61367aa12983405c021056e706eb5ba1250c860206000a400`,
+		`[{"anonymous":false,"inputs":[{"indexed":true,"name":"addr","type":"address"},{"indexed":true,"name":"id","type":"bytes32"},{"indexed":true,"name":"flag","type":"bool"},{"indexed":false,"name":"value","type":"uint256"}],"name":"SimpleEvent","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"name":"number","type":"uint256"},{"indexed":true,"name":"short","type":"int16"},{"indexed":true,"name":"long","type":"uint32"}],"name":"NodataEvent","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"name":"idxStr","type":"string"},{"indexed":true,"name":"idxDat","type":"bytes"},{"indexed":false,"name":"str","type":"string"},{"indexed":false,"name":"dat","type":"bytes"}],"name":"DynamicEvent","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"name":"","type":"uint8"},{"indexed":false,"name":"","type":"uint256"}],"name":"UnnamedEvent","type":"event"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy an eventer contract, raising an event from its constructor
+			_, _, eventer, err := DeployEventer(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy eventer contract: %v", err)
+			}
+			sim.Commit()
+
+			// Ensure the filterers and watchers take typed indexed arguments
+			var (
+				_ func(*bind.FilterOpts, []*big.Int, []int16, []uint32) (*EventerNodataEventIterator, error)                    = eventer.FilterNodataEvent
+				_ func(*bind.FilterOpts, []string, [][]byte) (*EventerDynamicEventIterator, error)                               = eventer.FilterDynamicEvent
+				_ func(*bind.WatchOpts, chan<- *EventerUnnamedEvent, []uint8) (event.Subscription, error)                      = eventer.WatchUnnamedEvent
+			)
+			_ = EventerDynamicEvent{IdxStr: common.Hash{}, IdxDat: common.Hash{}, Str: "", Dat: []byte{}}
+			_ = EventerUnnamedEvent{Arg0: 0, Arg1: new(big.Int)}
+
+			// Ensure past events are found and unpacked, indexed and non-indexed fields alike
+			it, err := eventer.FilterSimpleEvent(nil, []common.Address{auth.From}, nil, []bool{true})
+			if err != nil {
+				t.Fatalf("Failed to filter simple events: %v", err)
+			}
+			found := 0
+			for it.Next() {
+				if it.Event.Addr != auth.From || it.Event.Id != [32]byte{31: 7} || !it.Event.Flag || it.Event.Value.Cmp(big.NewInt(42)) != 0 {
+					t.Fatalf("Simple event mismatch: %+v", it.Event)
+				}
+				found++
+			}
+			if err := it.Error(); err != nil {
+				t.Fatalf("Simple event iteration failed: %v", err)
+			}
+			it.Close()
+			if found != 1 {
+				t.Fatalf("Simple event count mismatch: have %d, want 1", found)
+			}
+			// Ensure non-matching indexed rules filter events out
+			if it, err = eventer.FilterSimpleEvent(nil, []common.Address{{0xff}}, nil, nil); err != nil {
+				t.Fatalf("Failed to filter simple events: %v", err)
+			}
+			if it.Next() {
+				t.Fatalf("Mismatching simple event found: %+v", it.Event)
+			}
+			it.Close()
+
+			// Ensure live events are delivered to watchers
+			sink := make(chan *EventerSimpleEvent)
+			sub, err := eventer.WatchSimpleEvent(nil, sink, []common.Address{auth.From}, nil, nil)
+			if err != nil {
+				t.Fatalf("Failed to watch simple events: %v", err)
+			}
+			defer sub.Unsubscribe()
+
+			if _, err := (&EventerRaw{eventer}).Transfer(auth); err != nil {
+				t.Fatalf("Failed to raise simple event: %v", err)
+			}
+			sim.Commit()
+
+			select {
+			case ev := <-sink:
+				if ev.Addr != auth.From || ev.Value.Cmp(big.NewInt(42)) != 0 || ev.Raw.BlockNumber != 2 {
+					t.Fatalf("Watched simple event mismatch: %+v", ev)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Simple event not delivered")
+			}
+		`,
+	},
+	// Tests that reverted calls and transactions surface the decoded revert reason
+	{
+		`Reverter`,
+		`
+			contract Reverter {
+				function get() constant returns (uint256) { revert("denied"); }
+				function set(uint256 value) { revert("denied"); }
+			}
+		`, `607080600b6000396000f36064600c60003960646000fd08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000664656e6965640000000000000000000000000000000000000000000000000000`,
+		`[{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"set","outputs":[],"type":"function"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy a contract reverting on every call
+			_, _, reverter, err := DeployReverter(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy reverter contract: %v", err)
+			}
+			sim.Commit()
+
+			// Ensure both calls and transactions report the revert reason
+			_, err = reverter.Get(nil)
+			if rerr, ok := err.(*bind.RevertError); !ok || rerr.Reason != "denied" {
+				t.Fatalf("Call revert mismatch: have %v, want reason 'denied'", err)
+			}
+			_, err = reverter.Set(auth, big.NewInt(1))
+			if rerr, ok := err.(*bind.RevertError); !ok || rerr.Reason != "denied" || len(rerr.Data) != 100 {
+				t.Fatalf("Transaction revert mismatch: have %v, want reason 'denied'", err)
+			}
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
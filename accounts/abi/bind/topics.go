@@ -0,0 +1,151 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/fairblock/go-fairblock/accounts/abi"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// makeTopics converts a filter query argument list into a filter topic set.
+// Each argument is a list of values any of which the topic at the same position
+// may match, with an empty list matching everything. Dynamic values are indexed
+// by their hash and are hashed the same way.
+func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			// Try to generate the topic based on simple types
+			switch rule := rule.(type) {
+			case common.Hash:
+				copy(topic[:], rule[:])
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], abi.U256(new(big.Int).Set(rule)))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int16:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int32:
+				copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+			case int64:
+				copy(topic[:], abi.U256(big.NewInt(rule)))
+			case uint8:
+				topic[common.HashLength-1] = rule
+			case uint16:
+				binary.BigEndian.PutUint16(topic[common.HashLength-2:], rule)
+			case uint32:
+				binary.BigEndian.PutUint32(topic[common.HashLength-4:], rule)
+			case uint64:
+				binary.BigEndian.PutUint64(topic[common.HashLength-8:], rule)
+			case string:
+				topic = crypto.Keccak256Hash([]byte(rule))
+			case []byte:
+				topic = crypto.Keccak256Hash(rule)
+
+			default:
+				// Attempt to generate the topic from funky types, i.e. fixed size byte arrays
+				val := reflect.ValueOf(rule)
+				if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+					return nil, fmt.Errorf("unsupported indexed type: %T", rule)
+				}
+				reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// parseTopics converts the indexed topic fields into actual log field values.
+// Dynamic types are only available as their hash, which is expected to be
+// stored into a common.Hash field.
+func parseTopics(out interface{}, fields []abi.Argument, topics []common.Hash) error {
+	// Sanity check that the fields and topics match up
+	if len(fields) != len(topics) {
+		return errors.New("topic/field count mismatch")
+	}
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unpack topics into %T", out)
+	}
+	// Iterate over all the fields and reconstruct them from topics
+	for i, arg := range fields {
+		if !arg.Indexed {
+			return errors.New("non-indexed field in topic reconstruction")
+		}
+		if arg.Name == "" {
+			return fmt.Errorf("unnamed topic field %d", i)
+		}
+		field := value.Elem().FieldByName(capitalise(arg.Name))
+		if !field.IsValid() {
+			return fmt.Errorf("field %s not found in %T", capitalise(arg.Name), out)
+		}
+		topic := topics[i]
+
+		var parsed reflect.Value
+		switch arg.Type.T {
+		case abi.IntTy, abi.UintTy:
+			num := new(big.Int).SetBytes(topic[:])
+			if arg.Type.T == abi.IntTy && topic[0]&0x80 != 0 {
+				num.Sub(num, new(big.Int).Lsh(common.Big1, 256))
+			}
+			switch field.Kind() {
+			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				parsed = reflect.New(field.Type()).Elem()
+				parsed.SetInt(num.Int64())
+			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				parsed = reflect.New(field.Type()).Elem()
+				parsed.SetUint(num.Uint64())
+			default:
+				parsed = reflect.ValueOf(num)
+			}
+		case abi.BoolTy:
+			parsed = reflect.ValueOf(topic[common.HashLength-1] == 1)
+		case abi.AddressTy:
+			parsed = reflect.ValueOf(common.BytesToAddress(topic[common.HashLength-common.AddressLength:]))
+		case abi.FixedBytesTy, abi.FunctionTy:
+			parsed = reflect.New(arg.Type.Type).Elem()
+			reflect.Copy(parsed, reflect.ValueOf(topic[:parsed.Len()]))
+		default:
+			// Dynamic types are indexed by their hash
+			parsed = reflect.ValueOf(topic)
+		}
+		if !parsed.Type().AssignableTo(field.Type()) {
+			if !parsed.Type().ConvertibleTo(field.Type()) {
+				return fmt.Errorf("cannot unpack topic %s of type %v into %v", arg.Name, parsed.Type(), field.Type())
+			}
+			parsed = parsed.Convert(field.Type())
+		}
+		field.Set(parsed)
+	}
+	return nil
+}
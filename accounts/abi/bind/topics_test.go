@@ -0,0 +1,130 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/accounts/abi"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// Tests that topic filters are assembled as the EVM would emit them.
+func TestMakeTopics(t *testing.T) {
+	topics, err := makeTopics(
+		[]interface{}{common.Address{0xaa}},
+		[]interface{}{big.NewInt(-1), int8(-2), uint16(0x0102)},
+		[]interface{}{},
+		[]interface{}{true, "hello", [4]byte{1, 2, 3, 4}},
+	)
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	want := [][]common.Hash{
+		{common.BytesToHash(common.Address{0xaa}.Bytes())},
+		{
+			common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+			common.HexToHash("0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe"),
+			common.HexToHash("0x0102"),
+		},
+		nil,
+		{
+			common.HexToHash("0x01"),
+			crypto.Keccak256Hash([]byte("hello")),
+			common.HexToHash("0x0102030400000000000000000000000000000000000000000000000000000000"),
+		},
+	}
+	if !reflect.DeepEqual(topics, want) {
+		t.Fatalf("topic mismatch: have %x, want %x", topics, want)
+	}
+	if _, err := makeTopics([]interface{}{3.14}); err == nil {
+		t.Fatalf("unsupported type accepted")
+	}
+}
+
+// Tests that indexed event fields can be reconstructed from their topics.
+func TestParseTopics(t *testing.T) {
+	const definition = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"delta","type":"int16"},{"indexed":true,"name":"amount","type":"uint256"},{"indexed":true,"name":"memo","type":"string"}],"name":"Transfer","type":"event"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	var out struct {
+		From   common.Address
+		Delta  int16
+		Amount *big.Int
+		Memo   common.Hash
+	}
+	from, memo := common.Address{0xbb}, crypto.Keccak256Hash([]byte("memo"))
+
+	topics, err := makeTopics([]interface{}{from}, []interface{}{int16(-7)}, []interface{}{big.NewInt(1000)}, []interface{}{"memo"})
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	flat := make([]common.Hash, len(topics))
+	for i, topic := range topics {
+		flat[i] = topic[0]
+	}
+	if err := parseTopics(&out, parsed.Events["Transfer"].Inputs, flat); err != nil {
+		t.Fatalf("failed to parse topics: %v", err)
+	}
+	if out.From != from || out.Delta != -7 || out.Amount.Cmp(big.NewInt(1000)) != 0 || out.Memo != memo {
+		t.Fatalf("field mismatch: have %+v", out)
+	}
+	if err := parseTopics(&out, parsed.Events["Transfer"].Inputs, flat[1:]); err == nil {
+		t.Fatalf("mismatching topic count accepted")
+	}
+}
+
+// Tests that unnamed event arguments, indexed or not, are unpacked into the
+// positional fields abigen generates for them.
+func TestUnpackLogUnnamed(t *testing.T) {
+	const definition = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"","type":"address"},{"indexed":false,"name":"","type":"uint256"},{"indexed":true,"name":"","type":"uint64"}],"name":"Ping","type":"event"}]`
+
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	contract := NewBoundContract(common.Address{}, parsed, nil, nil, nil)
+
+	from := common.Address{0xcc}
+	topics, err := makeTopics([]interface{}{from}, []interface{}{uint64(42)})
+	if err != nil {
+		t.Fatalf("failed to make topics: %v", err)
+	}
+	log := types.Log{
+		Topics: []common.Hash{parsed.Events["Ping"].Id(), topics[0][0], topics[1][0]},
+		Data:   abi.U256(big.NewInt(1000)),
+	}
+	var out struct {
+		Arg0 common.Address
+		Arg1 *big.Int
+		Arg2 uint64
+	}
+	if err := contract.UnpackLog(&out, "Ping", log); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if out.Arg0 != from || out.Arg1 == nil || out.Arg1.Cmp(big.NewInt(1000)) != 0 || out.Arg2 != 42 {
+		t.Fatalf("field mismatch: have %+v", out)
+	}
+}
@@ -66,6 +66,12 @@ func typeCheck(t Type, value reflect.Value) error {
 	if t.T == SliceTy || t.T == ArrayTy {
 		return sliceTypeCheck(t, value)
 	}
+	if t.T == TupleTy {
+		if value.Kind() != reflect.Struct {
+			return typeErr(t, value.Type())
+		}
+		return nil
+	}
 
 	// Check base type validity. Element types will be checked later on.
 	if t.Kind != value.Kind() {
@@ -46,69 +46,29 @@ func (e Event) Id() common.Hash {
 	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ",")))))
 }
 
+// String returns a human readable signature of the event, e.g.
+// "event Transfer(address indexed from, address indexed to, uint256 value)".
+func (e Event) String() string {
+	anonymous := ""
+	if e.Anonymous {
+		anonymous = " anonymous"
+	}
+	return fmt.Sprintf("event %v(%v)%s", e.Name, humanArguments(e.Inputs), anonymous)
+}
+
 // unpacks an event return tuple into a struct of corresponding go types
 //
 // Unpacking can be done into a struct or a slice/array.
 func (e Event) tupleUnpack(v interface{}, output []byte) error {
-	// make sure the passed value is a pointer
+	// make sure the passed value is a pointer to a struct
 	valueOf := reflect.ValueOf(v)
 	if reflect.Ptr != valueOf.Kind() {
 		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
 	}
-
-	var (
-		value = valueOf.Elem()
-		typ   = value.Type()
-	)
-
-	if value.Kind() != reflect.Struct {
+	if typ := valueOf.Elem().Type(); typ.Kind() != reflect.Struct {
 		return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
 	}
-
-	j := 0
-	for i := 0; i < len(e.Inputs); i++ {
-		input := e.Inputs[i]
-		if input.Indexed {
-			// can't read, continue
-			continue
-		} else if input.Type.T == ArrayTy {
-			// need to move this up because they read sequentially
-			j += input.Type.Size
-		}
-		marshalledValue, err := toGoType((i+j)*32, input.Type, output)
-		if err != nil {
-			return err
-		}
-		reflectValue := reflect.ValueOf(marshalledValue)
-
-		switch value.Kind() {
-		case reflect.Struct:
-			for j := 0; j < typ.NumField(); j++ {
-				field := typ.Field(j)
-				// TODO read tags: `abi:"fieldName"`
-				if field.Name == strings.ToUpper(e.Inputs[i].Name[:1])+e.Inputs[i].Name[1:] {
-					if err := set(value.Field(j), reflectValue, e.Inputs[i]); err != nil {
-						return err
-					}
-				}
-			}
-		case reflect.Slice, reflect.Array:
-			if value.Len() < i {
-				return fmt.Errorf("abi: insufficient number of arguments for unpack, want %d, got %d", len(e.Inputs), value.Len())
-			}
-			v := value.Index(i)
-			if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
-				return fmt.Errorf("abi: cannot unmarshal %v in to %v", v.Type(), reflectValue.Type())
-			}
-			reflectValue := reflect.ValueOf(marshalledValue)
-			if err := set(v.Elem(), reflectValue, e.Inputs[i]); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
-		}
-	}
-	return nil
+	return unpackTuple(v, e.Inputs, output)
 }
 
 func (e Event) isTupleReturn() bool { return len(e.Inputs) > 1 }
@@ -126,6 +86,17 @@ func (e Event) singleUnpack(v interface{}, output []byte) error {
 
 	value := valueOf.Elem()
 
+	// a lone field may also be unpacked into a struct, as bound events do. A
+	// lone tuple goes into its named field, unless the struct is the tuple.
+	if value.Kind() == reflect.Struct {
+		name := e.Inputs[0].Name
+		if name == "" {
+			name = "arg0"
+		}
+		if e.Inputs[0].Type.T != TupleTy || abiFieldIndex(value.Type(), name) >= 0 {
+			return unpackTuple(v, e.Inputs, output)
+		}
+	}
 	marshalledValue, err := toGoType(0, e.Inputs[0].Type, output)
 	if err != nil {
 		return err
@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"math/big"
 	"strings"
 	"testing"
 
@@ -54,3 +55,39 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+// Tests that an event whose only data argument is a tuple unpacks the tuple
+// into its named field of the event struct, as well as directly into a struct
+// mirroring the tuple.
+func TestEventSingleTupleUnpack(t *testing.T) {
+	const definition = `[{"type":"event","name":"Stored","inputs":[{"name":"data","type":"tuple","components":[{"name":"a","type":"uint256"},{"name":"b","type":"address"}]}]}]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := append(U256(big.NewInt(7)), common.LeftPadBytes(common.Address{0xaa}.Bytes(), 32)...)
+
+	var event struct {
+		Data struct {
+			A *big.Int
+			B common.Address
+		}
+	}
+	if err := abi.Unpack(&event, "Stored", output); err != nil {
+		t.Fatalf("failed to unpack into event struct: %v", err)
+	}
+	if event.Data.A.Cmp(big.NewInt(7)) != 0 || event.Data.B != (common.Address{0xaa}) {
+		t.Fatalf("event field mismatch: have %+v", event.Data)
+	}
+	var tuple struct {
+		A *big.Int
+		B common.Address
+	}
+	if err := abi.Unpack(&tuple, "Stored", output); err != nil {
+		t.Fatalf("failed to unpack into tuple struct: %v", err)
+	}
+	if tuple.A.Cmp(big.NewInt(7)) != 0 || tuple.B != (common.Address{0xaa}) {
+		t.Fatalf("tuple field mismatch: have %+v", tuple)
+	}
+}
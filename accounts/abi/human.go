@@ -0,0 +1,460 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseHumanReadable parses a list of human-readable ABI fragments, written the
+// same way as the corresponding Solidity declarations, into an ABI. Supported
+// fragments are:
+//
+//     constructor(address owner)
+//     function transfer(address to, uint256 amount) returns (bool)
+//     function balanceOf(address owner) view returns (uint256)
+//     event Transfer(address indexed from, address indexed to, uint256 value)
+//     error Unauthorized(address caller)
+//
+// Tuples are spelled out as parenthesised parameter lists, optionally preceded
+// by the tuple keyword, e.g. "function set(tuple(uint256 a, bytes32[] b)[2] s)".
+// Fallback and receive functions are accepted but, as with JSON ABIs, dropped.
+func ParseHumanReadable(fragments []string) (ABI, error) {
+	abi := ABI{
+		Methods: make(map[string]Method),
+		Events:  make(map[string]Event),
+		Errors:  make(map[string]Error),
+	}
+	for _, fragment := range fragments {
+		if err := abi.parseFragment(fragment); err != nil {
+			return ABI{}, fmt.Errorf("abi: invalid fragment %q: %v", fragment, err)
+		}
+	}
+	return abi, nil
+}
+
+// parseFragment parses a single human-readable fragment and adds the resulting
+// definition to the ABI.
+func (abi *ABI) parseFragment(fragment string) error {
+	p := &humanParser{input: strings.TrimSuffix(strings.TrimSpace(fragment), ";")}
+
+	kind := p.ident()
+	var name string
+	switch kind {
+	case "function", "event", "error":
+		if name = p.ident(); name == "" {
+			return errors.New("missing name")
+		}
+	case "constructor", "fallback", "receive":
+	default:
+		return fmt.Errorf("unknown fragment kind %q", kind)
+	}
+	inputs, err := p.params()
+	if err != nil {
+		return err
+	}
+	// Parse any modifiers and the optional return values
+	var (
+		constant  bool
+		payable   bool
+		anonymous bool
+		outputs   []Argument
+	)
+	for !p.done() {
+		switch modifier := p.ident(); modifier {
+		case "view", "pure", "constant":
+			constant = true
+		case "payable":
+			payable = true
+		case "nonpayable", "external", "public":
+		case "anonymous":
+			anonymous = true
+		case "returns":
+			if outputs, err = p.params(); err != nil {
+				return err
+			}
+		case "":
+			return fmt.Errorf("unexpected character %q at offset %d", p.input[p.pos], p.pos)
+		default:
+			return fmt.Errorf("unknown modifier %q", modifier)
+		}
+	}
+	if outputs != nil && kind != "function" {
+		return fmt.Errorf("%s cannot return values", kind)
+	}
+	for _, input := range inputs {
+		if input.Indexed && kind != "event" {
+			return fmt.Errorf("%s parameters cannot be indexed", kind)
+		}
+	}
+	switch kind {
+	case "constructor":
+		abi.Constructor = Method{Payable: payable, Inputs: inputs}
+	case "function":
+		abi.Methods[name] = Method{Name: name, Const: constant, Payable: payable, Inputs: inputs, Outputs: outputs}
+	case "event":
+		abi.Events[name] = Event{Name: name, Anonymous: anonymous, Inputs: inputs}
+	case "error":
+		abi.Errors[name] = Error{Name: name, Inputs: inputs}
+	}
+	return nil
+}
+
+// humanParser is a minimal recursive descent parser over the parameter lists
+// of human-readable ABI fragments.
+type humanParser struct {
+	input string
+	pos   int
+}
+
+// done skips any whitespace and reports whether the whole input was consumed.
+func (p *humanParser) done() bool {
+	p.skipSpace()
+	return p.pos == len(p.input)
+}
+
+func (p *humanParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n' || p.input[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// peek returns the next non whitespace character, or 0 at the end of the input.
+func (p *humanParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// ident consumes the next identifier, returning an empty string if there's none.
+func (p *humanParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+// expect consumes the given character or fails.
+func (p *humanParser) expect(c byte) error {
+	if next := p.peek(); next != c {
+		if next == 0 {
+			return fmt.Errorf("expected %q, got end of input", c)
+		}
+		return fmt.Errorf("expected %q at offset %d, got %q", c, p.pos, next)
+	}
+	p.pos++
+	return nil
+}
+
+// arrays consumes any number of array and slice suffixes of a type.
+func (p *humanParser) arrays() (string, error) {
+	var suffix string
+	for p.peek() == '[' {
+		end := strings.IndexByte(p.input[p.pos:], ']')
+		if end < 0 {
+			return "", errors.New("unterminated array type")
+		}
+		size := strings.TrimSpace(p.input[p.pos+1 : p.pos+end])
+		for _, c := range size {
+			if c < '0' || c > '9' {
+				return "", fmt.Errorf("invalid array size %q", size)
+			}
+		}
+		suffix += "[" + size + "]"
+		p.pos += end + 1
+	}
+	return suffix, nil
+}
+
+// params parses a parenthesised, comma separated parameter list.
+func (p *humanParser) params() ([]Argument, error) {
+	marshalled, err := p.components()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]Argument, len(marshalled))
+	for i, arg := range marshalled {
+		typ, err := NewType(arg.Type, arg.InternalType, arg.Components)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = Argument{Name: arg.Name, Type: typ, Indexed: arg.Indexed}
+	}
+	return args, nil
+}
+
+// components parses a parenthesised parameter list into its JSON representation,
+// which nested tuples need to construct their types from.
+func (p *humanParser) components() ([]ArgumentMarshaling, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	args := []ArgumentMarshaling{}
+	if p.peek() == ')' {
+		p.pos++
+		return args, nil
+	}
+	for {
+		arg, err := p.param()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, p.expect(')')
+		}
+	}
+}
+
+// param parses a single parameter: its type followed by the optional indexed
+// keyword, data location and name.
+func (p *humanParser) param() (ArgumentMarshaling, error) {
+	var arg ArgumentMarshaling
+
+	// Parse the type, tuples being a nested parameter list
+	start := p.pos
+	if kind := p.ident(); kind != "" && kind != "tuple" {
+		arg.Type = kind
+	} else {
+		if kind == "" {
+			p.pos = start
+		}
+		components, err := p.components()
+		if err != nil {
+			return arg, err
+		}
+		arg.Type, arg.Components = "tuple", components
+	}
+	suffix, err := p.arrays()
+	if err != nil {
+		return arg, err
+	}
+	arg.Type += suffix
+
+	// Parse the modifiers and the name
+	for {
+		switch word := p.ident(); word {
+		case "":
+			return arg, nil
+		case "indexed":
+			arg.Indexed = true
+		case "memory", "calldata", "storage", "payable":
+		default:
+			if arg.Name != "" {
+				return arg, fmt.Errorf("unexpected %q after parameter %q", word, arg.Name)
+			}
+			arg.Name = word
+		}
+	}
+}
+
+// HumanReadable formats the ABI into a list of human-readable fragments, which
+// ParseHumanReadable accepts back. The constructor comes first if it takes any
+// parameters, followed by the methods, events and errors sorted by name.
+func (abi ABI) HumanReadable() []string {
+	var fragments []string
+	if len(abi.Constructor.Inputs) > 0 || abi.Constructor.Payable {
+		fragment := fmt.Sprintf("constructor(%s)", humanArguments(abi.Constructor.Inputs))
+		if abi.Constructor.Payable {
+			fragment += " payable"
+		}
+		fragments = append(fragments, fragment)
+	}
+	for _, name := range sortedKeys(abi.Methods) {
+		method := abi.Methods[name]
+
+		fragment := fmt.Sprintf("function %s(%s)", method.Name, humanArguments(method.Inputs))
+		if method.Const {
+			fragment += " view"
+		}
+		if method.Payable {
+			fragment += " payable"
+		}
+		if len(method.Outputs) > 0 {
+			fragment += fmt.Sprintf(" returns (%s)", humanArguments(method.Outputs))
+		}
+		fragments = append(fragments, fragment)
+	}
+	for _, name := range sortedKeys(abi.Events) {
+		fragments = append(fragments, abi.Events[name].String())
+	}
+	for _, name := range sortedKeys(abi.Errors) {
+		fragments = append(fragments, abi.Errors[name].String())
+	}
+	return fragments
+}
+
+// humanArguments formats a parameter list the way Solidity declares it.
+func humanArguments(args []Argument) string {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		formatted[i] = humanType(arg.Type)
+		if arg.Indexed {
+			formatted[i] += " indexed"
+		}
+		if arg.Name != "" {
+			formatted[i] += " " + arg.Name
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// humanType formats a type, spelling out the named fields of tuples.
+func humanType(t Type) string {
+	switch t.T {
+	case TupleTy:
+		fields := make([]string, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields[i] = humanType(*elem)
+			if t.TupleRawNames[i] != "" {
+				fields[i] += " " + t.TupleRawNames[i]
+			}
+		}
+		return "tuple(" + strings.Join(fields, ", ") + ")"
+	case SliceTy:
+		return humanType(*t.Elem) + "[]"
+	case ArrayTy:
+		return fmt.Sprintf("%s[%d]", humanType(*t.Elem), t.Size)
+	}
+	return t.String()
+}
+
+// abiField is the JSON representation of a single ABI definition.
+type abiField struct {
+	Type      string               `json:"type"`
+	Name      string               `json:"name,omitempty"`
+	Constant  bool                 `json:"constant,omitempty"`
+	Payable   bool                 `json:"payable,omitempty"`
+	Anonymous bool                 `json:"anonymous,omitempty"`
+	Inputs    []ArgumentMarshaling `json:"inputs"`
+	Outputs   []ArgumentMarshaling `json:"outputs,omitempty"`
+}
+
+// MarshalJSON formats the ABI into its JSON representation, in the same order
+// as HumanReadable.
+func (abi ABI) MarshalJSON() ([]byte, error) {
+	var fields []abiField
+	if len(abi.Constructor.Inputs) > 0 || abi.Constructor.Payable {
+		fields = append(fields, abiField{Type: "constructor", Payable: abi.Constructor.Payable, Inputs: marshalArguments(abi.Constructor.Inputs)})
+	}
+	for _, name := range sortedKeys(abi.Methods) {
+		method := abi.Methods[name]
+		fields = append(fields, abiField{
+			Type:     "function",
+			Name:     method.Name,
+			Constant: method.Const,
+			Payable:  method.Payable,
+			Inputs:   marshalArguments(method.Inputs),
+			Outputs:  marshalArguments(method.Outputs),
+		})
+	}
+	for _, name := range sortedKeys(abi.Events) {
+		event := abi.Events[name]
+		fields = append(fields, abiField{Type: "event", Name: event.Name, Anonymous: event.Anonymous, Inputs: marshalArguments(event.Inputs)})
+	}
+	for _, name := range sortedKeys(abi.Errors) {
+		e := abi.Errors[name]
+		fields = append(fields, abiField{Type: "error", Name: e.Name, Inputs: marshalArguments(e.Inputs)})
+	}
+	if fields == nil {
+		fields = []abiField{}
+	}
+	return json.Marshal(fields)
+}
+
+// marshalArguments converts a parameter list into its JSON representation.
+func marshalArguments(args []Argument) []ArgumentMarshaling {
+	marshalled := make([]ArgumentMarshaling, len(args))
+	for i, arg := range args {
+		marshalled[i] = marshalType(arg.Type)
+		marshalled[i].Name = arg.Name
+		marshalled[i].Indexed = arg.Indexed
+	}
+	return marshalled
+}
+
+// marshalType converts a type into its JSON representation, tuples being
+// spelled out in the components.
+func marshalType(t Type) ArgumentMarshaling {
+	switch t.T {
+	case TupleTy:
+		components := make([]ArgumentMarshaling, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			components[i] = marshalType(*elem)
+			components[i].Name = t.TupleRawNames[i]
+		}
+		marshalled := ArgumentMarshaling{Type: "tuple", Components: components}
+		if t.TupleRawName != "" {
+			marshalled.InternalType = "struct " + t.TupleRawName
+		}
+		return marshalled
+	case SliceTy, ArrayTy:
+		marshalled := marshalType(*t.Elem)
+
+		suffix := "[]"
+		if t.T == ArrayTy {
+			suffix = fmt.Sprintf("[%d]", t.Size)
+		}
+		marshalled.Type += suffix
+		if marshalled.InternalType != "" {
+			marshalled.InternalType += suffix
+		}
+		return marshalled
+	}
+	return ArgumentMarshaling{Type: t.String()}
+}
+
+// sortedKeys returns the keys of a method, event or error map in alphabetical
+// order.
+func sortedKeys(definitions interface{}) []string {
+	var keys []string
+	switch definitions := definitions.(type) {
+	case map[string]Method:
+		for key := range definitions {
+			keys = append(keys, key)
+		}
+	case map[string]Event:
+		for key := range definitions {
+			keys = append(keys, key)
+		}
+	case map[string]Error:
+		for key := range definitions {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
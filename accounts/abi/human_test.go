@@ -0,0 +1,167 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+var humanReadableTests = []struct {
+	fragment  string // Human-readable fragment to parse
+	canonical string // Formatted form of the parsed fragment
+	signature string // Canonical signature of the parsed definition
+}{
+	{"constructor(address owner, uint256 supply)", "constructor(address owner, uint256 supply)", ""},
+	{"constructor() payable", "constructor() payable", ""},
+	{"function transfer(address to, uint256 amount) returns (bool)", "function transfer(address to, uint256 amount) returns (bool)", "transfer(address,uint256)"},
+	{"function balanceOf(address owner) external view returns (uint256 balance);", "function balanceOf(address owner) view returns (uint256 balance)", "balanceOf(address)"},
+	{"function noop()", "function noop()", "noop()"},
+	{"function batch(address[] calldata to, uint256[2][] memory amounts) payable", "function batch(address[] to, uint256[2][] amounts) payable", "batch(address[],uint256[2][])"},
+	{"function set((uint256 a, bytes32[] b)[2] s, tuple(string c) t)", "function set(tuple(uint256 a, bytes32[] b)[2] s, tuple(string c) t)", "set((uint256,bytes32[])[2],(string))"},
+	{"event Transfer(address indexed from, address indexed to, uint256 value)", "event Transfer(address indexed from, address indexed to, uint256 value)", "Transfer(address,address,uint256)"},
+	{"event Ping(bytes32 indexed) anonymous", "event Ping(bytes32 indexed) anonymous", "Ping(bytes32)"},
+	{"error Unauthorized(address caller)", "error Unauthorized(address caller)", "Unauthorized(address)"},
+}
+
+func TestParseHumanReadable(t *testing.T) {
+	for i, tt := range humanReadableTests {
+		abi, err := ParseHumanReadable([]string{tt.fragment})
+		if err != nil {
+			t.Errorf("test %d: failed to parse %q: %v", i, tt.fragment, err)
+			continue
+		}
+		if formatted := abi.HumanReadable(); len(formatted) != 1 || formatted[0] != tt.canonical {
+			t.Errorf("test %d: formatted fragment mismatch: have %q, want %q", i, formatted, tt.canonical)
+		}
+		for _, method := range abi.Methods {
+			if method.Sig() != tt.signature {
+				t.Errorf("test %d: method signature mismatch: have %q, want %q", i, method.Sig(), tt.signature)
+			}
+		}
+		for _, event := range abi.Events {
+			if want := crypto.Keccak256Hash([]byte(tt.signature)); event.Id() != want {
+				t.Errorf("test %d: event id mismatch: have %x, want %x", i, event.Id(), want)
+			}
+		}
+		for _, e := range abi.Errors {
+			if e.Sig() != tt.signature {
+				t.Errorf("test %d: error signature mismatch: have %q, want %q", i, e.Sig(), tt.signature)
+			}
+		}
+	}
+}
+
+func TestParseHumanReadableErrors(t *testing.T) {
+	fragments := []string{
+		"transfer(address to)",
+		"function (address to)",
+		"function transfer(address to",
+		"function transfer(address to,)",
+		"function transfer(address to amount)",
+		"function transfer(address indexed to)",
+		"function transfer(address[x] to)",
+		"function transfer(address to) mutable",
+		"event Transfer(address to) returns (bool)",
+	}
+	for _, fragment := range fragments {
+		if _, err := ParseHumanReadable([]string{fragment}); err == nil {
+			t.Errorf("expected error parsing %q", fragment)
+		}
+	}
+}
+
+func TestHumanReadableJSONRoundTrip(t *testing.T) {
+	var fragments []string
+	for _, tt := range humanReadableTests {
+		fragments = append(fragments, tt.fragment)
+	}
+	parsed, err := ParseHumanReadable(fragments)
+	if err != nil {
+		t.Fatalf("failed to parse fragments: %v", err)
+	}
+	blob, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("failed to marshal ABI: %v", err)
+	}
+	var restored ABI
+	if err := json.Unmarshal(blob, &restored); err != nil {
+		t.Fatalf("failed to unmarshal ABI: %v", err)
+	}
+	if have, want := restored.HumanReadable(), parsed.HumanReadable(); !reflect.DeepEqual(have, want) {
+		t.Errorf("round trip mismatch:\nhave %q\nwant %q", have, want)
+	}
+	for name, method := range parsed.Methods {
+		if have, want := restored.Methods[name].Id(), method.Id(); string(have) != string(want) {
+			t.Errorf("method %s: id mismatch: have %x, want %x", name, have, want)
+		}
+	}
+	for name, event := range parsed.Events {
+		if restored.Events[name].Id() != event.Id() {
+			t.Errorf("event %s: id mismatch: have %x, want %x", name, restored.Events[name].Id(), event.Id())
+		}
+	}
+}
+
+func TestHumanTypeUnnamedFields(t *testing.T) {
+	uint256, _ := NewType("uint256", "", nil)
+	address, _ := NewType("address", "", nil)
+	tuple := Type{T: TupleTy, TupleElems: []*Type{&uint256, &address}, TupleRawNames: []string{"", "owner"}}
+
+	if have, want := humanType(tuple), "tuple(uint256, address owner)"; have != want {
+		t.Errorf("tuple format mismatch: have %q, want %q", have, want)
+	}
+}
+
+func TestABIMarshalJSONPayable(t *testing.T) {
+	parsed, err := ParseHumanReadable([]string{
+		"constructor(address owner) payable",
+		"function deposit() payable",
+		"function withdraw(uint256 amount)",
+	})
+	if err != nil {
+		t.Fatalf("failed to parse fragments: %v", err)
+	}
+	blob, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("failed to marshal ABI: %v", err)
+	}
+	var fields []map[string]interface{}
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		t.Fatalf("failed to decode marshalled ABI: %v", err)
+	}
+	payable := make(map[string]bool)
+	for _, field := range fields {
+		name, _ := field["name"].(string)
+		payable[field["type"].(string)+" "+name], _ = field["payable"].(bool)
+	}
+	want := map[string]bool{"constructor ": true, "function deposit": true, "function withdraw": false}
+	if !reflect.DeepEqual(payable, want) {
+		t.Errorf("payable flags mismatch: have %v, want %v", payable, want)
+	}
+	// The state mutability of newer compilers must be understood as well
+	var restored ABI
+	if err := json.Unmarshal([]byte(`[{"type":"function","name":"deposit","inputs":[],"stateMutability":"payable"}]`), &restored); err != nil {
+		t.Fatalf("failed to unmarshal ABI: %v", err)
+	}
+	if !restored.Methods["deposit"].Payable {
+		t.Errorf("payable state mutability not recognised")
+	}
+}
@@ -31,6 +31,30 @@ func packBytesSlice(bytes []byte, l int) []byte {
 	return append(len, common.RightPadBytes(bytes, (l+31)/32*32)...)
 }
 
+// packTuple packs the values as a tuple of the given types. Static values are
+// inlined into the head, dynamic ones are referenced from it by their offset
+// and appended after it.
+func packTuple(types []Type, values []reflect.Value) ([]byte, error) {
+	offset := 0
+	for _, t := range types {
+		offset += getTypeSize(t)
+	}
+	var head, tail []byte
+	for i, t := range types {
+		packed, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(t) {
+			head = append(head, packNum(reflect.ValueOf(offset+len(tail)))...)
+			tail = append(tail, packed...)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
 // packElement packs the given reflect value according to the abi specification in
 // t.
 func packElement(t Type, reflectValue reflect.Value) []byte {
@@ -320,7 +320,7 @@ func TestPack(t *testing.T) {
 			common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000006666f6f6261720000000000000000000000000000000000000000000000000000"),
 		},
 	} {
-		typ, err := NewType(test.typ)
+		typ, err := NewType(test.typ, "", nil)
 		if err != nil {
 			t.Fatalf("%v failed. Unexpected parse error: %v", i, err)
 		}
@@ -403,6 +403,51 @@ func TestMethodPack(t *testing.T) {
 	}
 }
 
+// Tests that tuples and nested dynamic arrays of tuples are packed according
+// to the head/tail encoding and unpack back into the original values.
+func TestPackTuple(t *testing.T) {
+	const args = `[
+		{"name": "s", "type": "tuple", "components": [{"name": "a", "type": "uint256"}, {"name": "b", "type": "uint256[]"}]},
+		{"name": "t", "type": "tuple[]", "components": [{"name": "a", "type": "uint256"}, {"name": "b", "type": "uint256[]"}]}
+	]`
+	abi, err := JSON(strings.NewReader(`[{"name": "f", "inputs": ` + args + `, "outputs": ` + args + `}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig, want := abi.Methods["f"].Sig(), "f((uint256,uint256[]),(uint256,uint256[])[])"; sig != want {
+		t.Fatalf("signature mismatch: have %s, want %s", sig, want)
+	}
+	type S struct {
+		A      *big.Int
+		Values []*big.Int `abi:"b"`
+	}
+	s := S{big.NewInt(1), []*big.Int{big.NewInt(2), big.NewInt(3)}}
+	ts := []S{{big.NewInt(1), []*big.Int{big.NewInt(2)}}, {big.NewInt(3), []*big.Int{}}}
+
+	packed, err := abi.Pack("f", s, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.Hex2Bytes("000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000e0000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000030000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000c00000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000000")
+	if !bytes.Equal(packed[4:], want) {
+		t.Fatalf("pack mismatch:\nhave %x\nwant %x", packed[4:], want)
+	}
+	var out struct {
+		S S
+		T []S
+	}
+	if err := abi.Unpack(&out, "f", want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.S, s) || !reflect.DeepEqual(out.T, ts) {
+		t.Fatalf("unpack mismatch: have %v, want %v %v", out, s, ts)
+	}
+	// Missing fields are reported instead of packed as zero
+	if _, err := abi.Pack("f", struct{ A *big.Int }{big.NewInt(1)}, ts); err == nil {
+		t.Fatal("expected error for struct without field b")
+	}
+}
+
 func TestPackNumber(t *testing.T) {
 	tests := []struct {
 		value  reflect.Value
@@ -19,6 +19,8 @@ package abi
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"unicode"
 )
 
 // indirect recursively dereferences the value until it either gets the value
@@ -69,7 +71,8 @@ func mustArrayToByteSlice(value reflect.Value) reflect.Value {
 // set attempts to assign src to dst by either setting, copying or otherwise.
 //
 // set is a bit more lenient when it comes to assignment and doesn't force an as
-// strict ruleset as bare `reflect` does.
+// strict ruleset as bare `reflect` does. Unpacked tuples are copied field by
+// field into any struct whose fields are bound to the tuple components.
 func set(dst, src reflect.Value, output Argument) error {
 	dstType := dst.Type()
 	srcType := src.Type()
@@ -79,9 +82,91 @@ func set(dst, src reflect.Value, output Argument) error {
 	case dstType.Kind() == reflect.Interface:
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
+		if dst.IsNil() && dst.CanSet() {
+			dst.Set(reflect.New(dstType.Elem()))
+		}
 		return set(dst.Elem(), src, output)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct:
+		// resolve all fields first to avoid partially filling dst
+		fields := make([]int, src.NumField())
+		for i := range fields {
+			name := srcType.Field(i).Tag.Get("abi")
+			if fields[i] = abiFieldIndex(dstType, name); fields[i] < 0 {
+				return fmt.Errorf("abi: cannot unmarshal %v in to %v: no field for %s", srcType, dstType, name)
+			}
+		}
+		for i, j := range fields {
+			if err := set(dst.Field(j), src.Field(i), output); err != nil {
+				return err
+			}
+		}
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), output); err != nil {
+				return fmt.Errorf("abi: cannot unmarshal %v in to %v", srcType, dstType)
+			}
+		}
+		dst.Set(slice)
+	case dstType.Kind() == reflect.Array && srcType.Kind() == reflect.Array && dst.Len() == src.Len():
+		for i := 0; i < src.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), output); err != nil {
+				return fmt.Errorf("abi: cannot unmarshal %v in to %v", srcType, dstType)
+			}
+		}
 	default:
 		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 	}
 	return nil
 }
+
+// abiFieldIndex returns the index of the field of the struct type typ that the
+// argument or tuple component called name is bound to, or -1 if there is none.
+// A field tagged with `abi:"name"` takes precedence over untagged fields named
+// after the capitalised or camel cased argument.
+func abiFieldIndex(typ reflect.Type, name string) int {
+	if name == "" {
+		return -1
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("abi") == name {
+			return i
+		}
+	}
+	capitalised, camel := strings.ToUpper(name[:1])+name[1:], ToCamelCase(name)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if _, tagged := field.Tag.Lookup("abi"); tagged {
+			continue
+		}
+		if field.Name == capitalised || field.Name == camel {
+			return i
+		}
+	}
+	return -1
+}
+
+// ToCamelCase converts an under-score separated argument name to the exported
+// Go field name it is bound to, e.g. "owner_address" becomes "OwnerAddress".
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, s := range parts {
+		if len(s) > 0 {
+			parts[i] = strings.ToUpper(s[:1]) + s[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// isValidFieldName checks whether name can be used as an exported struct field.
+func isValidFieldName(name string) bool {
+	for i, c := range name {
+		if i == 0 && !unicode.IsUpper(c) {
+			return false
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
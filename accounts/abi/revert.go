@@ -0,0 +1,205 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+var (
+	// revertSelector is the selector of the Error(string) payload Solidity
+	// reverts with on failing require and revert statements carrying a message.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+	// panicSelector is the selector of the Panic(uint256) payload Solidity
+	// reverts with on failing assertions and runtime errors.
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+	// stringType and uint256Type are the argument types of the Error(string)
+	// and Panic(uint256) payloads.
+	stringType, _  = NewType("string", "", nil)
+	uint256Type, _ = NewType("uint256", "", nil)
+
+	errInvalidRevert = errors.New("abi: invalid revert payload")
+)
+
+// panicReasons maps the Solidity panic codes to human readable descriptions.
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// Error is a custom error declared by a contract. Custom errors are raised by
+// revert statements, returning the error selector followed by the arguments
+// abi encoded the same way as method parameters.
+type Error struct {
+	Name   string
+	Inputs []Argument
+}
+
+// Sig returns the error's string signature according to the ABI spec.
+//
+// Example
+//
+//     error InsufficientBalance(uint256 available, uint256 required)  =  "InsufficientBalance(uint256,uint256)"
+func (e Error) Sig() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ","))
+}
+
+// String returns a human readable signature of the error.
+func (e Error) String() string {
+	return fmt.Sprintf("error %v(%v)", e.Name, humanArguments(e.Inputs))
+}
+
+// Id returns the selector of the error, the first 4 bytes of the hash of its
+// signature, which prefixes the revert payload.
+func (e Error) Id() []byte {
+	return crypto.Keccak256([]byte(e.Sig()))[:4]
+}
+
+// Unpack decodes the arguments of the error from a revert payload.
+func (e Error) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], e.Id()) {
+		return nil, fmt.Errorf("abi: revert payload is not a '%s' error", e.Name)
+	}
+	return unpackValues(e.Inputs, data[4:])
+}
+
+// ErrorById looks up the custom error whose selector prefixes the given revert
+// payload.
+func (abi ABI) ErrorById(data []byte) (*Error, error) {
+	if len(data) < 4 {
+		return nil, errInvalidRevert
+	}
+	for _, e := range abi.Errors {
+		if bytes.Equal(e.Id(), data[:4]) {
+			e := e
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("abi: no error with id %#x", data[:4])
+}
+
+// UnpackRevert resolves the revert reason from the payload of a reverted
+// execution, supporting both the Error(string) and the Panic(uint256) payloads
+// emitted by Solidity.
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errInvalidRevert
+	}
+	switch {
+	case bytes.Equal(data[:4], revertSelector):
+		values, err := unpackValues([]Argument{{Type: stringType}}, data[4:])
+		if err != nil {
+			return "", err
+		}
+		return values[0].(string), nil
+
+	case bytes.Equal(data[:4], panicSelector):
+		values, err := unpackValues([]Argument{{Type: uint256Type}}, data[4:])
+		if err != nil {
+			return "", err
+		}
+		code := values[0].(*big.Int)
+		if reason, ok := panicReasons[code.Uint64()]; code.IsUint64() && ok {
+			return fmt.Sprintf("panic: %s (%#x)", reason, code), nil
+		}
+		return fmt.Sprintf("panic: unknown code %#x", code), nil
+	}
+	return "", errInvalidRevert
+}
+
+// UnpackRevert resolves the revert reason from the payload of a reverted
+// execution, additionally decoding the custom errors declared in the ABI into
+// their name and arguments.
+func (abi ABI) UnpackRevert(data []byte) (string, error) {
+	if reason, err := UnpackRevert(data); err == nil {
+		return reason, nil
+	}
+	e, err := abi.ErrorById(data)
+	if err != nil {
+		return "", err
+	}
+	values, err := e.Unpack(data)
+	if err != nil {
+		return "", err
+	}
+	args := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case fmt.Stringer:
+			args[i] = value.String()
+		case []byte:
+			args[i] = fmt.Sprintf("%#x", value)
+		default:
+			args[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", ")), nil
+}
+
+// RevertedError is the error of an execution reverted by the EVM, as reported by
+// calls and gas estimations. It carries the JSON-RPC error code of reverts and
+// the hex encoded revert payload as error data, so RPC clients and simulated
+// backends see the same error.
+type RevertedError struct {
+	error
+	reason string // revert payload hex encoded
+}
+
+// NewRevertedError creates a RevertedError from the payload of a reverted
+// execution, decoding the revert reason into the message if possible. The EVM
+// only returns data from failed executions if they were explicitly reverted.
+func NewRevertedError(data []byte) *RevertedError {
+	err := errors.New("execution reverted")
+	if reason, errUnpack := UnpackRevert(data); errUnpack == nil {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	}
+	return &RevertedError{
+		error:  err,
+		reason: hexutil.Encode(data),
+	}
+}
+
+// ErrorCode returns the JSON error code for a revert.
+func (e *RevertedError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert payload.
+func (e *RevertedError) ErrorData() interface{} {
+	return e.reason
+}
@@ -37,6 +37,7 @@ const (
 	HashTy
 	FixedPointTy
 	FunctionTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -49,6 +50,11 @@ type Type struct {
 	T    byte // Our own type checking
 
 	stringKind string // holds the unparsed string for deriving signatures
+
+	// Tuple relative fields
+	TupleElems    []*Type  // Type information of all tuple fields
+	TupleRawNames []string // Raw field name of all tuple fields
+	TupleRawName  string   // Raw struct name defined in source code, may be empty
 }
 
 var (
@@ -56,8 +62,10 @@ var (
 	typeRegex = regexp.MustCompile("([a-zA-Z]+)(([0-9]+)(x([0-9]+))?)?")
 )
 
-// NewType creates a new reflection type of abi type given in t.
-func NewType(t string) (typ Type, err error) {
+// NewType creates a new reflection type of abi type given in t. The internal
+// type and the components are only consulted for tuples, where they carry the
+// struct name and the field definitions respectively.
+func NewType(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
 	// check that array brackets are equal if they exist
 	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("invalid arg type in abi")
@@ -69,13 +77,20 @@ func NewType(t string) (typ Type, err error) {
 	// recursively create the type
 	if strings.Count(t, "[") != 0 {
 		i := strings.LastIndex(t, "[")
+		// the internal type of an array is suffixed the same way, strip it too
+		subInternal := internalType
+		if j := strings.LastIndex(internalType, "["); j != -1 {
+			subInternal = internalType[:j]
+		}
 		// recursively embed the type
-		embeddedType, err := NewType(t[:i])
+		embeddedType, err := NewType(t[:i], subInternal, components)
 		if err != nil {
 			return Type{}, err
 		}
 		// grab the last cell and create a type from there
 		sliced := t[i:]
+		// tuples are spelled out in signatures, so rebuild the string from the element
+		typ.stringKind = embeddedType.stringKind + sliced
 		// grab the slice size with regexp
 		re := regexp.MustCompile("[0-9]+")
 		intz := re.FindAllString(sliced, -1)
@@ -159,6 +174,52 @@ func NewType(t string) (typ Type, err error) {
 			typ.T = FunctionTy
 			typ.Size = 24
 			typ.Type = reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
+		case "tuple":
+			if len(components) == 0 {
+				return Type{}, fmt.Errorf("abi: tuple without components")
+			}
+			var (
+				fields []reflect.StructField
+				elems  []*Type
+				names  []string
+				kinds  []string
+				used   = make(map[string]bool)
+			)
+			for _, c := range components {
+				cType, err := NewType(c.Type, c.InternalType, c.Components)
+				if err != nil {
+					return Type{}, err
+				}
+				name := ToCamelCase(c.Name)
+				if !isValidFieldName(name) {
+					return Type{}, fmt.Errorf("abi: invalid tuple field name %q", c.Name)
+				}
+				if used[name] {
+					return Type{}, fmt.Errorf("abi: duplicated tuple field name %q", c.Name)
+				}
+				used[name] = true
+
+				fields = append(fields, reflect.StructField{
+					Name: name,
+					Type: cType.Type,
+					Tag:  reflect.StructTag(fmt.Sprintf(`abi:"%s"`, c.Name)),
+				})
+				elems = append(elems, &cType)
+				names = append(names, c.Name)
+				kinds = append(kinds, cType.stringKind)
+			}
+			typ.Kind = reflect.Struct
+			typ.Type = reflect.StructOf(fields)
+			typ.T = TupleTy
+			typ.TupleElems = elems
+			typ.TupleRawNames = names
+			typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+
+			// Solidity reports the struct as "struct Contract.Name", which
+			// isn't a valid Go identifier, so flatten it to ContractName.
+			if strings.HasPrefix(internalType, "struct ") {
+				typ.TupleRawName = strings.Replace(strings.TrimPrefix(internalType, "struct "), ".", "", -1)
+			}
 		default:
 			return Type{}, fmt.Errorf("unsupported arg type: %s", t)
 		}
@@ -180,21 +241,36 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if t.T == SliceTy || t.T == ArrayTy {
-		var packed []byte
+	switch t.T {
+	case SliceTy, ArrayTy:
+		var ret []byte
+		if t.requiresLengthPrefix() {
+			ret = packNum(reflect.ValueOf(v.Len()))
+		}
+		// dynamic elements are referenced by offsets relative to the
+		// start of the elements, with their contents following them
+		elems := make([]Type, v.Len())
+		values := make([]reflect.Value, v.Len())
+		for i := range elems {
+			elems[i], values[i] = *t.Elem, v.Index(i)
+		}
+		packed, err := packTuple(elems, values)
+		if err != nil {
+			return nil, err
+		}
+		return append(ret, packed...), nil
 
-		for i := 0; i < v.Len(); i++ {
-			val, err := t.Elem.pack(v.Index(i))
-			if err != nil {
-				return nil, err
+	case TupleTy:
+		values := make([]reflect.Value, len(t.TupleElems))
+		elems := make([]Type, len(t.TupleElems))
+		for i, name := range t.TupleRawNames {
+			idx := abiFieldIndex(v.Type(), name)
+			if idx < 0 {
+				return nil, fmt.Errorf("abi: field %s of tuple not found in %v", name, v.Type())
 			}
-			packed = append(packed, val...)
-		}
-		if t.T == SliceTy {
-			return packBytesSlice(packed, v.Len()), nil
-		} else if t.T == ArrayTy {
-			return packed, nil
+			elems[i], values[i] = *t.TupleElems[i], v.Field(idx)
 		}
+		return packTuple(elems, values)
 	}
 	return packElement(t, v), nil
 }
@@ -204,3 +280,42 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 func (t Type) requiresLengthPrefix() bool {
 	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy
 }
+
+// isDynamicType returns whether the encoding of the type has a variable size.
+// These are bytes, string, T[] for any T, T[k] for any dynamic T and tuples
+// containing any dynamic component.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return isDynamicType(*t.Elem)
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getTypeSize returns the number of bytes the type occupies in the head of an
+// encoding. Static arrays and tuples are inlined, everything else takes up a
+// single word, which for dynamic types is an offset to the actual content.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.Size * getTypeSize(*t.Elem)
+	case TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += getTypeSize(*elem)
+		}
+		return size
+	}
+	return 32
+}
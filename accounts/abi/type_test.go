@@ -97,7 +97,7 @@ func TestTypeRegexp(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		typ, err := NewType(tt.blob)
+		typ, err := NewType(tt.blob, "", nil)
 		if err != nil {
 			t.Errorf("type %q: failed to parse type string: %v", tt.blob, err)
 		}
@@ -107,6 +107,49 @@ func TestTypeRegexp(t *testing.T) {
 	}
 }
 
+// Tests that tuple types are built from their components.
+func TestTupleType(t *testing.T) {
+	components := []ArgumentMarshaling{
+		{Name: "owner_address", Type: "address"},
+		{Name: "amounts", Type: "uint256[]"},
+		{Name: "inner", Type: "tuple", InternalType: "struct Inner", Components: []ArgumentMarshaling{{Name: "flag", Type: "bool"}}},
+	}
+	typ, err := NewType("tuple[2]", "struct Token.Holding[2]", components)
+	if err != nil {
+		t.Fatalf("failed to parse tuple: %v", err)
+	}
+	if have, want := typ.String(), "(address,uint256[],(bool))[2]"; have != want {
+		t.Errorf("string mismatch: have %s, want %s", have, want)
+	}
+	elem := typ.Elem
+	if elem.T != TupleTy || elem.TupleRawName != "TokenHolding" {
+		t.Errorf("element mismatch: have type %d named %q", elem.T, elem.TupleRawName)
+	}
+	if !reflect.DeepEqual(elem.TupleRawNames, []string{"owner_address", "amounts", "inner"}) {
+		t.Errorf("field names mismatch: have %v", elem.TupleRawNames)
+	}
+	if field, ok := elem.Type.FieldByName("OwnerAddress"); !ok || field.Tag.Get("abi") != "owner_address" {
+		t.Errorf("field OwnerAddress missing or mistagged: %v", field)
+	}
+	if inner := elem.TupleElems[2]; inner.TupleRawName != "Inner" || inner.Type.Field(0).Name != "Flag" {
+		t.Errorf("inner tuple mismatch: %s named %q", inner.Type, inner.TupleRawName)
+	}
+	if !isDynamicType(typ) || getTypeSize(*elem.TupleElems[2]) != 32 {
+		t.Errorf("size mismatch: dynamic %v, inner size %d", isDynamicType(typ), getTypeSize(*elem.TupleElems[2]))
+	}
+	// Fields must be exported and unique once converted to Go names
+	for _, invalid := range [][]ArgumentMarshaling{
+		nil,
+		{{Name: "", Type: "uint256"}},
+		{{Name: "_", Type: "uint256"}},
+		{{Name: "a", Type: "uint256"}, {Name: "A", Type: "uint256"}},
+	} {
+		if _, err := NewType("tuple", "", invalid); err == nil {
+			t.Errorf("expected error for components %v", invalid)
+		}
+	}
+}
+
 func TestTypeCheck(t *testing.T) {
 	for i, test := range []struct {
 		typ   string
@@ -253,7 +296,7 @@ func TestTypeCheck(t *testing.T) {
 		{"address", [20]byte{}, ""},
 		{"address", common.Address{}, ""},
 	} {
-		typ, err := NewType(test.typ)
+		typ, err := NewType(test.typ, "", nil)
 		if err != nil && len(test.err) == 0 {
 			t.Fatal("unexpected parse error:", err)
 		} else if err != nil && len(test.err) != 0 {
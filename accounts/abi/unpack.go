@@ -104,13 +104,15 @@ func readFixedBytes(t Type, word []byte) (interface{}, error) {
 
 // iteratively unpack elements
 func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("abi: cannot marshal in to go array: length %d is negative", size)
+	}
 	if start+32*size > len(output) {
 		return nil, fmt.Errorf("abi: cannot marshal in to go array: offset %d would go over slice boundary (len=%d)", len(output), start+32*size)
 	}
 
 	// this value will become our slice or our array, depending on the type
 	var refSlice reflect.Value
-	slice := output[start : start+size*32]
 
 	if t.T == SliceTy {
 		// declare our slice
@@ -122,11 +124,10 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 		return nil, fmt.Errorf("abi: invalid type in array/slice unpacking stage")
 	}
 
-	for i, j := start, 0; j*32 < len(slice); i, j = i+32, j+1 {
-		// this corrects the arrangement so that we get all the underlying array values
-		if t.Elem.T == ArrayTy && j != 0 {
-			i = start + t.Elem.Size*32*j
-		}
+	// static arrays and tuples are inlined, so the elements might span more
+	// than a single word each
+	elemSize := getTypeSize(*t.Elem)
+	for i, j := start, 0; j < size; i, j = i+elemSize, j+1 {
 		inter, err := toGoType(i, *t.Elem, output)
 		if err != nil {
 			return nil, err
@@ -139,6 +140,23 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 	return refSlice.Interface(), nil
 }
 
+// forTupleUnpack unpacks the fields of the tuple type t from output, which
+// starts at the head of the tuple.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.Type).Elem()
+
+	offset := 0
+	for i, elem := range t.TupleElems {
+		marshalledValue, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		retval.Field(i).Set(reflect.ValueOf(marshalledValue))
+		offset += getTypeSize(*elem)
+	}
+	return retval.Interface(), nil
+}
+
 // toGoType parses the output bytes and recursively assigns the value of these bytes
 // into a go type with accordance with the ABI spec.
 func toGoType(index int, t Type, output []byte) (interface{}, error) {
@@ -163,9 +181,26 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	}
 
 	switch t.T {
+	case TupleTy:
+		if isDynamicType(t) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
 	case SliceTy:
-		return forEachUnpack(t, output, begin, end)
+		// offsets of dynamic elements are relative to the first element
+		return forEachUnpack(t, output[begin:], 0, end)
 	case ArrayTy:
+		if isDynamicType(*t.Elem) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forEachUnpack(t, output[begin:], 0, t.Size)
+		}
 		return forEachUnpack(t, output, index, t.Size)
 	case StringTy: // variable arrays are written at the end of the return bytes
 		return string(output[begin : begin+end]), nil
@@ -188,6 +223,96 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	}
 }
 
+// unpackTuple unpacks the non-indexed arguments from output into v, which must
+// be a pointer to either a struct, whose fields are matched by abi tag or by
+// name, or to a slice or array of pointers.
+func unpackTuple(v interface{}, args []Argument, output []byte) error {
+	// make sure the passed value is a pointer
+	valueOf := reflect.ValueOf(v)
+	if reflect.Ptr != valueOf.Kind() {
+		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
+	}
+
+	var (
+		value = valueOf.Elem()
+		typ   = value.Type()
+	)
+
+	offset := 0
+	for i, arg := range args {
+		if arg.Indexed {
+			// indexed arguments are not part of the data
+			continue
+		}
+		marshalledValue, err := toGoType(offset, arg.Type, output)
+		if err != nil {
+			return err
+		}
+		offset += getTypeSize(arg.Type)
+		reflectValue := reflect.ValueOf(marshalledValue)
+
+		switch value.Kind() {
+		case reflect.Struct:
+			name := arg.Name
+			if name == "" {
+				// unnamed arguments are bound positionally, as abigen does
+				name = fmt.Sprintf("arg%d", i)
+			}
+			if j := abiFieldIndex(typ, name); j >= 0 {
+				if err := set(value.Field(j), reflectValue, arg); err != nil {
+					return err
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			if value.Len() <= i {
+				return fmt.Errorf("abi: insufficient number of arguments for unpack, want %d, got %d", len(args), value.Len())
+			}
+			v := value.Index(i)
+			if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+				return fmt.Errorf("abi: cannot unmarshal %v in to %v", v.Type(), reflectValue.Type())
+			}
+			if err := set(v.Elem(), reflectValue, arg); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
+		}
+	}
+	return nil
+}
+
+// unpackValues unpacks the non-indexed arguments from output into a list of
+// their corresponding go values.
+func unpackValues(args []Argument, output []byte) ([]interface{}, error) {
+	var (
+		values = make([]interface{}, 0, len(args))
+		offset = 0
+	)
+	for _, arg := range args {
+		if arg.Indexed {
+			// indexed arguments are not part of the data
+			continue
+		}
+		value, err := toGoType(offset, arg.Type, output)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(arg.Type)
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// tuplePointsTo resolves the location of a dynamic tuple or static array from
+// the offset stored at index.
+func tuplePointsTo(index int, output []byte) (start int, err error) {
+	offset := new(big.Int).SetBytes(output[index : index+32])
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(output)) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go tuple: offset %v would go over slice boundary (len=%d)", offset, len(output))
+	}
+	return int(offset.Int64()), nil
+}
+
 // interprets a 32 byte slice as an offset and then determines which indice to look to decode the type.
 func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err error) {
 	offset := int(binary.BigEndian.Uint64(output[index+24 : index+32]))
@@ -0,0 +1,241 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an accounts.Backend delegating the signing to an
+// external signer process over JSON-RPC.
+package external
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/fairblock/go-fairblock"
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/rlp"
+	"github.com/fairblock/go-fairblock/rpc"
+	"github.com/fairblock/go-fairblock/signer/core"
+)
+
+// errNotSupported is returned for operations the external signer doesn't provide.
+// Raw hashes are never signed, as the signer couldn't show what it approves.
+var errNotSupported = errors.New("operation not supported on external signers")
+
+// ExternalBackend is an accounts.Backend exposing a single external signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer at the given endpoint.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer is static, so there
+// are never any wallet events.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is an accounts.Wallet whose accounts and keys are held by an
+// external signer, which approves every request on its own.
+//
+// As every listing may need to be approved interactively on the signer, the
+// accounts are only requested once and cached until the wallet is closed.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	lock     sync.Mutex
+	accounts []accounts.Account // Accounts revealed by the last listing
+	listed   bool               // Whether the accounts were successfully listed
+	status   string
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalSigner{client: client, endpoint: endpoint, status: "ok"}, nil
+}
+
+// URL implements accounts.Wallet.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: "extapi", Path: api.endpoint}
+}
+
+// Status implements accounts.Wallet, returning the outcome of the last listing.
+func (api *ExternalSigner) Status() (string, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	return api.status, nil
+}
+
+// Open implements accounts.Wallet. Accounts are unlocked by the external signer.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return errNotSupported
+}
+
+// Close implements accounts.Wallet, dropping the cached accounts so that they are
+// listed again on the next request.
+func (api *ExternalSigner) Close() error {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	api.accounts, api.listed = nil, false
+	return nil
+}
+
+// Accounts implements accounts.Wallet, asking the external signer to reveal its
+// accounts if they were not listed yet. If the request fails, it's retried on
+// the next call.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	// Hold the lock across the request so concurrent callers don't each trigger
+	// an approval prompt on the signer
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.listed {
+		return api.accounts
+	}
+	var addrs []common.Address
+	if err := api.client.Call(&addrs, "account_list"); err != nil {
+		api.status = fmt.Sprintf("listing failed: %v", err)
+		return api.accounts
+	}
+	api.status, api.listed = "ok", true
+	api.accounts = make([]accounts.Account, len(addrs))
+	for i, addr := range addrs {
+		api.accounts[i] = accounts.Account{Address: addr, URL: api.URL()}
+	}
+	return api.accounts
+}
+
+// Contains implements accounts.Wallet, checking the revealed accounts.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	return containsAccount(api.Accounts(), account)
+}
+
+// containsAccount reports whether the account is in the list, ignoring the URL
+// if it's unset.
+func containsAccount(accs []accounts.Account, account accounts.Account) bool {
+	for _, acc := range accs {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == acc.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, errNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, which is a noop for external signers.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain fairblock.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet. Signing raw hashes is not supported.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, errNotSupported
+}
+
+// signTransactionResult is the response of the external signer to a signing
+// request.
+type signTransactionResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx implements accounts.Wallet, sending the transaction to the external
+// signer for approval. The signed transaction is rejected if the signer changed
+// anything but the signature, or signed for another chain or account.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := map[string]interface{}{
+		"from":     account.Address,
+		"to":       tx.To(),
+		"gas":      (*hexutil.Big)(tx.Gas()),
+		"gasPrice": (*hexutil.Big)(tx.GasPrice()),
+		"value":    (*hexutil.Big)(tx.Value()),
+		"nonce":    hexutil.Uint64(tx.Nonce()),
+		"data":     hexutil.Bytes(tx.Data()),
+	}
+	var res signTransactionResult
+	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(res.Raw, signed); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("external signer modified the transaction")
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction signature: %v", err)
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("transaction signed by %x instead of %x", sender, account.Address)
+	}
+	return signed, nil
+}
+
+// SignTypedData sends the EIP-712 typed data to the external signer, which shows
+// it in full for approval. The V value of the signature is 27 or 28.
+func (api *ExternalSigner) SignTypedData(account accounts.Account, typedData core.TypedData) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := api.client.Call(&signature, "account_signTypedData", account.Address, typedData); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet. Signing raw hashes is not
+// supported.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, errNotSupported
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. The passphrase is ignored, as
+// the external signer unlocks its keys on its own.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return api.SignTx(account, tx, chainID)
+}
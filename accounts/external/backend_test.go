@@ -0,0 +1,131 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/rpc"
+	"github.com/fairblock/go-fairblock/signer/core"
+)
+
+// approvingUI approves every request with a fixed password, optionally tampering
+// with the transactions.
+type approvingUI struct {
+	password string
+	tamper   bool
+	listings int
+}
+
+func (ui *approvingUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	tx := request.Transaction
+	if ui.tamper {
+		tx.Nonce++
+	}
+	return core.SignTxResponse{Transaction: tx, Approved: true, Password: ui.password}, nil
+}
+
+func (ui *approvingUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	return core.SignDataResponse{Approved: true, Password: ui.password}, nil
+}
+
+func (ui *approvingUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	ui.listings++
+	return core.ListResponse{Accounts: request.Accounts}, nil
+}
+
+func (ui *approvingUI) OnApprovedTx(result core.SignTxResult) {}
+func (ui *approvingUI) ShowError(message string)              {}
+func (ui *approvingUI) ShowInfo(message string)               {}
+
+func TestExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Start a signer and connect a backend to it over HTTP
+	chainID := big.NewInt(1337)
+	ui := &approvingUI{password: "secret"}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", core.NewSignerAPI(chainID, accounts.NewManager(ks), ui)); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(rpc.NewHTTPServer(nil, []string{"*"}, server).Handler)
+	defer httpsrv.Close()
+
+	backend, err := NewExternalBackend(httpsrv.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	manager := accounts.NewManager(backend)
+	defer manager.Close()
+
+	wallets := manager.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if found, err := manager.Find(accounts.Account{Address: account.Address}); err != nil || found != wallet {
+		t.Fatalf("account not found in external wallet: %v", err)
+	}
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("account mismatch: have %v, want %x", accs, account.Address)
+	}
+	if !wallet.Contains(accounts.Account{Address: account.Address}) {
+		t.Fatalf("account not contained in external wallet")
+	}
+	if ui.listings != 1 {
+		t.Fatalf("listing request count mismatch: have %d, want 1", ui.listings)
+	}
+	// Sign a transaction through the external signer
+	tx := types.NewTransaction(1, common.HexToAddress("0xdead"), big.NewInt(10), big.NewInt(21000), big.NewInt(1), []byte{0x01})
+	signed, err := wallet.SignTx(accounts.Account{Address: account.Address}, tx, chainID)
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	if sender, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || sender != account.Address {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", sender, err, account.Address)
+	}
+	// Signatures for another chain or over modified transactions must be rejected
+	if _, err := wallet.SignTx(accounts.Account{Address: account.Address}, tx, big.NewInt(1)); err == nil {
+		t.Fatalf("transaction signed for another chain accepted")
+	}
+	ui.tamper = true
+	if _, err := wallet.SignTx(accounts.Account{Address: account.Address}, tx, chainID); err == nil {
+		t.Fatalf("modified transaction accepted")
+	}
+	// Raw hashes are never signed
+	if _, err := wallet.SignHash(accounts.Account{Address: account.Address}, make([]byte, 32)); err != errNotSupported {
+		t.Fatalf("error mismatch for hash signing: have %v, want %v", err, errNotSupported)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common/math"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// hardenedOffset is the index from which BIP-32 child keys are hardened.
+const hardenedOffset = 0x80000000
+
+// errInvalidChildKey is returned in the astronomically unlikely case that a key
+// derivation produces an invalid key.
+var errInvalidChildKey = errors.New("invalid derived key, use the next index")
+
+// extendedKey is a BIP-32 extended private key.
+type extendedKey struct {
+	key       []byte // 32 byte private key
+	chainCode []byte // 32 byte chain code
+}
+
+// newMasterKey derives the BIP-32 master key from a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(sum[:32]); k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the child private key at the given index.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = compressPubkey(&priv.PublicKey)
+	}
+	var enc [4]byte
+	binary.BigEndian.PutUint32(enc[:], index)
+	data = append(data, enc[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	child := il.Add(il, new(big.Int).SetBytes(k.key))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, errInvalidChildKey
+	}
+	return &extendedKey{key: math.PaddedBigBytes(child, 32), chainCode: sum[32:]}, nil
+}
+
+// derive walks down the derivation path, returning the private key at its end.
+func (k *extendedKey) derive(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	var err error
+	for _, index := range path {
+		if k, err = k.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(k.key)
+}
+
+// compressPubkey encodes a public key in the 33 byte compressed format.
+func compressPubkey(pub *ecdsa.PublicKey) []byte {
+	enc := make([]byte, 33)
+	enc[0] = 0x02 + byte(pub.Y.Bit(0))
+	copy(enc[1:], math.PaddedBigBytes(pub.X, 32))
+	return enc
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	// ErrInvalidMnemonic is returned if a mnemonic contains unknown words, has an
+	// invalid length or its checksum doesn't match.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrInvalidEntropyLength is returned if the entropy size is not a multiple of
+	// 32 bits between 128 and 256 bits.
+	ErrInvalidEntropyLength = errors.New("entropy length must be [128, 256] and a multiple of 32")
+)
+
+var (
+	wordList  []string       // BIP-39 English word list
+	wordIndex map[string]int // Reverse lookup of the word list
+)
+
+func init() {
+	if crc32.ChecksumIEEE([]byte(english)) != englishWordlistChecksum {
+		panic("BIP-39 word list checksum mismatch")
+	}
+	wordList = strings.Split(strings.TrimSpace(english), "\n")
+	wordIndex = make(map[string]int, len(wordList))
+	for i, word := range wordList {
+		wordIndex[word] = i
+	}
+}
+
+// NewEntropy creates random entropy of the given bit size to generate a mnemonic
+// from. Valid sizes are 128, 160, 192, 224 and 256 bits.
+func NewEntropy(bits int) ([]byte, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return nil, ErrInvalidEntropyLength
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// NewMnemonic encodes the entropy as a BIP-39 mnemonic sentence: every word
+// represents 11 bits of the entropy followed by a checksum of entropy/32 bits.
+func NewMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", ErrInvalidEntropyLength
+	}
+	checksumBits := uint(bits / 32)
+	checksum := sha256.Sum256(entropy)
+
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	words := make([]string, (bits+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = wordList[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a BIP-39 mnemonic sentence, validating its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[strings.ToLower(word)]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) * 11 / 33)
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1))
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, len(words)*11/33*4)
+	blob := data.Bytes()
+	copy(entropy[len(entropy)-len(blob):], blob)
+
+	want := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(want[0]>>(8-checksumBits)) {
+		return nil, fmt.Errorf("%v: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+// NormalizeMnemonic returns the canonical form of a mnemonic sentence, with the
+// words lowercased and separated by single spaces.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+}
+
+// NewSeed derives the 512 bit BIP-32 seed from a mnemonic and an optional
+// passphrase. Note, the mnemonic and passphrase are used as given: non-ASCII
+// passphrases are not NFKD normalized as required by BIP-39.
+func NewSeed(mnemonic, passphrase string) []byte {
+	return pbkdf2.Key([]byte(NormalizeMnemonic(mnemonic)), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
@@ -0,0 +1,339 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// Test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+var bip39Tests = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"80808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+}
+
+func TestBIP39Vectors(t *testing.T) {
+	for i, tt := range bip39Tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("test %d: failed to create mnemonic: %v", i, err)
+		}
+		if mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch:\nhave %s\nwant %s", i, mnemonic, tt.mnemonic)
+		}
+		decoded, err := MnemonicToEntropy(tt.mnemonic)
+		if err != nil || hex.EncodeToString(decoded) != tt.entropy {
+			t.Errorf("test %d: entropy mismatch: have %x (%v), want %s", i, decoded, err, tt.entropy)
+		}
+		if seed := hex.EncodeToString(NewSeed(tt.mnemonic, "TREZOR")); seed != tt.seed {
+			t.Errorf("test %d: seed mismatch:\nhave %s\nwant %s", i, seed, tt.seed)
+		}
+	}
+}
+
+func TestBIP39Invalid(t *testing.T) {
+	tests := []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",   // bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",             // 11 words
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon fairblock", // unknown word
+	}
+	for i, mnemonic := range tests {
+		if _, err := MnemonicToEntropy(mnemonic); err == nil {
+			t.Errorf("test %d: invalid mnemonic accepted", i)
+		}
+	}
+	if _, err := NewEntropy(100); err != ErrInvalidEntropyLength {
+		t.Errorf("error mismatch for invalid entropy length: have %v, want %v", err, ErrInvalidEntropyLength)
+	}
+}
+
+// Test vector 1 from https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+func TestBIP32Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path accounts.DerivationPath
+		key  string
+	}{
+		{accounts.DerivationPath{}, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{accounts.DerivationPath{hardenedOffset}, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{accounts.DerivationPath{hardenedOffset, 1}, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{accounts.DerivationPath{hardenedOffset, 1, hardenedOffset + 2}, "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+	}
+	for _, tt := range tests {
+		key, err := master.derive(tt.path)
+		if err != nil {
+			t.Fatalf("%v: derivation failed: %v", tt.path, err)
+		}
+		if have := hex.EncodeToString(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("%v: key mismatch: have %s, want %s", tt.path, have, tt.key)
+		}
+	}
+}
+
+func TestDefaultPathDerivation(t *testing.T) {
+	master, err := newMasterKey(NewSeed(bip39Tests[0].mnemonic, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.derive(accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if have := crypto.PubkeyToAddress(key.PublicKey); have != want {
+		t.Fatalf("address mismatch: have %x, want %x", have, want)
+	}
+}
+
+func tmpHub(t *testing.T) (*Hub, string) {
+	dir, err := ioutil.TempDir("", "hdwallet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHub(dir, keystore.LightScryptN, keystore.LightScryptP), dir
+}
+
+func TestWalletLifecycle(t *testing.T) {
+	hub, dir := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	account, err := hub.Import(bip39Tests[0].mnemonic, "password")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if account.Address != common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94") {
+		t.Fatalf("imported account mismatch: have %x", account.Address)
+	}
+	if _, err := hub.Import(bip39Tests[0].mnemonic, "other"); err != ErrWalletExists {
+		t.Fatalf("error mismatch for duplicate import: have %v, want %v", err, ErrWalletExists)
+	}
+	wallets := hub.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if !strings.HasPrefix(account.URL.String(), wallet.URL().String()+"/") {
+		t.Errorf("account URL %v not within wallet URL %v", account.URL, wallet.URL())
+	}
+	// Locked wallets only expose the pinned accounts and can't derive new ones
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("locked account list mismatch: have %v", accs)
+	}
+	path := accounts.DerivationPath{hardenedOffset + 44, hardenedOffset + 60, hardenedOffset, 0, 1}
+	if _, err := wallet.Derive(path, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("error mismatch for deriving from a locked wallet: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := wallet.SignTx(account, tx, big.NewInt(1)); err != keystore.ErrLocked {
+		t.Fatalf("error mismatch for signing with a locked wallet: have %v, want %v", err, keystore.ErrLocked)
+	}
+	// Signing with the passphrase works while locked
+	if _, err := wallet.SignTxWithPassphrase(account, "wrong", tx, big.NewInt(1)); err != keystore.ErrDecrypt {
+		t.Fatalf("error mismatch for invalid passphrase: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	signed, err := wallet.SignTxWithPassphrase(account, "password", tx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to sign with passphrase: %v", err)
+	}
+	if sender, _ := types.Sender(types.NewEIP155Signer(big.NewInt(1)), signed); sender != account.Address {
+		t.Fatalf("sender mismatch: have %x, want %x", sender, account.Address)
+	}
+	// Open the wallet and pin a new account
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	derived, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if !wallet.Contains(derived) {
+		t.Fatalf("pinned account not contained in wallet")
+	}
+	hash := crypto.Keccak256([]byte("message"))
+	sig, err := wallet.SignHash(derived, hash)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != derived.Address {
+		t.Fatalf("signature recovery mismatch: %v", err)
+	}
+	wallet.Close()
+
+	// Pinned accounts must survive reloading the wallet
+	reloaded := NewHub(dir, keystore.LightScryptN, keystore.LightScryptP).Wallets()[0]
+	if accs := reloaded.Accounts(); len(accs) != 2 || accs[1].Address != derived.Address {
+		t.Fatalf("reloaded account list mismatch: have %v", accs)
+	}
+}
+
+// testChainState is a chain state reader reporting a balance for a set of funded
+// accounts, blocking until released.
+type testChainState struct {
+	funded map[common.Address]bool
+	calls  chan struct{} // Signalled on every balance query
+	gate   chan struct{} // Closed to let the balance queries proceed
+}
+
+func (c *testChainState) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	select {
+	case c.calls <- struct{}{}:
+	default:
+	}
+	<-c.gate
+	if c.funded[account] {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int), nil
+}
+
+func (c *testChainState) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChainState) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *testChainState) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 0, nil
+}
+
+// Tests that used accounts are discovered by self-derivation, without a slow
+// chain backend stalling the wallet meanwhile.
+func TestWalletSelfDerive(t *testing.T) {
+	hub, dir := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	account, err := hub.Import(bip39Tests[0].mnemonic, "password")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	wallet := hub.Wallets()[0]
+	if err := wallet.Open("password"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	chain := &testChainState{
+		funded: map[common.Address]bool{account.Address: true},
+		calls:  make(chan struct{}, 1),
+		gate:   make(chan struct{}),
+	}
+	wallet.SelfDerive(accounts.DefaultBaseDerivationPath, chain)
+
+	listed := make(chan []accounts.Account)
+	go func() {
+		// Requests are dropped while the self-deriver is busy or not yet running
+		for {
+			if accs := wallet.Accounts(); len(accs) > 1 {
+				listed <- accs
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	<-chain.calls
+
+	// While the chain is queried, the wallet state must remain accessible
+	done := make(chan struct{})
+	go func() {
+		wallet.Status()
+		wallet.Contains(account)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("wallet stalled by self-derivation")
+	}
+	close(chain.gate)
+
+	// The funded account and the first empty one after it should be tracked
+	accs := <-listed
+	if len(accs) != 2 || accs[0].Address != account.Address {
+		t.Fatalf("self-derived account list mismatch: have %v", accs)
+	}
+}
+
+func TestNewMnemonicWallet(t *testing.T) {
+	hub, dir := tmpHub(t)
+	defer os.RemoveAll(dir)
+
+	mnemonic, account, err := hub.NewMnemonic("password")
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if words := strings.Fields(mnemonic); len(words) != 24 {
+		t.Fatalf("mnemonic length mismatch: have %d words, want 24", len(words))
+	}
+	// Importing the mnemonic elsewhere must recreate the same account
+	other, otherDir := tmpHub(t)
+	defer os.RemoveAll(otherDir)
+
+	imported, err := other.Import(mnemonic, "another password")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if imported.Address != account.Address {
+		t.Fatalf("imported account mismatch: have %x, want %x", imported.Address, account.Address)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hdwallet implements a software hierarchical deterministic wallet, which
+// derives its accounts from a BIP-39 mnemonic seed stored encrypted on disk.
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// Scheme is the URL scheme of the mnemonic wallets.
+const Scheme = "hdwallet"
+
+// HubType is the reflect type of a mnemonic wallet backend.
+var HubType = reflect.TypeOf(&Hub{})
+
+// walletRefreshCycle is the interval at which the wallet directory is rescanned
+// while there are subscribers.
+const walletRefreshCycle = 3 * time.Second
+
+// ErrWalletExists is returned if a mnemonic is imported which is already present.
+var ErrWalletExists = errors.New("wallet already exists")
+
+// Hub is an accounts.Backend managing the mnemonic wallets stored in a directory.
+type Hub struct {
+	dir     string // Directory the wallet files are stored in
+	scryptN int    // Scrypt parameters to encrypt new seeds with
+	scryptP int
+
+	wallets     []*wallet               // Wallets loaded from the directory, sorted by URL
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whether the event notification loop is running
+
+	lock sync.Mutex
+}
+
+// NewHub creates a backend for the mnemonic wallets in the given directory,
+// encrypting new seeds with the given scrypt parameters.
+func NewHub(dir string, scryptN, scryptP int) *Hub {
+	hub := &Hub{dir: dir, scryptN: scryptN, scryptP: scryptP}
+	hub.refreshWallets()
+	return hub
+}
+
+// Wallets implements accounts.Backend, returning all the mnemonic wallets.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	hub.refreshWallets()
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	cpy := make([]accounts.Wallet, len(hub.wallets))
+	for i, wallet := range hub.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// refreshWallets rescans the wallet directory, loading new wallet files and
+// dropping the removed ones.
+func (hub *Hub) refreshWallets() {
+	files, err := ioutil.ReadDir(hub.dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to read HD wallet directory", "dir", hub.dir, "err", err)
+		return
+	}
+	var paths []string
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		paths = append(paths, filepath.Join(hub.dir, fi.Name()))
+	}
+	sort.Strings(paths)
+
+	hub.lock.Lock()
+	var (
+		wallets = make([]*wallet, 0, len(paths))
+		events  []accounts.WalletEvent
+		known   = make(map[string]*wallet, len(hub.wallets))
+	)
+	for _, wallet := range hub.wallets {
+		known[wallet.path] = wallet
+	}
+	for _, path := range paths {
+		if wallet, ok := known[path]; ok {
+			wallets = append(wallets, wallet)
+			delete(known, path)
+			continue
+		}
+		wallet, err := loadWallet(hub, path)
+		if err != nil {
+			log.Warn("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range known {
+		wallet.Close()
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	hub.wallets = wallets
+	hub.lock.Unlock()
+
+	for _, event := range events {
+		hub.updateFeed.Send(event)
+	}
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of mnemonic wallets.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	sub := hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+	if !hub.updating {
+		hub.updating = true
+		go hub.updater()
+	}
+	return sub
+}
+
+// updater periodically rescans the wallet directory while there are subscribers.
+func (hub *Hub) updater() {
+	for {
+		time.Sleep(walletRefreshCycle)
+		hub.refreshWallets()
+
+		hub.lock.Lock()
+		if hub.updateScope.Count() == 0 {
+			hub.updating = false
+			hub.lock.Unlock()
+			return
+		}
+		hub.lock.Unlock()
+	}
+}
+
+// NewMnemonic generates a random 24 word mnemonic and stores a wallet for it,
+// encrypted with the given password. The mnemonic is returned for the user to
+// back it up, it cannot be recovered from the wallet.
+func (hub *Hub) NewMnemonic(password string) (string, accounts.Account, error) {
+	entropy, err := NewEntropy(256)
+	if err != nil {
+		return "", accounts.Account{}, err
+	}
+	mnemonic, err := NewMnemonic(entropy)
+	if err != nil {
+		return "", accounts.Account{}, err
+	}
+	account, err := hub.Import(mnemonic, password)
+	if err != nil {
+		return "", accounts.Account{}, err
+	}
+	return mnemonic, account, nil
+}
+
+// Import stores a wallet for an existing mnemonic, encrypted with the given
+// password. The account at the default derivation path is pinned, so it's
+// visible while the wallet is locked, and returned.
+func (hub *Hub) Import(mnemonic, password string) (accounts.Account, error) {
+	if _, err := MnemonicToEntropy(mnemonic); err != nil {
+		return accounts.Account{}, err
+	}
+	seed := NewSeed(mnemonic, "")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	key, err := master.derive(accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	path := filepath.Join(hub.dir, fmt.Sprintf("hdwallet--%x.json", addr))
+	if _, err := os.Stat(path); err == nil {
+		return accounts.Account{}, ErrWalletExists
+	}
+	encrypted, err := keystore.EncryptDataV3(seed, []byte(password), hub.scryptN, hub.scryptP)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	file := walletFile{
+		Version:  walletFileVersion,
+		Crypto:   encrypted,
+		Accounts: []pinnedAccount{{Address: addr, Path: accounts.DefaultBaseDerivationPath.String()}},
+	}
+	if err := writeWalletFile(path, file); err != nil {
+		return accounts.Account{}, err
+	}
+	hub.refreshWallets()
+
+	return accounts.Account{
+		Address: addr,
+		URL:     accounts.URL{Scheme: Scheme, Path: fmt.Sprintf("%s/%s", path, accounts.DefaultBaseDerivationPath)},
+	}, nil
+}
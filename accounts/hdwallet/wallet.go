@@ -0,0 +1,490 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fairblock/go-fairblock"
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// walletFileVersion is the version of the wallet file format.
+const walletFileVersion = 1
+
+// selfDeriveThrottling is the minimum time between account discoveries.
+const selfDeriveThrottling = time.Second
+
+// walletFile is the on-disk representation of a mnemonic wallet.
+type walletFile struct {
+	Version  int                 `json:"version"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`   // BIP-32 seed encrypted with the wallet password
+	Accounts []pinnedAccount     `json:"accounts"` // Accounts pinned to the wallet, visible while locked
+}
+
+// pinnedAccount is an account derived and pinned to the wallet.
+type pinnedAccount struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+// wallet is a software HD wallet, deriving its accounts from an encrypted BIP-39
+// mnemonic seed.
+type wallet struct {
+	hub  *Hub         // Backend the wallet was loaded by
+	url  accounts.URL // Canonical URL of the wallet
+	path string       // File the wallet is stored in
+	log  log.Logger
+
+	stateLock sync.RWMutex                               // Protects the fields below
+	crypto    keystore.CryptoJSON                        // Encrypted seed
+	master    *extendedKey                               // Master key, nil if the wallet is closed
+	accounts  []accounts.Account                         // Pinned and self-derived accounts
+	pinned    map[common.Address]bool                    // Accounts persisted in the wallet file
+	paths     map[common.Address]accounts.DerivationPath // Derivation paths of the accounts
+
+	deriveNextPath accounts.DerivationPath    // Next derivation path for account auto-discovery
+	deriveChain    fairblock.ChainStateReader // Blockchain state reader to discover used account with
+	deriveReq      chan chan struct{}         // Channel to request a self-derivation on
+	deriveQuit     chan chan struct{}         // Channel to terminate the self-deriver with
+}
+
+// loadWallet reads the wallet stored in the given file.
+func loadWallet(hub *Hub, path string) (*wallet, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file walletFile
+	if err := json.Unmarshal(blob, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != walletFileVersion {
+		return nil, fmt.Errorf("unsupported wallet version %d", file.Version)
+	}
+	w := &wallet{
+		hub:    hub,
+		url:    accounts.URL{Scheme: Scheme, Path: path},
+		path:   path,
+		crypto: file.Crypto,
+		pinned: make(map[common.Address]bool),
+		paths:  make(map[common.Address]accounts.DerivationPath),
+	}
+	w.log = log.New("url", w.url)
+	for _, acc := range file.Accounts {
+		derivPath, err := accounts.ParseDerivationPath(acc.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path of account %x: %v", acc.Address, err)
+		}
+		w.track(acc.Address, derivPath)
+		w.pinned[acc.Address] = true
+	}
+	return w, nil
+}
+
+// track starts tracking a derived account. The caller must hold the state lock.
+func (w *wallet) track(addr common.Address, path accounts.DerivationPath) accounts.Account {
+	account := accounts.Account{
+		Address: addr,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	}
+	if _, ok := w.paths[addr]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[addr] = path
+	}
+	return account
+}
+
+// store writes the wallet with its pinned accounts to disk. The caller must hold
+// the state lock.
+func (w *wallet) store() error {
+	file := walletFile{Version: walletFileVersion, Crypto: w.crypto}
+	for _, acc := range w.accounts {
+		if w.pinned[acc.Address] {
+			file.Accounts = append(file.Accounts, pinnedAccount{Address: acc.Address, Path: w.paths[acc.Address].String()})
+		}
+	}
+	return writeWalletFile(w.path, file)
+}
+
+// writeWalletFile atomically writes a wallet file readable only by its owner.
+func writeWalletFile(path string, file walletFile) error {
+	blob, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), path)
+}
+
+// unlock decrypts the master key of the wallet with the given password.
+func (w *wallet) unlock(passphrase string) (*extendedKey, error) {
+	w.stateLock.RLock()
+	crypto := w.crypto
+	w.stateLock.RUnlock()
+
+	seed, err := keystore.DecryptDataV3(crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return newMasterKey(seed)
+}
+
+// URL implements accounts.Wallet, returning the path of the wallet file.
+func (w *wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the seed is unlocked.
+func (w *wallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master == nil {
+		return "Locked", nil
+	}
+	return "Unlocked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed with the passphrase and
+// keeping it in memory until the wallet is closed.
+func (w *wallet) Open(passphrase string) error {
+	master, err := w.unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	w.stateLock.Lock()
+	if w.master != nil {
+		w.stateLock.Unlock()
+		return accounts.ErrWalletAlreadyOpen
+	}
+	w.master = master
+	w.deriveReq = make(chan chan struct{})
+	w.deriveQuit = make(chan chan struct{})
+	go w.selfDerive(w.deriveReq, w.deriveQuit)
+	w.stateLock.Unlock()
+
+	w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// Close implements accounts.Wallet, stopping the self-derivation and dropping
+// the decrypted seed.
+func (w *wallet) Close() error {
+	// Terminate the self-derivation first, it might be using the seed
+	w.stateLock.RLock()
+	dQuit := w.deriveQuit
+	w.stateLock.RUnlock()
+
+	if dQuit != nil {
+		done := make(chan struct{})
+		dQuit <- done
+		<-done
+	}
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveReq, w.deriveQuit = nil, nil
+	if w.master != nil {
+		zeroBytes(w.master.key)
+		zeroBytes(w.master.chainCode)
+		w.master = nil
+	}
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the pinned accounts and, if the
+// wallet is open, the ones discovered by self-derivation.
+func (w *wallet) Accounts() []accounts.Account {
+	// Attempt self-derivation if it's running
+	w.stateLock.RLock()
+	dReq := w.deriveReq
+	w.stateLock.RUnlock()
+
+	reqc := make(chan struct{}, 1)
+	select {
+	case dReq <- reqc:
+		// Self-derivation request accepted, wait for it
+		<-reqc
+	default:
+		// Self-derivation offline, throttled or busy, skip
+	}
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	_, exists := w.paths[account.Address]
+	return exists
+}
+
+// Derive implements accounts.Wallet, deriving the account at the given path from
+// the seed of an open wallet. Pinned accounts are persisted in the wallet file.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	key, err := w.master.derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	if !pin {
+		return accounts.Account{
+			Address: addr,
+			URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+		}, nil
+	}
+	account := w.track(addr, path)
+	if !w.pinned[addr] {
+		w.pinned[addr] = true
+		if err := w.store(); err != nil {
+			delete(w.pinned, addr)
+			return accounts.Account{}, err
+		}
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, trying to discover accounts that the
+// user used previously (based on the chain state), but ones that he/she did not
+// explicitly pin to the wallet manually. Discovery only runs while the wallet
+// is open, in the background, requested by account listings.
+func (w *wallet) SelfDerive(base accounts.DerivationPath, chain fairblock.ChainStateReader) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPath = make(accounts.DerivationPath, len(base))
+	copy(w.deriveNextPath[:], base[:])
+
+	w.deriveChain = chain
+}
+
+// selfDerive is an account derivation loop that upon request derives accounts
+// from the next derivation path onwards until one without any balance or nonce
+// is found, which is tracked too. The chain is queried without holding the state
+// lock, so a slow backend doesn't stall the other wallet operations.
+func (w *wallet) selfDerive(reqs chan chan struct{}, quit chan chan struct{}) {
+	w.log.Debug("HD wallet self-derivation started")
+	defer w.log.Debug("HD wallet self-derivation stopped")
+
+	for {
+		// Wait until either derivation or termination is requested
+		var reqc chan struct{}
+		select {
+		case done := <-quit:
+			close(done)
+			return
+		case reqc = <-reqs:
+		}
+		// Derivation needs a chain to check the accounts against
+		w.stateLock.RLock()
+		master, chain := w.master, w.deriveChain
+		nextPath := make(accounts.DerivationPath, len(w.deriveNextPath))
+		copy(nextPath[:], w.deriveNextPath[:])
+		w.stateLock.RUnlock()
+
+		if master == nil || chain == nil || len(nextPath) == 0 {
+			reqc <- struct{}{}
+			continue
+		}
+		var (
+			addrs []common.Address
+			paths []accounts.DerivationPath
+
+			ctx = context.Background()
+		)
+		for empty := false; !empty; {
+			key, err := master.derive(nextPath)
+			if err != nil {
+				w.log.Warn("HD wallet account derivation failed", "err", err)
+				break
+			}
+			addr := crypto.PubkeyToAddress(key.PublicKey)
+			zeroKey(key)
+
+			balance, err := chain.BalanceAt(ctx, addr, nil)
+			if err != nil {
+				w.log.Warn("HD wallet balance retrieval failed", "err", err)
+				break
+			}
+			nonce, err := chain.NonceAt(ctx, addr, nil)
+			if err != nil {
+				w.log.Warn("HD wallet nonce retrieval failed", "err", err)
+				break
+			}
+			path := make(accounts.DerivationPath, len(nextPath))
+			copy(path[:], nextPath[:])
+			addrs, paths = append(addrs, addr), append(paths, path)
+
+			// Stop at the first empty account, checking it again next time
+			if empty = balance.Sign() == 0 && nonce == 0; !empty {
+				nextPath[len(nextPath)-1]++
+			}
+			w.log.Trace("HD wallet checked account", "address", addr, "path", path, "balance", balance, "nonce", nonce)
+		}
+		// Track the derived accounts, unless the derivation was reset meanwhile
+		w.stateLock.Lock()
+		if w.deriveChain == chain && len(paths) > 0 && paths[0].String() == w.deriveNextPath.String() {
+			for i, addr := range addrs {
+				if _, known := w.paths[addr]; !known {
+					w.log.Info("HD wallet discovered new account", "address", addr, "path", paths[i])
+				}
+				w.track(addr, paths[i])
+			}
+			w.deriveNextPath = nextPath
+		}
+		w.stateLock.Unlock()
+
+		// Notify the requester and wait a bit before deriving again (to avoid trashing)
+		reqc <- struct{}{}
+		select {
+		case done := <-quit:
+			close(done)
+			return
+		case <-time.After(selfDeriveThrottling):
+		}
+	}
+}
+
+// signingKey derives the private key of a tracked account from the given master
+// key, or from the unlocked seed if nil.
+func (w *wallet) signingKey(account accounts.Account, master *extendedKey) (*ecdsa.PrivateKey, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if master == nil {
+		if master = w.master; master == nil {
+			return nil, keystore.ErrLocked
+		}
+	}
+	return master.derive(path)
+}
+
+// SignHash implements accounts.Wallet, signing the hash with the key of an
+// account in an open wallet.
+func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return w.signHash(account, nil, hash)
+}
+
+// SignTx implements accounts.Wallet, signing the transaction with the key of an
+// account in an open wallet.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signTx(account, nil, tx, chainID)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, decrypting the seed with
+// the passphrase for this signature only.
+func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	master, err := w.unlock(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return w.signHash(account, master, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, decrypting the seed with the
+// passphrase for this signature only.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	master, err := w.unlock(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return w.signTx(account, master, tx, chainID)
+}
+
+func (w *wallet) signHash(account accounts.Account, master *extendedKey, hash []byte) ([]byte, error) {
+	key, err := w.signingKey(account, master)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	return crypto.Sign(hash, key)
+}
+
+func (w *wallet) signTx(account accounts.Account, master *extendedKey, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.signingKey(account, master)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	// Depending on the presence of the chain ID, sign with EIP155 or homestead
+	if chainID != nil {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	}
+	return types.SignTx(tx, types.HomesteadSigner{}, key)
+}
+
+// zeroKey zeroes a private key in memory.
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroBytes zeroes a byte slice in memory.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/math"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/crypto/randentropy"
+	"github.com/pborman/uuid"
+)
+
+const (
+	backupVersion = 1
+	backupCipher  = "aes-256-gcm"
+	backupDKLen   = 32
+)
+
+var (
+	ErrPlaintextStore = errors.New("key store is not encrypted")
+	ErrBackupVersion  = errors.New("unsupported backup version")
+)
+
+// Reencrypt decrypts the keys of the given accounts with their passphrases and
+// encrypts them again with the new passphrase, deriving the encryption key with
+// the specified key derivation function. A single passphrase is used for all the
+// accounts. All keys are decrypted before any is rewritten, so a wrong passphrase
+// for any account leaves the key store intact.
+func (ks *KeyStore) Reencrypt(accs []accounts.Account, passphrases []string, newPassphrase string, kdf KDF) error {
+	if err := checkPassphrases(accs, passphrases); err != nil {
+		return err
+	}
+	if _, ok := ks.storage.(*keyStorePassphrase); !ok {
+		return ErrPlaintextStore
+	}
+	if _, err := StandardKDF(kdf.Name); err != nil {
+		return err
+	}
+	// Decrypt all the keys, bailing out on the first failure
+	files := make([]string, 0, len(accs))
+	keys := make([]*Key, 0, len(accs))
+	defer func() {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+	}()
+	for i, acc := range accs {
+		a, key, err := ks.getDecryptedKey(acc, passphraseOf(passphrases, i))
+		if err != nil {
+			return fmt.Errorf("account %x: %v", acc.Address, err)
+		}
+		files = append(files, a.URL.Path)
+		keys = append(keys, key)
+	}
+	// Re-encrypt and atomically replace each of the key files
+	for i, key := range keys {
+		keyjson, err := EncryptKeyKDF(key, newPassphrase, kdf)
+		if err != nil {
+			return err
+		}
+		if err := writeKeyFile(files[i], keyjson); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupInfo is the metadata of a backup bundle, readable without its passphrase.
+type BackupInfo struct {
+	Version  int              // Version of the bundle format
+	Created  time.Time        // Time the bundle was created at
+	Accounts []common.Address // Accounts contained in the bundle
+}
+
+// backupHeaderJSON is the metadata of a backup bundle, authenticated alongside
+// the encrypted keys.
+type backupHeaderJSON struct {
+	Version   int                    `json:"version"`
+	Created   int64                  `json:"created"`
+	Accounts  []common.Address       `json:"accounts"`
+	Cipher    string                 `json:"cipher"`
+	Nonce     string                 `json:"nonce"`
+	KDF       string                 `json:"kdf"`
+	KDFParams map[string]interface{} `json:"kdfparams"`
+}
+
+// backupJSON is the on-disk format of a backup bundle.
+type backupJSON struct {
+	backupHeaderJSON
+	CipherText string `json:"ciphertext"`
+}
+
+// backupKeyJSON is a single plaintext key within a backup bundle.
+type backupKeyJSON struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"privatekey"`
+	Id         string `json:"id"`
+}
+
+// ExportBackup creates an encrypted backup bundle of the given accounts. The keys
+// are decrypted with their passphrases, or a single one used for all accounts,
+// and the whole bundle is encrypted and authenticated with the backup passphrase,
+// deriving the encryption key with the specified key derivation function.
+func (ks *KeyStore) ExportBackup(accs []accounts.Account, passphrases []string, backupPassphrase string, kdf KDF) ([]byte, error) {
+	if len(accs) == 0 {
+		return nil, errors.New("no accounts to back up")
+	}
+	if err := checkPassphrases(accs, passphrases); err != nil {
+		return nil, err
+	}
+	// Collect all the decrypted keys into the plaintext payload
+	header := backupHeaderJSON{
+		Version: backupVersion,
+		Created: time.Now().Unix(),
+		Cipher:  backupCipher,
+	}
+	entries := make([]backupKeyJSON, 0, len(accs))
+	for i, acc := range accs {
+		_, key, err := ks.getDecryptedKey(acc, passphraseOf(passphrases, i))
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", acc.Address, err)
+		}
+		header.Accounts = append(header.Accounts, key.Address)
+		entries = append(entries, backupKeyJSON{
+			Address:    hex.EncodeToString(key.Address[:]),
+			PrivateKey: hex.EncodeToString(math.PaddedBigBytes(key.PrivateKey.D, 32)),
+			Id:         key.Id.String(),
+		})
+		zeroKey(key.PrivateKey)
+	}
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	// Encrypt the payload, authenticating the header along with it
+	derivedKey, kdfParams, err := kdf.deriveKey([]byte(backupPassphrase), backupDKLen)
+	if err != nil {
+		return nil, err
+	}
+	header.KDF, header.KDFParams = kdf.Name, kdfParams
+
+	aead, err := newBackupAEAD(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := randentropy.GetEntropyCSPRNG(aead.NonceSize())
+	header.Nonce = hex.EncodeToString(nonce)
+
+	ad, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, ad)
+	return json.Marshal(backupJSON{header, hex.EncodeToString(ciphertext)})
+}
+
+// ReadBackupInfo parses the metadata of a backup bundle. Note, the metadata is
+// only authenticated when the bundle is imported with its passphrase.
+func ReadBackupInfo(bundle []byte) (*BackupInfo, error) {
+	backup, err := parseBackup(bundle)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupInfo{
+		Version:  backup.Version,
+		Created:  time.Unix(backup.Created, 0),
+		Accounts: backup.Accounts,
+	}, nil
+}
+
+// ImportBackup decrypts a backup bundle with the backup passphrase and stores
+// its keys into the key directory, encrypted with the passphrase. Accounts which
+// are already present in the key store are skipped; the newly imported ones are
+// returned.
+func (ks *KeyStore) ImportBackup(bundle []byte, backupPassphrase, passphrase string) ([]accounts.Account, error) {
+	backup, err := parseBackup(bundle)
+	if err != nil {
+		return nil, err
+	}
+	// Authenticate and decrypt the payload
+	nonce, err := hex.DecodeString(backup.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(backup.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := deriveKDFKey(backup.KDF, backup.KDFParams, []byte(backupPassphrase))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newBackupAEAD(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid backup nonce length %d", len(nonce))
+	}
+	ad, err := json.Marshal(backup.backupHeaderJSON)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	var entries []backupKeyJSON
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) != len(backup.Accounts) {
+		return nil, fmt.Errorf("backup contains %d keys, header lists %d", len(entries), len(backup.Accounts))
+	}
+	// Reconstruct all the keys before importing any of them
+	keys := make([]*Key, 0, len(entries))
+	defer func() {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+	}()
+	for i, entry := range entries {
+		priv, err := crypto.HexToECDSA(entry.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		key := &Key{
+			Id:         uuid.Parse(entry.Id),
+			Address:    crypto.PubkeyToAddress(priv.PublicKey),
+			PrivateKey: priv,
+		}
+		keys = append(keys, key)
+
+		if key.Address != backup.Accounts[i] || common.HexToAddress(entry.Address) != key.Address {
+			return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, backup.Accounts[i])
+		}
+		if key.Id == nil {
+			key.Id = uuid.NewRandom()
+		}
+	}
+	var imported []accounts.Account
+	for _, key := range keys {
+		if ks.cache.hasAddress(key.Address) {
+			continue
+		}
+		a, err := ks.importKey(key, passphrase)
+		if err != nil {
+			return imported, err
+		}
+		imported = append(imported, a)
+	}
+	return imported, nil
+}
+
+// checkPassphrases ensures that either a single passphrase or one for each of the
+// accounts was given.
+func checkPassphrases(accs []accounts.Account, passphrases []string) error {
+	if len(passphrases) != 1 && len(passphrases) != len(accs) {
+		return fmt.Errorf("passphrase count mismatch: have %d, want 1 or %d", len(passphrases), len(accs))
+	}
+	return nil
+}
+
+// passphraseOf returns the passphrase of the i-th account.
+func passphraseOf(passphrases []string, i int) string {
+	if len(passphrases) == 1 {
+		return passphrases[0]
+	}
+	return passphrases[i]
+}
+
+// parseBackup parses a backup bundle and checks its format.
+func parseBackup(bundle []byte) (*backupJSON, error) {
+	backup := new(backupJSON)
+	if err := json.Unmarshal(bundle, backup); err != nil {
+		return nil, err
+	}
+	if backup.Version != backupVersion {
+		return nil, ErrBackupVersion
+	}
+	if backup.Cipher != backupCipher {
+		return nil, fmt.Errorf("Cipher not supported: %v", backup.Cipher)
+	}
+	return backup, nil
+}
+
+// newBackupAEAD creates the authenticated cipher protecting backup bundles.
+func newBackupAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/accounts"
+)
+
+// Tests that keys can be re-encrypted in bulk with a different key derivation
+// function and that failures leave all the keys untouched.
+func TestReencrypt(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a1, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := ks.NewAccount("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Re-encrypting with a wrong passphrase for any key must not touch any
+	if err := ks.Reencrypt([]accounts.Account{a1, a2}, []string{"foo"}, "baz", Argon2idKDF(1, 64, 1)); err == nil {
+		t.Fatalf("re-encrypted keys with mismatching passphrase")
+	}
+	if err := ks.Unlock(a1, "foo"); err != nil {
+		t.Fatalf("failed to unlock untouched key: %v", err)
+	}
+	// Re-encrypt both keys and check the new key derivation function
+	if err := ks.Reencrypt([]accounts.Account{a1, a2}, []string{"foo", "bar"}, "baz", Argon2idKDF(1, 64, 1)); err != nil {
+		t.Fatalf("failed to re-encrypt keys: %v", err)
+	}
+	for _, a := range []accounts.Account{a1, a2} {
+		keyjson, err := ioutil.ReadFile(a.URL.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(keyjson), `"kdf":"argon2id"`) {
+			t.Errorf("account %x: key not re-encrypted with argon2id: %s", a.Address, keyjson)
+		}
+		if err := ks.Unlock(a, "baz"); err != nil {
+			t.Errorf("account %x: failed to unlock with new passphrase: %v", a.Address, err)
+		}
+	}
+	// Plaintext key stores cannot be re-encrypted
+	pdir, pks := tmpKeyStore(t, false)
+	defer os.RemoveAll(pdir)
+
+	pa, err := pks.NewAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pks.Reencrypt([]accounts.Account{pa}, []string{""}, "", ScryptKDF(veryLightScryptN, veryLightScryptP)); err != ErrPlaintextStore {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrPlaintextStore)
+	}
+}
+
+// Tests that backup bundles can be exported from one key store and restored
+// into another one.
+func TestBackupRestore(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	var accs []accounts.Account
+	for i := 0; i < 3; i++ {
+		a, err := ks.NewAccount("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		accs = append(accs, a)
+	}
+	bundle, err := ks.ExportBackup(accs[:2], []string{"foo"}, "backup", PBKDF2KDF(16))
+	if err != nil {
+		t.Fatalf("failed to export backup: %v", err)
+	}
+	// Check the metadata readable without a passphrase
+	info, err := ReadBackupInfo(bundle)
+	if err != nil {
+		t.Fatalf("failed to read backup info: %v", err)
+	}
+	if len(info.Accounts) != 2 || info.Accounts[0] != accs[0].Address || info.Accounts[1] != accs[1].Address {
+		t.Errorf("backup accounts mismatch: have %x, want %x and %x", info.Accounts, accs[0].Address, accs[1].Address)
+	}
+	// Restore into a fresh key store, failing with the wrong passphrase first
+	rdir, rks := tmpKeyStore(t, true)
+	defer os.RemoveAll(rdir)
+
+	if _, err := rks.ImportBackup(bundle, "wrong", "bar"); err != ErrDecrypt {
+		t.Fatalf("error mismatch with wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	restored, err := rks.ImportBackup(bundle, "backup", "bar")
+	if err != nil {
+		t.Fatalf("failed to restore backup: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("restored account count mismatch: have %d, want 2", len(restored))
+	}
+	for i, a := range restored {
+		if a.Address != accs[i].Address {
+			t.Errorf("restored account %d: address mismatch: have %x, want %x", i, a.Address, accs[i].Address)
+		}
+		if err := rks.Unlock(a, "bar"); err != nil {
+			t.Errorf("restored account %d: failed to unlock: %v", i, err)
+		}
+	}
+	// Restoring again should skip the already present accounts
+	if restored, err = rks.ImportBackup(bundle, "backup", "bar"); err != nil || len(restored) != 0 {
+		t.Errorf("repeated restore mismatch: have %d accounts (%v), want none", len(restored), err)
+	}
+	// Tampering with the metadata must fail authentication
+	tampered := strings.Replace(string(bundle), strings.ToLower(accs[1].Address.Hex()[2:]), strings.ToLower(accs[2].Address.Hex()[2:]), 1)
+	if tampered == string(bundle) {
+		t.Fatalf("failed to tamper with bundle")
+	}
+	if _, err := rks.ImportBackup([]byte(tampered), "backup", "bar"); err != ErrDecrypt {
+		t.Errorf("error mismatch with tampered bundle: have %v, want %v", err, ErrDecrypt)
+	}
+}
+
+// Tests that backups asking for excessive key derivation costs are rejected.
+func TestBackupKDFLimits(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := ks.ExportBackup([]accounts.Account{a}, []string{"foo"}, "backup", ScryptKDF(veryLightScryptN, veryLightScryptP))
+	if err != nil {
+		t.Fatalf("failed to export backup: %v", err)
+	}
+	var crafted map[string]interface{}
+	if err := json.Unmarshal(bundle, &crafted); err != nil {
+		t.Fatal(err)
+	}
+	crafted["kdfparams"].(map[string]interface{})["n"] = 1 << 40
+	if bundle, err = json.Marshal(crafted); err != nil {
+		t.Fatal(err)
+	}
+	rdir, rks := tmpKeyStore(t, true)
+	defer os.RemoveAll(rdir)
+
+	if _, err := rks.ImportBackup(bundle, "backup", "bar"); err == nil || err == ErrDecrypt {
+		t.Errorf("import error mismatch: have %v, want parameter error", err)
+	}
+}
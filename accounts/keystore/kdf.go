@@ -0,0 +1,302 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/fairblock/go-fairblock/crypto/randentropy"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// StandardPBKDF2C is the iteration count of the PBKDF2 key derivation function
+	// used when none is explicitly requested.
+	StandardPBKDF2C = 262144
+
+	// StandardArgon2Time is the number of passes of the Argon2id key derivation
+	// function, using 256MB memory and taking approximately 1s CPU time on a
+	// modern processor.
+	StandardArgon2Time = 4
+
+	// StandardArgon2Memory is the memory in KiB used by the Argon2id key derivation
+	// function, using 256MB memory and taking approximately 1s CPU time on a
+	// modern processor.
+	StandardArgon2Memory = 256 * 1024
+
+	// StandardArgon2Threads is the degree of parallelism of the Argon2id key
+	// derivation function.
+	StandardArgon2Threads = 4
+
+	// LightPBKDF2C is the iteration count of the PBKDF2 key derivation function
+	// used when a fast key derivation is requested.
+	LightPBKDF2C = 4096
+
+	// LightArgon2Time is the number of passes of the Argon2id key derivation
+	// function, using 4MB memory and taking approximately 10ms CPU time on a
+	// modern processor.
+	LightArgon2Time = 1
+
+	// LightArgon2Memory is the memory in KiB used by the Argon2id key derivation
+	// function, using 4MB memory and taking approximately 10ms CPU time on a
+	// modern processor.
+	LightArgon2Memory = 4 * 1024
+
+	// The limits below cap the key derivation costs accepted from key files and
+	// backups, so a crafted one can't exhaust the node's memory or CPU when
+	// decrypted. They leave ample room above the standard parameters.
+	maxKDFKeyLen     = 64              // Derived key length in bytes
+	maxKDFMemory     = 4 * 1024 * 1024 // Memory in KiB used by scrypt or argon2id
+	maxScryptN       = 1 << 22
+	maxScryptR       = 32
+	maxScryptP       = 16
+	maxPBKDF2C       = 1 << 24
+	maxArgon2Time    = 64
+	maxArgon2Memory  = maxKDFMemory
+	maxArgon2Threads = 64
+
+	pbkdf2PRF = "hmac-sha256"
+)
+
+// KDF specifies the key derivation function and its parameters used to derive
+// the encryption key of a key file or backup bundle from a passphrase.
+type KDF struct {
+	Name string // Name of the key derivation function: scrypt, pbkdf2 or argon2id
+
+	ScryptN int // CPU/memory cost parameter of scrypt
+	ScryptP int // Parallelization parameter of scrypt
+
+	PBKDF2C int // Iteration count of pbkdf2
+
+	Argon2Time    uint32 // Number of passes over the memory of argon2id
+	Argon2Memory  uint32 // Memory size of argon2id in KiB
+	Argon2Threads uint8  // Degree of parallelism of argon2id
+}
+
+// ScryptKDF returns a scrypt key derivation function with the given parameters.
+func ScryptKDF(n, p int) KDF {
+	return KDF{Name: "scrypt", ScryptN: n, ScryptP: p}
+}
+
+// PBKDF2KDF returns a pbkdf2 key derivation function with the given iteration
+// count, using HMAC-SHA256 as the pseudorandom function.
+func PBKDF2KDF(c int) KDF {
+	return KDF{Name: "pbkdf2", PBKDF2C: c}
+}
+
+// Argon2idKDF returns an argon2id key derivation function with the given time
+// and memory (in KiB) costs and parallelism.
+func Argon2idKDF(time, memory uint32, threads uint8) KDF {
+	return KDF{Name: "argon2id", Argon2Time: time, Argon2Memory: memory, Argon2Threads: threads}
+}
+
+// StandardKDF returns the named key derivation function with the standard
+// parameters, or an error if the function is unknown.
+func StandardKDF(name string) (KDF, error) {
+	switch name {
+	case "scrypt":
+		return ScryptKDF(StandardScryptN, StandardScryptP), nil
+	case "pbkdf2":
+		return PBKDF2KDF(StandardPBKDF2C), nil
+	case "argon2id":
+		return Argon2idKDF(StandardArgon2Time, StandardArgon2Memory, StandardArgon2Threads), nil
+	}
+	return KDF{}, fmt.Errorf("unsupported KDF: %s", name)
+}
+
+// LightKDF returns the named key derivation function with the light parameters,
+// or an error if the function is unknown.
+func LightKDF(name string) (KDF, error) {
+	switch name {
+	case "scrypt":
+		return ScryptKDF(LightScryptN, LightScryptP), nil
+	case "pbkdf2":
+		return PBKDF2KDF(LightPBKDF2C), nil
+	case "argon2id":
+		return Argon2idKDF(LightArgon2Time, LightArgon2Memory, StandardArgon2Threads), nil
+	}
+	return KDF{}, fmt.Errorf("unsupported KDF: %s", name)
+}
+
+// deriveKey derives a key of the requested length from the passphrase using a
+// freshly generated random salt, returning it along with the parameters which
+// need to be stored to derive it again.
+func (kdf KDF) deriveKey(auth []byte, dkLen int) ([]byte, map[string]interface{}, error) {
+	salt := randentropy.GetEntropyCSPRNG(32)
+
+	params := map[string]interface{}{
+		"dklen": dkLen,
+		"salt":  hex.EncodeToString(salt),
+	}
+	switch kdf.Name {
+	case "scrypt":
+		if err := checkScryptParams(kdf.ScryptN, scryptR, kdf.ScryptP); err != nil {
+			return nil, nil, err
+		}
+		params["n"] = kdf.ScryptN
+		params["r"] = scryptR
+		params["p"] = kdf.ScryptP
+	case "pbkdf2":
+		if err := checkPBKDF2Params(kdf.PBKDF2C); err != nil {
+			return nil, nil, err
+		}
+		params["c"] = kdf.PBKDF2C
+		params["prf"] = pbkdf2PRF
+	case "argon2id":
+		if err := checkArgon2Params(int(kdf.Argon2Time), int(kdf.Argon2Memory), int(kdf.Argon2Threads)); err != nil {
+			return nil, nil, err
+		}
+		params["t"] = int(kdf.Argon2Time)
+		params["m"] = int(kdf.Argon2Memory)
+		params["p"] = int(kdf.Argon2Threads)
+	default:
+		return nil, nil, fmt.Errorf("unsupported KDF: %s", kdf.Name)
+	}
+	key, err := deriveKDFKey(kdf.Name, params, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, params, nil
+}
+
+// deriveKDFKey derives a key from the passphrase using the named key derivation
+// function and its parameters, as stored in a key file.
+func deriveKDFKey(name string, params map[string]interface{}, auth []byte) ([]byte, error) {
+	salthex, ok := params["salt"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing %s salt", name)
+	}
+	salt, err := hex.DecodeString(salthex)
+	if err != nil {
+		return nil, err
+	}
+	dkLen, err := kdfParam(params, "dklen")
+	if err != nil {
+		return nil, err
+	}
+	if dkLen <= 0 || dkLen > maxKDFKeyLen {
+		return nil, fmt.Errorf("invalid %s key length: %d", name, dkLen)
+	}
+	switch name {
+	case keyHeaderKDF:
+		n, r, p, err := kdfParams3(params, "n", "r", "p")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkScryptParams(n, r, p); err != nil {
+			return nil, err
+		}
+		return scrypt.Key(auth, salt, n, r, p, dkLen)
+
+	case "pbkdf2":
+		c, err := kdfParam(params, "c")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPBKDF2Params(c); err != nil {
+			return nil, err
+		}
+		prf, _ := params["prf"].(string)
+		if prf != pbkdf2PRF {
+			return nil, fmt.Errorf("Unsupported PBKDF2 PRF: %s", prf)
+		}
+		return pbkdf2.Key(auth, salt, c, dkLen, sha256.New), nil
+
+	case "argon2id":
+		t, m, p, err := kdfParams3(params, "t", "m", "p")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkArgon2Params(t, m, p); err != nil {
+			return nil, err
+		}
+		return argon2.IDKey(auth, salt, uint32(t), uint32(m), uint8(p), uint32(dkLen)), nil
+	}
+	return nil, fmt.Errorf("Unsupported KDF: %s", name)
+}
+
+// kdfParam retrieves a non-negative integer parameter of a key derivation
+// function, as stored in a key file or decoded from its JSON form.
+func kdfParam(params map[string]interface{}, name string) (int, error) {
+	switch value := params[name].(type) {
+	case int:
+		if value >= 0 {
+			return value, nil
+		}
+	case float64:
+		if value >= 0 && value <= math.MaxInt32 && value == math.Trunc(value) {
+			return int(value), nil
+		}
+	case nil:
+		return 0, fmt.Errorf("missing KDF parameter %q", name)
+	}
+	return 0, fmt.Errorf("invalid KDF parameter %q: %v", name, params[name])
+}
+
+// kdfParams3 retrieves three integer parameters of a key derivation function.
+func kdfParams3(params map[string]interface{}, a, b, c string) (int, int, int, error) {
+	var values [3]int
+	for i, name := range []string{a, b, c} {
+		value, err := kdfParam(params, name)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		values[i] = value
+	}
+	return values[0], values[1], values[2], nil
+}
+
+// checkScryptParams verifies that the scrypt costs are valid and within the
+// limits a key file may ask for.
+func checkScryptParams(n, r, p int) error {
+	if n <= 1 || n&(n-1) != 0 || r <= 0 || p <= 0 {
+		return fmt.Errorf("invalid scrypt parameters: n=%d r=%d p=%d", n, r, p)
+	}
+	if n > maxScryptN || r > maxScryptR || p > maxScryptP || 128*n*r/1024 > maxKDFMemory {
+		return fmt.Errorf("scrypt parameters too costly: n=%d r=%d p=%d, max n=%d r=%d p=%d", n, r, p, maxScryptN, maxScryptR, maxScryptP)
+	}
+	return nil
+}
+
+// checkPBKDF2Params verifies that the PBKDF2 iteration count is positive and
+// within the limits a key file may ask for.
+func checkPBKDF2Params(c int) error {
+	if c <= 0 {
+		return fmt.Errorf("invalid PBKDF2 iteration count: %d", c)
+	}
+	if c > maxPBKDF2C {
+		return fmt.Errorf("PBKDF2 iteration count too costly: %d, max %d", c, maxPBKDF2C)
+	}
+	return nil
+}
+
+// checkArgon2Params verifies that the Argon2id costs are positive and within the
+// limits a key file may ask for.
+func checkArgon2Params(t, m, p int) error {
+	if t <= 0 || m <= 0 || p <= 0 {
+		return fmt.Errorf("invalid Argon2id parameters: t=%d m=%d p=%d", t, m, p)
+	}
+	if t > maxArgon2Time || m > maxArgon2Memory || p > maxArgon2Threads {
+		return fmt.Errorf("Argon2id parameters too costly: t=%d m=%d p=%d, max t=%d m=%d p=%d", t, m, p, maxArgon2Time, maxArgon2Memory, maxArgon2Threads)
+	}
+	return nil
+}
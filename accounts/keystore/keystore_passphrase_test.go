@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"testing"
 
@@ -58,3 +59,107 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that keys can be encrypted and decrypted with all the supported key
+// derivation functions.
+func TestKeyEncryptDecryptKDF(t *testing.T) {
+	keyjson, err := ioutil.ReadFile("testdata/very-light-scrypt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(keyjson, "")
+	if err != nil {
+		t.Fatalf("json key failed to decrypt: %v", err)
+	}
+	kdfs := []KDF{
+		ScryptKDF(veryLightScryptN, veryLightScryptP),
+		PBKDF2KDF(16),
+		Argon2idKDF(1, 64, 1),
+	}
+	for _, kdf := range kdfs {
+		keyjson, err := EncryptKeyKDF(key, "foo", kdf)
+		if err != nil {
+			t.Errorf("%s: failed to encrypt key: %v", kdf.Name, err)
+			continue
+		}
+		if _, err := DecryptKey(keyjson, "bar"); err != ErrDecrypt {
+			t.Errorf("%s: decryption error mismatch with bad password: have %v, want %v", kdf.Name, err, ErrDecrypt)
+		}
+		decrypted, err := DecryptKey(keyjson, "foo")
+		if err != nil {
+			t.Errorf("%s: failed to decrypt key: %v", kdf.Name, err)
+			continue
+		}
+		if decrypted.Address != key.Address || decrypted.Id.String() != key.Id.String() {
+			t.Errorf("%s: key mismatch: have %x/%s, want %x/%s", kdf.Name, decrypted.Address, decrypted.Id, key.Address, key.Id)
+		}
+	}
+	if _, err := EncryptKeyKDF(key, "foo", KDF{Name: "bcrypt"}); err == nil {
+		t.Errorf("encrypted key with unsupported KDF")
+	}
+}
+
+// Tests that key files asking for excessive or malformed key derivation costs
+// are rejected, without panicking, before any key derivation is attempted.
+func TestKeyDecryptKDFLimits(t *testing.T) {
+	keyjson, err := ioutil.ReadFile("testdata/very-light-scrypt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(keyjson, "")
+	if err != nil {
+		t.Fatalf("json key failed to decrypt: %v", err)
+	}
+	for _, kdf := range []KDF{ScryptKDF(maxScryptN*2, 1), PBKDF2KDF(maxPBKDF2C + 1), Argon2idKDF(maxArgon2Time+1, 64, 1)} {
+		if _, err := EncryptKeyKDF(key, "foo", kdf); err == nil {
+			t.Errorf("%s: encrypted key with excessive costs", kdf.Name)
+		}
+	}
+	tests := []struct {
+		kdf    KDF
+		params map[string]interface{}
+	}{
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"n": maxScryptN * 2}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"n": 3}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"r": maxScryptR + 1}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"p": maxScryptP + 1}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"dklen": 1 << 30}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"n": "1024"}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"n": nil}},
+		{ScryptKDF(veryLightScryptN, veryLightScryptP), map[string]interface{}{"p": -1}},
+		{PBKDF2KDF(16), map[string]interface{}{"c": maxPBKDF2C + 1}},
+		{PBKDF2KDF(16), map[string]interface{}{"c": 1.5}},
+		{PBKDF2KDF(16), map[string]interface{}{"prf": nil}},
+		{PBKDF2KDF(16), map[string]interface{}{"prf": 1}},
+		{Argon2idKDF(1, 64, 1), map[string]interface{}{"t": maxArgon2Time + 1}},
+		{Argon2idKDF(1, 64, 1), map[string]interface{}{"m": maxArgon2Memory + 1}},
+		{Argon2idKDF(1, 64, 1), map[string]interface{}{"p": maxArgon2Threads + 1}},
+		{Argon2idKDF(1, 64, 1), map[string]interface{}{"t": 0}},
+		{Argon2idKDF(1, 64, 1), map[string]interface{}{"dklen": nil}},
+	}
+	for i, tt := range tests {
+		keyjson, err := EncryptKeyKDF(key, "foo", tt.kdf)
+		if err != nil {
+			t.Fatalf("test %d: failed to encrypt key: %v", i, err)
+		}
+		var crafted map[string]interface{}
+		if err := json.Unmarshal(keyjson, &crafted); err != nil {
+			t.Fatal(err)
+		}
+		params := crafted["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})
+		for name, value := range tt.params {
+			if value == nil {
+				delete(params, name)
+			} else {
+				params[name] = value
+			}
+		}
+		blob, err := json.Marshal(crafted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecryptKey(blob, "foo"); err == nil || err == ErrDecrypt {
+			t.Errorf("test %d: decryption error mismatch: have %v, want parameter error", i, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+// Package watchonly implements an account backend tracking addresses whose keys
+// are kept offline. Its wallets can't sign, but transactions can be prepared for
+// signing elsewhere and the signed result submitted back.
+package watchonly
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// Scheme is the URL scheme of the watch-only wallets.
+const Scheme = "watch"
+
+// BackendType is the reflect type of a watch-only account backend.
+var BackendType = reflect.TypeOf(&Backend{})
+
+// ErrWatchOnly is returned for any signing request to a watch-only account.
+var ErrWatchOnly = errors.New("watch-only account cannot sign, prepare the transaction for offline signing instead")
+
+// ErrAlreadyWatched is returned if an address is added which is already watched.
+var ErrAlreadyWatched = errors.New("address already watched")
+
+// Entry is a watched address along with its user assigned label.
+type Entry struct {
+	Address common.Address `json:"address"`
+	Label   string         `json:"label,omitempty"`
+}
+
+// Backend is an accounts.Backend tracking a list of watch-only addresses, which
+// is persisted into a JSON file.
+type Backend struct {
+	path string // File the watched addresses are persisted into
+
+	wallets     []*wallet               // Wallets of the watched addresses, sorted by URL
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+
+	lock sync.Mutex
+}
+
+// NewBackend creates a watch-only backend, loading the watched addresses from
+// the given file if it exists.
+func NewBackend(path string) *Backend {
+	backend := &Backend{path: path}
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to read watch-only accounts", "path", path, "err", err)
+		}
+		return backend
+	}
+	var entries []Entry
+	if err := json.Unmarshal(blob, &entries); err != nil {
+		log.Warn("Failed to parse watch-only accounts", "path", path, "err", err)
+		return backend
+	}
+	for _, entry := range entries {
+		if backend.find(entry.Address) < 0 {
+			backend.wallets = append(backend.wallets, newWallet(entry))
+		}
+	}
+	backend.sort()
+	return backend
+}
+
+// Wallets implements accounts.Backend, returning a wallet for each of the
+// watched addresses.
+func (b *Backend) Wallets() []accounts.Wallet {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	cpy := make([]accounts.Wallet, len(b.wallets))
+	for i, wallet := range b.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of watched addresses.
+func (b *Backend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.updateScope.Track(b.updateFeed.Subscribe(sink))
+}
+
+// Entries returns the watched addresses along with their labels.
+func (b *Backend) Entries() []Entry {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries := make([]Entry, len(b.wallets))
+	for i, wallet := range b.wallets {
+		entries[i] = wallet.entry
+	}
+	return entries
+}
+
+// Add starts watching an address, persisting it with the given label.
+func (b *Backend) Add(address common.Address, label string) (accounts.Account, error) {
+	b.lock.Lock()
+	if b.find(address) >= 0 {
+		b.lock.Unlock()
+		return accounts.Account{}, ErrAlreadyWatched
+	}
+	wallet := newWallet(Entry{Address: address, Label: label})
+	b.wallets = append(b.wallets, wallet)
+	b.sort()
+
+	if err := b.persist(); err != nil {
+		index := b.find(address)
+		b.wallets = append(b.wallets[:index:index], b.wallets[index+1:]...)
+		b.lock.Unlock()
+		return accounts.Account{}, err
+	}
+	b.lock.Unlock()
+
+	b.updateFeed.Send(accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	return wallet.account, nil
+}
+
+// Remove stops watching an address.
+func (b *Backend) Remove(address common.Address) error {
+	b.lock.Lock()
+	index := b.find(address)
+	if index < 0 {
+		b.lock.Unlock()
+		return accounts.ErrUnknownAccount
+	}
+	wallet := b.wallets[index]
+	b.wallets = append(b.wallets[:index:index], b.wallets[index+1:]...)
+
+	if err := b.persist(); err != nil {
+		b.lock.Unlock()
+		return err
+	}
+	b.lock.Unlock()
+
+	b.updateFeed.Send(accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	return nil
+}
+
+// find returns the index of the wallet watching the address, or -1 if it's not
+// watched. The lock must be held by the caller.
+func (b *Backend) find(address common.Address) int {
+	for i, wallet := range b.wallets {
+		if wallet.account.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// sort orders the wallets by URL, as the account manager expects. The lock must
+// be held by the caller.
+func (b *Backend) sort() {
+	sort.Slice(b.wallets, func(i, j int) bool {
+		return b.wallets[i].account.URL.Cmp(b.wallets[j].account.URL) < 0
+	})
+}
+
+// persist atomically writes the watched addresses into the backend's file. The
+// lock must be held by the caller.
+func (b *Backend) persist() error {
+	entries := make([]Entry, len(b.wallets))
+	for i, wallet := range b.wallets {
+		entries[i] = wallet.entry
+	}
+	blob, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(b.path), "."+filepath.Base(b.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), b.path)
+}
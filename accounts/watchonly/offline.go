@@ -0,0 +1,96 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package watchonly
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// UnsignedTx is a transaction prepared for signing offline: the RLP encoding of
+// the unsigned transaction to transfer to the signer and the hash it needs to
+// sign.
+type UnsignedTx struct {
+	Raw         hexutil.Bytes      `json:"raw"`
+	SigningHash common.Hash        `json:"signingHash"`
+	ChainId     *hexutil.Big       `json:"chainId,omitempty"`
+	Tx          *types.Transaction `json:"tx"`
+}
+
+// NewUnsignedTx prepares a transaction for signing offline, replay protected
+// with the given chain id if it's not nil.
+func NewUnsignedTx(tx *types.Transaction, chainID *big.Int) (*UnsignedTx, error) {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	unsigned := &UnsignedTx{
+		Raw:         raw,
+		SigningHash: makeSigner(chainID).Hash(tx),
+		Tx:          tx,
+	}
+	if chainID != nil {
+		unsigned.ChainId = (*hexutil.Big)(chainID)
+	}
+	return unsigned, nil
+}
+
+// ApplySignature decodes an unsigned transaction prepared by NewUnsignedTx and
+// attaches the offline signature to it, returning the signed transaction and
+// its sender. The signature is in the [R || S || V] format, V being either 0/1
+// or 27/28.
+func ApplySignature(raw []byte, sig []byte, chainID *big.Int) (*types.Transaction, common.Address, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, common.Address{}, err
+	}
+	if len(sig) != 65 {
+		return nil, common.Address{}, fmt.Errorf("invalid signature length %d, want 65", len(sig))
+	}
+	sig = common.CopyBytes(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27 // Transform V from 27/28 to 0/1 as the signers expect
+	}
+	if sig[64] > 1 {
+		return nil, common.Address{}, errors.New("invalid signature recovery id")
+	}
+	signer := makeSigner(chainID)
+
+	signed, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return signed, sender, nil
+}
+
+// makeSigner returns the transaction signer for the given chain id, falling back
+// to unprotected signatures if it's nil.
+func makeSigner(chainID *big.Int) types.Signer {
+	if chainID == nil {
+		return types.HomesteadSigner{}
+	}
+	return types.NewEIP155Signer(chainID)
+}
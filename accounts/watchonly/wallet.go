@@ -0,0 +1,111 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package watchonly
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock"
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/core/types"
+)
+
+// wallet is a watch-only wallet, tracking a single address without its key.
+type wallet struct {
+	entry   Entry            // Watched address and its label
+	account accounts.Account // Account of the watched address
+}
+
+// newWallet creates a watch-only wallet for the given entry.
+func newWallet(entry Entry) *wallet {
+	return &wallet{
+		entry: entry,
+		account: accounts.Account{
+			Address: entry.Address,
+			URL:     accounts.URL{Scheme: Scheme, Path: entry.Address.Hex()},
+		},
+	}
+}
+
+// URL implements accounts.Wallet, returning the URL of the watched address.
+func (w *wallet) URL() accounts.URL {
+	return w.account.URL
+}
+
+// Status implements accounts.Wallet, returning the label of the watched address.
+func (w *wallet) Status() (string, error) {
+	if w.entry.Label == "" {
+		return "Watch-only", nil
+	}
+	return fmt.Sprintf("Watch-only (%s)", w.entry.Label), nil
+}
+
+// Open implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *wallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *wallet) Close() error { return nil }
+
+// Accounts implements accounts.Wallet, returning the watched account.
+func (w *wallet) Accounts() []accounts.Account {
+	return []accounts.Account{w.account}
+}
+
+// Contains implements accounts.Wallet, returning whether the account is the
+// watched one.
+func (w *wallet) Contains(account accounts.Account) bool {
+	return account.Address == w.account.Address && (account.URL == (accounts.URL{}) || account.URL == w.account.URL)
+}
+
+// Derive implements accounts.Wallet, but watch-only wallets can't derive accounts.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *wallet) SelfDerive(base accounts.DerivationPath, chain fairblock.ChainStateReader) {}
+
+// SignHash implements accounts.Wallet, but watch-only wallets can't sign.
+func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, w.signError(account)
+}
+
+// SignTx implements accounts.Wallet, but watch-only wallets can't sign.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, w.signError(account)
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, but watch-only wallets
+// can't sign.
+func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, w.signError(account)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, but watch-only wallets can't
+// sign.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, w.signError(account)
+}
+
+// signError returns the error of a signing request, differentiating between
+// accounts which are watched and those which are unknown.
+func (w *wallet) signError(account accounts.Account) error {
+	if !w.Contains(account) {
+		return accounts.ErrUnknownAccount
+	}
+	return ErrWatchOnly
+}
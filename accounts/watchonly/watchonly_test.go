@@ -0,0 +1,166 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package watchonly
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+func tmpBackend(t *testing.T) (string, *Backend) {
+	dir, err := ioutil.TempDir("", "watchonly-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, NewBackend(filepath.Join(dir, "watchonly.json"))
+}
+
+// Tests that watched addresses are persisted and reloaded, and that the account
+// manager is notified of their addition and removal.
+func TestBackendPersistence(t *testing.T) {
+	dir, backend := tmpBackend(t)
+	defer os.RemoveAll(dir)
+
+	manager := accounts.NewManager(backend)
+	defer manager.Close()
+
+	treasury := common.HexToAddress("0x01")
+	cold := common.HexToAddress("0x02")
+
+	if _, err := backend.Add(cold, ""); err != nil {
+		t.Fatalf("failed to watch address: %v", err)
+	}
+	account, err := backend.Add(treasury, "treasury")
+	if err != nil {
+		t.Fatalf("failed to watch address: %v", err)
+	}
+	if account.Address != treasury || account.URL.Scheme != Scheme {
+		t.Errorf("account mismatch: have %v", account)
+	}
+	if _, err := backend.Add(treasury, "again"); err != ErrAlreadyWatched {
+		t.Errorf("duplicate error mismatch: have %v, want %v", err, ErrAlreadyWatched)
+	}
+	// Wait for the manager to pick up the new wallets and check their status
+	var wallets []accounts.Wallet
+	for i := 0; i < 100; i++ {
+		if wallets = manager.Wallets(); len(wallets) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(wallets) != 2 {
+		t.Fatalf("manager wallet count mismatch: have %d, want 2", len(wallets))
+	}
+	if status, _ := wallets[0].Status(); status != "Watch-only (treasury)" {
+		t.Errorf("labelled status mismatch: have %q", status)
+	}
+	if status, _ := wallets[1].Status(); status != "Watch-only" {
+		t.Errorf("unlabelled status mismatch: have %q", status)
+	}
+	// Reload the addresses from disk, removing one afterwards
+	reloaded := NewBackend(filepath.Join(dir, "watchonly.json"))
+	want := []Entry{{Address: treasury, Label: "treasury"}, {Address: cold}}
+	if have := reloaded.Entries(); len(have) != 2 || have[0] != want[0] || have[1] != want[1] {
+		t.Errorf("reloaded entries mismatch: have %v, want %v", have, want)
+	}
+	if err := reloaded.Remove(cold); err != nil {
+		t.Fatalf("failed to unwatch address: %v", err)
+	}
+	if err := reloaded.Remove(cold); err != accounts.ErrUnknownAccount {
+		t.Errorf("unknown removal error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	if have := NewBackend(filepath.Join(dir, "watchonly.json")).Entries(); len(have) != 1 || have[0] != want[0] {
+		t.Errorf("entries mismatch after removal: have %v, want %v", have, want[:1])
+	}
+}
+
+// Tests that watch-only wallets refuse to sign.
+func TestWalletSigning(t *testing.T) {
+	dir, backend := tmpBackend(t)
+	defer os.RemoveAll(dir)
+
+	account, err := backend.Add(common.HexToAddress("0x01"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := backend.Wallets()[0]
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+
+	if _, err := wallet.SignHash(account, make([]byte, 32)); err != ErrWatchOnly {
+		t.Errorf("hash signing error mismatch: have %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := wallet.SignTx(account, tx, big.NewInt(1)); err != ErrWatchOnly {
+		t.Errorf("transaction signing error mismatch: have %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := wallet.SignTxWithPassphrase(account, "", tx, nil); err != ErrWatchOnly {
+		t.Errorf("passphrase signing error mismatch: have %v, want %v", err, ErrWatchOnly)
+	}
+	other := accounts.Account{Address: common.HexToAddress("0x02")}
+	if _, err := wallet.SignTx(other, tx, nil); err != accounts.ErrUnknownAccount {
+		t.Errorf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
+// Tests that transactions prepared for offline signing can be signed and the
+// signature applied back, with and without replay protection.
+func TestOfflineSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	for _, chainID := range []*big.Int{nil, big.NewInt(1337)} {
+		tx := types.NewTransaction(3, common.HexToAddress("0x01"), big.NewInt(1000), big.NewInt(21000), big.NewInt(1), []byte{0xde, 0xad})
+
+		unsigned, err := NewUnsignedTx(tx, chainID)
+		if err != nil {
+			t.Fatalf("chain %v: failed to prepare transaction: %v", chainID, err)
+		}
+		sig, err := crypto.Sign(unsigned.SigningHash[:], key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Apply the signature both in 0/1 and in 27/28 V format
+		for _, v := range []byte{0, 27} {
+			sig := common.CopyBytes(sig)
+			sig[64] += v
+
+			signed, from, err := ApplySignature(unsigned.Raw, sig, chainID)
+			if err != nil {
+				t.Fatalf("chain %v, v+%d: failed to apply signature: %v", chainID, v, err)
+			}
+			if from != sender {
+				t.Errorf("chain %v, v+%d: sender mismatch: have %x, want %x", chainID, v, from, sender)
+			}
+			if signed.Nonce() != 3 || signed.Value().Cmp(big.NewInt(1000)) != 0 {
+				t.Errorf("chain %v, v+%d: signed transaction mismatch: %v", chainID, v, signed)
+			}
+			if protected := chainID != nil; signed.Protected() != protected {
+				t.Errorf("chain %v, v+%d: replay protection mismatch: have %v, want %v", chainID, v, signed.Protected(), protected)
+			}
+		}
+	}
+	if _, _, err := ApplySignature([]byte{0x01}, make([]byte, 65), nil); err == nil {
+		t.Errorf("applied signature to invalid transaction")
+	}
+}
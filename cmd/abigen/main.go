@@ -24,12 +24,13 @@ import (
 	"os"
 	"strings"
 
+	"github.com/fairblock/go-fairblock/accounts/abi"
 	"github.com/fairblock/go-fairblock/accounts/abi/bind"
 	"github.com/fairblock/go-fairblock/common/compiler"
 )
 
 var (
-	abiFlag = flag.String("abi", "", "Path to the Fairblock contract ABI json to bind")
+	abiFlag = flag.String("abi", "", "Path to the Fairblock contract ABI (json or human-readable) to bind")
 	binFlag = flag.String("bin", "", "Path to the Fairblock contract bytecode (generate deploy method)")
 	typFlag = flag.String("type", "", "Struct name for the binding (default = package name)")
 
@@ -100,12 +101,30 @@ func main() {
 		}
 	} else {
 		// Otherwise load up the ABI, optional bytecode and type name from the parameters
-		abi, err := ioutil.ReadFile(*abiFlag)
+		input, err := ioutil.ReadFile(*abiFlag)
 		if err != nil {
 			fmt.Printf("Failed to read input ABI: %v\n", err)
 			os.Exit(-1)
 		}
-		abis = append(abis, string(abi))
+		// Human-readable ABIs list one fragment per line, convert them to JSON
+		if !strings.HasPrefix(strings.TrimSpace(string(input)), "[") {
+			var fragments []string
+			for _, line := range strings.Split(string(input), "\n") {
+				if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "//") {
+					fragments = append(fragments, line)
+				}
+			}
+			parsed, err := abi.ParseHumanReadable(fragments)
+			if err != nil {
+				fmt.Printf("Failed to parse input ABI: %v\n", err)
+				os.Exit(-1)
+			}
+			if input, err = json.Marshal(parsed); err != nil {
+				fmt.Printf("Failed to convert input ABI: %v\n", err)
+				os.Exit(-1)
+			}
+		}
+		abis = append(abis, string(input))
 
 		bin := []byte{}
 		if *binFlag != "" {
@@ -19,8 +19,11 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"time"
 
 	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/hdwallet"
 	"github.com/fairblock/go-fairblock/accounts/keystore"
 	"github.com/fairblock/go-fairblock/cmd/utils"
 	"github.com/fairblock/go-fairblock/console"
@@ -30,6 +33,16 @@ import (
 )
 
 var (
+	mnemonicFlag = cli.BoolFlag{
+		Name:  "mnemonic",
+		Usage: "Create an HD wallet from a new BIP-39 mnemonic instead of a single key",
+	}
+	kdfFlag = cli.StringFlag{
+		Name:  "kdf",
+		Usage: "Key derivation function to encrypt keys with (scrypt, pbkdf2, argon2id)",
+		Value: "scrypt",
+	}
+
 	walletCommand = cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage Fairblock presale wallets",
@@ -72,7 +85,8 @@ passwordfile as argument containing the wallet password in plaintext.`,
 		Description: `
 
 Manage accounts, list all existing accounts, import a private key into a new
-account, create a new account or update an existing account.
+account, create a new account, update an existing account or back up and
+restore accounts.
 
 It supports interactive mode, when you are prompted for password as well as
 non-interactive mode where passwords are supplied via a given password file.
@@ -110,6 +124,7 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					mnemonicFlag,
 				},
 				Description: `
     gfbc account new
@@ -120,6 +135,11 @@ The account is saved in encrypted format, you are prompted for a passphrase.
 
 You must remember this passphrase to unlock your account in the future.
 
+With the --mnemonic flag, a hierarchical deterministic wallet is created instead,
+whose accounts are derived from a new 24 word BIP-39 mnemonic. The mnemonic is
+printed once and is the only way to recover the wallet, write it down and keep
+it safe. Further accounts can be derived with personal.deriveAccount.
+
 For non-interactive use the passphrase can be specified with the --password flag:
 
 Note, this is meant to be used for testing only, it is a bad idea to save your
@@ -135,6 +155,7 @@ password to file or expose in any other way.
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.LightKDFFlag,
+					kdfFlag,
 				},
 				Description: `
     gfbc account update <address>
@@ -153,6 +174,59 @@ For non-interactive use the passphrase can be specified with the --password flag
 
 Since only one password can be given, only format update can be performed,
 changing your password is only possible interactively.
+
+The key derivation function protecting the passphrase can be changed with the
+--kdf flag, e.g. to migrate a key from scrypt to argon2id:
+
+    gfbc account update --kdf argon2id <address>
+`,
+			},
+			{
+				Name:      "backup",
+				Usage:     "Export an encrypted backup of existing accounts",
+				Action:    utils.MigrateFlags(accountBackup),
+				ArgsUsage: "<backupFile> <address> [<address>...]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					kdfFlag,
+				},
+				Description: `
+    gfbc account backup <backupfile> <address> [<address>...]
+
+Exports the given accounts into a single backup bundle, encrypted and
+authenticated with a new backup passphrase. You are prompted for the
+passphrase of each account and for the one of the backup.
+
+The bundle also records its creation time and the addresses it contains,
+which can be read without the passphrase.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, one per line: first the ones of the accounts, then the one of the backup.
+`,
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore accounts from an encrypted backup",
+				Action:    utils.MigrateFlags(accountRestore),
+				ArgsUsage: "<backupFile>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+				},
+				Description: `
+    gfbc account restore <backupfile>
+
+Imports the accounts of a backup bundle created by gfbc account backup, skipping
+the ones already present in the keystore. You are prompted for the passphrase
+of the backup and for a new passphrase to lock the restored accounts with.
+
+For non-interactive use the passphrases can be specified with the --password
+flag, one per line: first the one of the backup, then the new one.
 `,
 			},
 			{
@@ -186,6 +260,30 @@ Note:
 As you can directly copy your encrypted accounts to another fairblock instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "import-mnemonic",
+				Usage:  "Import a BIP-39 mnemonic into a new HD wallet",
+				Action: utils.MigrateFlags(accountImportMnemonic),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+				},
+				ArgsUsage: "[<mnemonicFile>]",
+				Description: `
+    gfbc account import-mnemonic [<mnemonicfile>]
+
+Imports a BIP-39 mnemonic into a new hierarchical deterministic wallet and prints
+the address of its first account (m/44'/60'/0'/0/0). The mnemonic is read from
+<mnemonicfile> if given, otherwise you are prompted for it.
+
+The seed of the wallet is saved in encrypted format, you are prompted for a
+passphrase. Further accounts can be derived with personal.deriveAccount.
+
+For non-interactive use the passphrase can be specified with the --password flag.
 `,
 			},
 		},
@@ -304,7 +402,9 @@ func accountCreate(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to read configuration: %v", err)
 	}
-
+	if ctx.Bool(mnemonicFlag.Name) {
+		return accountCreateMnemonic(ctx)
+	}
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
 	address, err := keystore.StoreKey(keydir, password, scryptN, scryptP)
@@ -328,13 +428,106 @@ func accountUpdate(ctx *cli.Context) error {
 	for _, addr := range ctx.Args() {
 		account, oldPassword := unlockAccount(ctx, ks, addr, 0, nil)
 		newPassword := getPassPhrase("Please give a new password. Do not forget this password.", true, 0, nil)
-		if err := ks.Update(account, oldPassword, newPassword); err != nil {
+
+		var err error
+		if ctx.IsSet(kdfFlag.Name) {
+			err = ks.Reencrypt([]accounts.Account{account}, []string{oldPassword}, newPassword, makeKDF(ctx))
+		} else {
+			err = ks.Update(account, oldPassword, newPassword)
+		}
+		if err != nil {
 			utils.Fatalf("Could not update the account: %v", err)
 		}
 	}
 	return nil
 }
 
+// accountBackup exports a set of accounts into an encrypted backup bundle.
+func accountBackup(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("Backup file and accounts must be given as arguments")
+	}
+	file, addrs := ctx.Args().First(), ctx.Args().Tail()
+	if _, err := os.Stat(file); err == nil {
+		utils.Fatalf("Backup file %s already exists", file)
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	var (
+		passwords = utils.MakePasswordList(ctx)
+		accs      = make([]accounts.Account, len(addrs))
+		auths     = make([]string, len(addrs))
+	)
+	for i, addr := range addrs {
+		accs[i], auths[i] = unlockAccount(ctx, ks, addr, i, passwords)
+	}
+	password := getPassPhrase("Your backup is locked with a password. Please give a password. Do not forget this password.", true, len(addrs), passwords)
+
+	bundle, err := ks.ExportBackup(accs, auths, password, makeKDF(ctx))
+	if err != nil {
+		utils.Fatalf("Could not create the backup: %v", err)
+	}
+	if err := ioutil.WriteFile(file, bundle, 0600); err != nil {
+		utils.Fatalf("Could not write the backup: %v", err)
+	}
+	fmt.Printf("Backed up %d accounts to %s\n", len(accs), file)
+	return nil
+}
+
+// accountRestore imports the accounts of an encrypted backup bundle.
+func accountRestore(ctx *cli.Context) error {
+	file := ctx.Args().First()
+	if len(file) == 0 {
+		utils.Fatalf("Backup file must be given as argument")
+	}
+	bundle, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Fatalf("Could not read the backup: %v", err)
+	}
+	info, err := keystore.ReadBackupInfo(bundle)
+	if err != nil {
+		utils.Fatalf("Invalid backup: %v", err)
+	}
+	fmt.Printf("Backup created at %v containing %d accounts\n", info.Created.UTC().Format(time.RFC3339), len(info.Accounts))
+
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	passwords := utils.MakePasswordList(ctx)
+	backupPassword := getPassPhrase("Please give the password of the backup.", false, 0, passwords)
+	password := getPassPhrase("Your restored accounts are locked with a password. Please give a password. Do not forget this password.", true, 1, passwords)
+
+	accs, err := ks.ImportBackup(bundle, backupPassword, password)
+	if err != nil {
+		utils.Fatalf("Could not restore the backup: %v", err)
+	}
+	for _, acc := range accs {
+		fmt.Printf("Address: {%x}\n", acc.Address)
+	}
+	if skipped := len(info.Accounts) - len(accs); skipped > 0 {
+		fmt.Printf("Skipped %d accounts already in the keystore\n", skipped)
+	}
+	return nil
+}
+
+// makeKDF creates the key derivation function requested on the command line.
+func makeKDF(ctx *cli.Context) keystore.KDF {
+	var (
+		kdf keystore.KDF
+		err error
+	)
+	if ctx.GlobalBool(utils.LightKDFFlag.Name) {
+		kdf, err = keystore.LightKDF(ctx.String(kdfFlag.Name))
+	} else {
+		kdf, err = keystore.StandardKDF(ctx.String(kdfFlag.Name))
+	}
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return kdf
+}
+
 func importWallet(ctx *cli.Context) error {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
@@ -377,3 +570,53 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// fetchHDWalletHub retrieves the mnemonic wallet backend of the node.
+func fetchHDWalletHub(ctx *cli.Context) *hdwallet.Hub {
+	stack, _ := makeConfigNode(ctx)
+	return stack.AccountManager().Backends(hdwallet.HubType)[0].(*hdwallet.Hub)
+}
+
+// accountCreateMnemonic creates a new HD wallet from a random mnemonic.
+func accountCreateMnemonic(ctx *cli.Context) error {
+	hub := fetchHDWalletHub(ctx)
+	password := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	mnemonic, account, err := hub.NewMnemonic(password)
+	if err != nil {
+		utils.Fatalf("Failed to create wallet: %v", err)
+	}
+	fmt.Printf("Mnemonic: %s\n\n", mnemonic)
+	fmt.Println("Write down the mnemonic and keep it safe, it is the only way to recover your wallet.")
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
+
+// accountImportMnemonic creates a new HD wallet from an existing mnemonic.
+func accountImportMnemonic(ctx *cli.Context) error {
+	var mnemonic string
+	if file := ctx.Args().First(); file != "" {
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read the mnemonic: %v", err)
+		}
+		mnemonic = string(blob)
+	} else {
+		var err error
+		if mnemonic, err = console.Stdin.PromptPassword("Mnemonic: "); err != nil {
+			utils.Fatalf("Failed to read the mnemonic: %v", err)
+		}
+	}
+	if _, err := hdwallet.MnemonicToEntropy(mnemonic); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	hub := fetchHDWalletHub(ctx)
+	password := getPassPhrase("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	account, err := hub.Import(mnemonic, password)
+	if err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
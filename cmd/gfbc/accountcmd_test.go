@@ -106,6 +106,39 @@ Repeat passphrase: {{.InputLine "foobar2"}}
 `)
 }
 
+func TestAccountBackupRestore(t *testing.T) {
+	datadir := tmpDatadirWithKeystore(t)
+	backup := filepath.Join(tmpdir(t), "backup.json")
+
+	gfbc := runGfbc(t, "account", "backup",
+		"--datadir", datadir, "--lightkdf", "--kdf", "argon2id",
+		backup, "f466859ead1932d743d622cb74fc058882e8648a")
+	gfbc.Expect(`
+Unlocking account f466859ead1932d743d622cb74fc058882e8648a | Attempt 1/3
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Your backup is locked with a password. Please give a password. Do not forget this password.
+Passphrase: {{.InputLine "backup"}}
+Repeat passphrase: {{.InputLine "backup"}}
+Backed up 1 accounts to ` + backup + `
+`)
+	gfbc.ExpectExit()
+
+	// Restore non-interactively into a fresh keystore
+	passwords := filepath.Join(tmpdir(t), "passwords.txt")
+	if err := ioutil.WriteFile(passwords, []byte("backup\nfoobar2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	gfbc = runGfbc(t, "account", "restore", "--lightkdf", "--password", passwords, backup)
+	defer gfbc.ExpectExit()
+	gfbc.ExpectRegexp(`Backup created at \S+ containing 1 accounts\nAddress: \{f466859ead1932d743d622cb74fc058882e8648a\}\n`)
+
+	files, err := ioutil.ReadDir(filepath.Join(gfbc.Datadir, "keystore"))
+	if len(files) != 1 {
+		t.Errorf("expected one key file in keystore directory, found %d files (error: %v)", len(files), err)
+	}
+}
+
 func TestWalletImport(t *testing.T) {
 	gfbc := runGfbc(t, "wallet", "import", "--lightkdf", "testdata/guswallet.json")
 	defer gfbc.ExpectExit()
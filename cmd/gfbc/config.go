@@ -173,6 +173,11 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the decryption key holder service if requested
+	if ctx.GlobalBool(utils.KeyperEnabledFlag.Name) || ctx.GlobalIsSet(utils.KeyperShareFlag.Name) {
+		utils.RegisterKeyperService(stack, ctx.GlobalString(utils.KeyperShareFlag.Name))
+	}
+
 	// Add the Fairblock Stats daemon if requested.
 	if cfg.Fbcstats.URL != "" {
 		utils.RegisterFbcStatsService(stack, cfg.Fbcstats.URL)
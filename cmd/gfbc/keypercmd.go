@@ -0,0 +1,99 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of go-fairblock.
+//
+// go-fairblock is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-fairblock is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-fairblock. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/fairblock/go-fairblock/cmd/utils"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/keyper"
+	"github.com/fairblock/go-fairblock/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var keyperCommand = cli.Command{
+	Name:     "keyper",
+	Usage:    "Manage the key holder committee of the encrypted mempool",
+	Category: "KEY HOLDER COMMANDS",
+	Description: `
+The encrypted mempool hides transactions until the block committing to them is
+final. Their decryption keys are revealed by a committee of key holders, any
+threshold of which can extract the keys of the envelopes of an epoch.`,
+	Subcommands: []cli.Command{
+		{
+			Name:      "generate",
+			Usage:     "Generate the key shares of a new committee",
+			Action:    utils.MigrateFlags(keyperGenerate),
+			ArgsUsage: "<threshold> <holders> <outputDir>",
+			Description: `
+    gfbc keyper generate <threshold> <holders> <outputDir>
+
+Generates a fresh master secret, splits it among the given number of key holders
+and writes the share of each into <outputDir>/share-<index>.json. The "fairblock"
+section to add to the genesis chain config is printed to stdout.
+
+The master secret is never written to disk, but is known to this process while
+dealing the shares. Hand each share file to its key holder, who passes it to the
+node via --keyper.share.`,
+		},
+	},
+}
+
+// keyperGenerate deals the key shares of a new key holder committee.
+func keyperGenerate(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 3 {
+		utils.Fatalf("Usage: gfbc keyper generate <threshold> <holders> <outputDir>")
+	}
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil {
+		utils.Fatalf("Invalid threshold: %v", err)
+	}
+	holders, err := strconv.Atoi(args[1])
+	if err != nil {
+		utils.Fatalf("Invalid number of key holders: %v", err)
+	}
+	master, shares, vks, err := ibe.GenerateShares(rand.Reader, threshold, holders)
+	if err != nil {
+		utils.Fatalf("Failed to generate key shares: %v", err)
+	}
+	if err := os.MkdirAll(args[2], 0700); err != nil {
+		utils.Fatalf("Failed to create output directory: %v", err)
+	}
+	config := &params.FairblockConfig{
+		MasterPublicKey: master.Marshal(),
+		Threshold:       uint64(threshold),
+		RevealWindow:    params.DefaultRevealWindow,
+	}
+	for i, share := range shares {
+		file := filepath.Join(args[2], fmt.Sprintf("share-%d.json", share.Index))
+		if err := keyper.SaveShare(file, share); err != nil {
+			utils.Fatalf("Failed to write key share: %v", err)
+		}
+		config.VerificationKeys = append(config.VerificationKeys, hexutil.Bytes(vks[i].Marshal()))
+	}
+	out, _ := json.MarshalIndent(map[string]interface{}{"fairblock": config}, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
@@ -61,6 +61,7 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -82,6 +83,9 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolEnvelopeLeadFlag,
+		utils.KeyperEnabledFlag,
+		utils.KeyperShareFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
@@ -98,6 +102,9 @@ var (
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
 		utils.TargetGasLimitFlag,
+		utils.MinerGasFloorFlag,
+		utils.MinerGasCeilFlag,
+		utils.MinerRecommitFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -111,6 +118,7 @@ var (
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
+		utils.RPCVirtualHostsFlag,
 		utils.FbcStatsURLFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
@@ -118,6 +126,10 @@ var (
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
 		utils.ExtraDataFlag,
+		utils.MinerTxOrderingFlag,
+		utils.MinerStratumFlag,
+		utils.MinerStratumDifficultyFlag,
+		utils.MinerStratumPasswordFlag,
 		configFileFlag,
 	}
 
@@ -160,6 +172,8 @@ func init() {
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See keypercmd.go:
+		keyperCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
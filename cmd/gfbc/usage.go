@@ -70,6 +70,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ExternalSignerFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
@@ -121,6 +122,14 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolEnvelopeLeadFlag,
+		},
+	},
+	{
+		Name: "ENCRYPTED MEMPOOL",
+		Flags: []cli.Flag{
+			utils.KeyperEnabledFlag,
+			utils.KeyperShareFlag,
 		},
 	},
 	{
@@ -152,6 +161,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
+			utils.RPCVirtualHostsFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -181,8 +191,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerThreadsFlag,
 			utils.FairblockbaseFlag,
 			utils.TargetGasLimitFlag,
+			utils.MinerGasFloorFlag,
+			utils.MinerGasCeilFlag,
+			utils.MinerRecommitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerTxOrderingFlag,
+			utils.MinerStratumFlag,
+			utils.MinerStratumDifficultyFlag,
+			utils.MinerStratumPasswordFlag,
 		},
 	},
 	{
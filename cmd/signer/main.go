@@ -0,0 +1,263 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of go-fairblock.
+//
+// go-fairblock is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-fairblock is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-fairblock. If not, see <http://www.gnu.org/licenses/>.
+
+// signer is a standalone signing daemon, holding account keys outside of the node
+// and requiring every request to be approved interactively or by rules.
+//
+// The passwords used to sign rule approved requests are kept encrypted with a
+// key derived from a master seed, itself encrypted with a master password:
+//
+//   signer init                 creates the master seed
+//   signer setpw <address>      stores the password of an account
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/cmd/utils"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/console"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/node"
+	"github.com/fairblock/go-fairblock/rpc"
+	"github.com/fairblock/go-fairblock/signer/core"
+	"github.com/fairblock/go-fairblock/signer/rules"
+	"github.com/fairblock/go-fairblock/signer/storage"
+)
+
+func main() {
+	defaultDatadir := filepath.Join(node.DefaultDataDir(), "signer")
+
+	var (
+		datadir     = flag.String("datadir", defaultDatadir, "directory for the signer state and IPC socket")
+		keystoreDir = flag.String("keystore", filepath.Join(node.DefaultDataDir(), "keystore"), "directory for the keystore")
+		chainID     = flag.Int64("chainid", 1, "chain id to sign transactions for")
+		lightKDF    = flag.Bool("lightkdf", false, "reduce key-derivation RAM & CPU usage at some expense of KDF strength")
+		noUSB       = flag.Bool("nousb", false, "disable monitoring for and managing USB hardware wallets")
+		rulesFile   = flag.String("rules", "", "JavaScript file with the rules to approve requests by")
+		auditLog    = flag.String("auditlog", filepath.Join(defaultDatadir, auditLogFile), "file to write the audit log to, kept in the data directory by default (empty to disable)")
+		ipcDisable  = flag.Bool("ipcdisable", false, "disable the IPC-RPC server")
+		httpEnabled = flag.Bool("rpc", false, "enable the HTTP-RPC server")
+		httpAddr    = flag.String("rpcaddr", "localhost", "HTTP-RPC server listening interface")
+		httpPort    = flag.Int("rpcport", 8550, "HTTP-RPC server listening port")
+		httpVhosts  = flag.String("rpcvhosts", "localhost", "comma separated list of virtual hostnames accepted by the HTTP-RPC server")
+		verbosity   = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-9)")
+	)
+	flag.Parse()
+
+	// Keep the audit log next to the rest of the signer state unless requested otherwise
+	auditLogSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "auditlog" {
+			auditLogSet = true
+		}
+	})
+	if !auditLogSet {
+		*auditLog = filepath.Join(*datadir, auditLogFile)
+	}
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	if err := os.MkdirAll(*datadir, 0700); err != nil {
+		utils.Fatalf("Failed to create data directory: %v", err)
+	}
+	switch flag.Arg(0) {
+	case "":
+	case "init":
+		initMasterSeed(*datadir)
+		return
+	case "setpw":
+		if flag.NArg() != 2 || !common.IsHexAddress(flag.Arg(1)) {
+			utils.Fatalf("Usage: signer setpw <address>")
+		}
+		setPassword(*datadir, common.HexToAddress(flag.Arg(1)))
+		return
+	default:
+		utils.Fatalf("Unknown command %q", flag.Arg(0))
+	}
+	// Assemble the approval UI, consulting the rules first if configured
+	var ui core.UIClientAPI = core.NewCommandlineUI()
+	if *rulesFile != "" {
+		code, err := ioutil.ReadFile(*rulesFile)
+		if err != nil {
+			utils.Fatalf("Failed to read rules: %v", err)
+		}
+		jsStorage, err := storage.NewJSONStorage(filepath.Join(*datadir, "rules.json"))
+		if err != nil {
+			utils.Fatalf("Failed to open rule storage: %v", err)
+		}
+		var creds storage.Storage = storage.NewEphemeralStorage()
+		if _, err := os.Stat(filepath.Join(*datadir, masterSeedFile)); err == nil {
+			creds = openCredentials(*datadir, readMasterSeed(*datadir))
+		} else {
+			log.Warn("No master seed, rule approved requests can't be signed", "init", "signer init")
+		}
+		ruleUI := rules.NewRuleEvaluator(ui, jsStorage, creds)
+		if err := ruleUI.Init(string(code)); err != nil {
+			utils.Fatalf("Failed to load rules: %v", err)
+		}
+		log.Info("Loaded approval rules", "file", *rulesFile)
+		ui = ruleUI
+	}
+	am := core.StartAccountManager(*keystoreDir, *lightKDF, *noUSB)
+
+	var api core.ExternalAPI = core.NewSignerAPI(big.NewInt(*chainID), am, ui)
+	if *auditLog != "" {
+		audit, err := core.NewAuditLogger(*auditLog, api)
+		if err != nil {
+			utils.Fatalf("Failed to open audit log: %v", err)
+		}
+		api = audit
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		utils.Fatalf("Failed to register signer API: %v", err)
+	}
+	if !*ipcDisable {
+		endpoint := filepath.Join(*datadir, "signer.ipc")
+		listener, err := rpc.CreateIPCListener(endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to start IPC endpoint: %v", err)
+		}
+		defer listener.Close()
+		go server.ServeListener(listener)
+		log.Info("IPC endpoint opened", "url", endpoint)
+	}
+	if *httpEnabled {
+		endpoint := fmt.Sprintf("%s:%d", *httpAddr, *httpPort)
+		listener, err := net.Listen("tcp", endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to start HTTP endpoint: %v", err)
+		}
+		defer listener.Close()
+		go rpc.NewHTTPServer(nil, strings.Split(*httpVhosts, ","), server).Serve(listener)
+		log.Info("HTTP endpoint opened", "url", "http://"+endpoint)
+	}
+	// Serve requests until interrupted
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	<-sigc
+	log.Info("Shutting down signer")
+	server.Stop()
+}
+
+const (
+	masterSeedFile  = "masterseed.json"  // Master seed, encrypted with the master password
+	credentialsFile = "credentials.json" // Account passwords, encrypted with the credentials key
+	auditLogFile    = "audit.log"        // Log of the requests made to the signer
+)
+
+// initMasterSeed generates a new master seed and stores it encrypted with a
+// master password read from the terminal.
+func initMasterSeed(datadir string) {
+	path := filepath.Join(datadir, masterSeedFile)
+	if _, err := os.Stat(path); err == nil {
+		utils.Fatalf("Master seed %s already exists", path)
+	}
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		utils.Fatalf("Failed to generate master seed: %v", err)
+	}
+	password := promptPassword("Master password: ")
+	if password != promptPassword("Repeat master password: ") {
+		utils.Fatalf("Master passwords do not match")
+	}
+	cryptoStruct, err := keystore.EncryptDataV3(seed, []byte(password), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		utils.Fatalf("Failed to encrypt master seed: %v", err)
+	}
+	blob, err := json.Marshal(cryptoStruct)
+	if err != nil {
+		utils.Fatalf("Failed to encode master seed: %v", err)
+	}
+	if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+		utils.Fatalf("Failed to write master seed: %v", err)
+	}
+	fmt.Printf("Master seed stored in %s\n", path)
+}
+
+// readMasterSeed loads the master seed, decrypting it with the master password
+// read from the terminal.
+func readMasterSeed(datadir string) []byte {
+	path := filepath.Join(datadir, masterSeedFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		utils.Fatalf("Failed to open master seed (run signer init): %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		utils.Fatalf("Master seed %s is accessible by others (mode %v), expected 0600", path, info.Mode().Perm())
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		utils.Fatalf("Failed to read master seed: %v", err)
+	}
+	var cryptoStruct keystore.CryptoJSON
+	if err := json.Unmarshal(blob, &cryptoStruct); err != nil {
+		utils.Fatalf("Invalid master seed: %v", err)
+	}
+	seed, err := keystore.DecryptDataV3(cryptoStruct, promptPassword("Master password: "))
+	if err != nil {
+		utils.Fatalf("Failed to decrypt master seed: %v", err)
+	}
+	return seed
+}
+
+// openCredentials opens the encrypted account password storage with a key
+// derived from the master seed.
+func openCredentials(datadir string, seed []byte) storage.Storage {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte("credentials"))
+
+	creds, err := storage.NewAESEncryptedStorage(filepath.Join(datadir, credentialsFile), mac.Sum(nil))
+	if err != nil {
+		utils.Fatalf("Failed to open credentials: %v", err)
+	}
+	return creds
+}
+
+// setPassword stores the password of an account in the credential storage, so
+// requests approved by the rules can be signed with it.
+func setPassword(datadir string, addr common.Address) {
+	creds := openCredentials(datadir, readMasterSeed(datadir))
+
+	password := promptPassword(fmt.Sprintf("Password of %s: ", addr.Hex()))
+	if err := creds.Put(strings.ToLower(addr.Hex()), password); err != nil {
+		utils.Fatalf("Failed to store password: %v", err)
+	}
+	fmt.Printf("Password of %s stored\n", addr.Hex())
+}
+
+// promptPassword reads a password from the terminal without echoing it.
+func promptPassword(prompt string) string {
+	password, err := console.Stdin.PromptPassword(prompt)
+	if err != nil {
+		utils.Fatalf("Failed to read password: %v", err)
+	}
+	return password
+}
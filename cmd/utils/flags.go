@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -34,6 +35,7 @@ import (
 	"github.com/fairblock/go-fairblock/consensus"
 	"github.com/fairblock/go-fairblock/consensus/clique"
 	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/consensus/ibft"
 	"github.com/fairblock/go-fairblock/core"
 	"github.com/fairblock/go-fairblock/core/state"
 	"github.com/fairblock/go-fairblock/core/vm"
@@ -44,9 +46,11 @@ import (
 	"github.com/fairblock/go-fairblock/fbc/gasprice"
 	"github.com/fairblock/go-fairblock/fbcdb"
 	"github.com/fairblock/go-fairblock/fbcstats"
+	"github.com/fairblock/go-fairblock/keyper"
 	"github.com/fairblock/go-fairblock/les"
 	"github.com/fairblock/go-fairblock/log"
 	"github.com/fairblock/go-fairblock/metrics"
+	"github.com/fairblock/go-fairblock/miner"
 	"github.com/fairblock/go-fairblock/node"
 	"github.com/fairblock/go-fairblock/p2p"
 	"github.com/fairblock/go-fairblock/p2p/discover"
@@ -125,6 +129,10 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer endpoint (IPC path or HTTP/websocket URL) to use for signing",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -289,6 +297,20 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: fbc.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolEnvelopeLeadFlag = cli.Uint64Flag{
+		Name:  "txpool.envelopelead",
+		Usage: "Maximum number of epochs ahead encrypted envelopes may target",
+		Value: fbc.DefaultConfig.TxPool.EnvelopeLead,
+	}
+	// Encrypted mempool settings
+	KeyperEnabledFlag = cli.BoolFlag{
+		Name:  "keyper",
+		Usage: "Enable the decryption key share gossip of the encrypted mempool",
+	}
+	KeyperShareFlag = cli.StringFlag{
+		Name:  "keyper.share",
+		Usage: "Key holder share file to contribute decryption key shares with (implies --keyper)",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -312,9 +334,24 @@ var (
 	}
 	TargetGasLimitFlag = cli.Uint64Flag{
 		Name:  "targetgaslimit",
-		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
+		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine (deprecated, use --miner.gasfloor)",
 		Value: params.GenesisGasLimit.Uint64(),
 	}
+	MinerGasFloorFlag = cli.Uint64Flag{
+		Name:  "miner.gasfloor",
+		Usage: "Gas limit the mined blocks are raised towards",
+		Value: fbc.DefaultConfig.MinerGasFloor,
+	}
+	MinerGasCeilFlag = cli.Uint64Flag{
+		Name:  "miner.gasceil",
+		Usage: "Gas limit the mined blocks may not exceed (0 = unbounded)",
+		Value: fbc.DefaultConfig.MinerGasCeil,
+	}
+	MinerRecommitFlag = cli.DurationFlag{
+		Name:  "miner.recommit",
+		Usage: "Base interval at which the block being sealed is rebuilt with new transactions",
+		Value: fbc.DefaultConfig.MinerRecommit,
+	}
 	FairblockbaseFlag = cli.StringFlag{
 		Name:  "fbcerbase",
 		Usage: "Public address for block mining rewards (default = first account created)",
@@ -329,6 +366,24 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerTxOrderingFlag = cli.StringFlag{
+		Name:  "miner.txorder",
+		Usage: "Ordering of the transactions in mined blocks (" + strings.Join(miner.TxPolicies(), ", ") + ")",
+		Value: fbc.DefaultConfig.MinerTxOrdering,
+	}
+	MinerStratumFlag = cli.StringFlag{
+		Name:  "miner.stratum",
+		Usage: "Stratum mining server listening address for remote miners (e.g. 0.0.0.0:8008)",
+	}
+	MinerStratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "miner.stratum.difficulty",
+		Usage: "Difficulty of the shares accepted by the Stratum mining server",
+		Value: fbc.DefaultConfig.MinerStratumDifficulty,
+	}
+	MinerStratumPasswordFlag = cli.StringFlag{
+		Name:  "miner.stratum.password",
+		Usage: "Password remote miners must authorize with on the Stratum mining server",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -382,6 +437,11 @@ var (
 		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
 		Value: "",
 	}
+	RPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "rpcvhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' and '*.domain' wildcards.",
+		Value: strings.Join(node.DefaultConfig.HTTPVirtualHosts, ","),
+	}
 	RPCApiFlag = cli.StringFlag{
 		Name:  "rpcapi",
 		Usage: "API's offered over the HTTP-RPC interface",
@@ -681,6 +741,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(RPCApiFlag.Name) {
 		cfg.HTTPModules = splitAndTrim(ctx.GlobalString(RPCApiFlag.Name))
 	}
+	if ctx.GlobalIsSet(RPCVirtualHostsFlag.Name) {
+		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(RPCVirtualHostsFlag.Name))
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -864,6 +927,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -906,6 +972,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolEnvelopeLeadFlag.Name) {
+		cfg.EnvelopeLead = ctx.GlobalUint64(TxPoolEnvelopeLeadFlag.Name)
+	}
 }
 
 func setFbcash(ctx *cli.Context, cfg *fbc.Config) {
@@ -998,6 +1067,30 @@ func SetFbcConfig(ctx *cli.Context, stack *node.Node, cfg *fbc.Config) {
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerTxOrderingFlag.Name) {
+		cfg.MinerTxOrdering = ctx.GlobalString(MinerTxOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(TargetGasLimitFlag.Name) {
+		cfg.MinerGasFloor = ctx.GlobalUint64(TargetGasLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerGasFloorFlag.Name) {
+		cfg.MinerGasFloor = ctx.GlobalUint64(MinerGasFloorFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerGasCeilFlag.Name) {
+		cfg.MinerGasCeil = ctx.GlobalUint64(MinerGasCeilFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerRecommitFlag.Name) {
+		cfg.MinerRecommit = ctx.GlobalDuration(MinerRecommitFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumFlag.Name) {
+		cfg.MinerStratum = ctx.GlobalString(MinerStratumFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumDifficultyFlag.Name) {
+		cfg.MinerStratumDifficulty = ctx.GlobalUint64(MinerStratumDifficultyFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumPasswordFlag.Name) {
+		cfg.MinerStratumPassword = ctx.GlobalString(MinerStratumPasswordFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1091,6 +1184,28 @@ func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 	}
 }
 
+// RegisterKeyperService adds the decryption key holder service of the encrypted
+// mempool to the given node, contributing key shares if a share file is given.
+func RegisterKeyperService(stack *node.Node, shareFile string) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var fbcServ *fbc.Fairblock
+		if err := ctx.Service(&fbcServ); err != nil {
+			return nil, errors.New("key holder service requires a full node")
+		}
+		config := new(keyper.Config)
+		if shareFile != "" {
+			share, err := keyper.LoadShare(shareFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load key share: %v", err)
+			}
+			config.Share = share
+		}
+		return keyper.New(config, fbcServ)
+	}); err != nil {
+		Fatalf("Failed to register the key holder service: %v", err)
+	}
+}
+
 // RegisterFbcStatsService configures the Fairblock Stats daemon and adds it to
 // th egiven node.
 func RegisterFbcStatsService(stack *node.Node, url string) {
@@ -1156,6 +1271,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var engine consensus.Engine
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.IBFT != nil {
+		engine = ibft.New(config.IBFT, chainDb)
 	} else {
 		engine = fbcash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
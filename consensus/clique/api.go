@@ -17,12 +17,30 @@
 package clique
 
 import (
+	"bytes"
+	"errors"
+
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/consensus"
 	"github.com/fairblock/go-fairblock/core/types"
 	"github.com/fairblock/go-fairblock/rpc"
 )
 
+const (
+	statusBlocks     = 64   // Default number of recent blocks to report the signer activity over
+	maxHistoryBlocks = 8192 // Maximum number of blocks to report the activity or votes of in one call
+)
+
+var (
+	// errInvalidRange is returned if the activity or votes are requested for an
+	// empty block range.
+	errInvalidRange = errors.New("invalid block range")
+
+	// errRangeTooLarge is returned if the activity or votes are requested for
+	// more than maxHistoryBlocks blocks.
+	errRangeTooLarge = errors.New("block range too large")
+)
+
 // API is a user facing RPC API to allow controlling the signer and voting
 // mechanisms of the proof-of-authority scheme.
 type API struct {
@@ -117,3 +135,134 @@ func (api *API) Discard(address common.Address) {
 
 	delete(api.clique.proposals, address)
 }
+
+// SignerStatus is the sealing activity of a single signer over a range of blocks.
+type SignerStatus struct {
+	InTurn    uint64 `json:"inTurn"`    // Number of blocks sealed in-turn
+	OutOfTurn uint64 `json:"outOfTurn"` // Number of blocks sealed out-of-turn
+	Missed    uint64 `json:"missed"`    // Number of in-turn slots it could seal, sealed by another signer
+	LastBlock uint64 `json:"lastBlock"` // Last block sealed in the range (0 = none)
+}
+
+// Status is the sealing activity of the signers over a range of recent blocks.
+type Status struct {
+	FromBlock uint64                           `json:"fromBlock"` // First block of the reported range
+	ToBlock   uint64                           `json:"toBlock"`   // Last block of the reported range
+	Signers   map[common.Address]*SignerStatus `json:"signers"`   // Activity of the current and active signers
+}
+
+// Status returns the in-turn and out-of-turn blocks sealed by each signer over
+// the given number of recent blocks (64 by default), along with the in-turn
+// slots they missed. Signers not sealing a single block are reported too.
+func (api *API) Status(blocks *uint64) (*Status, error) {
+	window := uint64(statusBlocks)
+	if blocks != nil {
+		window = *blocks
+	}
+	if window == 0 {
+		return nil, errInvalidRange
+	}
+	if window > maxHistoryBlocks {
+		return nil, errRangeTooLarge
+	}
+	head := api.chain.CurrentHeader()
+	status := &Status{
+		FromBlock: 1,
+		ToBlock:   head.Number.Uint64(),
+		Signers:   make(map[common.Address]*SignerStatus),
+	}
+	if status.ToBlock >= window {
+		status.FromBlock = status.ToBlock - window + 1
+	}
+	// Report on all current signers, even if they didn't seal anything
+	snap, err := api.clique.snapshot(api.chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, signer := range snap.signers() {
+		status.Signers[signer] = new(SignerStatus)
+	}
+	activity := func(signer common.Address) *SignerStatus {
+		if status.Signers[signer] == nil {
+			status.Signers[signer] = new(SignerStatus)
+		}
+		return status.Signers[signer]
+	}
+	// Attribute every block in the range to its signer and in-turn signer
+	for number := status.FromBlock; number <= status.ToBlock; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		snap, err := api.clique.snapshot(api.chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ecrecover(header, api.clique.signatures)
+		if err != nil {
+			return nil, err
+		}
+		if inturn := snap.inturnSigner(number); inturn == signer {
+			activity(signer).InTurn++
+		} else {
+			activity(signer).OutOfTurn++
+
+			// Signers barred by having signed recently didn't miss anything
+			if !snap.recentlySigned(number, inturn) {
+				activity(inturn).Missed++
+			}
+		}
+		activity(signer).LastBlock = number
+	}
+	return status, nil
+}
+
+// GetVotes retrieves the authorization votes cast in the canonical blocks of the
+// given range, in chronological order. Votes are returned as sealed, including
+// ones that had no effect on the signer list.
+func (api *API) GetVotes(fromBlock, toBlock rpc.BlockNumber) ([]*Vote, error) {
+	// Resolve the range, with any special block number meaning the current head
+	head := api.chain.CurrentHeader().Number.Uint64()
+
+	from, to := head, head
+	if fromBlock >= 0 {
+		from = uint64(fromBlock.Int64())
+	}
+	if toBlock >= 0 {
+		to = uint64(toBlock.Int64())
+	}
+	if to > head {
+		to = head
+	}
+	if from > to {
+		return nil, errInvalidRange
+	}
+	if to-from >= maxHistoryBlocks {
+		return nil, errRangeTooLarge
+	}
+	// Gather the votes from the headers, skipping the genesis and non-voting blocks
+	votes := make([]*Vote, 0)
+	for number := from; number <= to; number++ {
+		if number == 0 {
+			continue
+		}
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		if header.Coinbase == (common.Address{}) {
+			continue
+		}
+		signer, err := ecrecover(header, api.clique.signatures)
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, &Vote{
+			Signer:    signer,
+			Block:     number,
+			Address:   header.Coinbase,
+			Authorize: bytes.Equal(header.Nonce[:], nonceAuthVote),
+		})
+	}
+	return votes, nil
+}
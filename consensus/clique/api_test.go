@@ -0,0 +1,171 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rpc"
+)
+
+// testerHeaderChain implements consensus.ChainReader over an in-memory list of
+// canonical headers. Block bodies are not supported.
+type testerHeaderChain struct {
+	headers []*types.Header
+}
+
+func (c *testerHeaderChain) Config() *params.ChainConfig               { return params.AllCliqueProtocolChanges }
+func (c *testerHeaderChain) CurrentHeader() *types.Header              { return c.headers[len(c.headers)-1] }
+func (c *testerHeaderChain) GetBlock(common.Hash, uint64) *types.Block { panic("not supported") }
+func (c *testerHeaderChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+func (c *testerHeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+func (c *testerHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number < uint64(len(c.headers)) {
+		return c.headers[number]
+	}
+	return nil
+}
+
+// Tests that the signer activity and the voting history are reported correctly
+// from the sealed headers.
+func TestStatusAndVotes(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	// Sort the signers so their turns are known in advance
+	names := []string{"A", "B", "C"}
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			if bytes.Compare(accounts.address(names[i]).Bytes(), accounts.address(names[j]).Bytes()) > 0 {
+				names[i], names[j] = names[j], names[i]
+			}
+		}
+	}
+	// Create a genesis authorizing the signers and a chain sealed by them
+	genesis := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       big.NewInt(0),
+		UncleHash:  uncleHash,
+		Difficulty: big.NewInt(1),
+		Extra:      make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+	}
+	for i, name := range names {
+		copy(genesis.Extra[extraVanity+i*common.AddressLength:], accounts.address(name).Bytes())
+	}
+	seals := []testerVote{
+		{signer: names[1]},                         // In-turn
+		{signer: names[2], voted: "D", auth: true}, // In-turn
+		{signer: names[1]},                         // Out-of-turn, names[0] missed
+		{signer: names[0], voted: "D"},             // Out-of-turn, names[1] recently signed, not missed
+		{signer: names[2]},                         // In-turn
+	}
+	chain := &testerHeaderChain{headers: []*types.Header{genesis}}
+	for i, seal := range seals {
+		header := &types.Header{
+			Number:     big.NewInt(int64(i) + 1),
+			ParentHash: chain.CurrentHeader().Hash(),
+			Time:       big.NewInt(int64(i) + 1),
+			Difficulty: diffInTurn,
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		if seal.voted != "" {
+			header.Coinbase = accounts.address(seal.voted)
+		}
+		if seal.auth {
+			copy(header.Nonce[:], nonceAuthVote)
+		}
+		accounts.sign(header, seal.signer)
+		chain.headers = append(chain.headers, header)
+	}
+	db, _ := fbcdb.NewMemDatabase()
+	api := &API{chain: chain, clique: New(params.AllCliqueProtocolChanges.Clique, db)}
+
+	// Check the activity over the entire chain
+	status, err := api.Status(nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+	want := &Status{
+		FromBlock: 1,
+		ToBlock:   5,
+		Signers: map[common.Address]*SignerStatus{
+			accounts.address(names[0]): {InTurn: 0, OutOfTurn: 1, Missed: 1, LastBlock: 4},
+			accounts.address(names[1]): {InTurn: 1, OutOfTurn: 1, Missed: 0, LastBlock: 3},
+			accounts.address(names[2]): {InTurn: 2, OutOfTurn: 0, Missed: 0, LastBlock: 5},
+		},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("status mismatch: have %+v, want %+v", status, want)
+	}
+	// Check the activity over a recent window, reporting idle signers too
+	window := uint64(1)
+	if status, err = api.Status(&window); err != nil {
+		t.Fatalf("failed to retrieve windowed status: %v", err)
+	}
+	want = &Status{
+		FromBlock: 5,
+		ToBlock:   5,
+		Signers: map[common.Address]*SignerStatus{
+			accounts.address(names[0]): {},
+			accounts.address(names[1]): {},
+			accounts.address(names[2]): {InTurn: 1, LastBlock: 5},
+		},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("windowed status mismatch: have %+v, want %+v", status, want)
+	}
+	window = maxHistoryBlocks + 1
+	if _, err := api.Status(&window); err != errRangeTooLarge {
+		t.Errorf("oversized window error mismatch: have %v, want %v", err, errRangeTooLarge)
+	}
+	// Check the voting history over various ranges
+	votes, err := api.GetVotes(rpc.EarliestBlockNumber, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to retrieve votes: %v", err)
+	}
+	wantVotes := []*Vote{
+		{Signer: accounts.address(names[2]), Block: 2, Address: accounts.address("D"), Authorize: true},
+		{Signer: accounts.address(names[0]), Block: 4, Address: accounts.address("D"), Authorize: false},
+	}
+	if !reflect.DeepEqual(votes, wantVotes) {
+		t.Errorf("votes mismatch: have %v, want %v", votes, wantVotes)
+	}
+	if votes, err = api.GetVotes(3, 3); err != nil || len(votes) != 0 {
+		t.Errorf("vote-less range mismatch: have %v, %v, want none", votes, err)
+	}
+	if _, err := api.GetVotes(4, 2); err != errInvalidRange {
+		t.Errorf("inverted range error mismatch: have %v, want %v", err, errInvalidRange)
+	}
+}
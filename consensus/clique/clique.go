@@ -36,6 +36,7 @@ import (
 	"github.com/fairblock/go-fairblock/crypto/sha3"
 	"github.com/fairblock/go-fairblock/fbcdb"
 	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/metrics"
 	"github.com/fairblock/go-fairblock/params"
 	"github.com/fairblock/go-fairblock/rlp"
 	"github.com/fairblock/go-fairblock/rpc"
@@ -67,6 +68,13 @@ var (
 	diffNoTurn = big.NewInt(1) // Block difficulty for out-of-turn signatures
 )
 
+// Signer liveness metrics, updated as blocks extending the canonical chain are
+// imported or sealed locally.
+var (
+	inturnBlockMeter = metrics.NewMeter("clique/blocks/inturn") // Blocks sealed by the in-turn signer
+	noturnBlockMeter = metrics.NewMeter("clique/blocks/noturn") // Blocks sealed out-of-turn
+)
+
 // Various error messages to mark blocks invalid. These should be private to
 // prevent engine specific errors from being referenced in the remainder of the
 // codebase, inherently breaking if the engine is swapped out. Please put common
@@ -206,6 +214,9 @@ type Clique struct {
 	signer common.Address // Fairblock address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
 	lock   sync.RWMutex   // Protects the signer fields
+
+	metered   uint64     // Highest block number counted in the liveness metrics
+	meterLock sync.Mutex // Protects the metered block number
 }
 
 // New creates a Clique proof-of-authority consensus engine with the initial
@@ -499,9 +510,38 @@ func (c *Clique) verifySeal(chain consensus.ChainReader, header *types.Header, p
 	if !inturn && header.Difficulty.Cmp(diffNoTurn) != 0 {
 		return errInvalidDifficulty
 	}
+	// Only count blocks extending the canonical chain in the liveness metrics,
+	// side chains and already imported blocks would skew them
+	if head := chain.CurrentHeader(); head != nil && head.Hash() == header.ParentHash {
+		c.meterSeal(snap, number, signer)
+	}
 	return nil
 }
 
+// meterSeal updates the signer liveness metrics with a block extending the
+// canonical chain, sealed by the given signer on top of the snapshot. Every
+// height is counted once, even if the block is replaced by a competing one. An
+// in-turn signer is only charged with a missed slot if it was allowed to seal.
+func (c *Clique) meterSeal(snap *Snapshot, number uint64, signer common.Address) {
+	c.meterLock.Lock()
+	defer c.meterLock.Unlock()
+
+	if number <= c.metered {
+		return
+	}
+	c.metered = number
+
+	inturn := snap.inturnSigner(number)
+	if inturn == signer {
+		inturnBlockMeter.Mark(1)
+		return
+	}
+	noturnBlockMeter.Mark(1)
+	if !snap.recentlySigned(number, inturn) {
+		metrics.NewCounter("clique/missed/" + inturn.Hex()).Inc(1)
+	}
+}
+
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) error {
@@ -573,7 +613,18 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
 // rewards given, and returns the final block.
 func (c *Clique) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
-	// No block rewards in PoA, so the state remains as is and uncles are dropped
+	// Block rewards are only paid in PoA if the chain schedules them explicitly,
+	// to the signer of the block. Uncles are dropped.
+	if reward := chain.Config().ScheduledBlockReward(header.Number); reward.Sign() > 0 {
+		signer, err := c.Author(header)
+		if err != nil {
+			// The header is not sealed yet, we're the ones signing it
+			c.lock.RLock()
+			signer = c.signer
+			c.lock.RUnlock()
+		}
+		state.AddBalance(signer, reward)
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
 
@@ -652,6 +703,9 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 
+	// Locally sealed blocks are written without verification, meter them here
+	c.meterSeal(snap, number, signer)
+
 	return block.WithSeal(header), nil
 }
 
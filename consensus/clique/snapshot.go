@@ -300,6 +300,24 @@ func (s *Snapshot) signers() []common.Address {
 	return signers
 }
 
+// inturnSigner returns the signer whose turn it is to seal at a given block height.
+func (s *Snapshot) inturnSigner(number uint64) common.Address {
+	signers := s.signers()
+	return signers[number%uint64(len(signers))]
+}
+
+// recentlySigned returns whether a signer is barred from sealing the block at a
+// given height by having signed one of the preceding blocks.
+func (s *Snapshot) recentlySigned(number uint64, signer common.Address) bool {
+	limit := uint64(len(s.Signers)/2 + 1)
+	for seen, recent := range s.Recents {
+		if recent == signer && (number < limit || seen > number-limit) {
+			return true
+		}
+	}
+	return false
+}
+
 // inturn returns if a signer at a given block height is in-turn or not.
 func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
 	signers, offset := s.signers(), 0
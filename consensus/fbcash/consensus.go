@@ -17,7 +17,6 @@
 package fbcash
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -36,9 +35,7 @@ import (
 
 // Fbcash proof-of-work protocol constants.
 var (
-	frontierBlockReward  *big.Int = big.NewInt(0) // Block reward in wei for successfully mining a block
-	byzantiumBlockReward *big.Int = big.NewInt(3e+18) // Block reward in wei for successfully mining a block upward from Byzantium
-	maxUncles                     = 2                 // Maximum number of uncles allowed in a single block
+	maxUncles = 2 // Maximum number of uncles allowed in a single block
 )
 
 // Various error messages to mark blocks invalid. These should be private to
@@ -466,32 +463,69 @@ func (fbcash *Fbcash) VerifySeal(chain consensus.ChainReader, header *types.Head
 	if fbcash.shared != nil {
 		return fbcash.shared.VerifySeal(chain, header)
 	}
+	// Ensure that we have a valid difficulty for the block
+	if header.Difficulty.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+	return fbcash.verifyPoW(header, header.Difficulty)
+}
+
+// VerifyShare checks whether the given header satisfies the PoW requirements of
+// a share difficulty, usually lower than the block's own one. It is used to credit
+// the partial solutions of pooled miners.
+func (fbcash *Fbcash) VerifyShare(header *types.Header, difficulty *big.Int) error {
+	// If we're running a fake PoW, accept any share as valid
+	if fbcash.fakeMode {
+		return nil
+	}
+	// If we're running a shared PoW, delegate verification to it
+	if fbcash.shared != nil {
+		return fbcash.shared.VerifyShare(header, difficulty)
+	}
+	if difficulty.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+	return fbcash.verifyPoW(header, difficulty)
+}
+
+// verifyPoW recomputes the digest and PoW value of a header from the verification
+// cache and checks them against the header and the given difficulty.
+func (fbcash *Fbcash) verifyPoW(header *types.Header, difficulty *big.Int) error {
 	// Sanity check that the block number is below the lookup table size (60M blocks)
 	number := header.Number.Uint64()
 	if number/epochLength >= uint64(len(cacheSizes)) {
 		// Go < 1.7 cannot calculate new cache/dataset sizes (no fast prime check)
 		return errNonceOutOfRange
 	}
-	// Ensure that we have a valid difficulty for the block
-	if header.Difficulty.Sign() <= 0 {
-		return errInvalidDifficulty
+	digest, result := fbcash.Hashimoto(header, header.Nonce.Uint64())
+	if header.MixDigest != digest {
+		return errInvalidMixDigest
+	}
+	target := new(big.Int).Div(maxUint256, difficulty)
+	if result.Cmp(target) > 0 {
+		return errInvalidPoW
+	}
+	return nil
+}
+
+// Hashimoto computes the mix digest and PoW value of a header sealed with the
+// given nonce using the verification cache. A fake PoW returns zero values.
+func (fbcash *Fbcash) Hashimoto(header *types.Header, nonce uint64) (common.Hash, *big.Int) {
+	if fbcash.fakeMode {
+		return common.Hash{}, new(big.Int)
+	}
+	if fbcash.shared != nil {
+		return fbcash.shared.Hashimoto(header, nonce)
 	}
-	// Recompute the digest and PoW value and verify against the header
+	number := header.Number.Uint64()
 	cache := fbcash.cache(number)
 
 	size := datasetSize(number)
 	if fbcash.tester {
 		size = 32 * 1024
 	}
-	digest, result := hashimotoLight(size, cache, header.HashNoNonce().Bytes(), header.Nonce.Uint64())
-	if !bytes.Equal(header.MixDigest[:], digest) {
-		return errInvalidMixDigest
-	}
-	target := new(big.Int).Div(maxUint256, header.Difficulty)
-	if new(big.Int).SetBytes(result).Cmp(target) > 0 {
-		return errInvalidPoW
-	}
-	return nil
+	digest, result := hashimotoLight(size, cache, header.HashNoNonce().Bytes(), nonce)
+	return common.BytesToHash(digest), new(big.Int).SetBytes(result)
 }
 
 // Prepare implements consensus.Engine, initializing the difficulty field of a
@@ -519,20 +553,19 @@ func (fbcash *Fbcash) Finalize(chain consensus.ChainReader, header *types.Header
 
 // Some weird constants to avoid constant memory allocs for them.
 var (
-	big8  = big.NewInt(8)
-	big32 = big.NewInt(32)
+	big8 = big.NewInt(8)
 )
 
 // AccumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
+// reward. The total reward consists of the block reward scheduled by the chain
+// config and rewards for included uncles. The coinbase of each uncle block is
+// also rewarded.
 // TODO (karalabe): Move the chain maker into this package and make this private!
 func AccumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
 	// Select the correct block reward based on chain progression
-	blockReward := frontierBlockReward
-	if config.IsByzantium(header.Number) {
-		blockReward = byzantiumBlockReward
-	}
+	blockReward := config.BlockReward(header.Number)
+	uncleDivisor, nephewDivisor := config.UncleRewardDivisors()
+
 	// Accumulate the rewards for the miner and any included uncles
 	reward := new(big.Int).Set(blockReward)
 	r := new(big.Int)
@@ -540,10 +573,10 @@ func AccumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 		r.Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
-		r.Div(r, big8)
+		r.Div(r, uncleDivisor)
 		state.AddBalance(uncle.Coinbase, r)
 
-		r.Div(blockReward, big32)
+		r.Div(blockReward, nephewDivisor)
 		reward.Add(reward, r)
 	}
 	state.AddBalance(header.Coinbase, reward)
@@ -0,0 +1,822 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ibft implements a Byzantine fault tolerant proof-of-authority
+// consensus engine in the style of Istanbul BFT. Validators agree on every block
+// in pre-prepare, prepare and commit phases before it is added to the chain, so
+// blocks are final as soon as they are imported.
+package ibft
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/consensus"
+	"github.com/fairblock/go-fairblock/consensus/misc"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/state"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+	"github.com/fairblock/go-fairblock/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	checkpointInterval = 1024 // Number of blocks after which to save the vote snapshot to the database
+	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inmemoryMessages   = 4096 // Number of recent consensus messages to keep in memory for deduplication
+
+	defaultRequestTimeout = 10000 // Default milliseconds after which a consensus round is abandoned
+)
+
+// IBFT proof-of-authority protocol constants.
+var (
+	epochLength = uint64(30000) // Default number of blocks after which to checkpoint and reset the pending votes
+
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for validator vanity
+	extraSeal   = 65 // Fixed number of bytes of the proposer and committed seals
+
+	nonceAuthVote = hexutil.MustDecode("0xffffffffffffffff") // Magic nonce number to vote on adding a new validator
+	nonceDropVote = hexutil.MustDecode("0x0000000000000000") // Magic nonce number to vote on removing a validator.
+
+	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+
+	defaultDifficulty = big.NewInt(1) // Block difficulty, there is no fork choice between final blocks
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the list of validators is requested for a
+	// block that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidCheckpointBeneficiary is returned if a checkpoint/epoch transition
+	// block has a beneficiary set to non-zeroes.
+	errInvalidCheckpointBeneficiary = errors.New("beneficiary in checkpoint block non-zero")
+
+	// errInvalidVote is returned if a nonce value is somfbcing else that the two
+	// allowed constants of 0x00..0 or 0xff..f.
+	errInvalidVote = errors.New("vote nonce not 0x00..0 or 0xff..f")
+
+	// errInvalidCheckpointVote is returned if a checkpoint/epoch transition block
+	// has a vote nonce set to non-zeroes.
+	errInvalidCheckpointVote = errors.New("vote nonce in checkpoint block non-zero")
+
+	// errMissingVanity is returned if a block's extra-data section is shorter than
+	// 32 bytes, which is required to store the validator vanity.
+	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
+
+	// errInvalidExtraData is returned if the consensus data following the vanity
+	// in a block's extra-data section cannot be decoded.
+	errInvalidExtraData = errors.New("invalid consensus extra-data")
+
+	// errMissingSignature is returned if a block's extra-data section doesn't seem
+	// to contain a 65 byte secp256k1 proposer signature.
+	errMissingSignature = errors.New("extra-data 65 byte proposer signature missing")
+
+	// errExtraValidators is returned if non-checkpoint block contain validator
+	// data in their extra-data fields.
+	errExtraValidators = errors.New("non-checkpoint block contains extra validator list")
+
+	// errInvalidCheckpointValidators is returned if a checkpoint block contains an
+	// invalid list of validators (i.e. not the correct ones).
+	errInvalidCheckpointValidators = errors.New("invalid validator list on checkpoint block")
+
+	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
+	errInvalidMixDigest = errors.New("non-zero mix digest")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// ErrInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the previous block's timestamp + the minimum block period.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errInvalidVotingChain is returned if an authorization list is attempted to
+	// be modified via out-of-range or non-contiguous headers.
+	errInvalidVotingChain = errors.New("invalid voting chain")
+
+	// errUnauthorized is returned if a header is signed by a non-validator.
+	errUnauthorized = errors.New("unauthorized")
+
+	// errInvalidCommittedSeals is returned if a block is not committed by a
+	// quorum of distinct validators.
+	errInvalidCommittedSeals = errors.New("invalid committed seals")
+
+	// errFinalized is returned if a block competes with one already finalized.
+	errFinalized = errors.New("block conflicts with the finalized chain")
+
+	// errWaitTransactions is returned if an empty block is attempted to be sealed
+	// on an instant chain (0 second period). It's important to refuse these as the
+	// block reward is zero, so an empty block just bloats the chain... fast.
+	errWaitTransactions = errors.New("waiting for transactions")
+
+	// errNotStarted is returned if a block is attempted to be sealed before the
+	// consensus state machine is started.
+	errNotStarted = errors.New("consensus engine not started")
+)
+
+// SignerFn is a signer callback function to request a hash to be signed by a
+// backing account.
+type SignerFn func(accounts.Account, []byte) ([]byte, error)
+
+// Chain is the blockchain the consensus engine agrees on and finalizes blocks
+// into.
+type Chain interface {
+	consensus.ChainReader
+
+	// InsertChain imports finalized blocks into the chain.
+	InsertChain(chain types.Blocks) (int, error)
+
+	// SubscribeChainHeadEvent notifies about new chain heads.
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Extra is the consensus data stored in the extra-data section of a header,
+// following the vanity prefix.
+type Extra struct {
+	Validators    []common.Address // Validator set, only on checkpoint blocks
+	Seal          []byte           // Signature of the proposer over the block
+	CommittedSeal [][]byte         // Signatures of the validators committing the block
+}
+
+// extractExtra decodes the consensus data of a header's extra-data section.
+func extractExtra(header *types.Header) (*Extra, error) {
+	if len(header.Extra) < extraVanity {
+		return nil, errMissingVanity
+	}
+	extra := new(Extra)
+	if err := rlp.DecodeBytes(header.Extra[extraVanity:], extra); err != nil {
+		return nil, errInvalidExtraData
+	}
+	return extra, nil
+}
+
+// writeExtra replaces the consensus data of a header's extra-data section,
+// keeping its vanity prefix.
+func writeExtra(header *types.Header, extra *Extra) error {
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return err
+	}
+	vanity := make([]byte, extraVanity)
+	copy(vanity, header.Extra)
+	header.Extra = append(vanity, payload...)
+	return nil
+}
+
+// EncodeExtra assembles the extra-data section of a checkpoint header, such as
+// the genesis block, authorizing the given validators.
+func EncodeExtra(vanity []byte, validators []common.Address) ([]byte, error) {
+	header := &types.Header{Extra: vanity}
+	if len(vanity) > extraVanity {
+		header.Extra = vanity[:extraVanity]
+	}
+	if err := writeExtra(header, &Extra{Validators: validators}); err != nil {
+		return nil, err
+	}
+	return header.Extra, nil
+}
+
+// filteredHeader returns a copy of the header stripped of the committed seals,
+// and optionally of the proposer seal too. The header must carry valid extra-data.
+func filteredHeader(header *types.Header, keepSeal bool) *types.Header {
+	cpy := types.CopyHeader(header)
+	extra, err := extractExtra(cpy)
+	if err != nil {
+		return cpy
+	}
+	if !keepSeal {
+		extra.Seal = nil
+	}
+	extra.CommittedSeal = nil
+	writeExtra(cpy, extra)
+	return cpy
+}
+
+// sigHash returns the hash which is signed by the proposer of a block. It is the
+// hash of the entire header apart from the seals in its extra-data.
+func sigHash(header *types.Header) common.Hash {
+	return filteredHeader(header, false).Hash()
+}
+
+// proposalHash returns the hash validators agree on and commit to. It is the hash
+// of the entire header, including the proposer seal but not the committed seals.
+func proposalHash(header *types.Header) common.Hash {
+	return filteredHeader(header, true).Hash()
+}
+
+// commitHash returns the hash the validators sign when committing to a proposal.
+func commitHash(proposal common.Hash) []byte {
+	return crypto.Keccak256(proposal.Bytes(), []byte{byte(msgCommit)})
+}
+
+// recoverAddress extracts the Fairblock account address from a signature.
+func recoverAddress(hash []byte, signature []byte) (common.Address, error) {
+	pubkey, err := crypto.Ecrecover(hash, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
+// ecrecover extracts the Fairblock account address of the proposer of a block.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	extra, err := extractExtra(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(extra.Seal) != extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signer, err := recoverAddress(sigHash(header).Bytes(), extra.Seal)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// IBFT is the Byzantine fault tolerant proof-of-authority consensus engine.
+type IBFT struct {
+	config *params.IBFTConfig // Consensus engine configuration parameters
+	db     fbcdb.Database     // Database to store and retrieve snapshot checkpoints
+
+	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+	messages   *lru.ARCCache // Hashes of recent consensus messages to drop duplicates
+
+	proposals map[common.Address]bool // Current list of proposals we are pushing
+
+	signer  common.Address // Fairblock address of the signing key
+	signFn  SignerFn       // Signer function to authorize hashes with
+	machine *machine       // Consensus state machine, nil if not started
+	lock    sync.RWMutex   // Protects the signer and state machine fields
+
+	peers *peerSet // Peers gossiping consensus messages
+}
+
+// New creates an IBFT proof-of-authority consensus engine with the initial
+// validators set to the ones provided by the user.
+func New(config *params.IBFTConfig, db fbcdb.Database) *IBFT {
+	// Set any missing consensus parameters to their defaults
+	conf := *config
+	if conf.Epoch == 0 {
+		conf.Epoch = epochLength
+	}
+	if conf.RequestTimeout == 0 {
+		conf.RequestTimeout = defaultRequestTimeout
+	}
+	// Allocate the snapshot caches and create the engine
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	signatures, _ := lru.NewARC(inmemorySignatures)
+	messages, _ := lru.NewARC(inmemoryMessages)
+
+	return &IBFT{
+		config:     &conf,
+		db:         db,
+		recents:    recents,
+		signatures: signatures,
+		messages:   messages,
+		proposals:  make(map[common.Address]bool),
+		peers:      newPeerSet(),
+	}
+}
+
+// Author implements consensus.Engine, returning the Fairblock address of the
+// validator that proposed the block.
+func (c *IBFT) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, c.signatures)
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (c *IBFT) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return c.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (c *IBFT) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := c.verifyHeader(chain, header, headers[:i])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules, and is
+// committed by a quorum of validators. The caller may optionally pass in a batch
+// of parents (ascending order) to avoid looking those up from the database.
+func (c *IBFT) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if err := c.verifyFields(chain, header); err != nil {
+		return err
+	}
+	// Blocks are final once committed, never accept a competing one
+	number := header.Number.Uint64()
+	if current := chain.CurrentHeader(); current != nil && number <= current.Number.Uint64() {
+		if canonical := chain.GetHeaderByNumber(number); canonical != nil && canonical.Hash() != header.Hash() {
+			return errFinalized
+		}
+	}
+	return c.verifyCascadingFields(chain, header, parents, true)
+}
+
+// verifyFields checks the standalone consensus fields of a header.
+func (c *IBFT) verifyFields(chain consensus.ChainReader, header *types.Header) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	// Don't waste time checking blocks from the future
+	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	// Checkpoint blocks need to enforce zero beneficiary
+	checkpoint := (number % c.config.Epoch) == 0
+	if checkpoint && header.Coinbase != (common.Address{}) {
+		return errInvalidCheckpointBeneficiary
+	}
+	// Nonces must be 0x00..0 or 0xff..f, zeroes enforced on checkpoints
+	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+		return errInvalidVote
+	}
+	if checkpoint && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+		return errInvalidCheckpointVote
+	}
+	// Ensure that the extra-data contains a validator list on checkpoint, but none otherwise
+	extra, err := extractExtra(header)
+	if err != nil {
+		return err
+	}
+	if !checkpoint && len(extra.Validators) != 0 {
+		return errExtraValidators
+	}
+	// Ensure that the mix digest is zero as we don't have fork protection currently
+	if header.MixDigest != (common.Hash{}) {
+		return errInvalidMixDigest
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	// Ensure that the block's difficulty is meaningful
+	if number > 0 && (header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0) {
+		return errInvalidDifficulty
+	}
+	// If all checks passed, validate any special fields for hard forks
+	return misc.VerifyForkHashes(chain.Config(), header, false)
+}
+
+// verifyCascadingFields verifies all the header fields that are not standalone,
+// rather depend on a batch of previous headers. The committed seals are only
+// checked if requested, proposals being agreed on don't carry them yet.
+func (c *IBFT) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, committed bool) error {
+	// The genesis block is the always valid dead-end
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	// Ensure that the block's timestamp isn't too close to it's parent
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// Retrieve the snapshot needed to verify this header and cache it
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return err
+	}
+	// If the block is a checkpoint block, verify the validator list
+	if number%c.config.Epoch == 0 {
+		extra, err := extractExtra(header)
+		if err != nil {
+			return err
+		}
+		validators := snap.validators()
+		if len(extra.Validators) != len(validators) {
+			return errInvalidCheckpointValidators
+		}
+		for i, validator := range validators {
+			if extra.Validators[i] != validator {
+				return errInvalidCheckpointValidators
+			}
+		}
+	}
+	// All basic checks passed, verify the seals and return
+	if err := c.verifySeal(snap, header); err != nil {
+		return err
+	}
+	if committed {
+		return c.verifyCommittedSeals(snap, header)
+	}
+	return nil
+}
+
+// snapshot retrieves the authorization snapshot at a given point in time.
+func (c *IBFT) snapshot(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	// Search for a snapshot in memory or on disk for checkpoints
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := c.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(c.config, c.signatures, c.db, hash); err == nil {
+				log.Trace("Loaded voting snapshot form disk", "number", number, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at block zero, make a snapshot
+		if number == 0 {
+			genesis := chain.GetHeaderByNumber(0)
+			extra, err := extractExtra(genesis)
+			if err != nil {
+				return nil, err
+			}
+			snap = newSnapshot(c.config, c.signatures, 0, genesis.Hash(), extra.Validators)
+			if err := snap.store(c.db); err != nil {
+				return nil, err
+			}
+			log.Trace("Stored genesis voting snapshot to disk")
+			break
+		}
+		// No snapshot for this header, gather the header and move backward
+		var header *types.Header
+		if len(parents) > 0 {
+			// If we have explicit parents, pick from there (enforced)
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			// No explicit parents (or no more left), reach out to the database
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	// If we've generated a new checkpoint snapshot, save to disk
+	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
+		if err = snap.store(c.db); err != nil {
+			return nil, err
+		}
+		log.Trace("Stored voting snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+	}
+	return snap, err
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (c *IBFT) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine, checking whether the block is proposed
+// and committed by the validators of its parent.
+func (c *IBFT) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.verifySeal(snap, header); err != nil {
+		return err
+	}
+	return c.verifyCommittedSeals(snap, header)
+}
+
+// verifySeal checks whether the proposer seal of the header is signed by one of
+// the validators of the snapshot.
+func (c *IBFT) verifySeal(snap *Snapshot, header *types.Header) error {
+	proposer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Validators[proposer]; !ok {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// verifyCommittedSeals checks whether the header is committed by a quorum of
+// distinct validators of the snapshot.
+func (c *IBFT) verifyCommittedSeals(snap *Snapshot, header *types.Header) error {
+	extra, err := extractExtra(header)
+	if err != nil {
+		return err
+	}
+	hash := commitHash(proposalHash(header))
+
+	committers := make(map[common.Address]struct{})
+	for _, seal := range extra.CommittedSeal {
+		if len(seal) != extraSeal {
+			return errInvalidCommittedSeals
+		}
+		committer, err := recoverAddress(hash, seal)
+		if err != nil {
+			return errInvalidCommittedSeals
+		}
+		if _, ok := snap.Validators[committer]; !ok {
+			return errInvalidCommittedSeals
+		}
+		if _, dup := committers[committer]; dup {
+			return errInvalidCommittedSeals
+		}
+		committers[committer] = struct{}{}
+	}
+	if len(committers) < snap.quorum() {
+		return errInvalidCommittedSeals
+	}
+	return nil
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top.
+func (c *IBFT) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	// If the block isn't a checkpoint, cast a random vote (good enough for now)
+	header.Coinbase = common.Address{}
+	header.Nonce = types.BlockNonce{}
+
+	number := header.Number.Uint64()
+
+	// Assemble the voting snapshot to check which votes make sense
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	extra := new(Extra)
+	if number%c.config.Epoch != 0 {
+		c.lock.RLock()
+
+		// Gather all the proposals that make sense voting on
+		addresses := make([]common.Address, 0, len(c.proposals))
+		for address, authorize := range c.proposals {
+			if snap.validVote(address, authorize) {
+				addresses = append(addresses, address)
+			}
+		}
+		// If there's pending proposals, cast a vote on them
+		if len(addresses) > 0 {
+			header.Coinbase = addresses[rand.Intn(len(addresses))]
+			if c.proposals[header.Coinbase] {
+				copy(header.Nonce[:], nonceAuthVote)
+			} else {
+				copy(header.Nonce[:], nonceDropVote)
+			}
+		}
+		c.lock.RUnlock()
+	} else {
+		extra.Validators = snap.validators()
+	}
+	// Set the correct difficulty
+	header.Difficulty = new(big.Int).Set(defaultDifficulty)
+
+	// Ensure the extra data has all it's components
+	if err := writeExtra(header, extra); err != nil {
+		return err
+	}
+	// Mix digest is reserved for now, set to empty
+	header.MixDigest = common.Hash{}
+
+	// Ensure the timestamp has the correct delay
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(c.config.Period))
+	if header.Time.Int64() < time.Now().Unix() {
+		header.Time = big.NewInt(time.Now().Unix())
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, ensuring no uncles are set, and returns
+// the final block.
+func (c *IBFT) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	// Block rewards are only paid in PoA if the chain schedules them explicitly,
+	// to the proposer of the block. Uncles are dropped.
+	if reward := chain.Config().ScheduledBlockReward(header.Number); reward.Sign() > 0 {
+		proposer, err := c.Author(header)
+		if err != nil {
+			// The header is not sealed yet, we're the ones proposing it
+			c.lock.RLock()
+			proposer = c.signer
+			c.lock.RUnlock()
+		}
+		state.AddBalance(proposer, reward)
+	}
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// Authorize injects a private key into the consensus engine to propose and
+// commit new blocks with.
+func (c *IBFT) Authorize(validator common.Address, signFn SignerFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.signer = validator
+	c.signFn = signFn
+}
+
+// Seal implements consensus.Engine, submitting the block to the validators for
+// agreement. The block is returned with the committed seals once finalized, if
+// it was proposed by the local validator.
+func (c *IBFT) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, errUnknownBlock
+	}
+	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
+	if c.config.Period == 0 && len(block.Transactions()) == 0 {
+		return nil, errWaitTransactions
+	}
+	// Don't hold the signer fields for the entire sealing procedure
+	c.lock.RLock()
+	signer, signFn, machine := c.signer, c.signFn, c.machine
+	c.lock.RUnlock()
+
+	if machine == nil {
+		return nil, errNotStarted
+	}
+	// Bail out if we're unauthorized to propose a block
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, authorized := snap.Validators[signer]; !authorized {
+		return nil, errUnauthorized
+	}
+	// Sweet, the protocol permits us to propose the block, wait for our time
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now())
+	log.Trace("Waiting for slot to propose", "delay", common.PrettyDuration(delay))
+
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	// Sign the block as its proposer and submit it for agreement
+	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	extra, err := extractExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	extra.Seal = sighash
+	if err := writeExtra(header, extra); err != nil {
+		return nil, err
+	}
+	req := newRequest(block.WithSeal(header))
+	if !machine.submit(req) {
+		return nil, errNotStarted
+	}
+	select {
+	case result := <-req.result:
+		return result, nil
+	case <-stop:
+		// The block might have been finalized concurrently, don't lose it
+		return req.cancel(), nil
+	}
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to allow
+// controlling the validator voting.
+func (c *IBFT) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "ibft",
+		Version:   "1.0",
+		Service:   &API{chain: chain, ibft: c},
+		Public:    false,
+	}}
+}
+
+// Start launches the consensus state machine, agreeing on blocks on top of the
+// chain and importing them once finalized.
+func (c *IBFT) Start(chain Chain) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.machine != nil {
+		return
+	}
+	c.machine = newMachine(c, chain)
+	go c.machine.loop()
+}
+
+// Stop terminates the consensus state machine.
+func (c *IBFT) Stop() {
+	c.lock.Lock()
+	machine := c.machine
+	c.machine = nil
+	c.lock.Unlock()
+
+	// The state machine accesses the signer fields, don't hold the lock
+	if machine != nil {
+		machine.stop()
+	}
+}
+
+// validator returns the local validator address and signing function.
+func (c *IBFT) validator() (common.Address, SignerFn) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.signer, c.signFn
+}
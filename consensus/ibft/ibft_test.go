@@ -0,0 +1,263 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package ibft
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/miner"
+	"github.com/fairblock/go-fairblock/node"
+	"github.com/fairblock/go-fairblock/p2p"
+	"github.com/fairblock/go-fairblock/p2p/discover"
+	"github.com/fairblock/go-fairblock/p2p/simulations"
+	"github.com/fairblock/go-fairblock/p2p/simulations/adapters"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rpc"
+)
+
+// testNode is a validator running the consensus engine and a miner on top of an
+// in-memory chain, without any of the other Fairblock protocols.
+type testNode struct {
+	key    *ecdsa.PrivateKey
+	db     fbcdb.Database
+	engine *IBFT
+	chain  *core.BlockChain
+	txpool *core.TxPool
+	mux    *event.TypeMux
+	miner  *miner.Miner
+}
+
+func newTestNode(genesis *core.Genesis, key *ecdsa.PrivateKey, datadir string) (*testNode, error) {
+	db, _ := fbcdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := New(genesis.Config.IBFT, db)
+	chain, err := core.NewBlockChain(db, genesis.Config, engine, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// Keep the local transaction journal out of the working directory
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = filepath.Join(datadir, "transactions.rlp")
+
+	n := &testNode{
+		key:    key,
+		db:     db,
+		engine: engine,
+		chain:  chain,
+		txpool: core.NewTxPool(poolConfig, genesis.Config, chain),
+		mux:    new(event.TypeMux),
+	}
+	n.miner = miner.New(n, genesis.Config, n.mux, engine)
+
+	engine.Authorize(crypto.PubkeyToAddress(key.PublicKey), func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	return n, nil
+}
+
+// Methods needed by the miner.
+func (n *testNode) AccountManager() *accounts.Manager { return accounts.NewManager() }
+func (n *testNode) BlockChain() *core.BlockChain      { return n.chain }
+func (n *testNode) TxPool() *core.TxPool              { return n.txpool }
+func (n *testNode) ChainDb() fbcdb.Database           { return n.db }
+
+// Methods implementing node.Service.
+func (n *testNode) Protocols() []p2p.Protocol { return n.engine.Protocols() }
+func (n *testNode) APIs() []rpc.API           { return nil }
+
+func (n *testNode) Start(*p2p.Server) error {
+	n.engine.Start(n.chain)
+	n.miner.Start(crypto.PubkeyToAddress(n.key.PublicKey))
+	return nil
+}
+
+func (n *testNode) Stop() error {
+	n.miner.Stop()
+	n.engine.Stop()
+	n.txpool.Stop()
+	n.chain.Stop()
+	n.mux.Stop()
+	return nil
+}
+
+// testNetwork is a fully connected simulation network of validators.
+type testNetwork struct {
+	network *simulations.Network
+	datadir string
+	ids     []discover.NodeID
+	nodes   []*testNode
+}
+
+func newTestNetwork(t *testing.T, validators int) *testNetwork {
+	// Generate the validator keys and a genesis block authorizing them
+	keys := make(map[string]*ecdsa.PrivateKey)
+	names := make([]string, validators)
+	addrs := make([]common.Address, validators)
+	for i := range names {
+		names[i] = fmt.Sprintf("validator%d", i)
+		keys[names[i]], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[names[i]].PublicKey)
+	}
+	extra, err := EncodeExtra(nil, addrs)
+	if err != nil {
+		t.Fatalf("failed to encode genesis extra-data: %v", err)
+	}
+	config := *params.TestChainConfig
+	config.Fbcash, config.IBFT = nil, &params.IBFTConfig{Period: 1, RequestTimeout: 1000}
+
+	datadir, err := ioutil.TempDir("", "ibft-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+
+	// Create a simulation network running a validator on each node
+	var (
+		lock  sync.Mutex
+		nodes = make(map[string]*testNode)
+	)
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		"ibft": func(ctx *adapters.ServiceContext) (node.Service, error) {
+			genesis := &core.Genesis{
+				Config:     &config,
+				ExtraData:  extra,
+				GasLimit:   params.GenesisGasLimit.Uint64(),
+				Difficulty: defaultDifficulty,
+				Alloc:      core.GenesisAlloc{},
+			}
+			dir := filepath.Join(datadir, ctx.Config.Name)
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return nil, err
+			}
+			n, err := newTestNode(genesis, keys[ctx.Config.Name], dir)
+			if err != nil {
+				return nil, err
+			}
+			lock.Lock()
+			nodes[ctx.Config.Name] = n
+			lock.Unlock()
+			return n, nil
+		},
+	})
+	net := &testNetwork{
+		network: simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: "ibft"}),
+		datadir: datadir,
+	}
+	for _, name := range names {
+		conf := adapters.RandomNodeConfig()
+		conf.Name, conf.Services = name, []string{"ibft"}
+
+		node, err := net.network.NewNodeWithConfig(conf)
+		if err != nil {
+			net.shutdown()
+			t.Fatalf("failed to create node %s: %v", name, err)
+		}
+		if err := net.network.Start(node.ID()); err != nil {
+			net.shutdown()
+			t.Fatalf("failed to start node %s: %v", name, err)
+		}
+		net.ids = append(net.ids, node.ID())
+		net.nodes = append(net.nodes, nodes[name])
+	}
+	for i := range net.ids {
+		for j := i + 1; j < len(net.ids); j++ {
+			if err := net.network.Connect(net.ids[i], net.ids[j]); err != nil {
+				net.shutdown()
+				t.Fatalf("failed to connect nodes %d and %d: %v", i, j, err)
+			}
+		}
+	}
+	return net
+}
+
+// shutdown stops all the validators and removes their data directories.
+func (net *testNetwork) shutdown() {
+	net.network.Shutdown()
+	os.RemoveAll(net.datadir)
+}
+
+// waitHeight waits until all the given validators imported the given block and
+// checks that they agree on the chain up to it.
+func (net *testNetwork) waitHeight(t *testing.T, validators []int, height uint64, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for _, i := range validators {
+		for net.nodes[i].chain.CurrentBlock().NumberU64() < height {
+			if time.Now().After(deadline) {
+				t.Fatalf("validator %d: timeout waiting for block %d, have %d", i, height, net.nodes[i].chain.CurrentBlock().NumberU64())
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	for number := uint64(1); number <= height; number++ {
+		want := net.nodes[validators[0]].chain.GetBlockByNumber(number)
+		for _, i := range validators[1:] {
+			if have := net.nodes[i].chain.GetBlockByNumber(number); have.Hash() != want.Hash() {
+				t.Fatalf("validator %d, block %d: hash mismatch: have %x, want %x", i, number, have.Hash(), want.Hash())
+			}
+		}
+		if err := net.nodes[validators[0]].engine.verifyCommittedSeals(net.snapshot(t, validators[0], want.Header()), want.Header()); err != nil {
+			t.Fatalf("block %d: invalid committed seals: %v", number, err)
+		}
+	}
+}
+
+// snapshot returns the validators of a block as seen by the given validator.
+func (net *testNetwork) snapshot(t *testing.T, validator int, header *types.Header) *Snapshot {
+	n := net.nodes[validator]
+	snap, err := n.engine.snapshot(n.chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot of block %d: %v", header.Number, err)
+	}
+	return snap
+}
+
+// Tests that a network of validators agrees on a single chain, and keeps doing
+// so with one of them offline.
+func TestConsensus(t *testing.T) {
+	net := newTestNetwork(t, 4)
+	defer net.shutdown()
+
+	all := []int{0, 1, 2, 3}
+	net.waitHeight(t, all, 3, 30*time.Second)
+
+	// Take a validator offline, the others still make up a quorum
+	if err := net.network.Stop(net.ids[3]); err != nil {
+		t.Fatalf("failed to stop validator: %v", err)
+	}
+	height := net.nodes[0].chain.CurrentBlock().NumberU64()
+	for _, i := range all[1:3] {
+		if number := net.nodes[i].chain.CurrentBlock().NumberU64(); number > height {
+			height = number
+		}
+	}
+	net.waitHeight(t, all[:3], height+4, 60*time.Second)
+}
@@ -0,0 +1,646 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package ibft
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// Phases of a consensus round.
+const (
+	stateAcceptRequest uint8 = iota // Waiting for the proposal of the round
+	statePreprepared                // Proposal accepted, collecting prepares
+	statePrepared                   // Proposal prepared and locked, collecting commits
+	stateCommitted                  // Proposal committed by a quorum, final
+)
+
+const (
+	chainHeadChanSize = 10 // Size of the channel listening to new chain heads
+
+	maxBacklog      = 1024 // Maximum number of messages for future rounds to keep
+	backlogDepth    = 16   // Number of future blocks to keep messages of
+	maxTimeoutShift = 8    // Maximum number of times the round timeout is doubled
+)
+
+// errInvalidProposal is returned if a proposed block doesn't extend the local
+// chain, or its body doesn't match its header.
+var errInvalidProposal = errors.New("invalid proposal")
+
+// request is a block the local miner asks the validators to agree on.
+type request struct {
+	block  *types.Block
+	result chan *types.Block // Finalized block, if agreed on
+
+	done bool       // Whether the result is delivered or abandoned
+	lock sync.Mutex // Protects the done flag
+}
+
+func newRequest(block *types.Block) *request {
+	return &request{
+		block:  block,
+		result: make(chan *types.Block, 1),
+	}
+}
+
+// deliver hands the finalized block to the requester, returning false if the
+// request was abandoned in the meantime.
+func (r *request) deliver(block *types.Block) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.done {
+		return false
+	}
+	r.done = true
+	r.result <- block
+	return true
+}
+
+// cancel abandons the request, returning the finalized block if it was
+// delivered concurrently.
+func (r *request) cancel() *types.Block {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.done = true
+	select {
+	case block := <-r.result:
+		return block
+	default:
+		return nil
+	}
+}
+
+// machine is the consensus state machine agreeing on the next block of the chain
+// with the other validators. All its state is owned by the loop goroutine.
+type machine struct {
+	engine *IBFT
+	chain  Chain
+
+	requestCh chan *request
+	messageCh chan *message
+	blockCh   chan *types.Block
+	quit      chan struct{}
+	done      chan struct{}
+
+	parent       *types.Header  // Head of the chain the block is agreed on top of
+	snap         *Snapshot      // Validators of the block being agreed on
+	lastProposer common.Address // Proposer of the parent block, to rotate from
+	sequence     uint64         // Number of the block being agreed on
+	round        uint64         // Current round of agreement on the block
+	desiredRound uint64         // Latest round a change was requested to
+
+	state    uint8                          // Phase of the current round
+	proposal *types.Block                   // Block proposed in the current round
+	digest   common.Hash                    // Proposal hash of the proposed block
+	locked   *types.Block                   // Block prepared by a quorum, the only one acceptable
+	prepares map[common.Address]common.Hash // Proposal hashes prepared by the validators
+	commits  map[common.Address]*message    // Commits of the validators, with their seals
+
+	roundChanges map[uint64]map[common.Address]struct{} // Validators requesting each future round
+	request      *request                               // Latest block requested by the local miner
+	backlog      []*message                             // Messages of future rounds and blocks
+	timer        *time.Timer                            // Round timeout
+}
+
+func newMachine(engine *IBFT, chain Chain) *machine {
+	return &machine{
+		engine:    engine,
+		chain:     chain,
+		requestCh: make(chan *request),
+		messageCh: make(chan *message),
+		blockCh:   make(chan *types.Block),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// submit passes a sealing request to the state machine, returning false if it
+// is stopped.
+func (m *machine) submit(req *request) bool {
+	select {
+	case m.requestCh <- req:
+		return true
+	case <-m.quit:
+		return false
+	}
+}
+
+// deliverMessage passes a consensus message received from the network to the
+// state machine.
+func (m *machine) deliverMessage(msg *message) {
+	select {
+	case m.messageCh <- msg:
+	case <-m.quit:
+	}
+}
+
+// deliverBlock passes a finalized block received from the network to the state
+// machine.
+func (m *machine) deliverBlock(block *types.Block) {
+	select {
+	case m.blockCh <- block:
+	case <-m.quit:
+	}
+}
+
+// stop terminates the state machine and waits for it to return.
+func (m *machine) stop() {
+	close(m.quit)
+	<-m.done
+}
+
+// loop is the event loop of the state machine.
+func (m *machine) loop() {
+	defer close(m.done)
+
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := m.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	m.timer = time.NewTimer(m.timeout(0))
+	defer m.timer.Stop()
+
+	m.syncHead()
+	for {
+		select {
+		case <-heads:
+			m.syncHead()
+		case req := <-m.requestCh:
+			m.handleRequest(req)
+		case msg := <-m.messageCh:
+			m.handleMessage(msg)
+		case block := <-m.blockCh:
+			m.handleFinalBlock(block)
+		case <-m.timer.C:
+			m.handleTimeout()
+		case <-sub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// syncHead starts agreeing on a new block if the head of the chain changed.
+func (m *machine) syncHead() {
+	head := m.chain.CurrentHeader()
+	if m.parent != nil && head.Hash() == m.parent.Hash() {
+		return
+	}
+	snap, err := m.engine.snapshot(m.chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		log.Warn("Failed to retrieve validator snapshot", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	proposer, _ := m.engine.Author(head) // Genesis has no proposer, rotate from the start
+
+	m.parent, m.snap, m.lastProposer = head, snap, proposer
+	m.sequence, m.desiredRound = head.Number.Uint64()+1, 0
+	m.locked = nil
+	m.roundChanges = make(map[uint64]map[common.Address]struct{})
+
+	log.Trace("Starting agreement on new block", "number", m.sequence, "validators", len(snap.Validators))
+	m.startRound(0)
+}
+
+// startRound resets the round state, replays the messages backlogged for it and
+// proposes a block if it's the local validator's turn.
+func (m *machine) startRound(round uint64) {
+	m.round, m.state = round, stateAcceptRequest
+	m.proposal, m.digest = nil, common.Hash{}
+	m.prepares = make(map[common.Address]common.Hash)
+	m.commits = make(map[common.Address]*message)
+	if m.desiredRound < round {
+		m.desiredRound = round
+	}
+	for r := range m.roundChanges {
+		if r <= round {
+			delete(m.roundChanges, r)
+		}
+	}
+	m.resetTimer(round)
+	if round > 0 {
+		log.Debug("Started new consensus round", "number", m.sequence, "round", round, "proposer", m.proposer())
+	}
+	// Replay any messages received early, then propose if it's our turn
+	backlog := m.backlog
+	m.backlog = nil
+	for _, msg := range backlog {
+		m.handleMessage(msg)
+	}
+	m.propose()
+}
+
+// timeout returns the time after which the given round is abandoned. The first
+// round waits for the block period too, later ones back off exponentially.
+func (m *machine) timeout(round uint64) time.Duration {
+	shift := round
+	if shift > maxTimeoutShift {
+		shift = maxTimeoutShift
+	}
+	timeout := time.Duration(m.engine.config.RequestTimeout) * time.Millisecond << shift
+	if round == 0 {
+		timeout += time.Duration(m.engine.config.Period) * time.Second
+	}
+	return timeout
+}
+
+func (m *machine) resetTimer(round uint64) {
+	if !m.timer.Stop() {
+		select {
+		case <-m.timer.C:
+		default:
+		}
+	}
+	m.timer.Reset(m.timeout(round))
+}
+
+// proposer returns the validator proposing in the current round.
+func (m *machine) proposer() common.Address {
+	return m.snap.proposer(m.lastProposer, m.round)
+}
+
+// waiting returns whether the local validator requested to move to a later round.
+func (m *machine) waiting() bool {
+	return m.desiredRound > m.round
+}
+
+// broadcast signs a consensus message of the local validator and sends it to
+// the network, returning false if the local node is not a validator.
+func (m *machine) broadcast(msg *message) bool {
+	validator, signFn := m.engine.validator()
+	if _, ok := m.snap.Validators[validator]; !ok || signFn == nil {
+		return false
+	}
+	if err := msg.sign(validator, signFn); err != nil {
+		log.Warn("Failed to sign consensus message", "err", err)
+		return false
+	}
+	m.engine.messages.Add(msg.hash, struct{}{})
+	m.engine.peers.broadcast(msg.hash, ConsensusMsg, msg)
+	return true
+}
+
+// handleRequest records a block the local miner wants agreed on, proposing it if
+// it's the local validator's turn.
+func (m *machine) handleRequest(req *request) {
+	m.syncHead()
+	if m.parent == nil || req.block.ParentHash() != m.parent.Hash() {
+		return // Stale request, the miner will be given new work
+	}
+	m.request = req
+	m.propose()
+}
+
+// propose sends the block to agree on in the current round, if the local
+// validator is its proposer. A locked block must be proposed again.
+func (m *machine) propose() {
+	if m.state != stateAcceptRequest || m.waiting() {
+		return
+	}
+	if validator, _ := m.engine.validator(); validator != m.proposer() {
+		return
+	}
+	block := m.locked
+	if block == nil {
+		if m.request == nil || m.request.block.ParentHash() != m.parent.Hash() {
+			return // Nothing to propose yet
+		}
+		block = m.request.block
+	}
+	blob, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		log.Error("Failed to encode proposal", "err", err)
+		return
+	}
+	msg := &message{
+		Code:     msgPreprepare,
+		Sequence: m.sequence,
+		Round:    m.round,
+		Digest:   proposalHash(block.Header()),
+		Proposal: blob,
+	}
+	if !m.broadcast(msg) {
+		return
+	}
+	log.Debug("Proposed new block", "number", m.sequence, "round", m.round, "txs", len(block.Transactions()), "digest", msg.Digest)
+	m.acceptProposal(block)
+}
+
+// handleMessage processes a consensus message of another validator.
+func (m *machine) handleMessage(msg *message) {
+	if m.parent == nil {
+		return
+	}
+	// Drop messages of past blocks, keep the ones of future blocks for later
+	switch {
+	case msg.Sequence < m.sequence:
+		return
+	case msg.Sequence > m.sequence:
+		if msg.Sequence <= m.sequence+backlogDepth && len(m.backlog) < maxBacklog {
+			m.backlog = append(m.backlog, msg)
+			m.engine.peers.broadcast(msg.hash, ConsensusMsg, msg)
+		}
+		return
+	}
+	if _, ok := m.snap.Validators[msg.address]; !ok {
+		log.Trace("Dropping message of non-validator", "msg", msg)
+		return
+	}
+	// Valid message for the current block, relay it to the rest of the network
+	m.engine.peers.broadcast(msg.hash, ConsensusMsg, msg)
+
+	if msg.Code == msgRoundChange {
+		m.handleRoundChange(msg)
+		return
+	}
+	switch {
+	case msg.Round < m.round:
+		return
+	case msg.Round > m.round:
+		if len(m.backlog) < maxBacklog {
+			m.backlog = append(m.backlog, msg)
+		}
+		return
+	}
+	log.Trace("Handling consensus message", "msg", msg)
+
+	switch msg.Code {
+	case msgPreprepare:
+		m.handlePreprepare(msg)
+	case msgPrepare:
+		m.prepares[msg.address] = msg.Digest
+		m.checkPrepared()
+	case msgCommit:
+		m.handleCommit(msg)
+	}
+}
+
+// handlePreprepare accepts the block proposed in the current round if it's sent
+// by the round's proposer, valid and not conflicting with a locked block.
+func (m *machine) handlePreprepare(msg *message) {
+	if msg.address != m.proposer() || m.state != stateAcceptRequest || m.waiting() {
+		return
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(msg.Proposal, block); err != nil {
+		log.Debug("Failed to decode proposal", "msg", msg, "err", err)
+		return
+	}
+	if proposalHash(block.Header()) != msg.Digest {
+		log.Debug("Proposal digest mismatch", "msg", msg)
+		return
+	}
+	if err := m.verifyProposal(block); err != nil {
+		log.Debug("Rejected invalid proposal", "number", m.sequence, "round", m.round, "err", err)
+		return
+	}
+	if m.locked != nil && proposalHash(m.locked.Header()) != msg.Digest {
+		log.Debug("Rejected proposal conflicting with locked block", "number", m.sequence, "round", m.round)
+		return
+	}
+	m.acceptProposal(block)
+}
+
+// verifyProposal checks whether a proposed block is valid on top of the local
+// chain, apart from the committed seals it can't have yet.
+func (m *machine) verifyProposal(block *types.Block) error {
+	header := block.Header()
+	if header.Number.Uint64() != m.sequence || header.ParentHash != m.parent.Hash() {
+		return errInvalidProposal
+	}
+	if len(block.Uncles()) > 0 || types.DeriveSha(block.Transactions()) != header.TxHash {
+		return errInvalidProposal
+	}
+	if err := m.engine.verifyFields(m.chain, header); err != nil {
+		return err
+	}
+	return m.engine.verifyCascadingFields(m.chain, header, nil, false)
+}
+
+// acceptProposal records the proposal of the current round and prepares it.
+func (m *machine) acceptProposal(block *types.Block) {
+	m.proposal, m.digest, m.state = block, proposalHash(block.Header()), statePreprepared
+
+	msg := &message{
+		Code:     msgPrepare,
+		Sequence: m.sequence,
+		Round:    m.round,
+		Digest:   m.digest,
+	}
+	if m.broadcast(msg) {
+		m.prepares[msg.address] = m.digest
+	}
+	m.checkPrepared()
+	m.checkCommitted()
+}
+
+// checkPrepared locks and commits the proposal once a quorum prepared it.
+func (m *machine) checkPrepared() {
+	if m.state != statePreprepared {
+		return
+	}
+	prepared := 0
+	for _, digest := range m.prepares {
+		if digest == m.digest {
+			prepared++
+		}
+	}
+	if prepared < m.snap.quorum() {
+		return
+	}
+	m.locked, m.state = m.proposal, statePrepared
+	log.Trace("Proposal prepared", "number", m.sequence, "round", m.round, "digest", m.digest)
+
+	validator, signFn := m.engine.validator()
+	if _, ok := m.snap.Validators[validator]; ok && signFn != nil {
+		seal, err := signFn(accounts.Account{Address: validator}, commitHash(m.digest))
+		if err != nil {
+			log.Warn("Failed to sign committed seal", "err", err)
+			return
+		}
+		msg := &message{
+			Code:          msgCommit,
+			Sequence:      m.sequence,
+			Round:         m.round,
+			Digest:        m.digest,
+			CommittedSeal: seal,
+		}
+		if m.broadcast(msg) {
+			m.commits[msg.address] = msg
+		}
+	}
+	m.checkCommitted()
+}
+
+// handleCommit records the commit of a validator if its seal is valid.
+func (m *machine) handleCommit(msg *message) {
+	committer, err := recoverAddress(commitHash(msg.Digest), msg.CommittedSeal)
+	if err != nil || committer != msg.address {
+		log.Debug("Dropping commit with invalid seal", "msg", msg)
+		return
+	}
+	m.commits[msg.address] = msg
+	m.checkCommitted()
+}
+
+// checkCommitted finalizes the proposal once a quorum committed it. Only the
+// proposer of the round assembles the final block, so that all nodes import the
+// same committed seals.
+func (m *machine) checkCommitted() {
+	if m.proposal == nil || m.state == stateCommitted {
+		return
+	}
+	committed := 0
+	for _, commit := range m.commits {
+		if commit.Digest == m.digest {
+			committed++
+		}
+	}
+	if committed < m.snap.quorum() {
+		return
+	}
+	m.locked, m.state = m.proposal, stateCommitted
+
+	if validator, _ := m.engine.validator(); validator != m.proposer() {
+		log.Trace("Proposal committed, waiting for final block", "number", m.sequence, "round", m.round, "digest", m.digest)
+		return
+	}
+	m.finalize()
+}
+
+// finalize seals the committed proposal with the committed seals, broadcasts it
+// and hands it to the local miner or imports it directly.
+func (m *machine) finalize() {
+	header := m.proposal.Header()
+	extra, err := extractExtra(header)
+	if err != nil {
+		log.Error("Failed to decode committed proposal", "err", err)
+		return
+	}
+	extra.CommittedSeal = nil
+	for _, validator := range m.snap.validators() {
+		if commit := m.commits[validator]; commit != nil && commit.Digest == m.digest {
+			extra.CommittedSeal = append(extra.CommittedSeal, commit.CommittedSeal)
+		}
+	}
+	if err := writeExtra(header, extra); err != nil {
+		log.Error("Failed to encode committed seals", "err", err)
+		return
+	}
+	block := m.proposal.WithSeal(header)
+	log.Debug("Finalized block", "number", block.Number(), "hash", block.Hash(), "round", m.round, "seals", len(extra.CommittedSeal))
+
+	m.engine.messages.Add(block.Hash(), struct{}{})
+	m.engine.peers.broadcast(block.Hash(), FinalBlockMsg, block)
+
+	// Hand the block to the miner if it requested it, import it otherwise
+	if req := m.request; req != nil && proposalHash(req.block.Header()) == m.digest && req.deliver(block) {
+		return
+	}
+	m.insert(block)
+}
+
+// handleFinalBlock imports a block finalized by another validator if it extends
+// the local chain and is committed by a quorum.
+func (m *machine) handleFinalBlock(block *types.Block) {
+	if m.parent == nil || block.NumberU64() != m.sequence || block.ParentHash() != m.parent.Hash() {
+		return
+	}
+	if err := m.engine.verifyHeader(m.chain, block.Header(), nil); err != nil {
+		log.Debug("Rejected invalid final block", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	m.engine.peers.broadcast(block.Hash(), FinalBlockMsg, block)
+	m.insert(block)
+}
+
+// insert imports a finalized block into the chain.
+func (m *machine) insert(block *types.Block) {
+	if _, err := m.chain.InsertChain(types.Blocks{block}); err != nil {
+		log.Warn("Failed to import final block", "number", block.Number(), "hash", block.Hash(), "err", err)
+	}
+}
+
+// handleRoundChange records a validator's request to move to a later round,
+// joining in once enough validators asked so at least one of them is honest.
+func (m *machine) handleRoundChange(msg *message) {
+	if msg.Round <= m.round {
+		return
+	}
+	set := m.roundChanges[msg.Round]
+	if set == nil {
+		set = make(map[common.Address]struct{})
+		m.roundChanges[msg.Round] = set
+	}
+	set[msg.address] = struct{}{}
+
+	if len(set) > m.snap.faulty() && msg.Round > m.desiredRound {
+		m.sendRoundChange(msg.Round)
+		return
+	}
+	m.checkRoundChange(msg.Round)
+}
+
+// sendRoundChange requests the other validators to move to the given round.
+func (m *machine) sendRoundChange(round uint64) {
+	m.desiredRound = round
+
+	msg := &message{
+		Code:     msgRoundChange,
+		Sequence: m.sequence,
+		Round:    round,
+	}
+	if m.broadcast(msg) {
+		if m.roundChanges[round] == nil {
+			m.roundChanges[round] = make(map[common.Address]struct{})
+		}
+		m.roundChanges[round][msg.address] = struct{}{}
+	}
+	m.resetTimer(round)
+	m.checkRoundChange(round)
+}
+
+// checkRoundChange moves to the given round once a quorum requested it.
+func (m *machine) checkRoundChange(round uint64) {
+	if round > m.round && len(m.roundChanges[round]) >= m.snap.quorum() {
+		m.startRound(round)
+	}
+}
+
+// handleTimeout requests a round change if no block was finalized in time.
+func (m *machine) handleTimeout() {
+	if m.parent == nil {
+		return
+	}
+	round := m.round
+	if m.desiredRound > round {
+		round = m.desiredRound
+	}
+	log.Debug("Consensus round timed out", "number", m.sequence, "round", round)
+	m.sendRoundChange(round + 1)
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package ibft
+
+import (
+	"fmt"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// Consensus message codes, in the order of the phases of a round.
+const (
+	msgPreprepare uint64 = iota
+	msgPrepare
+	msgCommit
+	msgRoundChange
+)
+
+// message is a signed consensus message a validator sends to the others about
+// the block at a given sequence (block number) and round.
+type message struct {
+	Code          uint64
+	Sequence      uint64
+	Round         uint64
+	Digest        common.Hash // Proposal hash being prepared or committed
+	Proposal      []byte      // RLP encoded proposed block, only for pre-prepare
+	CommittedSeal []byte      // Signature over the commit hash, only for commit
+	Signature     []byte      // Signature of the validator over the above fields
+
+	address common.Address // Validator that sent the message (derived)
+	hash    common.Hash    // Hash of the encoded message, for deduplication (derived)
+}
+
+// String implements fmt.Stringer.
+func (m *message) String() string {
+	names := []string{"PRE-PREPARE", "PREPARE", "COMMIT", "ROUND-CHANGE"}
+	name := fmt.Sprintf("UNKNOWN(%d)", m.Code)
+	if m.Code < uint64(len(names)) {
+		name = names[m.Code]
+	}
+	return fmt.Sprintf("%s{seq: %d, round: %d, from: %x}", name, m.Sequence, m.Round, m.address[:4])
+}
+
+// sigHash returns the hash of the message fields the sender signs.
+func (m *message) sigHash() []byte {
+	blob, _ := rlp.EncodeToBytes([]interface{}{m.Code, m.Sequence, m.Round, m.Digest, m.Proposal, m.CommittedSeal})
+	return crypto.Keccak256(blob)
+}
+
+// sign signs the message on behalf of the given validator, filling in the
+// derived fields too.
+func (m *message) sign(validator common.Address, signFn SignerFn) error {
+	sig, err := signFn(accounts.Account{Address: validator}, m.sigHash())
+	if err != nil {
+		return err
+	}
+	m.Signature = sig
+	m.address = validator
+
+	blob, err := rlp.EncodeToBytes(m)
+	if err != nil {
+		return err
+	}
+	m.hash = crypto.Keccak256Hash(blob)
+	return nil
+}
+
+// decodeMessage decodes a consensus message and recovers its sender.
+func decodeMessage(payload []byte) (*message, error) {
+	msg := new(message)
+	if err := rlp.DecodeBytes(payload, msg); err != nil {
+		return nil, err
+	}
+	if msg.Code > msgRoundChange {
+		return nil, fmt.Errorf("invalid message code %d", msg.Code)
+	}
+	address, err := recoverAddress(msg.sigHash(), msg.Signature)
+	if err != nil {
+		return nil, err
+	}
+	msg.address = address
+	msg.hash = crypto.Keccak256Hash(payload)
+	return msg, nil
+}
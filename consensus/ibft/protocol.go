@@ -0,0 +1,216 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package ibft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/p2p"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	ProtocolName       = "ibft"
+	ProtocolVersion    = 1
+	ProtocolLength     = 2
+	ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+)
+
+// Protocol message codes
+const (
+	ConsensusMsg  = 0x00 // Signed consensus message of a validator
+	FinalBlockMsg = 0x01 // Block finalized with the committed seals of the validators
+)
+
+const (
+	maxKnownMessages  = 4096 // Maximum message hashes to keep in the known list per peer
+	maxQueuedMessages = 256  // Maximum number of messages to queue up for sending to a peer
+)
+
+// outbound is a protocol message queued up for sending to a peer.
+type outbound struct {
+	code uint64
+	data interface{}
+}
+
+// peer is a remote node speaking the consensus gossip protocol.
+type peer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	known *lru.Cache    // Hashes of the messages known to the peer
+	queue chan outbound // Messages queued up for sending
+	term  chan struct{} // Termination channel to stop the sender
+}
+
+func newPeer(p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	known, _ := lru.New(maxKnownMessages)
+	return &peer{
+		Peer:  p,
+		rw:    rw,
+		known: known,
+		queue: make(chan outbound, maxQueuedMessages),
+		term:  make(chan struct{}),
+	}
+}
+
+// send queues up a message for the peer, unless it's known to have it already.
+func (p *peer) send(hash common.Hash, code uint64, data interface{}) {
+	if p.known.Contains(hash) {
+		return
+	}
+	p.known.Add(hash, struct{}{})
+	select {
+	case p.queue <- outbound{code: code, data: data}:
+	default:
+		p.Log().Debug("Dropping consensus message, queue full", "code", code)
+	}
+}
+
+// broadcast sends the queued up messages to the remote peer until terminated.
+func (p *peer) broadcast() {
+	for {
+		select {
+		case msg := <-p.queue:
+			if err := p2p.Send(p.rw, msg.code, msg.data); err != nil {
+				return
+			}
+		case <-p.term:
+			return
+		}
+	}
+}
+
+// peerSet is the set of peers gossiping consensus messages.
+type peerSet struct {
+	peers map[string]*peer
+	lock  sync.RWMutex
+}
+
+func newPeerSet() *peerSet {
+	return &peerSet{peers: make(map[string]*peer)}
+}
+
+func (ps *peerSet) register(p *peer) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ps.peers[p.ID().String()] = p
+}
+
+func (ps *peerSet) unregister(p *peer) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	delete(ps.peers, p.ID().String())
+}
+
+// broadcast sends a message to all peers not yet knowing about it.
+func (ps *peerSet) broadcast(hash common.Hash, code uint64, data interface{}) {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	for _, p := range ps.peers {
+		p.send(hash, code, data)
+	}
+}
+
+// Protocols returns the gossip sub-protocol the validators exchange consensus
+// messages and finalized blocks over.
+func (c *IBFT) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    ProtocolName,
+		Version: ProtocolVersion,
+		Length:  ProtocolLength,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return c.handlePeer(newPeer(p, rw))
+		},
+	}}
+}
+
+// handlePeer is invoked for every peer speaking the gossip protocol, feeding the
+// messages it sends into the consensus state machine.
+func (c *IBFT) handlePeer(p *peer) error {
+	p.Log().Debug("Consensus peer connected", "name", p.Name())
+
+	c.peers.register(p)
+	defer c.peers.unregister(p)
+
+	go p.broadcast()
+	defer close(p.term)
+
+	for {
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > ProtocolMaxMsgSize {
+			msg.Discard()
+			return fmt.Errorf("message too large: %v > %v", msg.Size, ProtocolMaxMsgSize)
+		}
+		switch msg.Code {
+		case ConsensusMsg:
+			payload, err := ioutil.ReadAll(msg.Payload)
+			if err != nil {
+				return err
+			}
+			cmsg, err := decodeMessage(payload)
+			if err != nil {
+				return fmt.Errorf("invalid consensus message: %v", err)
+			}
+			p.known.Add(cmsg.hash, struct{}{})
+			if c.messages.Contains(cmsg.hash) {
+				continue
+			}
+			c.messages.Add(cmsg.hash, struct{}{})
+			if machine := c.running(); machine != nil {
+				machine.deliverMessage(cmsg)
+			}
+
+		case FinalBlockMsg:
+			block := new(types.Block)
+			if err := msg.Decode(block); err != nil {
+				return fmt.Errorf("invalid final block: %v", err)
+			}
+			hash := block.Hash()
+			p.known.Add(hash, struct{}{})
+			if c.messages.Contains(hash) {
+				continue
+			}
+			c.messages.Add(hash, struct{}{})
+			if machine := c.running(); machine != nil {
+				machine.deliverBlock(block)
+			}
+
+		default:
+			msg.Discard()
+			log.Trace("Unknown consensus protocol message", "code", msg.Code)
+		}
+	}
+}
+
+// running returns the consensus state machine if the engine is started.
+func (c *IBFT) running() *machine {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.machine
+}
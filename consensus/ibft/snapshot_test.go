@@ -0,0 +1,258 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package ibft
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+type testerVote struct {
+	validator string
+	voted     string
+	auth      bool
+}
+
+// testerAccountPool is a pool to maintain currently active tester accounts,
+// mapped from textual names used in the tests below to actual Fairblock private
+// keys capable of signing blocks.
+type testerAccountPool struct {
+	accounts map[string]*ecdsa.PrivateKey
+}
+
+func newTesterAccountPool() *testerAccountPool {
+	return &testerAccountPool{
+		accounts: make(map[string]*ecdsa.PrivateKey),
+	}
+}
+
+func (ap *testerAccountPool) key(account string) *ecdsa.PrivateKey {
+	// Ensure we have a persistent key for the account
+	if ap.accounts[account] == nil {
+		ap.accounts[account], _ = crypto.GenerateKey()
+	}
+	return ap.accounts[account]
+}
+
+func (ap *testerAccountPool) seal(header *types.Header, validator string) {
+	// Sign the header and embed the proposer seal in the extra data
+	sig, _ := crypto.Sign(sigHash(header).Bytes(), ap.key(validator))
+
+	extra, _ := extractExtra(header)
+	extra.Seal = sig
+	writeExtra(header, extra)
+}
+
+func (ap *testerAccountPool) address(account string) common.Address {
+	return crypto.PubkeyToAddress(ap.key(account).PublicKey)
+}
+
+// Tests that voting is evaluated correctly for various simple and complex scenarios.
+func TestVoting(t *testing.T) {
+	// Define the various voting scenarios to test
+	tests := []struct {
+		epoch      uint64
+		validators []string
+		votes      []testerVote
+		results    []string
+	}{
+		{
+			// Single validator, no votes cast
+			validators: []string{"A"},
+			votes:      []testerVote{{validator: "A"}},
+			results:    []string{"A"},
+		}, {
+			// Single validator, voting to add two others (only accept first, second needs 2 votes)
+			validators: []string{"A"},
+			votes: []testerVote{
+				{validator: "A", voted: "B", auth: true},
+				{validator: "B"},
+				{validator: "A", voted: "C", auth: true},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Single validator, dropping itself (weird, but one less cornercase by explicitly allowing this)
+			validators: []string{"A"},
+			votes: []testerVote{
+				{validator: "A", voted: "A", auth: false},
+			},
+			results: []string{},
+		}, {
+			// Four validators, a majority is needed to drop one
+			validators: []string{"A", "B", "C", "D"},
+			votes: []testerVote{
+				{validator: "A", voted: "D", auth: false},
+				{validator: "B", voted: "D", auth: false},
+				{validator: "C"},
+			},
+			results: []string{"A", "B", "C", "D"},
+		}, {
+			// Four validators, the dropped validator's votes are discarded
+			validators: []string{"A", "B", "C", "D"},
+			votes: []testerVote{
+				{validator: "D", voted: "E", auth: true},
+				{validator: "A", voted: "D", auth: false},
+				{validator: "B", voted: "D", auth: false},
+				{validator: "C", voted: "D", auth: false},
+				{validator: "A", voted: "E", auth: true},
+				{validator: "B", voted: "E", auth: true},
+			},
+			results: []string{"A", "B", "C", "E"},
+		}, {
+			// Votes are reset on epoch boundaries
+			epoch:      3,
+			validators: []string{"A", "B"},
+			votes: []testerVote{
+				{validator: "A", voted: "C", auth: true},
+				{validator: "B"},
+				{validator: "A"}, // Checkpoint block, (don't vote here, it's validated outside of snapshots)
+				{validator: "B", voted: "C", auth: true},
+			},
+			results: []string{"A", "B"},
+		},
+	}
+	// Run through the scenarios and test them
+	for i, tt := range tests {
+		accounts := newTesterAccountPool()
+
+		validators := make([]common.Address, len(tt.validators))
+		for j, validator := range tt.validators {
+			validators[j] = accounts.address(validator)
+		}
+		config := &params.IBFTConfig{Epoch: tt.epoch}
+		if config.Epoch == 0 {
+			config.Epoch = epochLength
+		}
+		sigcache, _ := lru.NewARC(inmemorySignatures)
+		snap := newSnapshot(config, sigcache, 0, common.Hash{}, validators)
+
+		// Assemble a chain of headers from the cast votes
+		headers := make([]*types.Header, len(tt.votes))
+		for j, vote := range tt.votes {
+			extra, _ := EncodeExtra(nil, nil)
+			headers[j] = &types.Header{
+				Number: big.NewInt(int64(j) + 1),
+				Time:   big.NewInt(int64(j) * 1),
+				Extra:  extra,
+			}
+			if j > 0 {
+				headers[j].ParentHash = headers[j-1].Hash()
+			}
+			if vote.voted != "" {
+				headers[j].Coinbase = accounts.address(vote.voted)
+			}
+			if vote.auth {
+				copy(headers[j].Nonce[:], nonceAuthVote)
+			}
+			accounts.seal(headers[j], vote.validator)
+		}
+		snap, err := snap.apply(headers)
+		if err != nil {
+			t.Errorf("test %d: failed to apply headers: %v", i, err)
+			continue
+		}
+		// Verify the final list of validators against the expected ones
+		validators = make([]common.Address, len(tt.results))
+		for j, validator := range tt.results {
+			validators[j] = accounts.address(validator)
+		}
+		for j := 0; j < len(validators); j++ {
+			for k := j + 1; k < len(validators); k++ {
+				if bytes.Compare(validators[j][:], validators[k][:]) > 0 {
+					validators[j], validators[k] = validators[k], validators[j]
+				}
+			}
+		}
+		result := snap.validators()
+		if len(result) != len(validators) {
+			t.Errorf("test %d: validators mismatch: have %x, want %x", i, result, validators)
+			continue
+		}
+		for j := 0; j < len(result); j++ {
+			if !bytes.Equal(result[j][:], validators[j][:]) {
+				t.Errorf("test %d, validator %d: validator mismatch: have %x, want %x", i, j, result[j], validators[j])
+			}
+		}
+	}
+}
+
+// Tests that the quorum and the number of tolerated faulty validators are
+// derived correctly from the size of the validator set.
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		validators int
+		quorum     int
+		faulty     int
+	}{
+		{1, 1, 0},
+		{2, 2, 0},
+		{3, 2, 0},
+		{4, 3, 1},
+		{5, 4, 1},
+		{6, 4, 1},
+		{7, 5, 2},
+		{10, 7, 3},
+	}
+	for _, tt := range tests {
+		validators := make([]common.Address, tt.validators)
+		for i := range validators {
+			validators[i] = common.BytesToAddress([]byte{byte(i + 1)})
+		}
+		snap := newSnapshot(&params.IBFTConfig{}, nil, 0, common.Hash{}, validators)
+		if quorum := snap.quorum(); quorum != tt.quorum {
+			t.Errorf("%d validators: quorum mismatch: have %d, want %d", tt.validators, quorum, tt.quorum)
+		}
+		if faulty := snap.faulty(); faulty != tt.faulty {
+			t.Errorf("%d validators: faulty mismatch: have %d, want %d", tt.validators, faulty, tt.faulty)
+		}
+	}
+}
+
+// Tests that the proposer rotates through the validators in ascending order,
+// starting after the proposer of the previous block and advancing with rounds.
+func TestProposer(t *testing.T) {
+	a, b, c := common.Address{0x01}, common.Address{0x02}, common.Address{0x03}
+	snap := newSnapshot(&params.IBFTConfig{}, nil, 0, common.Hash{}, []common.Address{c, a, b})
+
+	tests := []struct {
+		last  common.Address
+		round uint64
+		want  common.Address
+	}{
+		{common.Address{}, 0, a}, // Genesis parent, start from the first validator
+		{a, 0, b},
+		{b, 0, c},
+		{c, 0, a},
+		{a, 1, c},
+		{a, 2, a},
+		{b, 4, a},
+		{common.Address{0xff}, 1, b}, // Unknown (dropped) proposer, start from the first validator
+	}
+	for i, tt := range tests {
+		if have := snap.proposer(tt.last, tt.round); have != tt.want {
+			t.Errorf("test %d: proposer mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
@@ -52,9 +52,9 @@ type Config struct {
 // newly released versions of the client being run and issues a warning to the
 // user about it.
 type ReleaseService struct {
-	config Config          // Current version to check releases against
-	oracle *ReleaseOracle  // Native binding to the release oracle contract
-	quit   chan chan error // Quit channel to terminate the version checker
+	config Config               // Current version to check releases against
+	oracle *ReleaseOracleCaller // Native binding to the release oracle contract
+	quit   chan chan error      // Quit channel to terminate the version checker
 }
 
 // NewReleaseService creates a new service to periodically check for new client
@@ -74,7 +74,7 @@ func NewReleaseService(ctx *node.ServiceContext, config Config) (node.Service, e
 		}
 	}
 	// Construct the release service
-	contract, err := NewReleaseOracle(config.Oracle, fbc.NewContractBackend(apiBackend))
+	contract, err := NewReleaseOracleCaller(config.Oracle, fbc.NewContractBackend(apiBackend))
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +69,11 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveSha(block.Transactions()); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
+	if v.config.Fairblock != nil {
+		if err := ValidateRevealOrder(block.Transactions()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -100,10 +105,13 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	return nil
 }
 
-// CalcGasLimit computes the gas limit of the next block after parent.
+// CalcGasLimit computes the gas limit of the next block after parent. It aims
+// to keep the limit above the given floor, and lets it follow the gas usage up
+// to the given ceiling (nil = unbounded). Outside of the targets the limit is
+// moved towards them as much as the protocol bound divisor allows.
 // The result may be modified by the caller.
 // This is miner strategy, not consensus protocol.
-func CalcGasLimit(parent *types.Block) *big.Int {
+func CalcGasLimit(parent *types.Block, gasFloor, gasCeil *big.Int) *big.Int {
 	// contrib = (parentGasUsed * 3 / 2) / 1024
 	contrib := new(big.Int).Mul(parent.GasUsed(), big.NewInt(3))
 	contrib = contrib.Div(contrib, big.NewInt(2))
@@ -124,11 +132,15 @@ func CalcGasLimit(parent *types.Block) *big.Int {
 	gl = gl.Add(gl, contrib)
 	gl.Set(math.BigMax(gl, params.MinGasLimit))
 
-	// however, if we're now below the target (TargetGasLimit) we increase the
-	// limit as much as we can (parentGasLimit / 1024 -1)
-	if gl.Cmp(params.TargetGasLimit) < 0 {
+	// however, if we're now below the floor we increase the limit as much as we
+	// can (parentGasLimit / 1024 -1), and if we're above the ceiling we decrease
+	// it as much as we can
+	if gl.Cmp(gasFloor) < 0 {
 		gl.Add(parent.GasLimit(), decay)
-		gl.Set(math.BigMin(gl, params.TargetGasLimit))
+		gl.Set(math.BigMin(gl, gasFloor))
+	} else if gasCeil != nil && gl.Cmp(gasCeil) > 0 {
+		gl.Sub(parent.GasLimit(), decay)
+		gl.Set(math.BigMax(gl, gasCeil))
 	}
 	return gl
 }
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"math/big"
 	"runtime"
 	"testing"
 	"time"
@@ -197,3 +198,35 @@ func testHeaderConcurrentAbortion(t *testing.T, threads int) {
 		t.Errorf("verification count too large: have %d, want below %d", verified, 2*threads)
 	}
 }
+
+// Tests that the gas limit of new blocks moves towards the configured floor and
+// ceiling, but never faster than the protocol bound divisor allows.
+func TestCalcGasLimit(t *testing.T) {
+	limit := params.GenesisGasLimit.Uint64() // decay = limit / 1024 - 1 = 4600
+
+	tests := []struct {
+		limit, used uint64
+		floor, ceil uint64 // ceil 0 means unbounded
+		want        uint64
+	}{
+		{limit, 0, limit, 0, limit},                         // At the floor, empty parent: keep
+		{limit, 0, 8000000, 0, limit + 4600},                // Below the floor: raise by decay
+		{limit, 0, 1000000, 2000000, limit - 4600},          // Above the ceiling: lower by decay
+		{limit, limit, 1000000, 0, limit + 2302},            // Full parent: regular usage based growth
+		{limit, limit, 1000000, limit + 1000, limit + 1000}, // Growth capped at the ceiling
+		{5000, 0, 1, 0, params.MinGasLimit.Uint64()},        // Never below the protocol minimum
+	}
+	for i, tt := range tests {
+		parent := types.NewBlockWithHeader(&types.Header{
+			GasLimit: new(big.Int).SetUint64(tt.limit),
+			GasUsed:  new(big.Int).SetUint64(tt.used),
+		})
+		var ceil *big.Int
+		if tt.ceil != 0 {
+			ceil = new(big.Int).SetUint64(tt.ceil)
+		}
+		if have := CalcGasLimit(parent, new(big.Int).SetUint64(tt.floor), ceil); have.Uint64() != tt.want {
+			t.Errorf("test %d: gas limit mismatch: have %v, want %d", i, have, tt.want)
+		}
+	}
+}
@@ -51,6 +51,7 @@ type BlockGen struct {
 	uncles   []*types.Header
 
 	config *params.ChainConfig
+	db     fbcdb.Database
 }
 
 // SetCoinbase sets the coinbase of the generated block.
@@ -84,7 +85,7 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 		b.SetCoinbase(common.Address{})
 	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	receipt, _, err := ApplyTransaction(b.config, nil, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, b.header.GasUsed, vm.Config{})
+	receipt, _, err := applyTransaction(b.config, nil, b, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, b.header.GasUsed, vm.Config{})
 	if err != nil {
 		panic(err)
 	}
@@ -92,6 +93,26 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 	b.receipts = append(b.receipts, receipt)
 }
 
+// GetBlock retrieves a block generated so far or present in the database, used
+// to look up the envelopes of an epoch when revealing its decryption key.
+func (b *BlockGen) GetBlock(hash common.Hash, number uint64) *types.Block {
+	for _, block := range b.chain[:b.i] {
+		if block.Hash() == hash {
+			return block
+		}
+	}
+	return GetBlock(b.db, hash, number)
+}
+
+// GetHeader retrieves the header of a block generated so far or present in the
+// database.
+func (b *BlockGen) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if block := b.GetBlock(hash, number); block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
 // Number returns the block number of the block being generated.
 func (b *BlockGen) Number() *big.Int {
 	return new(big.Int).Set(b.header.Number)
@@ -162,7 +183,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db fbcdb.Dat
 	}
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
 	genblock := func(i int, h *types.Header, statedb *state.StateDB) (*types.Block, types.Receipts) {
-		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config}
+		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config, db: db}
 		// Mutate the state and block according to any hard-fork specs
 		if daoBlock := config.DAOForkBlock; daoBlock != nil {
 			limit := new(big.Int).Add(daoBlock, params.DAOForkExtraRange)
@@ -219,7 +240,7 @@ func makeHeader(config *params.ChainConfig, parent *types.Block, state *state.St
 			Difficulty: parent.Difficulty(),
 			UncleHash:  parent.UncleHash(),
 		}),
-		GasLimit: CalcGasLimit(parent),
+		GasLimit: CalcGasLimit(parent, params.TargetGasLimit, nil),
 		GasUsed:  new(big.Int),
 		Number:   new(big.Int).Add(parent.Number(), common.Big1),
 		Time:     time,
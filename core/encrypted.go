@@ -0,0 +1,234 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/state"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+var (
+	// ErrEncryptionDisabled is returned if an encrypted envelope or a decryption
+	// key is submitted to a chain without a threshold encrypted mempool.
+	ErrEncryptionDisabled = errors.New("encrypted transactions disabled")
+
+	// ErrEnvelopeEpoch is returned if an encrypted envelope targets an epoch that
+	// was already sealed or is too far in the future.
+	ErrEnvelopeEpoch = errors.New("envelope epoch out of range")
+
+	// ErrKeyRevealed is returned if the decryption key of an epoch was already
+	// revealed on chain.
+	ErrKeyRevealed = errors.New("decryption key already revealed")
+
+	// ErrRevealWindow is returned if a decryption key is submitted before its
+	// epoch is final or after its reveal window has passed.
+	ErrRevealWindow = errors.New("decryption key outside reveal window")
+
+	// ErrInvalidDecryptionKey is returned if a decryption key does not belong to
+	// the identity of its envelope under the committee's master public key.
+	ErrInvalidDecryptionKey = errors.New("invalid decryption key")
+
+	// ErrRevealOrder is returned if a block contains a decryption key transaction
+	// after any other transaction, letting the latter be ordered knowing the
+	// content of the envelopes the key unlocks.
+	ErrRevealOrder = errors.New("decryption key after plain transaction")
+)
+
+// blockReader retrieves the blocks of the chain being extended, needed to look
+// up the envelopes of an epoch.
+type blockReader interface {
+	GetHeader(hash common.Hash, number uint64) *types.Header
+	GetBlock(hash common.Hash, number uint64) *types.Block
+}
+
+// revealedMarker is stored under the epoch in the storage of the decryption key
+// address once the epoch's key was revealed.
+var revealedMarker = common.BytesToHash([]byte{1})
+
+// KeyRevealed returns whether the decryption key of an epoch was already revealed
+// in the given state.
+func KeyRevealed(statedb *state.StateDB, epoch uint64) bool {
+	return statedb.GetState(types.DecryptionKeyAddress, epochSlot(epoch)) == revealedMarker
+}
+
+// markKeyRevealed records that the decryption key of an epoch was revealed. The
+// nonce of the reserved account is set to keep it from being deleted as empty.
+func markKeyRevealed(statedb *state.StateDB, epoch uint64) {
+	if statedb.GetNonce(types.DecryptionKeyAddress) == 0 {
+		statedb.SetNonce(types.DecryptionKeyAddress, 1)
+	}
+	statedb.SetState(types.DecryptionKeyAddress, epochSlot(epoch), revealedMarker)
+}
+
+// epochSlot returns the storage slot of the revealed marker of an epoch.
+func epochSlot(epoch uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(epoch))
+}
+
+// EpochEnvelopes returns the encrypted envelopes a block commits to, which are
+// the well formed ones targeting the block's own epoch, in block order.
+func EpochEnvelopes(block *types.Block) []*types.EncryptedEnvelope {
+	var envelopes []*types.EncryptedEnvelope
+	for _, tx := range block.Transactions() {
+		if env, err := tx.Envelope(); err == nil && env.Epoch == block.NumberU64() {
+			envelopes = append(envelopes, env)
+		}
+	}
+	return envelopes
+}
+
+// ValidateEnvelope checks whether an encrypted envelope may still be included
+// in the chain, given the number of the current head block. Envelopes may only
+// target the next lead many epochs.
+func ValidateEnvelope(config *params.ChainConfig, head uint64, env *types.EncryptedEnvelope, lead uint64) error {
+	if config.Fairblock == nil {
+		return ErrEncryptionDisabled
+	}
+	if env.Epoch <= head || env.Epoch > head+lead {
+		return ErrEnvelopeEpoch
+	}
+	return nil
+}
+
+// ValidateDecryptionKey checks whether a decryption key may be revealed in the
+// block with the given number on top of the given state, returning the parsed
+// keys by envelope identifier if so.
+func ValidateDecryptionKey(config *params.ChainConfig, statedb *state.StateDB, number uint64, key *types.DecryptionKey) (map[common.Hash]*bn256.G1, error) {
+	if config.Fairblock == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	final := key.Epoch + config.FinalityDepth()
+	if number <= final || number > final+config.Fairblock.Window() {
+		return nil, ErrRevealWindow
+	}
+	if KeyRevealed(statedb, key.Epoch) {
+		return nil, ErrKeyRevealed
+	}
+	master, ok := new(bn256.G2).Unmarshal(config.Fairblock.MasterPublicKey)
+	if !ok || len(key.Keys) == 0 {
+		return nil, ErrInvalidDecryptionKey
+	}
+	points := make(map[common.Hash]*bn256.G1, len(key.Keys))
+	for _, k := range key.Keys {
+		point, ok := new(bn256.G1).Unmarshal(k.Key)
+		if !ok || !ibe.VerifyKey(master, types.EnvelopeIdentity(key.Epoch, k.ID), point) {
+			return nil, ErrInvalidDecryptionKey
+		}
+		points[k.ID] = point
+	}
+	return points, nil
+}
+
+// ValidateRevealOrder checks that all the decryption key transactions of a block
+// lead it, so the envelopes they unlock execute before any transaction whose
+// sender could have seen the keys.
+func ValidateRevealOrder(txs types.Transactions) error {
+	for i := 1; i < len(txs); i++ {
+		if txs[i].IsDecryptionKey() && !txs[i-1].IsDecryptionKey() {
+			return ErrRevealOrder
+		}
+	}
+	return nil
+}
+
+// applyDecryptionKey reveals the decryption keys carried by a transaction, and
+// executes the envelopes of their epoch in the order they were committed to. A
+// transaction carrying invalid or redundant keys, or not exactly the keys of
+// the envelopes committed to, reveals nothing.
+//
+// The decrypted transactions share the gas pool of the block, their logs being
+// attributed to the transaction revealing the keys. Envelopes that fail to
+// decrypt or whose inner transaction cannot be applied are skipped.
+func applyDecryptionKey(config *params.ChainConfig, bc *BlockChain, blocks blockReader, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, cfg vm.Config) *big.Int {
+	used := new(big.Int)
+	if config.Fairblock == nil || blocks == nil {
+		return used
+	}
+	key, err := tx.DecryptionKey()
+	if err != nil {
+		return used
+	}
+	points, err := ValidateDecryptionKey(config, statedb, header.Number.Uint64(), key)
+	if err != nil {
+		return used
+	}
+	// Retrieve the epoch's block from the chain being extended
+	hash, number := header.ParentHash, header.Number.Uint64()-1
+	for number > key.Epoch {
+		parent := blocks.GetHeader(hash, number)
+		if parent == nil {
+			return used
+		}
+		hash, number = parent.ParentHash, number-1
+	}
+	block := blocks.GetBlock(hash, number)
+	if block == nil {
+		return used
+	}
+	// Ensure the keys cover exactly the envelopes committed to
+	envelopes := EpochEnvelopes(block)
+	committed := make(map[common.Hash]bool)
+	for _, env := range envelopes {
+		if points[env.ID] == nil {
+			return used
+		}
+		committed[env.ID] = true
+	}
+	if len(committed) != len(points) {
+		return used
+	}
+	markKeyRevealed(statedb, key.Epoch)
+
+	signer := types.MakeSigner(config, header.Number)
+	for _, env := range envelopes {
+		blob, err := ibe.Decrypt(points[env.ID], env.Ciphertext)
+		if err != nil {
+			continue
+		}
+		inner := new(types.Transaction)
+		if err := rlp.DecodeBytes(blob, inner); err != nil || inner.Type() != types.LegacyTxType {
+			continue
+		}
+		msg, err := inner.AsMessage(signer)
+		if err != nil {
+			continue
+		}
+		var (
+			snap      = statedb.Snapshot()
+			available = new(big.Int).Set((*big.Int)(gp))
+			vmenv     = vm.NewEVM(NewEVMContext(msg, header, bc, author), statedb, config, cfg)
+		)
+		_, gas, _, err := ApplyMessage(vmenv, msg, gp)
+		if err != nil {
+			log.Debug("Skipping decrypted transaction", "epoch", key.Epoch, "hash", inner.Hash(), "err", err)
+			statedb.RevertToSnapshot(snap)
+			(*big.Int)(gp).Set(available)
+			continue
+		}
+		used.Add(used, gas)
+	}
+	return used
+}
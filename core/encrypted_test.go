@@ -0,0 +1,328 @@
+// Copyright 2014 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package core
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core/state"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// newEncryptedTestChain creates a chain with a threshold encrypted mempool whose
+// epochs are final after a single confirmation, returning the chain along with
+// its genesis, database and the committee's master public key and shares.
+func newEncryptedTestChain(t *testing.T, alloc GenesisAlloc) (*BlockChain, *Genesis, *types.Block, fbcdb.Database, *bn256.G2, []*ibe.Share) {
+	master, shares, _, err := ibe.GenerateShares(rand.Reader, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to generate key shares: %v", err)
+	}
+	var (
+		db, _ = fbcdb.NewMemDatabase()
+		gspec = &Genesis{
+			Config: &params.ChainConfig{
+				ChainId:        big.NewInt(1),
+				HomesteadBlock: new(big.Int),
+				EIP155Block:    new(big.Int),
+				EIP158Block:    new(big.Int),
+				Fairblock: &params.FairblockConfig{
+					MasterPublicKey: master.Marshal(),
+					Threshold:       2,
+					Confirmations:   1,
+				},
+			},
+			Alloc: alloc,
+		}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := NewBlockChain(db, gspec.Config, fbcash.NewFaker(), vm.Config{})
+	return blockchain, gspec, genesis, db, master, shares
+}
+
+// Tests that encrypted envelopes are executed in committed order once the
+// decryption keys of their epoch are revealed, and that envelopes of foreign
+// epochs, incomplete and redundant keys are ignored.
+func TestEncryptedTransactions(t *testing.T) {
+	var (
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address   = crypto.PubkeyToAddress(key.PublicKey)
+		keyper, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		funds     = big.NewInt(1000000000)
+	)
+	blockchain, gspec, genesis, db, master, shares := newEncryptedTestChain(t, GenesisAlloc{address: {Balance: funds}})
+	defer blockchain.Stop()
+
+	signer := types.NewEIP155Signer(gspec.Config.ChainId)
+
+	// seal encrypts a transfer to the given recipient into an envelope of an epoch
+	seal := func(nonce uint64, to common.Address, epoch uint64) *types.EncryptedEnvelope {
+		inner, err := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1000), big.NewInt(21000), new(big.Int), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign inner transaction: %v", err)
+		}
+		env := &types.EncryptedEnvelope{Epoch: epoch}
+		rand.Read(env.ID[:])
+
+		blob, _ := rlp.EncodeToBytes(inner)
+		if env.Ciphertext, err = ibe.Encrypt(rand.Reader, master, env.Identity(), blob); err != nil {
+			t.Fatalf("failed to encrypt inner transaction: %v", err)
+		}
+		return env
+	}
+	// reveal combines the decryption keys of envelopes into a key transaction
+	reveal := func(nonce uint64, epoch uint64, envelopes ...*types.EncryptedEnvelope) *types.Transaction {
+		payload := &types.DecryptionKey{Epoch: epoch}
+		for _, env := range envelopes {
+			id := env.Identity()
+			key, err := ibe.CombineShares([]uint64{1, 3}, []*bn256.G1{ibe.ExtractKey(shares[0].Secret, id), ibe.ExtractKey(shares[2].Secret, id)})
+			if err != nil {
+				t.Fatalf("failed to combine key shares: %v", err)
+			}
+			payload.Keys = append(payload.Keys, types.EnvelopeKey{ID: env.ID, Key: key.Marshal()})
+		}
+		data, _ := rlp.EncodeToBytes(payload)
+		tx, err := types.SignTx(types.NewDecryptionKeyTransaction(nonce, big.NewInt(50000), new(big.Int), data), signer, keyper)
+		if err != nil {
+			t.Fatalf("failed to sign key transaction: %v", err)
+		}
+		return tx
+	}
+	var (
+		first   = common.Address{1}
+		second  = common.Address{2}
+		foreign = common.Address{3}
+
+		// Two transfers, whose inner nonces only apply in committed order, and
+		// one of a foreign epoch
+		envelopes = []*types.EncryptedEnvelope{seal(3, first, 1), seal(3, foreign, 2), seal(4, second, 1)}
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, db, 4, func(i int, block *BlockGen) {
+		var txs []*types.Transaction
+		switch i {
+		case 0:
+			for j, env := range envelopes {
+				payload, _ := rlp.EncodeToBytes(env)
+				tx, _ := types.SignTx(types.NewEncryptedTransaction(uint64(j), big.NewInt(100000), new(big.Int), payload), signer, key)
+				txs = append(txs, tx)
+			}
+		case 1:
+			// The epoch is not final yet, so its keys can't be revealed
+		case 2:
+			txs = append(txs, reveal(0, 1, envelopes[0]))
+			txs = append(txs, reveal(1, 1, envelopes[0], envelopes[2]))
+		case 3:
+			txs = append(txs, reveal(2, 1, envelopes[0], envelopes[2]))
+		}
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	statedb, _ := blockchain.State()
+
+	if balance := statedb.GetBalance(second); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("second recipient balance mismatch: have %v, want %v", balance, 1000)
+	}
+	if balance := statedb.GetBalance(first); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("first recipient balance mismatch: have %v, want %v", balance, 1000)
+	}
+	if statedb.Exist(foreign) {
+		t.Errorf("envelope of foreign epoch executed")
+	}
+	if nonce := statedb.GetNonce(address); nonce != 5 {
+		t.Errorf("sender nonce mismatch: have %d, want %d", nonce, 5)
+	}
+	if !KeyRevealed(statedb, 1) {
+		t.Errorf("decryption keys of epoch 1 not marked revealed")
+	}
+	// The decrypted transfers must be attributed to the complete reveal only
+	receipts := GetBlockReceipts(db, blocks[2].Hash(), blocks[2].NumberU64())
+	if gas := receipts[0].GasUsed.Uint64(); gas >= 2*params.TxGas {
+		t.Errorf("incomplete key transaction gas too high: have %d, want < %d", gas, 2*params.TxGas)
+	}
+	if gas := receipts[1].GasUsed.Uint64(); gas <= 2*params.TxGas {
+		t.Errorf("revealing transaction gas too low: have %d, want > %d", gas, 2*params.TxGas)
+	}
+	receipts = GetBlockReceipts(db, blocks[3].Hash(), blocks[3].NumberU64())
+	if gas := receipts[0].GasUsed.Uint64(); gas >= 2*params.TxGas {
+		t.Errorf("redundant key transaction gas too high: have %d, want < %d", gas, 2*params.TxGas)
+	}
+}
+
+// Tests that blocks placing a decryption key transaction after any other one are
+// rejected, as its envelopes could be front-run by the preceding transactions.
+func TestRevealOrder(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	blockchain, gspec, genesis, db, _, _ := newEncryptedTestChain(t, GenesisAlloc{address: {Balance: big.NewInt(1000000000)}})
+	defer blockchain.Stop()
+
+	signer := types.NewEIP155Signer(gspec.Config.ChainId)
+	blocks, _ := GenerateChain(gspec.Config, genesis, db, 1, func(i int, block *BlockGen) {
+		transfer, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil), signer, key)
+		data, _ := rlp.EncodeToBytes(&types.DecryptionKey{Epoch: 0})
+		reveal, _ := types.SignTx(types.NewDecryptionKeyTransaction(1, big.NewInt(50000), new(big.Int), data), signer, key)
+
+		block.AddTx(transfer)
+		block.AddTx(reveal)
+	})
+	if _, err := blockchain.InsertChain(blocks); err != ErrRevealOrder {
+		t.Fatalf("misordered block error mismatch: have %v, want %v", err, ErrRevealOrder)
+	}
+}
+
+// Tests that decryption keys are only accepted within their reveal window once
+// their epoch is final, and if they match the identities of their envelopes.
+func TestValidateDecryptionKey(t *testing.T) {
+	master, shares, _, _ := ibe.GenerateShares(rand.Reader, 1, 1)
+
+	config := &params.ChainConfig{Fairblock: &params.FairblockConfig{MasterPublicKey: master.Marshal(), Threshold: 1, RevealWindow: 4, Confirmations: 2}}
+	db, _ := fbcdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	var (
+		id    = common.Hash{0x01}
+		valid = types.EnvelopeKey{ID: id, Key: ibe.ExtractKey(shares[0].Secret, types.EnvelopeIdentity(10, id)).Marshal()}
+		other = types.EnvelopeKey{ID: common.Hash{0x02}, Key: valid.Key}
+	)
+	tests := []struct {
+		number uint64
+		epoch  uint64
+		keys   []types.EnvelopeKey
+		err    error
+	}{
+		{13, 10, []types.EnvelopeKey{valid}, nil},
+		{16, 10, []types.EnvelopeKey{valid}, nil},
+		{11, 10, []types.EnvelopeKey{valid}, ErrRevealWindow},
+		{12, 10, []types.EnvelopeKey{valid}, ErrRevealWindow},
+		{17, 10, []types.EnvelopeKey{valid}, ErrRevealWindow},
+		{14, 9, []types.EnvelopeKey{valid}, ErrInvalidDecryptionKey},
+		{14, 10, []types.EnvelopeKey{valid, other}, ErrInvalidDecryptionKey},
+		{14, 10, []types.EnvelopeKey{{ID: id, Key: []byte{0x01}}}, ErrInvalidDecryptionKey},
+		{14, 10, nil, ErrInvalidDecryptionKey},
+	}
+	for i, tt := range tests {
+		if _, err := ValidateDecryptionKey(config, statedb, tt.number, &types.DecryptionKey{Epoch: tt.epoch, Keys: tt.keys}); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	markKeyRevealed(statedb, 10)
+	if _, err := ValidateDecryptionKey(config, statedb, 13, &types.DecryptionKey{Epoch: 10, Keys: []types.EnvelopeKey{valid}}); err != ErrKeyRevealed {
+		t.Errorf("revealed key error mismatch: have %v, want %v", err, ErrKeyRevealed)
+	}
+	if _, err := ValidateDecryptionKey(&params.ChainConfig{}, statedb, 13, &types.DecryptionKey{Epoch: 10, Keys: []types.EnvelopeKey{valid}}); err != ErrEncryptionDisabled {
+		t.Errorf("disabled error mismatch: have %v, want %v", err, ErrEncryptionDisabled)
+	}
+}
+
+// Tests that the transaction pool only admits envelopes of upcoming epochs and
+// valid decryption keys, the latter regardless of their price.
+func TestEncryptedTransactionAdmission(t *testing.T) {
+	master, shares, _, _ := ibe.GenerateShares(rand.Reader, 1, 1)
+
+	db, _ := fbcdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	config := *params.TestChainConfig
+	config.Fairblock = &params.FairblockConfig{MasterPublicKey: master.Marshal(), Threshold: 1, Confirmations: 1}
+
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	send := func(tx *types.Transaction) error {
+		signed, _ := types.SignTx(tx, types.HomesteadSigner{}, key)
+		return pool.AddRemote(signed)
+	}
+	envelope := func(nonce uint64, payload interface{}) *types.Transaction {
+		data, _ := rlp.EncodeToBytes(payload)
+		return types.NewEncryptedTransaction(nonce, big.NewInt(100000), big.NewInt(1), data)
+	}
+	reveal := func(nonce uint64, payload *types.DecryptionKey) *types.Transaction {
+		data, _ := rlp.EncodeToBytes(payload)
+		return types.NewDecryptionKeyTransaction(nonce, big.NewInt(100000), new(big.Int), data)
+	}
+	if err := send(envelope(0, &types.EncryptedEnvelope{Epoch: 0})); err != ErrEnvelopeEpoch {
+		t.Errorf("sealed epoch error mismatch: have %v, want %v", err, ErrEnvelopeEpoch)
+	}
+	if err := send(envelope(0, &types.EncryptedEnvelope{Epoch: testTxPoolConfig.EnvelopeLead + 1})); err != ErrEnvelopeEpoch {
+		t.Errorf("distant epoch error mismatch: have %v, want %v", err, ErrEnvelopeEpoch)
+	}
+	if err := send(envelope(0, []byte{0x01})); err != ErrInvalidPayload {
+		t.Errorf("malformed envelope error mismatch: have %v, want %v", err, ErrInvalidPayload)
+	}
+	if err := send(envelope(0, &types.EncryptedEnvelope{Epoch: 1})); err != nil {
+		t.Errorf("failed to add envelope: %v", err)
+	}
+	// Decryption keys of an epoch are only admitted once final, and for free
+	id := common.Hash{0x01}
+	valid := types.EnvelopeKey{ID: id, Key: ibe.ExtractKey(shares[0].Secret, types.EnvelopeIdentity(0, id)).Marshal()}
+	invalid := types.EnvelopeKey{ID: id, Key: ibe.ExtractKey(shares[0].Secret, types.EnvelopeIdentity(1, id)).Marshal()}
+
+	if err := send(reveal(1, &types.DecryptionKey{Epoch: 0, Keys: []types.EnvelopeKey{valid}})); err != ErrRevealWindow {
+		t.Errorf("unfinal key error mismatch: have %v, want %v", err, ErrRevealWindow)
+	}
+	pool.mu.Lock()
+	pool.reset(nil, &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)})
+	pool.mu.Unlock()
+
+	if err := send(reveal(1, &types.DecryptionKey{Epoch: 0, Keys: []types.EnvelopeKey{invalid}})); err != ErrInvalidDecryptionKey {
+		t.Errorf("invalid key error mismatch: have %v, want %v", err, ErrInvalidDecryptionKey)
+	}
+	if err := send(reveal(1, &types.DecryptionKey{Epoch: 0, Keys: []types.EnvelopeKey{valid}})); err != nil {
+		t.Errorf("failed to add free decryption key: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Errorf("pending transactions mismatch: have %d, want %d", pending, 2)
+	}
+}
+
+// Tests that typed transactions are rejected by chains without an encrypted
+// mempool.
+func TestEncryptedTransactionsDisabled(t *testing.T) {
+	db, _ := fbcdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	key, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	data, _ := rlp.EncodeToBytes(&types.EncryptedEnvelope{Epoch: 1})
+	tx, _ := types.SignTx(types.NewEncryptedTransaction(0, big.NewInt(100000), new(big.Int), data), types.HomesteadSigner{}, key)
+
+	header := &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)}
+	if _, _, err := ApplyTransaction(params.TestChainConfig, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, new(big.Int), vm.Config{}); err != ErrEncryptionDisabled {
+		t.Errorf("disabled error mismatch: have %v, want %v", err, ErrEncryptionDisabled)
+	}
+}
@@ -18,6 +18,16 @@ package core
 
 import (
 	"math/big"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/params"
 )
 
-var BlockReward = big.NewInt(0)
+// payFees credits the fees of a transaction included by coinbase to the fee sink
+// configured for the chain, or drops them if the fees are burnt.
+func payFees(config *params.ChainConfig, statedb vm.StateDB, coinbase common.Address, fee *big.Int) {
+	if recipient, ok := config.FeeRecipient(coinbase); ok {
+		statedb.AddBalance(recipient, fee)
+	}
+}
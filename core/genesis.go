@@ -147,6 +147,8 @@ func (e *GenesisMismatchError) Error() string {
 // The stored chain configuration will be updated if it is compatible (i.e. does not
 // specify a fork block below the local head block). In case of a conflict, the
 // error is a *params.ConfigCompatError and the new, unwritten config is returned.
+// Changing the block rewards of a chain past its genesis block is never compatible
+// and fails with params.ErrRewardsChanged instead.
 //
 // The returned chain configuration is never nil.
 func SetupGenesisBlock(db fbcdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
@@ -183,6 +185,11 @@ func SetupGenesisBlock(db fbcdb.Database, genesis *Genesis) (*params.ChainConfig
 		if err == ErrChainConfigNotFound {
 			// This case happens if a genesis write was interrupted.
 			log.Warn("Found genesis block without chain config")
+			if newcfg.Rewards != nil {
+				if err := newcfg.Rewards.Validate(); err != nil {
+					return newcfg, stored, fmt.Errorf("invalid rewards config: %v", err)
+				}
+			}
 			err = WriteChainConfig(db, stored, newcfg)
 		}
 		return newcfg, stored, err
@@ -200,6 +207,9 @@ func SetupGenesisBlock(db fbcdb.Database, genesis *Genesis) (*params.ChainConfig
 	if height == missingNumber {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
+	if err := storedcfg.CheckRewards(newcfg, height); err != nil {
+		return newcfg, stored, err
+	}
 	compatErr := storedcfg.CheckCompatible(newcfg, height)
 	if compatErr != nil && height != 0 && compatErr.RewindTo != 0 {
 		return newcfg, stored, compatErr
@@ -262,6 +272,11 @@ func (g *Genesis) Commit(db fbcdb.Database) (*types.Block, error) {
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
 	}
+	if g.Config != nil && g.Config.Rewards != nil {
+		if err := g.Config.Rewards.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rewards config: %v", err)
+		}
+	}
 	if _, err := statedb.CommitTo(db, false); err != nil {
 		return nil, fmt.Errorf("cannot write state: %v", err)
 	}
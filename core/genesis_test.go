@@ -23,8 +23,12 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/consensus/clique"
 	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core/state"
+	"github.com/fairblock/go-fairblock/core/types"
 	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto"
 	"github.com/fairblock/go-fairblock/fbcdb"
 	"github.com/fairblock/go-fairblock/params"
 )
@@ -159,3 +163,200 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the block rewards and the transaction fees configured in the genesis
+// are paid out as scheduled.
+func TestGenesisRewards(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		miner    = common.HexToAddress("0xc0ffee")
+		treasury = common.HexToAddress("0x7ea5")
+		funds    = big.NewInt(1000000000000000000)
+		fee      = big.NewInt(21000)
+	)
+	ether := func(n float64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(int64(n*1000)), big.NewInt(1000000000000000))
+	}
+	tests := []struct {
+		name         string
+		clique       bool
+		rewards      *params.RewardsConfig
+		wantMiner    *big.Int
+		wantTreasury *big.Int
+	}{
+		{
+			name:      "default rewards and fees",
+			wantMiner: new(big.Int).Add(ether(9), fee),
+		},
+		{
+			name: "custom rewards with halving",
+			rewards: &params.RewardsConfig{
+				FrontierReward:  ether(1),
+				ByzantiumReward: ether(2),
+				HalvingInterval: 2,
+				FeeSink:         params.FeeSinkCoinbase,
+			},
+			wantMiner: new(big.Int).Add(ether(3.5), fee),
+		},
+		{
+			name:      "burnt fees",
+			rewards:   &params.RewardsConfig{FeeSink: params.FeeSinkBurn},
+			wantMiner: ether(9),
+		},
+		{
+			name:         "treasury fees",
+			rewards:      &params.RewardsConfig{FeeSink: params.FeeSinkTreasury, Treasury: treasury},
+			wantMiner:    ether(9),
+			wantTreasury: fee,
+		},
+		{
+			name:      "clique without scheduled rewards",
+			clique:    true,
+			rewards:   &params.RewardsConfig{FeeSink: params.FeeSinkBurn},
+			wantMiner: new(big.Int),
+		},
+		{
+			name:   "clique with scheduled rewards",
+			clique: true,
+			rewards: &params.RewardsConfig{
+				FrontierReward:  ether(1),
+				ByzantiumReward: ether(2),
+				HalvingInterval: 2,
+			},
+			wantMiner: ether(3.5),
+		},
+	}
+	for _, test := range tests {
+		config := &params.ChainConfig{
+			ChainId:        big.NewInt(1),
+			HomesteadBlock: big.NewInt(0),
+			EIP150Block:    big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			EIP158Block:    big.NewInt(0),
+			ByzantiumBlock: big.NewInt(2),
+			Fbcash:         new(params.FbcashConfig),
+			Rewards:        test.rewards,
+		}
+		if test.clique {
+			config.Fbcash, config.Clique = nil, &params.CliqueConfig{Epoch: 30000}
+		}
+		db, _ := fbcdb.NewMemDatabase()
+		genesis := (&Genesis{Config: config, Alloc: GenesisAlloc{sender: {Balance: funds}}}).MustCommit(db)
+
+		var (
+			statedb *state.StateDB
+			spent   = new(big.Int)
+			err     error
+		)
+		if test.clique {
+			// Finalize empty blocks signed by the miner, paying only the scheduled rewards
+			engine := clique.New(config.Clique, db)
+			engine.Authorize(miner, nil)
+
+			chain, _ := NewBlockChain(db, config, engine, vm.Config{})
+			defer chain.Stop()
+
+			if statedb, err = state.New(genesis.Root(), state.NewDatabase(db)); err != nil {
+				t.Fatalf("%s: failed to open state: %v", test.name, err)
+			}
+			for i := int64(1); i <= 4; i++ {
+				header := &types.Header{Number: big.NewInt(i), Coinbase: miner}
+				if _, err := engine.Finalize(chain, header, statedb, nil, nil, nil); err != nil {
+					t.Fatalf("%s: failed to finalize block %d: %v", test.name, i, err)
+				}
+			}
+		} else {
+			blocks, _ := GenerateChain(config, genesis, db, 4, func(i int, b *BlockGen) {
+				b.SetCoinbase(miner)
+				if i == 0 {
+					tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, new(big.Int), big.NewInt(21000), big.NewInt(1), nil), types.HomesteadSigner{}, key)
+					b.AddTx(tx)
+				}
+			})
+			if statedb, err = state.New(blocks[len(blocks)-1].Root(), state.NewDatabase(db)); err != nil {
+				t.Fatalf("%s: failed to open state: %v", test.name, err)
+			}
+			spent = fee
+		}
+		if have := statedb.GetBalance(miner); have.Cmp(test.wantMiner) != 0 {
+			t.Errorf("%s: miner balance mismatch: have %v, want %v", test.name, have, test.wantMiner)
+		}
+		wantTreasury := test.wantTreasury
+		if wantTreasury == nil {
+			wantTreasury = new(big.Int)
+		}
+		if have := statedb.GetBalance(treasury); have.Cmp(wantTreasury) != 0 {
+			t.Errorf("%s: treasury balance mismatch: have %v, want %v", test.name, have, wantTreasury)
+		}
+		if have, want := statedb.GetBalance(sender), new(big.Int).Sub(funds, spent); have.Cmp(want) != 0 {
+			t.Errorf("%s: sender balance mismatch: have %v, want %v", test.name, have, want)
+		}
+	}
+}
+
+// Tests that the block rewards of a chain cannot be changed past its genesis
+// block, and that invalid rewards are rejected when upgrading the stored config.
+func TestSetupGenesisRewards(t *testing.T) {
+	genesis := &Genesis{Config: &params.ChainConfig{
+		Fbcash:  new(params.FbcashConfig),
+		Rewards: &params.RewardsConfig{HalvingInterval: 100},
+	}}
+	tests := []struct {
+		name    string
+		rewards *params.RewardsConfig
+		blocks  int
+		ok      bool
+	}{
+		{name: "same rewards", rewards: &params.RewardsConfig{HalvingInterval: 100}, blocks: 4, ok: true},
+		{name: "changed rewards at genesis", rewards: &params.RewardsConfig{HalvingInterval: 200}, ok: true},
+		{name: "changed rewards", rewards: &params.RewardsConfig{HalvingInterval: 200}, blocks: 4},
+		{name: "dropped rewards", blocks: 4},
+		{name: "invalid rewards at genesis", rewards: &params.RewardsConfig{FeeSink: "miner"}},
+	}
+	for _, test := range tests {
+		db, _ := fbcdb.NewMemDatabase()
+		block := genesis.MustCommit(db)
+		if test.blocks > 0 {
+			bc, _ := NewBlockChain(db, genesis.Config, fbcash.NewFullFaker(), vm.Config{})
+			chain, _ := GenerateChain(genesis.Config, block, db, test.blocks, nil)
+			if _, err := bc.InsertChain(chain); err != nil {
+				t.Fatalf("%s: failed to insert chain: %v", test.name, err)
+			}
+			bc.Stop()
+		}
+		upgrade := *genesis
+		upgrade.Config = &params.ChainConfig{Fbcash: new(params.FbcashConfig), Rewards: test.rewards}
+
+		_, _, err := SetupGenesisBlock(db, &upgrade)
+		if _, compat := err.(*params.ConfigCompatError); compat {
+			t.Errorf("%s: rewards change reported as rewindable: %v", test.name, err)
+		}
+		if (err == nil) != test.ok {
+			t.Errorf("%s: error mismatch: have %v, want ok %v", test.name, err, test.ok)
+		}
+		stored, _ := GetChainConfig(db, block.Hash())
+		want := genesis.Config
+		if test.ok {
+			want = upgrade.Config
+		}
+		if !reflect.DeepEqual(stored, want) {
+			t.Errorf("%s: stored config mismatch: have %v, want %v", test.name, stored, want)
+		}
+	}
+}
+
+// Tests that genesis blocks with an invalid fee sink are rejected.
+func TestGenesisInvalidRewards(t *testing.T) {
+	tests := []*params.RewardsConfig{
+		{FeeSink: "miner"},
+		{FeeSink: params.FeeSinkTreasury},
+	}
+	for i, rewards := range tests {
+		db, _ := fbcdb.NewMemDatabase()
+		genesis := &Genesis{Config: &params.ChainConfig{Fbcash: new(params.FbcashConfig), Rewards: rewards}}
+		if _, err := genesis.Commit(db); err == nil {
+			t.Errorf("test %d: invalid rewards config %+v accepted", i, rewards)
+		}
+	}
+}
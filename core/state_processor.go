@@ -88,6 +88,19 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, cfg vm.Config) (*types.Receipt, *big.Int, error) {
+	var blocks blockReader
+	if bc != nil {
+		blocks = bc
+	}
+	return applyTransaction(config, bc, blocks, author, gp, statedb, header, tx, usedGas, cfg)
+}
+
+// applyTransaction is ApplyTransaction with a separate source of ancestor blocks
+// for decrypting envelopes, which the chain maker substitutes.
+func applyTransaction(config *params.ChainConfig, bc *BlockChain, blocks blockReader, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, cfg vm.Config) (*types.Receipt, *big.Int, error) {
+	if tx.Type() != types.LegacyTxType && config.Fairblock == nil {
+		return nil, nil, ErrEncryptionDisabled
+	}
 	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
 	if err != nil {
 		return nil, nil, err
@@ -102,6 +115,10 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	if err != nil {
 		return nil, nil, err
 	}
+	// Execute the encrypted transactions unlocked by a decryption key
+	if tx.IsDecryptionKey() {
+		gas = new(big.Int).Add(gas, applyDecryptionKey(config, bc, blocks, author, gp, statedb, header, tx, cfg))
+	}
 
 	// Update the state with pending changes
 	var root []byte
@@ -78,6 +78,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrInvalidPayload is returned if an encrypted envelope or a decryption key
+	// transaction does not carry a well formed payload.
+	ErrInvalidPayload = errors.New("invalid encrypted mempool payload")
 )
 
 var (
@@ -138,6 +142,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	EnvelopeLead uint64 // Maximum number of epochs ahead encrypted envelopes may target
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -155,6 +161,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	EnvelopeLead: 16,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -173,6 +181,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.EnvelopeLead < 1 {
+		log.Warn("Sanitizing invalid txpool envelope lead", "provided", conf.EnvelopeLead, "updated", DefaultTxPoolConfig.EnvelopeLead)
+		conf.EnvelopeLead = DefaultTxPoolConfig.EnvelopeLead
+	}
 	return conf
 }
 
@@ -198,6 +210,7 @@ type TxPool struct {
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas *big.Int            // Current gas limit for transaction caps
+	currentNumber uint64              // Current head number for envelope epoch checks
 
 	locals  *accountSet // Set of local transaction to exepmt from evicion rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -410,6 +423,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.currentNumber = newHead.Number.Uint64()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -572,9 +586,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
+	// Validate the payloads of the encrypted mempool, valid decryption keys being
+	// free to reveal
+	revealing, err := pool.validateEncrypted(tx)
+	if err != nil {
+		return err
+	}
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && !revealing && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
@@ -593,6 +613,30 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// validateEncrypted checks the payload of encrypted envelopes and decryption keys
+// against the current head, reporting whether the transaction reveals a key.
+func (pool *TxPool) validateEncrypted(tx *types.Transaction) (bool, error) {
+	switch {
+	case tx.IsEnvelope():
+		env, err := tx.Envelope()
+		if err != nil {
+			return false, ErrInvalidPayload
+		}
+		return false, ValidateEnvelope(pool.chainconfig, pool.currentNumber, env, pool.config.EnvelopeLead)
+
+	case tx.IsDecryptionKey():
+		key, err := tx.DecryptionKey()
+		if err != nil {
+			return false, ErrInvalidPayload
+		}
+		if _, err := ValidateDecryptionKey(pool.chainconfig, pool.currentState, pool.currentNumber+1, key); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 // add validates a transaction and inserts it into the non-executable queue for
 // later pending promotion and execution. If the transaction is a replacement for
 // an already pending or queued one, it overwrites the previous and returns this
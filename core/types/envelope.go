@@ -0,0 +1,158 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package types
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// Transaction types of the threshold encrypted mempool. Plain transactions are
+// of the legacy type, encoded as an RLP list. Typed transactions are encoded as
+// an RLP string holding the type byte followed by the RLP encoded fields.
+const (
+	LegacyTxType        uint8 = 0x00 // Plain transaction
+	EncryptedTxType     uint8 = 0x01 // Encrypted transaction envelope
+	DecryptionKeyTxType uint8 = 0x02 // Decryption key reveal
+)
+
+var (
+	// EncryptedTxAddress is the system account encrypted transaction envelopes
+	// are addressed to. Its only purpose is to account for their execution, a
+	// plain transaction sent to it is an ordinary transfer.
+	EncryptedTxAddress = common.HexToAddress("0x00000000000000000000000000000000000fb001")
+
+	// DecryptionKeyAddress is the system account decryption key transactions are
+	// addressed to. It also stores the epochs whose keys were already revealed.
+	DecryptionKeyAddress = common.HexToAddress("0x00000000000000000000000000000000000fb002")
+
+	// ErrTxTypeNotSupported is returned if a typed transaction of an unknown type
+	// or with fields its type does not allow is decoded.
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+
+	errNotEnvelope      = errors.New("not an encrypted transaction envelope")
+	errNotDecryptionKey = errors.New("not a decryption key transaction")
+)
+
+// EncryptedEnvelope is the payload of a transaction whose real content is kept
+// secret until the block including it is final. The ciphertext is an encrypted
+// RLP encoded signed transaction, encrypted to the identity of the envelope.
+type EncryptedEnvelope struct {
+	Epoch      uint64      // Block number the envelope must be included in
+	ID         common.Hash // Random identifier chosen by the sender
+	Ciphertext []byte      // Identity based encryption of the inner transaction
+}
+
+// Identity returns the identity the envelope is encrypted to.
+func (env *EncryptedEnvelope) Identity() []byte {
+	return EnvelopeIdentity(env.Epoch, env.ID)
+}
+
+// EnvelopeKey is the decryption key of a single envelope identity.
+type EnvelopeKey struct {
+	ID  common.Hash // Identifier of the envelope the key decrypts
+	Key []byte      // Marshalled decryption key of the envelope identity
+}
+
+// DecryptionKey is the payload of a transaction revealing the decryption keys of
+// all the envelopes included in the block of an epoch, combined from the shares
+// of the key holder committee.
+type DecryptionKey struct {
+	Epoch uint64        // Epoch the keys decrypt the envelopes of
+	Keys  []EnvelopeKey // Keys of the envelopes included in the epoch's block
+}
+
+// EnvelopeIdentity returns the identity an envelope of an epoch with the given
+// identifier is encrypted to. Binding the identity to the identifier chosen by
+// the sender keeps the key of one envelope from decrypting any other.
+func EnvelopeIdentity(epoch uint64, id common.Hash) []byte {
+	const prefix = "fairblock envelope "
+
+	identity := make([]byte, len(prefix)+8+common.HashLength)
+	copy(identity, prefix)
+	binary.BigEndian.PutUint64(identity[len(prefix):], epoch)
+	copy(identity[len(prefix)+8:], id[:])
+	return identity
+}
+
+// NewEncryptedTransaction creates an encrypted transaction envelope carrying the
+// given RLP encoded EncryptedEnvelope.
+func NewEncryptedTransaction(nonce uint64, gasLimit, gasPrice *big.Int, envelope []byte) *Transaction {
+	tx := newTransaction(nonce, nil, nil, gasLimit, gasPrice, envelope)
+	tx.data.Type = EncryptedTxType
+	return tx
+}
+
+// NewDecryptionKeyTransaction creates a transaction revealing the given RLP
+// encoded DecryptionKey.
+func NewDecryptionKeyTransaction(nonce uint64, gasLimit, gasPrice *big.Int, key []byte) *Transaction {
+	tx := newTransaction(nonce, nil, nil, gasLimit, gasPrice, key)
+	tx.data.Type = DecryptionKeyTxType
+	return tx
+}
+
+// Envelope decodes the encrypted envelope carried by the transaction.
+func (tx *Transaction) Envelope() (*EncryptedEnvelope, error) {
+	if tx.data.Type != EncryptedTxType {
+		return nil, errNotEnvelope
+	}
+	env := new(EncryptedEnvelope)
+	if err := rlp.DecodeBytes(tx.data.Payload, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// DecryptionKey decodes the decryption key carried by the transaction.
+func (tx *Transaction) DecryptionKey() (*DecryptionKey, error) {
+	if tx.data.Type != DecryptionKeyTxType {
+		return nil, errNotDecryptionKey
+	}
+	key := new(DecryptionKey)
+	if err := rlp.DecodeBytes(tx.data.Payload, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// IsEnvelope returns whether the transaction is an encrypted envelope, regardless
+// of the validity of its payload.
+func (tx *Transaction) IsEnvelope() bool {
+	return tx.data.Type == EncryptedTxType
+}
+
+// IsDecryptionKey returns whether the transaction reveals a decryption key,
+// regardless of the validity of its payload.
+func (tx *Transaction) IsDecryptionKey() bool {
+	return tx.data.Type == DecryptionKeyTxType
+}
+
+// systemAddress returns the system account a typed transaction is addressed to.
+func systemAddress(typ uint8) *common.Address {
+	switch typ {
+	case EncryptedTxType:
+		addr := EncryptedTxAddress
+		return &addr
+	case DecryptionKeyTxType:
+		addr := DecryptionKeyAddress
+		return &addr
+	}
+	return nil
+}
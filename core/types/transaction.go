@@ -23,6 +23,7 @@ import (
 	"io"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/common/hexutil"
@@ -48,6 +49,7 @@ func deriveSigner(V *big.Int) Signer {
 
 type Transaction struct {
 	data txdata
+	time time.Time // Time first seen locally, used for arrival ordering
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -67,6 +69,9 @@ type txdata struct {
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
 
+	// Type of the transaction, encoded in front of the fields of typed ones.
+	Type uint8 `json:"type,omitempty" rlp:"-"`
+
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
@@ -80,6 +85,7 @@ type txdataMarshaling struct {
 	V            *hexutil.Big
 	R            *hexutil.Big
 	S            *hexutil.Big
+	Type         hexutil.Uint64
 }
 
 func NewTransaction(nonce uint64, to common.Address, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
@@ -115,7 +121,7 @@ func newTransaction(nonce uint64, to *common.Address, amount, gasLimit, gasPrice
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, time: time.Now()}
 }
 
 // ChainId returns which chain id this transaction was signed for (if at all)
@@ -139,20 +145,57 @@ func isProtectedV(V *big.Int) bool {
 
 // DecodeRLP implements rlp.Encoder
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := rlp.EncodeToBytes(&tx.data)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, append([]byte{tx.data.Type}, enc...))
 }
 
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	var data txdata
+	if kind == rlp.List {
+		err = s.Decode(&data)
+	} else {
+		err = decodeTyped(s, &data)
+	}
 	if err == nil {
+		tx.data = data
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		tx.time = time.Now()
 	}
 
 	return err
 }
 
+// decodeTyped decodes the fields of a typed transaction, which are only allowed
+// to carry a payload besides the ones paying for its inclusion.
+func decodeTyped(s *rlp.Stream, data *txdata) error {
+	enc, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	if len(enc) == 0 {
+		return ErrTxTypeNotSupported
+	}
+	if err := rlp.DecodeBytes(enc[1:], data); err != nil {
+		return err
+	}
+	data.Type = enc[0]
+	if systemAddress(data.Type) == nil || data.Recipient != nil || data.Amount.Sign() != 0 {
+		return ErrTxTypeNotSupported
+	}
+	return nil
+}
+
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
 	hash := tx.Hash()
 	data := tx.data
@@ -176,10 +219,11 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if !crypto.ValidateSignatureValues(V, dec.R, dec.S, false) {
 		return ErrInvalidSig
 	}
-	*tx = Transaction{data: dec}
+	*tx = Transaction{data: dec, time: time.Now()}
 	return nil
 }
 
+func (tx *Transaction) Type() uint8        { return tx.data.Type }
 func (tx *Transaction) Data() []byte       { return common.CopyBytes(tx.data.Payload) }
 func (tx *Transaction) Gas() *big.Int      { return new(big.Int).Set(tx.data.GasLimit) }
 func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.data.Price) }
@@ -187,9 +231,17 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// FirstSeen returns the time the transaction was first created or decoded by the
+// local node, approximating its arrival time.
+func (tx *Transaction) FirstSeen() time.Time { return tx.time }
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
+// Typed transactions are addressed to the system account of their type.
 func (tx *Transaction) To() *common.Address {
+	if tx.data.Type != LegacyTxType {
+		return systemAddress(tx.data.Type)
+	}
 	if tx.data.Recipient == nil {
 		return nil
 	} else {
@@ -214,7 +266,7 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -229,7 +281,7 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 		nonce:      tx.data.AccountNonce,
 		price:      new(big.Int).Set(tx.data.Price),
 		gasLimit:   new(big.Int).Set(tx.data.GasLimit),
-		to:         tx.data.Recipient,
+		to:         tx.To(),
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
 		checkNonce: true,
@@ -247,7 +299,7 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
@@ -278,12 +330,12 @@ func (tx *Transaction) String() string {
 		from = "[invalid sender: nil V field]"
 	}
 
-	if tx.data.Recipient == nil {
+	if recipient := tx.To(); recipient == nil {
 		to = "[contract creation]"
 	} else {
-		to = fmt.Sprintf("%x", tx.data.Recipient[:])
+		to = fmt.Sprintf("%x", recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := rlp.EncodeToBytes(tx)
 	return fmt.Sprintf(`
 	TX(%x)
 	Contract: %v
@@ -300,7 +352,7 @@ func (tx *Transaction) String() string {
 	Hex:      %x
 `,
 		tx.Hash(),
-		tx.data.Recipient == nil,
+		tx.To() == nil,
 		from,
 		to,
 		tx.data.AccountNonce,
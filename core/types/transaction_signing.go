@@ -153,15 +153,7 @@ func (s EIP155Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
-		tx.data.AccountNonce,
-		tx.data.Price,
-		tx.data.GasLimit,
-		tx.data.Recipient,
-		tx.data.Amount,
-		tx.data.Payload,
-		s.chainId, uint(0), uint(0),
-	})
+	return rlpHash(append(sigFields(tx), s.chainId, uint(0), uint(0)))
 }
 
 // HomesteadTransaction implements TransactionInterface using the
@@ -205,14 +197,25 @@ func (fs FrontierSigner) SignatureValues(tx *Transaction, sig []byte) (r, s, v *
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (fs FrontierSigner) Hash(tx *Transaction) common.Hash {
-	return rlpHash([]interface{}{
+	return rlpHash(sigFields(tx))
+}
+
+// sigFields returns the transaction fields covered by the signature. Typed
+// transactions are prefixed with their type, so a signature can't be replayed
+// as a transaction of another type.
+func sigFields(tx *Transaction) []interface{} {
+	fields := []interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
 		tx.data.GasLimit,
 		tx.data.Recipient,
 		tx.data.Amount,
 		tx.data.Payload,
-	})
+	}
+	if tx.data.Type != LegacyTxType {
+		fields = append([]interface{}{tx.data.Type}, fields...)
+	}
+	return fields
 }
 
 func (fs FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
@@ -233,3 +233,55 @@ func TestTransactionJSON(t *testing.T) {
 		}
 	}
 }
+
+// Tests that typed transactions survive an RLP and JSON roundtrip, are addressed
+// to the system account of their type and that their signature doesn't verify
+// for a transaction of another type.
+func TestTypedTransactionEncode(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := NewEIP155Signer(common.Big1)
+
+	tx, err := SignTx(NewEncryptedTransaction(3, big.NewInt(50000), big.NewInt(1), []byte{0xc0}), signer, key)
+	if err != nil {
+		t.Fatalf("failed to sign typed transaction: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("failed to encode typed transaction: %v", err)
+	}
+	dec := new(Transaction)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("failed to decode typed transaction: %v", err)
+	}
+	if dec.Type() != EncryptedTxType || dec.Hash() != tx.Hash() || dec.Size() != tx.Size() {
+		t.Fatalf("typed transaction mismatch: have type %d hash %x, want type %d hash %x", dec.Type(), dec.Hash(), EncryptedTxType, tx.Hash())
+	}
+	if to := dec.To(); to == nil || *to != EncryptedTxAddress {
+		t.Errorf("recipient mismatch: have %v, want %x", to, EncryptedTxAddress)
+	}
+	if from, err := Sender(signer, dec); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("sender mismatch: have %x (%v), want %x", from, err, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	blob, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("failed to marshal typed transaction: %v", err)
+	}
+	if err := json.Unmarshal(blob, dec); err != nil || dec.Type() != EncryptedTxType || dec.Hash() != tx.Hash() {
+		t.Errorf("JSON roundtrip mismatch: have type %d hash %x (%v)", dec.Type(), dec.Hash(), err)
+	}
+	// The same fields and signature must not pass as another type
+	v, r, s := tx.RawSignatureValues()
+	other := NewDecryptionKeyTransaction(3, big.NewInt(50000), big.NewInt(1), []byte{0xc0})
+	other.data.V, other.data.R, other.data.S = v, r, s
+	if from, err := Sender(signer, other); err == nil && from == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("signature valid across transaction types")
+	}
+	// Typed transactions of unknown types are rejected
+	for _, typ := range []byte{0x00, 0x7f} {
+		payload, _ := rlp.EncodeToBytes(&tx.data)
+		blob, _ := rlp.EncodeToBytes(append([]byte{typ}, payload...))
+		if err := rlp.DecodeBytes(blob, new(Transaction)); err != ErrTxTypeNotSupported {
+			t.Errorf("type %#x: error mismatch: have %v, want %v", typ, err, ErrTxTypeNotSupported)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+// Package ibe implements Boneh-Franklin identity-based encryption over the bn256
+// pairing, with the master secret shared among a committee of key holders who
+// each contribute a share of the decryption key of an identity.
+//
+// The identities are hashed onto G1 and the master public key lives in G2:
+//
+//     Q = H(id)                  identity point in G1
+//     P = s*G2                   master public key
+//     U = r*G2, k = e(Q, P)^r    ciphertext header and shared secret
+//     d = s*Q, k = e(d, U)       decryption key and recovered secret
+//
+// The payload itself is encrypted with AES-256-GCM under the Keccak256 hash of
+// the shared secret.
+package ibe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+)
+
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrDecrypt           = errors.New("could not decrypt ciphertext with given key")
+)
+
+// g2HeaderLength is the length of a marshalled G2 point, the ciphertext header.
+const g2HeaderLength = 128
+
+var (
+	// curveB is the constant of the bn256 curve equation y² = x³ + b.
+	curveB = big.NewInt(3)
+
+	// sqrtExponent is (p+1)/4, raising to which computes square roots modulo p
+	// as p = 3 mod 4.
+	sqrtExponent = new(big.Int).Rsh(new(big.Int).Add(bn256.P, big.NewInt(1)), 2)
+
+	// g2 is the generator of G2.
+	g2 = new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+)
+
+// HashToG1 deterministically maps an identity onto a point of G1 whose discrete
+// logarithm is unknown, by hashing it into an x coordinate until one lies on
+// the curve.
+func HashToG1(id []byte) *bn256.G1 {
+	buf := make([]byte, len(id)+1)
+	copy(buf, id)
+
+	for counter := 0; ; counter++ {
+		buf[len(id)] = byte(counter)
+		x := new(big.Int).SetBytes(crypto.Keccak256(buf))
+		x.Mod(x, bn256.P)
+
+		// Compute y² = x³ + b and try to find its square root
+		rhs := new(big.Int).Exp(x, big.NewInt(3), bn256.P)
+		rhs.Add(rhs, curveB).Mod(rhs, bn256.P)
+
+		y := new(big.Int).Exp(rhs, sqrtExponent, bn256.P)
+		if new(big.Int).Exp(y, big.NewInt(2), bn256.P).Cmp(rhs) != 0 {
+			continue
+		}
+		blob := make([]byte, 64)
+		xb, yb := x.Bytes(), y.Bytes()
+		copy(blob[32-len(xb):], xb)
+		copy(blob[64-len(yb):], yb)
+
+		if point, ok := new(bn256.G1).Unmarshal(blob); ok {
+			return point
+		}
+	}
+}
+
+// ExtractKey derives the decryption key of an identity from the master secret,
+// or from a share of it.
+func ExtractKey(secret *big.Int, id []byte) *bn256.G1 {
+	return new(bn256.G1).ScalarMult(HashToG1(id), secret)
+}
+
+// VerifyKey checks whether the decryption key belongs to the identity under the
+// given master public key, i.e. e(d, G2) = e(H(id), P).
+func VerifyKey(master *bn256.G2, id []byte, key *bn256.G1) bool {
+	return bn256.PairingCheck(
+		[]*bn256.G1{key, new(bn256.G1).Neg(HashToG1(id))},
+		[]*bn256.G2{g2, master},
+	)
+}
+
+// Encrypt encrypts the message to the identity under the master public key.
+func Encrypt(rand io.Reader, master *bn256.G2, id []byte, msg []byte) ([]byte, error) {
+	r, header, err := bn256.RandomG2(rand)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(bn256.GT).ScalarMult(bn256.Pair(HashToG1(id), master), r)
+
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := header.Marshal()
+	return aead.Seal(ciphertext, make([]byte, aead.NonceSize()), msg, ciphertext), nil
+}
+
+// Decrypt decrypts a ciphertext with the decryption key of its identity.
+func Decrypt(key *bn256.G1, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < g2HeaderLength {
+		return nil, ErrInvalidCiphertext
+	}
+	header, ok := new(bn256.G2).Unmarshal(ciphertext[:g2HeaderLength])
+	if !ok {
+		return nil, ErrInvalidCiphertext
+	}
+	aead, err := newAEAD(bn256.Pair(key, header))
+	if err != nil {
+		return nil, err
+	}
+	msg, err := aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[g2HeaderLength:], ciphertext[:g2HeaderLength])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return msg, nil
+}
+
+// newAEAD creates the symmetric cipher keyed by the shared pairing secret. As
+// every ciphertext uses a fresh secret, a fixed nonce is safe.
+func newAEAD(secret *bn256.GT) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(crypto.Keccak256(secret.Marshal()))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package ibe
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+)
+
+// Tests that messages encrypted to an identity can be decrypted with the key
+// combined from any threshold of key shares, but not with fewer or with the key
+// of another identity.
+func TestThresholdEncryptDecrypt(t *testing.T) {
+	master, shares, vks, err := GenerateShares(rand.Reader, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to generate shares: %v", err)
+	}
+	id, other := []byte("epoch 1"), []byte("epoch 2")
+	msg := []byte("the quick brown fox jumps over the lazy dog")
+
+	ciphertext, err := Encrypt(rand.Reader, master, id, msg)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	// Extract and verify the key shares of all the key holders
+	keyShares := make([]*bn256.G1, len(shares))
+	for i, share := range shares {
+		keyShares[i] = ExtractKey(share.Secret, id)
+		if !VerifyShare(vks[i], id, keyShares[i]) {
+			t.Errorf("share %d: failed to verify", share.Index)
+		}
+		if VerifyShare(vks[(i+1)%len(vks)], id, keyShares[i]) {
+			t.Errorf("share %d: verified against foreign verification key", share.Index)
+		}
+	}
+	// Any pair of shares should decrypt the message
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {2, 1}} {
+		key, err := CombineShares(
+			[]uint64{shares[pair[0]].Index, shares[pair[1]].Index},
+			[]*bn256.G1{keyShares[pair[0]], keyShares[pair[1]]},
+		)
+		if err != nil {
+			t.Fatalf("shares %v: failed to combine: %v", pair, err)
+		}
+		if !VerifyKey(master, id, key) {
+			t.Errorf("shares %v: combined key failed to verify", pair)
+		}
+		plaintext, err := Decrypt(key, ciphertext)
+		if err != nil {
+			t.Fatalf("shares %v: failed to decrypt: %v", pair, err)
+		}
+		if !bytes.Equal(plaintext, msg) {
+			t.Errorf("shares %v: plaintext mismatch: have %q, want %q", pair, plaintext, msg)
+		}
+	}
+	// A single share or the key of another identity must not decrypt
+	if _, err := Decrypt(keyShares[0], ciphertext); err != ErrDecrypt {
+		t.Errorf("single share decryption error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	key, _ := CombineShares([]uint64{1, 2}, []*bn256.G1{ExtractKey(shares[0].Secret, other), ExtractKey(shares[1].Secret, other)})
+	if VerifyKey(master, id, key) {
+		t.Errorf("foreign identity key verified")
+	}
+	if _, err := Decrypt(key, ciphertext); err != ErrDecrypt {
+		t.Errorf("foreign identity decryption error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if _, err := Decrypt(key, ciphertext[:64]); err != ErrInvalidCiphertext {
+		t.Errorf("truncated ciphertext error mismatch: have %v, want %v", err, ErrInvalidCiphertext)
+	}
+}
+
+// Tests that identities hash onto valid, distinct curve points.
+func TestHashToG1(t *testing.T) {
+	a, b := HashToG1([]byte("a")), HashToG1([]byte("b"))
+	if bytes.Equal(a.Marshal(), b.Marshal()) {
+		t.Errorf("distinct identities hashed to the same point")
+	}
+	if !bytes.Equal(a.Marshal(), HashToG1([]byte("a")).Marshal()) {
+		t.Errorf("identity hashed non-deterministically")
+	}
+	if _, ok := new(bn256.G1).Unmarshal(a.Marshal()); !ok {
+		t.Errorf("hashed point not on the curve")
+	}
+	if ExtractKey(big.NewInt(1), []byte("a")).String() != a.String() {
+		t.Errorf("unit key mismatch")
+	}
+}
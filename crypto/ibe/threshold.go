@@ -0,0 +1,109 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package ibe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+)
+
+// Share is a key holder's share of the master secret, the evaluation of the
+// secret sharing polynomial at the key holder's index.
+type Share struct {
+	Index  uint64   // Index of the key holder, starting from 1
+	Secret *big.Int // Share of the master secret
+}
+
+// GenerateShares creates a fresh master secret and splits it among n key holders
+// such that any threshold of them can extract decryption keys. It returns the
+// master public key, the shares and the verification keys of the key holders.
+//
+// Note, the master secret is known to the dealer for the duration of the call.
+func GenerateShares(rand io.Reader, threshold, n int) (*bn256.G2, []*Share, []*bn256.G2, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, nil, fmt.Errorf("invalid threshold %d of %d", threshold, n)
+	}
+	// Generate a random polynomial of degree threshold-1, the master secret being
+	// its constant term
+	coeffs := make([]*big.Int, threshold)
+	for i := range coeffs {
+		k, _, err := bn256.RandomG1(rand)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		coeffs[i] = k
+	}
+	master := new(bn256.G2).ScalarBaseMult(coeffs[0])
+
+	shares := make([]*Share, n)
+	vks := make([]*bn256.G2, n)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+
+		// Evaluate the polynomial at the key holder's index with Horner's method
+		y := new(big.Int)
+		for j := len(coeffs) - 1; j >= 0; j-- {
+			y.Mul(y, x).Add(y, coeffs[j]).Mod(y, bn256.Order)
+		}
+		shares[i] = &Share{Index: uint64(i + 1), Secret: y}
+		vks[i] = new(bn256.G2).ScalarBaseMult(y)
+	}
+	return master, shares, vks, nil
+}
+
+// VerifyShare checks whether a key share of the identity was extracted with the
+// secret share belonging to the verification key.
+func VerifyShare(vk *bn256.G2, id []byte, share *bn256.G1) bool {
+	return VerifyKey(vk, id, share)
+}
+
+// CombineShares interpolates the decryption key of an identity from the key
+// shares of distinct key holders, indexed as in their secret shares. At least
+// threshold many shares are needed to obtain the correct key.
+func CombineShares(indices []uint64, shares []*bn256.G1) (*bn256.G1, error) {
+	if len(indices) != len(shares) || len(shares) == 0 {
+		return nil, errors.New("share count mismatch")
+	}
+	var key *bn256.G1
+	for i, xi := range indices {
+		// Compute the Lagrange coefficient of the share at zero
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j, xj := range indices {
+			if i == j {
+				continue
+			}
+			if xi == xj {
+				return nil, fmt.Errorf("duplicate share index %d", xi)
+			}
+			num.Mul(num, new(big.Int).SetUint64(xj)).Mod(num, bn256.Order)
+			diff := new(big.Int).Sub(new(big.Int).SetUint64(xj), new(big.Int).SetUint64(xi))
+			den.Mul(den, diff).Mod(den, bn256.Order)
+		}
+		coeff := num.Mul(num, den.ModInverse(den, bn256.Order)).Mod(num, bn256.Order)
+
+		term := new(bn256.G1).ScalarMult(shares[i], coeff)
+		if key == nil {
+			key = term
+		} else {
+			key = new(bn256.G1).Add(key, term)
+		}
+	}
+	return key, nil
+}
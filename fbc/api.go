@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -194,6 +195,23 @@ func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	return true
 }
 
+// SetTxOrdering selects the policy ordering the transactions of mined blocks.
+func (api *PrivateMinerAPI) SetTxOrdering(name string) (bool, error) {
+	if err := api.e.Miner().SetTxOrdering(name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetGasLimit sets the gas limit targets of mined blocks: the limit is raised
+// towards the floor and kept below the ceiling (0 = unbounded).
+func (api *PrivateMinerAPI) SetGasLimit(floor, ceil hexutil.Uint64) (bool, error) {
+	if err := api.e.Miner().SetGasLimit(uint64(floor), uint64(ceil)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // SetFairblockbase sets the fbcerbase of the miner
 func (api *PrivateMinerAPI) SetFairblockbase(fbcerbase common.Address) bool {
 	api.e.SetFairblockbase(fbcerbase)
@@ -205,6 +223,137 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.e.miner.HashRate())
 }
 
+// GetBlockTemplate returns the unsealed block the miner is currently working on,
+// for external sealers and block builders to complete. The coinbase of the
+// template is only set while mining.
+func (api *PrivateMinerAPI) GetBlockTemplate() (map[string]interface{}, error) {
+	template, err := api.e.Miner().BlockTemplate()
+	if err != nil {
+		return nil, err
+	}
+	block := template.Block
+
+	txs := make([]hexutil.Bytes, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if txs[i], err = rlp.EncodeToBytes(tx); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		"header":       block.Header(),
+		"sealHash":     block.HashNoNonce(),
+		"transactions": txs,
+		"uncles":       block.Uncles(),
+		"stateRoot":    block.Root(),
+		"receiptsRoot": block.ReceiptHash(),
+		"fees":         (*hexutil.Big)(template.Fees),
+	}, nil
+}
+
+// SubmitBlock imports and broadcasts an RLP encoded block sealed from a template,
+// returning its hash.
+func (api *PrivateMinerAPI) SubmitBlock(encoded hexutil.Bytes) (common.Hash, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(encoded, block); err != nil {
+		return common.Hash{}, err
+	}
+	if err := api.e.Miner().SubmitBlock(block); err != nil {
+		return common.Hash{}, err
+	}
+	return block.Hash(), nil
+}
+
+// PrivateBundleAPI provides private RPC methods to submit transaction bundles
+// directly to the miner. Bundles are simulated on every block built for their
+// target, so these methods must only be exposed to trusted users.
+type PrivateBundleAPI struct {
+	e *Fairblock
+}
+
+// NewPrivateBundleAPI creates a new RPC service accepting bundles for the miner
+// of this node.
+func NewPrivateBundleAPI(e *Fairblock) *PrivateBundleAPI {
+	return &PrivateBundleAPI{e: e}
+}
+
+// SendBundleArgs represents the arguments to submit a bundle of transactions
+// directly to the miner.
+type SendBundleArgs struct {
+	Txs          []hexutil.Bytes `json:"txs"`
+	BlockNumber  hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp *hexutil.Uint64 `json:"minTimestamp"`
+	MaxTimestamp *hexutil.Uint64 `json:"maxTimestamp"`
+}
+
+// SendBundle submits a bundle of signed transactions to be included atomically
+// and in order in the target block, if none of them reverts. The bundle is not
+// propagated to the network. It returns the bundle hash for status queries.
+func (api *PrivateBundleAPI) SendBundle(args SendBundleArgs) (common.Hash, error) {
+	bundle := &miner.Bundle{BlockNumber: uint64(args.BlockNumber)}
+	for i, encoded := range args.Txs {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(encoded, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("bundle transaction %d: %v", i, err)
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = uint64(*args.MinTimestamp)
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*args.MaxTimestamp)
+	}
+	return api.e.Miner().SendBundle(bundle)
+}
+
+// GetBundleStatus returns the status of a bundle submitted to this node, or nil
+// if the bundle is unknown.
+func (api *PrivateBundleAPI) GetBundleStatus(hash common.Hash) map[string]interface{} {
+	status := api.e.Miner().BundleStatus(hash)
+	if status == nil {
+		return nil
+	}
+	fields := map[string]interface{}{
+		"state":       status.State,
+		"blockNumber": hexutil.Uint64(status.BlockNumber),
+		"blockHash":   nil,
+		"error":       nil,
+	}
+	if status.State == miner.BundleIncluded {
+		fields["blockHash"] = status.BlockHash
+	}
+	if status.Error != "" {
+		fields["error"] = status.Error
+	}
+	return fields
+}
+
+// StratumWorkers returns the share accounting of the workers mining through the
+// Stratum server.
+func (api *PrivateMinerAPI) StratumWorkers() ([]map[string]interface{}, error) {
+	if api.e.stratum == nil {
+		return nil, errors.New("stratum server not enabled")
+	}
+	workers := api.e.stratum.Workers()
+	result := make([]map[string]interface{}, len(workers))
+	for i, worker := range workers {
+		var lastShare uint64
+		if !worker.LastShare.IsZero() {
+			lastShare = uint64(worker.LastShare.Unix())
+		}
+		result[i] = map[string]interface{}{
+			"name":      worker.Name,
+			"hashrate":  hexutil.Uint64(worker.Hashrate),
+			"accepted":  hexutil.Uint64(worker.Accepted),
+			"rejected":  hexutil.Uint64(worker.Rejected),
+			"stale":     hexutil.Uint64(worker.Stale),
+			"blocks":    hexutil.Uint64(worker.Blocks),
+			"lastShare": hexutil.Uint64(lastShare),
+		}
+	}
+	return result, nil
+}
+
 // PrivateAdminAPI is the collection of Fairblock full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,7 @@ import (
 	"github.com/fairblock/go-fairblock/consensus"
 	"github.com/fairblock/go-fairblock/consensus/clique"
 	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/consensus/ibft"
 	"github.com/fairblock/go-fairblock/core"
 	"github.com/fairblock/go-fairblock/core/bloombits"
 	"github.com/fairblock/go-fairblock/core/types"
@@ -85,6 +87,7 @@ type Fairblock struct {
 	ApiBackend *FbcApiBackend
 
 	miner     *miner.Miner
+	stratum   *miner.StratumServer
 	gasPrice  *big.Int
 	fbcerbase common.Address
 
@@ -168,6 +171,39 @@ func New(ctx *node.ServiceContext, config *Config) (*Fairblock, error) {
 	}
 	fbc.miner = miner.New(fbc, fbc.chainConfig, fbc.EventMux(), fbc.engine)
 	fbc.miner.SetExtra(makeExtraData(config.ExtraData))
+	if config.MinerTxOrdering != "" {
+		if err := fbc.miner.SetTxOrdering(config.MinerTxOrdering); err != nil {
+			return nil, err
+		}
+	}
+	if config.MinerGasFloor != 0 || config.MinerGasCeil != 0 {
+		floor := config.MinerGasFloor
+		if floor == 0 {
+			floor = params.TargetGasLimit.Uint64()
+		}
+		if err := fbc.miner.SetGasLimit(floor, config.MinerGasCeil); err != nil {
+			return nil, err
+		}
+	}
+	if config.MinerRecommit != 0 {
+		fbc.miner.SetRecommitInterval(config.MinerRecommit)
+	}
+	if config.MinerStratum != "" {
+		verifier, ok := fbc.engine.(miner.ShareVerifier)
+		if !ok {
+			return nil, errors.New("stratum mining requires a proof-of-work consensus engine")
+		}
+		difficulty := config.MinerStratumDifficulty
+		if difficulty == 0 {
+			log.Warn("Sanitizing invalid stratum share difficulty", "provided", difficulty, "updated", miner.DefaultStratumDifficulty)
+			difficulty = miner.DefaultStratumDifficulty
+		}
+		if config.MinerStratumPassword == "" {
+			log.Warn("Stratum workers are not authenticated, set a password if the endpoint is public")
+		}
+		fbc.stratum = miner.NewStratumServer(fbc.blockchain, verifier, new(big.Int).SetUint64(difficulty), config.MinerStratumPassword)
+		fbc.miner.Register(fbc.stratum)
+	}
 
 	fbc.ApiBackend = &FbcApiBackend{fbc, nil}
 	gpoParams := config.GPO
@@ -214,6 +250,9 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *Config, chainConfig
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	if chainConfig.IBFT != nil {
+		return ibft.New(chainConfig.IBFT, db)
+	}
 	// Otherwise assume proof-of-work
 	switch {
 	case config.PowFake:
@@ -263,6 +302,11 @@ func (s *Fairblock) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPrivateMinerAPI(s),
 			Public:    false,
+		}, {
+			Namespace: "fbc",
+			Version:   "1.0",
+			Service:   NewPrivateBundleAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "fbc",
 			Version:   "1.0",
@@ -333,6 +377,14 @@ func (s *Fairblock) StartMining(local bool) error {
 		}
 		clique.Authorize(eb, wallet.SignHash)
 	}
+	if ibft, ok := s.engine.(*ibft.IBFT); ok {
+		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+		if wallet == nil || err != nil {
+			log.Error("Fairblockbase account unavailable locally", "err", err)
+			return fmt.Errorf("validator missing: %v", err)
+		}
+		ibft.Authorize(eb, wallet.SignHash)
+	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
 		// mechanism introduced to speed sync times. CPU mining on mainnet is ludicrous
@@ -362,10 +414,14 @@ func (s *Fairblock) Downloader() *downloader.Downloader { return s.protocolManag
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Fairblock) Protocols() []p2p.Protocol {
-	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+	protos := s.protocolManager.SubProtocols
+	if ibft, ok := s.engine.(*ibft.IBFT); ok {
+		protos = append(protos, ibft.Protocols()...)
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	if s.lesServer != nil {
+		protos = append(protos, s.lesServer.Protocols()...)
+	}
+	return protos
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -390,6 +446,18 @@ func (s *Fairblock) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Start agreeing on blocks with the other validators if running IBFT
+	if ibft, ok := s.engine.(*ibft.IBFT); ok {
+		ibft.Start(s.blockchain)
+	}
+	// Open the Stratum endpoint for remote miners if requested
+	if s.stratum != nil {
+		listener, err := net.Listen("tcp", s.config.MinerStratum)
+		if err != nil {
+			return err
+		}
+		go s.stratum.Serve(listener)
+	}
 	return nil
 }
 
@@ -400,12 +468,18 @@ func (s *Fairblock) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if ibft, ok := s.engine.(*ibft.IBFT); ok {
+		ibft.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
 	s.txPool.Stop()
+	if s.stratum != nil {
+		s.stratum.Close()
+	}
 	s.miner.Stop()
 	s.eventMux.Stop()
 
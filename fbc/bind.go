@@ -29,9 +29,9 @@ import (
 	"github.com/fairblock/go-fairblock/rpc"
 )
 
-// ContractBackend implements bind.ContractBackend with direct calls to Fairblock
-// internals to support operating on contracts within subprotocols like fbc and
-// swarm.
+// ContractBackend implements bind.ContractCaller and bind.ContractTransactor with
+// direct calls to Fairblock internals to support operating on contracts within
+// subprotocols like fbc and swarm. Event filtering is not supported.
 //
 // Internally this backend uses the already exposed API endpoints of the Fairblock
 // object. These should be rewritten to internal Go method calls when the Go API
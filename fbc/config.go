@@ -22,27 +22,33 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/common/hexutil"
 	"github.com/fairblock/go-fairblock/core"
 	"github.com/fairblock/go-fairblock/fbc/downloader"
 	"github.com/fairblock/go-fairblock/fbc/gasprice"
+	"github.com/fairblock/go-fairblock/miner"
 	"github.com/fairblock/go-fairblock/params"
 )
 
 // DefaultConfig contains default settings for use on the Fairblock main net.
 var DefaultConfig = Config{
-	SyncMode:             downloader.FastSync,
-	FbcashCacheDir:       "fbcash",
-	FbcashCachesInMem:    2,
-	FbcashCachesOnDisk:   3,
-	FbcashDatasetsInMem:  1,
-	FbcashDatasetsOnDisk: 2,
-	NetworkId:            1,
-	LightPeers:           20,
-	DatabaseCache:        128,
-	GasPrice:             big.NewInt(18 * params.Shannon),
+	SyncMode:               downloader.FastSync,
+	FbcashCacheDir:         "fbcash",
+	FbcashCachesInMem:      2,
+	FbcashCachesOnDisk:     3,
+	FbcashDatasetsInMem:    1,
+	FbcashDatasetsOnDisk:   2,
+	NetworkId:              1,
+	LightPeers:             20,
+	DatabaseCache:          128,
+	GasPrice:               big.NewInt(18 * params.Shannon),
+	MinerTxOrdering:        miner.DefaultTxPolicy,
+	MinerGasFloor:          params.GenesisGasLimit.Uint64(),
+	MinerRecommit:          miner.DefaultRecommitInterval,
+	MinerStratumDifficulty: miner.DefaultStratumDifficulty,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -91,6 +97,17 @@ type Config struct {
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	MinerTxOrdering string `toml:",omitempty"` // Name of the policy ordering the transactions of mined blocks
+
+	MinerGasFloor uint64 `toml:",omitempty"` // Gas limit the mined blocks are raised towards
+	MinerGasCeil  uint64 `toml:",omitempty"` // Gas limit the mined blocks may not exceed (0 = unbounded)
+
+	MinerRecommit time.Duration `toml:",omitempty"` // Base interval at which the block being sealed is rebuilt
+
+	MinerStratum           string `toml:",omitempty"` // TCP endpoint of the Stratum mining server (empty = disabled)
+	MinerStratumDifficulty uint64 `toml:",omitempty"` // Difficulty of the shares accepted by the Stratum server
+	MinerStratumPassword   string `toml:",omitempty"` // Password the Stratum workers must authorize with (empty = any)
+
 	// Fbcash options
 	FbcashCacheDir       string
 	FbcashCachesInMem    int
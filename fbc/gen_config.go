@@ -4,6 +4,7 @@ package fbc
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/common/hexutil"
@@ -27,6 +28,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerTxOrdering         string        `toml:",omitempty"`
+		MinerGasFloor           uint64        `toml:",omitempty"`
+		MinerGasCeil            uint64        `toml:",omitempty"`
+		MinerRecommit           time.Duration `toml:",omitempty"`
+		MinerStratum            string        `toml:",omitempty"`
+		MinerStratumDifficulty  uint64        `toml:",omitempty"`
+		MinerStratumPassword    string        `toml:",omitempty"`
 		FbcashCacheDir          string
 		FbcashCachesInMem       int
 		FbcashCachesOnDisk      int
@@ -54,6 +62,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.MinerTxOrdering = c.MinerTxOrdering
+	enc.MinerGasFloor = c.MinerGasFloor
+	enc.MinerGasCeil = c.MinerGasCeil
+	enc.MinerRecommit = c.MinerRecommit
+	enc.MinerStratum = c.MinerStratum
+	enc.MinerStratumDifficulty = c.MinerStratumDifficulty
+	enc.MinerStratumPassword = c.MinerStratumPassword
 	enc.FbcashCacheDir = c.FbcashCacheDir
 	enc.FbcashCachesInMem = c.FbcashCachesInMem
 	enc.FbcashCachesOnDisk = c.FbcashCachesOnDisk
@@ -85,6 +100,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes   `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerTxOrdering         *string        `toml:",omitempty"`
+		MinerGasFloor           *uint64        `toml:",omitempty"`
+		MinerGasCeil            *uint64        `toml:",omitempty"`
+		MinerRecommit           *time.Duration `toml:",omitempty"`
+		MinerStratum            *string        `toml:",omitempty"`
+		MinerStratumDifficulty  *uint64        `toml:",omitempty"`
+		MinerStratumPassword    *string        `toml:",omitempty"`
 		FbcashCacheDir          *string
 		FbcashCachesInMem       *int
 		FbcashCachesOnDisk      *int
@@ -139,6 +161,27 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.MinerTxOrdering != nil {
+		c.MinerTxOrdering = *dec.MinerTxOrdering
+	}
+	if dec.MinerGasFloor != nil {
+		c.MinerGasFloor = *dec.MinerGasFloor
+	}
+	if dec.MinerGasCeil != nil {
+		c.MinerGasCeil = *dec.MinerGasCeil
+	}
+	if dec.MinerRecommit != nil {
+		c.MinerRecommit = *dec.MinerRecommit
+	}
+	if dec.MinerStratum != nil {
+		c.MinerStratum = *dec.MinerStratum
+	}
+	if dec.MinerStratumDifficulty != nil {
+		c.MinerStratumDifficulty = *dec.MinerStratumDifficulty
+	}
+	if dec.MinerStratumPassword != nil {
+		c.MinerStratumPassword = *dec.MinerStratumPassword
+	}
 	if dec.FbcashCacheDir != nil {
 		c.FbcashCacheDir = *dec.FbcashCacheDir
 	}
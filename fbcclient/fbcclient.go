@@ -295,6 +295,10 @@ func (ec *Client) SyncProgress(ctx context.Context) (*fairblock.SyncProgress, er
 
 // SubscribeNewHead subscribes to notifications about the current blockchain head
 // on the given channel.
+//
+// If the underlying RPC client is in reconnecting mode, the subscription survives
+// a lost connection and the heads announced meanwhile are missed. Use
+// SubscriptionGaps to be notified of such gaps.
 func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (fairblock.Subscription, error) {
 	return ec.c.FbcSubscribe(ctx, ch, "newHeads", map[string]struct{}{})
 }
@@ -356,10 +360,26 @@ func (ec *Client) FilterLogs(ctx context.Context, q fairblock.FilterQuery) ([]ty
 }
 
 // SubscribeFilterLogs subscribes to the results of a streaming filter query.
+//
+// If the underlying RPC client is in reconnecting mode, the subscription survives
+// a lost connection and the logs emitted meanwhile are missed. Use
+// SubscriptionGaps to be notified of such gaps and backfill them with FilterLogs.
 func (ec *Client) SubscribeFilterLogs(ctx context.Context, q fairblock.FilterQuery, ch chan<- types.Log) (fairblock.Subscription, error) {
 	return ec.c.FbcSubscribe(ctx, ch, "logs", toFilterArg(q))
 }
 
+// SubscriptionGaps returns the channel notified whenever a subscription created
+// by SubscribeNewHead or SubscribeFilterLogs was re-established after the
+// connection to the server was lost. Gaps are only reported if reconnecting mode
+// is enabled on the RPC client (see rpc.Client.EnableReconnect). It returns nil
+// for subscriptions not created by this package, whose gaps can't be observed.
+func SubscriptionGaps(sub fairblock.Subscription) <-chan rpc.SubscriptionGap {
+	if sub, ok := sub.(*rpc.ClientSubscription); ok {
+		return sub.Gaps()
+	}
+	return nil
+}
+
 func toFilterArg(q fairblock.FilterQuery) interface{} {
 	arg := map[string]interface{}{
 		"fromBlock": toBlockNumArg(q.FromBlock),
@@ -16,7 +16,22 @@
 
 package fbcclient
 
-import "github.com/fairblock/go-fairblock"
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/rpc"
+)
 
 // Verify that Client implements the fairblock interfaces.
 var (
@@ -32,3 +47,141 @@ var (
 	// _ = fairblock.PendingStateEventer(&Client{})
 	_ = fairblock.PendingContractCaller(&Client{})
 )
+
+// HeadService announces an ever increasing chain head to its subscribers.
+type HeadService struct{}
+
+func (s *HeadService) NewHeads(ctx context.Context, _ map[string]struct{}) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := int64(0); ; i++ {
+			select {
+			case <-time.After(5 * time.Millisecond):
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+			header := &types.Header{
+				Difficulty: big.NewInt(1),
+				Number:     big.NewInt(i),
+				GasLimit:   big.NewInt(0),
+				GasUsed:    big.NewInt(0),
+				Time:       big.NewInt(i),
+				Extra:      []byte{},
+			}
+			if err := notifier.Notify(sub.ID, header); err != nil {
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// dropListener serves RPC connections over IPC, allowing the live ones to be
+// dropped.
+type dropListener struct {
+	net.Listener
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func (l *dropListener) serve(server *rpc.Server) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		l.lock.Lock()
+		l.conns = append(l.conns, conn)
+		l.lock.Unlock()
+		go server.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+	}
+}
+
+func (l *dropListener) drop() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+	l.conns = nil
+}
+
+// Tests that head subscriptions report the gaps caused by lost connections.
+func TestSubscriptionGaps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fbcclient-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("fbc", new(HeadService)); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := filepath.Join(dir, "test.ipc")
+	listener, err := net.Listen("unix", endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	dropper := &dropListener{Listener: listener}
+	go dropper.serve(server)
+
+	rpcClient, err := rpc.DialIPC(context.Background(), endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcClient.Close()
+	if err := rpcClient.EnableReconnect(rpc.ReconnectConfig{MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(rpcClient)
+
+	heads := make(chan *types.Header, 100)
+	sub, err := client.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	gaps := SubscriptionGaps(sub)
+	if gaps == nil {
+		t.Fatalf("no gap notifications for head subscription")
+	}
+	<-heads
+
+	lost := time.Now()
+	dropper.drop()
+
+	select {
+	case gap := <-gaps:
+		if gap.Lost.Before(lost) || gap.Restored.Before(gap.Lost) {
+			t.Errorf("invalid gap: lost %v, restored %v, dropped at %v", gap.Lost, gap.Restored, lost)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not re-established")
+	}
+	// Heads must keep flowing after the gap
+	for len(heads) > 0 {
+		<-heads
+	}
+	select {
+	case <-heads:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no heads after the gap")
+	}
+	// Subscriptions not backed by the RPC client have no gaps to report
+	local := event.NewSubscription(func(quit <-chan struct{}) error { <-quit; return nil })
+	defer local.Unsubscribe()
+	if SubscriptionGaps(local) != nil {
+		t.Errorf("gap notifications for a local subscription")
+	}
+}
@@ -25,7 +25,10 @@ import (
 	"time"
 
 	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/abi"
 	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/accounts/usbwallet"
+	"github.com/fairblock/go-fairblock/accounts/watchonly"
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/common/hexutil"
 	"github.com/fairblock/go-fairblock/common/math"
@@ -39,6 +42,7 @@ import (
 	"github.com/fairblock/go-fairblock/params"
 	"github.com/fairblock/go-fairblock/rlp"
 	"github.com/fairblock/go-fairblock/rpc"
+	signercore "github.com/fairblock/go-fairblock/signer/core"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
@@ -300,6 +304,38 @@ func fetchKeystore(am *accounts.Manager) *keystore.KeyStore {
 	return am.Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 }
 
+// fetchWatchOnly retrieves the watch-only account backend from the account manager.
+func fetchWatchOnly(am *accounts.Manager) (*watchonly.Backend, error) {
+	backends := am.Backends(watchonly.BackendType)
+	if len(backends) == 0 {
+		return nil, errors.New("watch-only accounts not supported")
+	}
+	return backends[0].(*watchonly.Backend), nil
+}
+
+// WatchAccount starts tracking an address whose key is kept offline, labelling
+// it with the optional label. Transactions from the account can be prepared
+// with fbc_prepareTransaction and sent with fbc_sendSignedTransaction.
+func (s *PrivateAccountAPI) WatchAccount(addr common.Address, label *string) (accounts.Account, error) {
+	backend, err := fetchWatchOnly(s.am)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	if label == nil {
+		label = new(string)
+	}
+	return backend.Add(addr, *label)
+}
+
+// UnwatchAccount stops tracking a watch-only address.
+func (s *PrivateAccountAPI) UnwatchAccount(addr common.Address) error {
+	backend, err := fetchWatchOnly(s.am)
+	if err != nil {
+		return err
+	}
+	return backend.Remove(addr)
+}
+
 // ImportRawKey stores the given hex encoded ECDSA key into the key directory,
 // encrypting it with the passphrase.
 func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (common.Address, error) {
@@ -408,6 +444,39 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// SignTypedData calculates an ECDSA signature over the EIP-712 hash of the typed
+// structured data, unlocking the account with the given passphrase just for the
+// signing. The V value of the signature will be 27 or 28.
+//
+// Keystore accounts and external signers are supported. Ledger and Trezor
+// accounts are rejected, as the devices can't sign typed data.
+func (s *PrivateAccountAPI) SignTypedData(ctx context.Context, typedData signercore.TypedData, addr common.Address, passwd string) (hexutil.Bytes, error) {
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := wallet.(typedDataSigner); ok {
+		return signer.SignTypedData(account, typedData)
+	}
+	if isHardwareWallet(wallet) {
+		return nil, errTypedDataHardware
+	}
+	hash, _, err := signercore.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	// Assemble sign the data with the wallet
+	signature, err := wallet.SignHashWithPassphrase(account, passwd, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with fbc_sign and personal_sign. As such it recovers
 // the address of:
@@ -645,7 +714,10 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{DisableGasMetering: true})
+	result, _, failed, err := s.doCall(ctx, args, blockNr, vm.Config{DisableGasMetering: true})
+	if err == nil && failed && len(result) > 0 {
+		return nil, abi.NewRevertedError(result)
+	}
 	return (hexutil.Bytes)(result), err
 }
 
@@ -670,19 +742,23 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 	}
 	cap = hi
 
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	// Create a helper to check if a gas allowance results in an executable transaction,
+	// also returning the revert payload if the execution was reverted
+	executable := func(gas uint64) (bool, []byte) {
 		(*big.Int)(&args.Gas).SetUint64(gas)
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{})
-		if err != nil || failed {
-			return false
+		result, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{})
+		if err != nil {
+			return false, nil
 		}
-		return true
+		if failed {
+			return false, result
+		}
+		return true, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -690,7 +766,10 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, revert := executable(hi); !ok {
+			if len(revert) > 0 {
+				return nil, abi.NewRevertedError(revert)
+			}
 			return nil, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
@@ -832,6 +911,7 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+	Type             hexutil.Uint64  `json:"type,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -856,6 +936,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		V:        (*hexutil.Big)(v),
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
+		Type:     hexutil.Uint64(tx.Type()),
 	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
@@ -1194,6 +1275,62 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	return signature, err
 }
 
+// typedDataSigner is implemented by wallets signing EIP-712 typed data on their
+// own, such as external signers which need the structured data for approval.
+type typedDataSigner interface {
+	SignTypedData(account accounts.Account, typedData signercore.TypedData) ([]byte, error)
+}
+
+// errTypedDataHardware is returned if typed data is to be signed by a USB wallet.
+// The devices only sign transactions, never arbitrary hashes, so an EIP-712
+// message can't be signed with them.
+var errTypedDataHardware = errors.New("EIP-712 signing not supported by hardware wallets")
+
+// isHardwareWallet reports whether the wallet is a Ledger or Trezor device.
+func isHardwareWallet(wallet accounts.Wallet) bool {
+	switch wallet.URL().Scheme {
+	case usbwallet.LedgerScheme, usbwallet.TrezorScheme:
+		return true
+	}
+	return false
+}
+
+// SignTypedData calculates an ECDSA signature over the EIP-712 hash of the typed
+// structured data:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The account associated with addr must be unlocked. Keystore accounts and
+// external signers are supported, Ledger and Trezor accounts are rejected as the
+// devices can't sign typed data.
+func (s *PublicTransactionPoolAPI) SignTypedData(addr common.Address, typedData signercore.TypedData) (hexutil.Bytes, error) {
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := wallet.(typedDataSigner); ok {
+		return signer.SignTypedData(account, typedData)
+	}
+	if isHardwareWallet(wallet) {
+		return nil, errTypedDataHardware
+	}
+	hash, _, err := signercore.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	// Sign the typed data hash with the wallet
+	signature, err := wallet.SignHash(account, hash)
+	if err == nil {
+		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	}
+	return signature, err
+}
+
 // SignTransactionResult represents a RLP encoded signed transaction.
 type SignTransactionResult struct {
 	Raw hexutil.Bytes      `json:"raw"`
@@ -1224,6 +1361,44 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	return &SignTransactionResult{data, tx}, nil
 }
 
+// PrepareTransaction fills in the defaults of the given transaction and returns
+// it unsigned, along with the hash to sign, for signing offline. The signature
+// can be submitted back with SendSignedTransaction.
+//
+// Note, the nonce of the transaction is not reserved, preparing another one
+// before submitting the first results in the same nonce.
+func (s *PublicTransactionPoolAPI) PrepareTransaction(ctx context.Context, args SendTxArgs) (*watchonly.UnsignedTx, error) {
+	if args.Nonce == nil {
+		// Hold the addresse's mutex around filling the defaults to prevent
+		// concurrent assignment of the same nonce to multiple accounts.
+		s.nonceLock.LockAddr(args.From)
+		defer s.nonceLock.UnlockAddr(args.From)
+	}
+	if err := args.setDefaults(ctx, s.b); err != nil {
+		return nil, err
+	}
+	return watchonly.NewUnsignedTx(args.toTransaction(), s.chainID())
+}
+
+// SendSignedTransaction attaches the offline signature to a transaction prepared
+// by PrepareTransaction and submits it to the transaction pool.
+func (s *PublicTransactionPoolAPI) SendSignedTransaction(ctx context.Context, encodedTx hexutil.Bytes, signature hexutil.Bytes) (common.Hash, error) {
+	tx, _, err := watchonly.ApplySignature(encodedTx, signature, s.chainID())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, tx)
+}
+
+// chainID returns the chain id to replay protect new transactions with, or nil
+// if EIP155 isn't active yet.
+func (s *PublicTransactionPoolAPI) chainID() *big.Int {
+	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+		return config.ChainId
+	}
+	return nil
+}
+
 // PendingTransactions returns the transactions that are in the transaction pool and have a from address that is one of
 // the accounts this node manages.
 func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, error) {
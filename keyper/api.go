@@ -0,0 +1,95 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package keyper
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// SealTransaction encrypts a signed transaction to a fresh envelope identity of
+// an epoch, returning the payload of the encrypted transaction to include in the
+// block of that epoch.
+func SealTransaction(rand io.Reader, config *params.FairblockConfig, epoch uint64, tx *types.Transaction) ([]byte, error) {
+	master, ok := new(bn256.G2).Unmarshal(config.MasterPublicKey)
+	if !ok {
+		return nil, errors.New("invalid master public key")
+	}
+	blob, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var id common.Hash
+	if _, err := io.ReadFull(rand, id[:]); err != nil {
+		return nil, err
+	}
+	ciphertext, err := ibe.Encrypt(rand, master, types.EnvelopeIdentity(epoch, id), blob)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(&types.EncryptedEnvelope{Epoch: epoch, ID: id, Ciphertext: ciphertext})
+}
+
+// PublicKeyperAPI provides an API to access the encrypted mempool of the chain.
+type PublicKeyperAPI struct {
+	k *Keyper
+}
+
+// NewPublicKeyperAPI creates a new encrypted mempool API.
+func NewPublicKeyperAPI(k *Keyper) *PublicKeyperAPI {
+	return &PublicKeyperAPI{k}
+}
+
+// EncryptTransaction encrypts an RLP encoded signed transaction to an epoch,
+// returning the envelope payload of an encrypted transaction to include in the
+// block of that epoch.
+func (api *PublicKeyperAPI) EncryptTransaction(raw hexutil.Bytes, epoch hexutil.Uint64) (hexutil.Bytes, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	return SealTransaction(rand.Reader, api.k.chain.Fairblock, uint64(epoch), tx)
+}
+
+// Status returns the key holder index of the node and the number of holders
+// whose key shares were collected for each epoch still within its window.
+func (api *PublicKeyperAPI) Status() map[string]interface{} {
+	api.k.lock.RLock()
+	defer api.k.lock.RUnlock()
+
+	shares := make(map[hexutil.Uint64]int)
+	for _, epoch := range api.k.epochs {
+		if collected := len(epoch.shares); collected > shares[hexutil.Uint64(epoch.number)] {
+			shares[hexutil.Uint64(epoch.number)] = collected
+		}
+	}
+	return map[string]interface{}{
+		"index":     hexutil.Uint64(api.k.index()),
+		"threshold": hexutil.Uint64(api.k.chain.Fairblock.Threshold),
+		"head":      hexutil.Uint64(api.k.head),
+		"peers":     len(api.k.peers),
+		"shares":    shares,
+	}
+}
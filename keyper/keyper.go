@@ -0,0 +1,430 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+// Package keyper implements the decryption key holder service of the threshold
+// encrypted mempool.
+//
+// Every key holder of the committee owns a share of the master secret. Once the
+// block of an epoch is final, each of them extracts its shares of the decryption
+// keys of the envelopes committed to in that block and gossips them over the fbk
+// protocol. Any node collecting enough valid shares combines them into the keys
+// and submits them in a transaction, unlocking the envelopes.
+//
+// Every envelope is encrypted to an identity of its own, so the keys released
+// for a block don't decrypt envelopes left out of it, nor any of a competing
+// fork, which are never final.
+package keyper
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/crypto/bn256"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/p2p"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+	"github.com/fairblock/go-fairblock/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// shareLead is the number of epochs beyond the local head shares are accepted
+	// for, tolerating peers slightly ahead in the chain.
+	shareLead = 4
+)
+
+var (
+	errNoFairblock     = errors.New("chain has no encrypted mempool configured")
+	errUnknownHolder   = errors.New("unknown key holder")
+	errInvalidShare    = errors.New("invalid key share")
+	errEpochOutOfRange = errors.New("share epoch out of range")
+)
+
+// backend is the full node the key holder service runs on top of.
+type backend interface {
+	BlockChain() *core.BlockChain
+	TxPool() *core.TxPool
+}
+
+// Config is the configuration of the key holder service.
+type Config struct {
+	Share *ibe.Share // Share of the master secret, nil to only relay and combine
+}
+
+// epochShares collects the key shares of the envelopes committed to in the block
+// of an epoch.
+type epochShares struct {
+	number   uint64                 // Number of the epoch
+	ids      []common.Hash          // Distinct identifiers of the envelopes committed to
+	shares   map[uint64][]*bn256.G1 // Verified key shares by holder index, aligned with ids
+	revealed bool                   // Whether the keys were already submitted
+}
+
+// Keyper gossips decryption key shares and reveals the combined decryption keys
+// of final epochs.
+type Keyper struct {
+	config  *Config
+	chain   *params.ChainConfig
+	master  *bn256.G2   // Master public key of the committee
+	vks     []*bn256.G2 // Verification keys of the key holders, by index-1
+	depth   uint64      // Number of blocks on top of an epoch making it final
+	backend backend
+
+	protocol p2p.Protocol
+	key      *ecdsa.PrivateKey // Node key signing the decryption key transactions
+
+	head     uint64                       // Number of the current head block
+	released uint64                       // Last epoch the local shares were released for
+	epochs   map[common.Hash]*epochShares // Key shares by the hash of their epoch's block
+	peers    map[*peer]struct{}
+	lock     sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a key holder service running on top of the given full node.
+func New(config *Config, backend backend) (*Keyper, error) {
+	chain := backend.BlockChain().Config()
+	if chain.Fairblock == nil {
+		return nil, errNoFairblock
+	}
+	master, ok := new(bn256.G2).Unmarshal(chain.Fairblock.MasterPublicKey)
+	if !ok {
+		return nil, errors.New("invalid master public key")
+	}
+	vks := make([]*bn256.G2, len(chain.Fairblock.VerificationKeys))
+	for i, blob := range chain.Fairblock.VerificationKeys {
+		if vks[i], ok = new(bn256.G2).Unmarshal(blob); !ok {
+			return nil, errors.New("invalid key holder verification key")
+		}
+	}
+	if config.Share != nil && (config.Share.Index == 0 || config.Share.Index > uint64(len(vks))) {
+		return nil, errUnknownHolder
+	}
+	k := &Keyper{
+		config:  config,
+		chain:   chain,
+		master:  master,
+		vks:     vks,
+		depth:   chain.FinalityDepth(),
+		backend: backend,
+		epochs:  make(map[common.Hash]*epochShares),
+		peers:   make(map[*peer]struct{}),
+		quit:    make(chan struct{}),
+	}
+	k.protocol = p2p.Protocol{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     k.handlePeer,
+	}
+	return k, nil
+}
+
+// Protocols implements node.Service, returning the key share gossip protocol.
+func (k *Keyper) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{k.protocol}
+}
+
+// APIs implements node.Service, returning the RPC API endpoints of the key
+// holder service.
+func (k *Keyper) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "keyper",
+			Version:   "1.0",
+			Service:   NewPublicKeyperAPI(k),
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, starting to track the chain head and to
+// extract the key shares of the final epochs.
+func (k *Keyper) Start(srv *p2p.Server) error {
+	k.key = srv.PrivateKey
+
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := k.backend.BlockChain().SubscribeChainHeadEvent(headCh)
+
+	k.wg.Add(1)
+	go k.loop(headCh, headSub)
+
+	log.Info("Key holder service started", "index", k.index(), "threshold", k.chain.Fairblock.Threshold, "holders", len(k.vks), "depth", k.depth)
+	return nil
+}
+
+// Stop implements node.Service, terminating the key holder service.
+func (k *Keyper) Stop() error {
+	close(k.quit)
+	k.wg.Wait()
+
+	log.Info("Key holder service stopped")
+	return nil
+}
+
+// index returns the key holder index of the local node, or 0 if the node holds
+// no share.
+func (k *Keyper) index() uint64 {
+	if k.config.Share == nil {
+		return 0
+	}
+	return k.config.Share.Index
+}
+
+// loop releases the local key shares of every epoch becoming final.
+func (k *Keyper) loop(headCh chan core.ChainHeadEvent, headSub interface{ Unsubscribe() }) {
+	defer k.wg.Done()
+	defer headSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			k.setHead(ev.Block.NumberU64())
+			if k.config.Share != nil {
+				k.release()
+			}
+
+		case <-k.quit:
+			return
+		}
+	}
+}
+
+// release extracts and gossips the local key shares of all the epochs that
+// became final since the last release and are still within their window.
+func (k *Keyper) release() {
+	k.lock.Lock()
+	head, first := k.head, k.released+1
+	k.lock.Unlock()
+
+	if head < k.depth {
+		return
+	}
+	final := head - k.depth
+	if window := k.chain.Fairblock.Window(); final > window && first < final-window {
+		first = final - window
+	}
+	for epoch := first; epoch <= final; epoch++ {
+		block := k.backend.BlockChain().GetBlockByNumber(epoch)
+		if block == nil {
+			return
+		}
+		if msg := k.extract(block); msg != nil {
+			if err := k.addShare(msg); err != nil {
+				log.Warn("Failed to add local key shares", "epoch", epoch, "err", err)
+				continue
+			}
+			k.broadcast(msg, nil)
+		}
+		k.lock.Lock()
+		k.released = epoch
+		k.lock.Unlock()
+	}
+}
+
+// extract derives the local key shares of the envelopes committed to in a block,
+// or nil if there are none.
+func (k *Keyper) extract(block *types.Block) *shareMessage {
+	ids := envelopeIDs(block)
+	if len(ids) == 0 {
+		return nil
+	}
+	msg := &shareMessage{Epoch: block.NumberU64(), Block: block.Hash(), Index: k.config.Share.Index}
+	for _, id := range ids {
+		share := ibe.ExtractKey(k.config.Share.Secret, types.EnvelopeIdentity(msg.Epoch, id))
+		msg.Shares = append(msg.Shares, share.Marshal())
+	}
+	return msg
+}
+
+// envelopeIDs returns the distinct identifiers of the envelopes committed to in
+// a block, in block order.
+func envelopeIDs(block *types.Block) []common.Hash {
+	var (
+		ids  []common.Hash
+		seen = make(map[common.Hash]bool)
+	)
+	for _, env := range core.EpochEnvelopes(block) {
+		if !seen[env.ID] {
+			seen[env.ID] = true
+			ids = append(ids, env.ID)
+		}
+	}
+	return ids
+}
+
+// setHead updates the current head, drops all state of epochs whose reveal
+// window has passed and reveals the keys of epochs that became final.
+func (k *Keyper) setHead(number uint64) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.head = number
+	window := k.depth + k.chain.Fairblock.Window()
+	for hash, epoch := range k.epochs {
+		if epoch.number+window < number {
+			delete(k.epochs, hash)
+		}
+	}
+	for p := range k.peers {
+		p.expire(func(epoch uint64) bool { return epoch+window >= number })
+	}
+	for hash, epoch := range k.epochs {
+		k.tryReveal(hash, epoch)
+	}
+}
+
+// addShare verifies the key shares of a holder and stores them, revealing the
+// decryption keys of the epoch if enough shares were collected. It returns an
+// error if the shares are invalid or out of range, which includes shares of
+// blocks not known locally.
+func (k *Keyper) addShare(msg *shareMessage) error {
+	if msg.Index == 0 || msg.Index > uint64(len(k.vks)) {
+		return errUnknownHolder
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if msg.Epoch == 0 || msg.Epoch > k.head+shareLead || msg.Epoch+k.depth+k.chain.Fairblock.Window() < k.head {
+		return errEpochOutOfRange
+	}
+	epoch := k.epochs[msg.Block]
+	if epoch == nil {
+		block := k.backend.BlockChain().GetBlock(msg.Block, msg.Epoch)
+		if block == nil {
+			return errEpochOutOfRange
+		}
+		epoch = &epochShares{number: msg.Epoch, ids: envelopeIDs(block), shares: make(map[uint64][]*bn256.G1)}
+		k.epochs[msg.Block] = epoch
+	}
+	if _, known := epoch.shares[msg.Index]; known {
+		return nil
+	}
+	if len(msg.Shares) != len(epoch.ids) || len(epoch.ids) == 0 {
+		return errInvalidShare
+	}
+	shares := make([]*bn256.G1, len(msg.Shares))
+	for i, blob := range msg.Shares {
+		share, ok := new(bn256.G1).Unmarshal(blob)
+		if !ok || !ibe.VerifyShare(k.vks[msg.Index-1], types.EnvelopeIdentity(msg.Epoch, epoch.ids[i]), share) {
+			return errInvalidShare
+		}
+		shares[i] = share
+	}
+	epoch.shares[msg.Index] = shares
+	k.tryReveal(msg.Block, epoch)
+
+	return nil
+}
+
+// tryReveal submits the decryption keys of an epoch if enough shares were
+// collected and its block is canonical and final. The lock must be held.
+func (k *Keyper) tryReveal(hash common.Hash, epoch *epochShares) {
+	if epoch.revealed || uint64(len(epoch.shares)) < k.chain.Fairblock.Threshold {
+		return
+	}
+	if epoch.number+k.depth > k.head {
+		return
+	}
+	if header := k.backend.BlockChain().GetHeaderByNumber(epoch.number); header == nil || header.Hash() != hash {
+		return
+	}
+	if err := k.reveal(epoch); err != nil {
+		log.Warn("Failed to reveal decryption keys", "epoch", epoch.number, "err", err)
+		return
+	}
+	epoch.revealed = true
+}
+
+// reveal combines the collected key shares of an epoch and submits the resulting
+// decryption keys to the transaction pool. The lock must be held.
+func (k *Keyper) reveal(epoch *epochShares) error {
+	indices := make([]uint64, 0, len(epoch.shares))
+	for index := range epoch.shares {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	indices = indices[:k.chain.Fairblock.Threshold]
+
+	reveal := &types.DecryptionKey{Epoch: epoch.number}
+	for i, id := range epoch.ids {
+		shares := make([]*bn256.G1, len(indices))
+		for j, index := range indices {
+			shares[j] = epoch.shares[index][i]
+		}
+		key, err := ibe.CombineShares(indices, shares)
+		if err != nil {
+			return err
+		}
+		if !ibe.VerifyKey(k.master, types.EnvelopeIdentity(epoch.number, id), key) {
+			return errInvalidShare
+		}
+		reveal.Keys = append(reveal.Keys, types.EnvelopeKey{ID: id, Key: key.Marshal()})
+	}
+	if k.key == nil {
+		return errors.New("service not started")
+	}
+	data, err := rlp.EncodeToBytes(reveal)
+	if err != nil {
+		return err
+	}
+	var (
+		pool   = k.backend.TxPool()
+		number = new(big.Int).SetUint64(k.head + 1)
+		nonce  = pool.State().GetNonce(crypto.PubkeyToAddress(k.key.PublicKey))
+		gas    = core.IntrinsicGas(data, false, k.chain.IsHomestead(number))
+	)
+	tx, err := types.SignTx(types.NewDecryptionKeyTransaction(nonce, gas, new(big.Int), data), types.MakeSigner(k.chain, number), k.key)
+	if err != nil {
+		return err
+	}
+	if err := pool.AddLocal(tx); err != nil {
+		return err
+	}
+	log.Info("Revealed decryption keys", "epoch", epoch.number, "envelopes", len(reveal.Keys), "tx", tx.Hash())
+	return nil
+}
+
+// broadcast relays key shares to all peers not yet knowing about them, except
+// the one it originated from.
+func (k *Keyper) broadcast(msg *shareMessage, origin *peer) {
+	k.lock.RLock()
+	peers := make([]*peer, 0, len(k.peers))
+	for p := range k.peers {
+		if p != origin && !p.known(msg) {
+			peers = append(peers, p)
+		}
+	}
+	k.lock.RUnlock()
+
+	for _, p := range peers {
+		if err := p.send(msg); err != nil {
+			log.Debug("Failed to relay key share", "peer", p.peer, "err", err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package keyper
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/params"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// testBackend is a full node backed by an in-memory chain.
+type testBackend struct {
+	chain *core.BlockChain
+	pool  *core.TxPool
+}
+
+func (b *testBackend) BlockChain() *core.BlockChain { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool         { return b.pool }
+
+// newTestBackend creates a chain configured with a fresh key holder committee,
+// whose first block commits to the given number of envelopes and is made final
+// by a second one. It returns the backend, the key shares of the
+// holders and the first block.
+func newTestBackend(t *testing.T, threshold, holders int, envelopes int) (*testBackend, []*ibe.Share, *types.Block) {
+	master, shares, vks, err := ibe.GenerateShares(rand.Reader, threshold, holders)
+	if err != nil {
+		t.Fatalf("failed to generate key shares: %v", err)
+	}
+	config := *params.TestChainConfig
+	config.Fairblock = &params.FairblockConfig{MasterPublicKey: master.Marshal(), Threshold: uint64(threshold), Confirmations: 1}
+	for _, vk := range vks {
+		config.Fairblock.VerificationKeys = append(config.Fairblock.VerificationKeys, hexutil.Bytes(vk.Marshal()))
+	}
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = fbcdb.NewMemDatabase()
+		genesis = (&core.Genesis{Config: &config, Alloc: core.GenesisAlloc{address: {Balance: big.NewInt(1000000000)}}}).MustCommit(db)
+		signer  = types.MakeSigner(&config, big.NewInt(1))
+	)
+	chain, err := core.NewBlockChain(db, &config, fbcash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	blocks, _ := core.GenerateChain(&config, genesis, db, 2, func(i int, block *core.BlockGen) {
+		for j := 0; i == 0 && j < envelopes; j++ {
+			inner := types.NewTransaction(uint64(j), common.Address{}, new(big.Int), big.NewInt(21000), new(big.Int), nil)
+			payload, err := SealTransaction(rand.Reader, config.Fairblock, 1, inner)
+			if err != nil {
+				t.Fatalf("failed to seal transaction: %v", err)
+			}
+			tx, _ := types.SignTx(types.NewEncryptedTransaction(uint64(j), big.NewInt(100000), new(big.Int), payload), signer, key)
+			block.AddTx(tx)
+		}
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+
+	return &testBackend{chain: chain, pool: core.NewTxPool(poolConfig, &config, chain)}, shares, blocks[0]
+}
+
+// Tests that the decryption keys of an epoch are revealed to the transaction
+// pool once a threshold of valid key shares is collected and the epoch is final,
+// and that invalid shares are dropped.
+func TestShareCombination(t *testing.T) {
+	backend, shares, block := newTestBackend(t, 2, 3, 2)
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	k, err := New(&Config{Share: shares[0]}, backend)
+	if err != nil {
+		t.Fatalf("failed to create key holder service: %v", err)
+	}
+	k.key, _ = crypto.GenerateKey()
+	k.setHead(1)
+
+	share := func(holder int) *shareMessage {
+		k := &Keyper{config: &Config{Share: shares[holder]}}
+		return k.extract(block)
+	}
+	// Shares of unknown holders, blocks or out of range are rejected
+	if err := k.addShare(&shareMessage{Epoch: 1, Block: block.Hash(), Index: 4}); err != errUnknownHolder {
+		t.Errorf("unknown holder error mismatch: have %v, want %v", err, errUnknownHolder)
+	}
+	forged := share(1)
+	forged.Shares[0], forged.Shares[1] = forged.Shares[1], forged.Shares[0]
+	if err := k.addShare(forged); err != errInvalidShare {
+		t.Errorf("forged share error mismatch: have %v, want %v", err, errInvalidShare)
+	}
+	truncated := share(1)
+	truncated.Shares = truncated.Shares[:1]
+	if err := k.addShare(truncated); err != errInvalidShare {
+		t.Errorf("truncated share error mismatch: have %v, want %v", err, errInvalidShare)
+	}
+	unknown := share(1)
+	unknown.Block = common.Hash{0xff}
+	if err := k.addShare(unknown); err != errEpochOutOfRange {
+		t.Errorf("unknown block error mismatch: have %v, want %v", err, errEpochOutOfRange)
+	}
+	future := share(1)
+	future.Epoch = 1 + shareLead + 1
+	if err := k.addShare(future); err != errEpochOutOfRange {
+		t.Errorf("future share error mismatch: have %v, want %v", err, errEpochOutOfRange)
+	}
+	// A single share must not reveal, nor two before the epoch is final
+	if err := k.addShare(share(0)); err != nil {
+		t.Fatalf("failed to add first share: %v", err)
+	}
+	if err := k.addShare(share(2)); err != nil {
+		t.Fatalf("failed to add second share: %v", err)
+	}
+	if pending, _ := backend.pool.Stats(); pending != 0 {
+		t.Fatalf("keys revealed before finality")
+	}
+	// Finalising the epoch should reveal the keys of all its envelopes
+	k.setHead(2)
+
+	pending, _ := backend.pool.Pending()
+	txs := pending[crypto.PubkeyToAddress(k.key.PublicKey)]
+	if len(txs) != 1 {
+		t.Fatalf("revealing transactions mismatch: have %d, want %d", len(txs), 1)
+	}
+	key, err := txs[0].DecryptionKey()
+	if err != nil {
+		t.Fatalf("failed to decode decryption key: %v", err)
+	}
+	if key.Epoch != 1 || len(key.Keys) != 2 {
+		t.Errorf("revealed keys mismatch: have epoch %d with %d keys, want epoch %d with %d", key.Epoch, len(key.Keys), 1, 2)
+	}
+	// Further shares of the epoch must not reveal again
+	if err := k.addShare(share(1)); err != nil {
+		t.Fatalf("failed to add third share: %v", err)
+	}
+	if pending, _ := backend.pool.Stats(); pending != 1 {
+		t.Errorf("pending transactions mismatch: have %d, want %d", pending, 1)
+	}
+}
+
+// Tests that no key shares are extracted for blocks without envelopes.
+func TestExtractEmpty(t *testing.T) {
+	backend, shares, block := newTestBackend(t, 1, 1, 0)
+	defer backend.chain.Stop()
+	defer backend.pool.Stop()
+
+	k := &Keyper{config: &Config{Share: shares[0]}}
+	if msg := k.extract(block); msg != nil {
+		t.Errorf("shares extracted for block without envelopes: %v", msg)
+	}
+}
+
+// Tests that sealed transactions decrypt with the key of their own envelope only.
+func TestSealTransaction(t *testing.T) {
+	master, shares, _, _ := ibe.GenerateShares(rand.Reader, 1, 1)
+	config := &params.FairblockConfig{MasterPublicKey: master.Marshal(), Threshold: 1}
+
+	tx := types.NewTransaction(7, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	payload, err := SealTransaction(rand.Reader, config, 3, tx)
+	if err != nil {
+		t.Fatalf("failed to seal transaction: %v", err)
+	}
+	env := new(types.EncryptedEnvelope)
+	if err := rlp.DecodeBytes(payload, env); err != nil {
+		t.Fatalf("failed to decode envelope: %v", err)
+	}
+	if env.Epoch != 3 {
+		t.Errorf("envelope epoch mismatch: have %d, want %d", env.Epoch, 3)
+	}
+	if _, err := ibe.Decrypt(ibe.ExtractKey(shares[0].Secret, types.EnvelopeIdentity(3, common.Hash{})), env.Ciphertext); err == nil {
+		t.Errorf("envelope decrypted with the key of another identity")
+	}
+	blob, err := ibe.Decrypt(ibe.ExtractKey(shares[0].Secret, env.Identity()), env.Ciphertext)
+	if err != nil {
+		t.Fatalf("failed to decrypt envelope: %v", err)
+	}
+	inner := new(types.Transaction)
+	if err := rlp.DecodeBytes(blob, inner); err != nil {
+		t.Fatalf("failed to decode inner transaction: %v", err)
+	}
+	if inner.Hash() != tx.Hash() {
+		t.Errorf("inner transaction mismatch: have %x, want %x", inner.Hash(), tx.Hash())
+	}
+}
+
+// Tests that key shares survive a roundtrip through their file format.
+func TestShareFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyper-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "share.json")
+	share := &ibe.Share{Index: 2, Secret: big.NewInt(123456789)}
+	if err := SaveShare(file, share); err != nil {
+		t.Fatalf("failed to save share: %v", err)
+	}
+	loaded, err := LoadShare(file)
+	if err != nil {
+		t.Fatalf("failed to load share: %v", err)
+	}
+	if loaded.Index != share.Index || loaded.Secret.Cmp(share.Secret) != 0 {
+		t.Errorf("share mismatch: have %d/%v, want %d/%v", loaded.Index, loaded.Secret, share.Index, share.Secret)
+	}
+}
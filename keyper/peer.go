@@ -0,0 +1,141 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package keyper
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/p2p"
+)
+
+const (
+	protocolName    = "fbk" // Name of the key share gossip protocol
+	protocolVersion = 1     // Version of the key share gossip protocol
+	protocolLength  = 1     // Number of message codes of the protocol
+
+	shareMsg = 0x00 // Message code of a key share
+
+	maxMessageSize = 10 * 1024 * 1024 // Maximum size of a protocol message
+)
+
+// shareMessage is a key holder's shares of the decryption keys of the envelopes
+// committed to in the block of an epoch.
+type shareMessage struct {
+	Epoch  uint64      // Epoch the shares are extracted for
+	Block  common.Hash // Hash of the epoch's block committing to the envelopes
+	Index  uint64      // Index of the key holder the shares belong to
+	Shares [][]byte    // Marshalled G1 key shares, in envelope order
+}
+
+// shareID identifies the key shares of a holder across the network.
+type shareID struct {
+	epoch uint64
+	block common.Hash
+	index uint64
+}
+
+// peer is a remote node speaking the key share gossip protocol.
+type peer struct {
+	peer *p2p.Peer
+	rw   p2p.MsgReadWriter
+
+	shares map[shareID]struct{} // Shares already known by the peer
+	lock   sync.Mutex
+}
+
+// known returns whether the peer already knows about a key share.
+func (p *peer) known(msg *shareMessage) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, ok := p.shares[shareID{msg.Epoch, msg.Block, msg.Index}]
+	return ok
+}
+
+// mark marks a key share known to the peer so it won't be sent back.
+func (p *peer) mark(msg *shareMessage) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.shares[shareID{msg.Epoch, msg.Block, msg.Index}] = struct{}{}
+}
+
+// expire forgets all the known shares of epochs no longer kept.
+func (p *peer) expire(keep func(epoch uint64) bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id := range p.shares {
+		if !keep(id.epoch) {
+			delete(p.shares, id)
+		}
+	}
+}
+
+// send transmits a key share to the peer, marking it known.
+func (p *peer) send(msg *shareMessage) error {
+	p.mark(msg)
+	return p2p.Send(p.rw, shareMsg, msg)
+}
+
+// handlePeer is called by the p2p layer when the key share gossip protocol is
+// negotiated with a remote node. It relays all valid shares received.
+func (k *Keyper) handlePeer(remote *p2p.Peer, rw p2p.MsgReadWriter) error {
+	p := &peer{peer: remote, rw: rw, shares: make(map[shareID]struct{})}
+
+	k.lock.Lock()
+	k.peers[p] = struct{}{}
+	k.lock.Unlock()
+
+	defer func() {
+		k.lock.Lock()
+		delete(k.peers, p)
+		k.lock.Unlock()
+	}()
+	log.Debug("Key share peer connected", "peer", remote)
+
+	for {
+		packet, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if packet.Size > maxMessageSize {
+			packet.Discard()
+			return fmt.Errorf("message too large: %v > %v", packet.Size, maxMessageSize)
+		}
+		if packet.Code != shareMsg {
+			packet.Discard()
+			return fmt.Errorf("unknown message code: %d", packet.Code)
+		}
+		msg := new(shareMessage)
+		if err := packet.Decode(msg); err != nil {
+			return fmt.Errorf("invalid key share: %v", err)
+		}
+		p.mark(msg)
+
+		switch err := k.addShare(msg); err {
+		case nil:
+			k.broadcast(msg, p)
+		case errEpochOutOfRange:
+			log.Trace("Ignoring key share out of range", "peer", remote, "epoch", msg.Epoch, "index", msg.Index)
+		default:
+			return fmt.Errorf("key shares %d of epoch %d: %v", msg.Index, msg.Epoch, err)
+		}
+	}
+}
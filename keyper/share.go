@@ -0,0 +1,58 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+package keyper
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/crypto/ibe"
+)
+
+// shareJSON is the on-disk format of a key holder's share of the master secret.
+type shareJSON struct {
+	Index  uint64       `json:"index"`
+	Secret *hexutil.Big `json:"secret"`
+}
+
+// LoadShare reads a key holder's share of the master secret from a file.
+func LoadShare(file string) (*ibe.Share, error) {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var enc shareJSON
+	if err := json.Unmarshal(blob, &enc); err != nil {
+		return nil, err
+	}
+	if enc.Index == 0 || enc.Secret == nil {
+		return nil, errors.New("incomplete key share")
+	}
+	return &ibe.Share{Index: enc.Index, Secret: (*big.Int)(enc.Secret)}, nil
+}
+
+// SaveShare writes a key holder's share of the master secret to a file only
+// readable by the current user.
+func SaveShare(file string, share *ibe.Share) error {
+	blob, err := json.MarshalIndent(&shareJSON{Index: share.Index, Secret: (*hexutil.Big)(share.Secret)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, blob, 0600)
+}
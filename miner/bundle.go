@@ -0,0 +1,367 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+const (
+	// maxBundleTxs is the maximum number of transactions a single bundle may hold.
+	maxBundleTxs = 64
+
+	// maxPendingBundles is the maximum number of bundles waiting for their target
+	// block at any time.
+	maxPendingBundles = 1024
+
+	// bundleStatusRetention is the number of blocks past its target a finalized
+	// bundle's status is kept around for querying.
+	bundleStatusRetention = 1024
+)
+
+// Bundle states reported by BundleStatus.
+const (
+	BundlePending  = "pending"  // Waiting for the target block to be sealed
+	BundleIncluded = "included" // Included in the canonical target block
+	BundleExpired  = "expired"  // Target block sealed without the bundle
+)
+
+var (
+	// ErrEmptyBundle is returned if a bundle without transactions is submitted.
+	ErrEmptyBundle = errors.New("empty bundle")
+
+	// ErrBundleTooLarge is returned if a bundle holds more transactions than
+	// allowed.
+	ErrBundleTooLarge = fmt.Errorf("bundle exceeds %d transactions", maxBundleTxs)
+
+	// ErrBundleTarget is returned if a bundle targets an already sealed block.
+	ErrBundleTarget = errors.New("bundle target block already sealed")
+
+	// ErrBundleTimestamps is returned if a bundle's timestamp range is empty.
+	ErrBundleTimestamps = errors.New("bundle maximum timestamp below minimum")
+
+	// ErrBundleFinalized is returned if a bundle is resubmitted after its target
+	// block was already sealed.
+	ErrBundleFinalized = errors.New("bundle already finalized")
+
+	// ErrBundlePoolFull is returned if too many bundles are waiting for their
+	// target blocks.
+	ErrBundlePoolFull = errors.New("too many pending bundles")
+)
+
+// Bundle is a list of transactions submitted directly to the miner, to be
+// included atomically and in order in a specific block. Bundles are never
+// propagated to the network.
+type Bundle struct {
+	Txs          types.Transactions // Transactions to include, in order
+	BlockNumber  uint64             // Number of the block to include the bundle in
+	MinTimestamp uint64             // Earliest block timestamp accepted (0 = unbounded)
+	MaxTimestamp uint64             // Latest block timestamp accepted (0 = unbounded)
+}
+
+// Hash returns the identifier of the bundle, the hash of the concatenated
+// hashes of its transactions followed by its target block number and timestamp
+// range, so the same transactions may be submitted for several blocks.
+func (b *Bundle) Hash() common.Hash {
+	fields := make([][]byte, 0, len(b.Txs)+3)
+	for _, tx := range b.Txs {
+		fields = append(fields, tx.Hash().Bytes())
+	}
+	for _, n := range []uint64{b.BlockNumber, b.MinTimestamp, b.MaxTimestamp} {
+		var enc [8]byte
+		binary.BigEndian.PutUint64(enc[:], n)
+		fields = append(fields, enc[:])
+	}
+	return crypto.Keccak256Hash(fields...)
+}
+
+// validTime reports whether the bundle may be included in a block with the given
+// timestamp.
+func (b *Bundle) validTime(time uint64) bool {
+	if b.MinTimestamp != 0 && time < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && time > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// BundleStatus reports the progress of a submitted bundle.
+type BundleStatus struct {
+	State       string      // One of BundlePending, BundleIncluded or BundleExpired
+	BlockNumber uint64      // Number of the block targeted by the bundle
+	BlockHash   common.Hash // Hash of the block including the bundle, if included
+	Error       string      // Last reason the bundle was left out of a block, if any
+}
+
+// trackedBundle is a submitted bundle along with its current status.
+type trackedBundle struct {
+	bundle  *Bundle
+	status  BundleStatus
+	seq     uint64      // Submission order, bundles are committed first come first served
+	settled common.Hash // Canonical target block the status was settled against
+}
+
+// blockRetriever is used by the bundle pool to check whether a bundle made it
+// into the canonical chain.
+type blockRetriever interface {
+	// GetHeaderByNumber retrieves the canonical header associated with a block number.
+	GetHeaderByNumber(number uint64) *types.Header
+
+	// GetBlockByNumber retrieves the canonical block associated with a block number.
+	GetBlockByNumber(number uint64) *types.Block
+}
+
+// bundlePool tracks the bundles submitted to the miner until their target block
+// is sealed, and their final status for a while afterwards.
+type bundlePool struct {
+	chain   blockRetriever
+	bundles map[common.Hash]*trackedBundle
+	pending int    // Number of bundles still waiting for their target block
+	seq     uint64 // Submission counter
+	lock    sync.RWMutex
+}
+
+// newBundlePool creates an empty bundle pool checking inclusions against chain.
+func newBundlePool(chain blockRetriever) *bundlePool {
+	return &bundlePool{
+		chain:   chain,
+		bundles: make(map[common.Hash]*trackedBundle),
+	}
+}
+
+// Add inserts a bundle into the pool, replacing an identical pending one, and
+// returns its identifier. The bundle must target a block above head.
+func (pool *bundlePool) Add(bundle *Bundle, head uint64) (common.Hash, error) {
+	switch {
+	case len(bundle.Txs) == 0:
+		return common.Hash{}, ErrEmptyBundle
+	case len(bundle.Txs) > maxBundleTxs:
+		return common.Hash{}, ErrBundleTooLarge
+	case bundle.BlockNumber <= head:
+		return common.Hash{}, ErrBundleTarget
+	case bundle.MaxTimestamp != 0 && bundle.MaxTimestamp < bundle.MinTimestamp:
+		return common.Hash{}, ErrBundleTimestamps
+	}
+	hash := bundle.Hash()
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if old, ok := pool.bundles[hash]; ok {
+		if old.status.State != BundlePending {
+			return common.Hash{}, ErrBundleFinalized
+		}
+		pool.pending--
+	}
+	if pool.pending >= maxPendingBundles {
+		return common.Hash{}, ErrBundlePoolFull
+	}
+	pool.seq++
+	pool.pending++
+	pool.bundles[hash] = &trackedBundle{
+		bundle: bundle,
+		status: BundleStatus{State: BundlePending, BlockNumber: bundle.BlockNumber},
+		seq:    pool.seq,
+	}
+	return hash, nil
+}
+
+// Executable returns the pending bundles that may be included in a block with
+// the given number and timestamp, in submission order.
+func (pool *bundlePool) Executable(number, time uint64) []*Bundle {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	var tracked []*trackedBundle
+	for _, item := range pool.bundles {
+		if item.status.State == BundlePending && item.bundle.BlockNumber == number && item.bundle.validTime(time) {
+			tracked = append(tracked, item)
+		}
+	}
+	sort.Slice(tracked, func(i, j int) bool { return tracked[i].seq < tracked[j].seq })
+
+	bundles := make([]*Bundle, len(tracked))
+	for i, item := range tracked {
+		bundles[i] = item.bundle
+	}
+	return bundles
+}
+
+// Failed records the reason a pending bundle was left out of a block.
+func (pool *bundlePool) Failed(hash common.Hash, err error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if item, ok := pool.bundles[hash]; ok && item.status.State == BundlePending {
+		item.status.Error = err.Error()
+	}
+}
+
+// Finalize settles the status of every bundle targeting a block up to the new
+// chain head, and drops the statuses of bundles finalized long enough ago. A
+// bundle is included if the canonical target block contains all of its
+// transactions consecutively and in order.
+//
+// Statuses are settled again if a reorg replaced the canonical target block, and
+// bundles are pending again if the target block is no longer in the chain.
+func (pool *bundlePool) Finalize(head uint64) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for hash, item := range pool.bundles {
+		number := item.bundle.BlockNumber
+		if number > head {
+			if item.status.State != BundlePending {
+				item.status.State, item.status.BlockHash, item.settled = BundlePending, common.Hash{}, common.Hash{}
+				pool.pending++
+				log.Debug("Bundle pending again after reorg", "hash", hash, "number", number)
+			}
+			continue
+		}
+		if item.status.State != BundlePending {
+			if number+bundleStatusRetention < head {
+				delete(pool.bundles, hash)
+				continue
+			}
+			if header := pool.chain.GetHeaderByNumber(number); header != nil && header.Hash() == item.settled {
+				continue
+			}
+		} else {
+			pool.pending--
+		}
+		block := pool.chain.GetBlockByNumber(number)
+		if block == nil {
+			item.status.State, item.status.BlockHash, item.settled = BundleExpired, common.Hash{}, common.Hash{}
+			continue
+		}
+		item.settled = block.Hash()
+		if containsBundle(block.Transactions(), item.bundle.Txs) {
+			item.status.State, item.status.BlockHash, item.status.Error = BundleIncluded, block.Hash(), ""
+			log.Debug("Bundle included", "hash", hash, "number", number, "block", block.Hash())
+		} else {
+			item.status.State, item.status.BlockHash = BundleExpired, common.Hash{}
+			log.Debug("Bundle expired", "hash", hash, "number", number, "err", item.status.Error)
+		}
+	}
+}
+
+// Status returns the status of the bundle with the given hash, or nil if the
+// bundle is unknown.
+func (pool *bundlePool) Status(hash common.Hash) *BundleStatus {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	item, ok := pool.bundles[hash]
+	if !ok {
+		return nil
+	}
+	status := item.status
+	return &status
+}
+
+// containsBundle reports whether txs contains every transaction of bundle
+// consecutively and in order.
+func containsBundle(txs, bundle types.Transactions) bool {
+	first := bundle[0].Hash()
+	for i := 0; i+len(bundle) <= len(txs); i++ {
+		if txs[i].Hash() != first {
+			continue
+		}
+		for j := 1; j < len(bundle); j++ {
+			if txs[i+j].Hash() != bundle[j].Hash() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// commitBundle executes the transactions of the bundle on top of the pending
+// state, keeping them only if all of them execute and none reverts. On failure
+// the work is left untouched.
+func (env *Work) commitBundle(bundle *Bundle, bc *core.BlockChain, coinbase common.Address) ([]*types.Log, error) {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	// The state journal is flushed between transactions, so reverting the whole
+	// bundle needs a copy of the state taken before its first transaction
+	var (
+		statedb = env.state.Copy()
+		gas     = new(big.Int).Set((*big.Int)(env.gasPool))
+		used    = new(big.Int).Set(env.header.GasUsed)
+		count   = len(env.txs)
+		tcount  = env.tcount
+		profit  = new(big.Int).Set(env.profit)
+
+		logs []*types.Log
+	)
+	for _, tx := range bundle.Txs {
+		err := env.commitBundleTx(tx, bc, coinbase)
+		if err == nil {
+			receipt := env.receipts[len(env.receipts)-1]
+			if receipt.Status == types.ReceiptStatusFailed {
+				err = fmt.Errorf("transaction %x reverted", tx.Hash())
+			}
+			logs = append(logs, receipt.Logs...)
+		}
+		if err != nil {
+			env.state = statedb
+			(*big.Int)(env.gasPool).Set(gas)
+			env.header.GasUsed.Set(used)
+			env.txs, env.receipts = env.txs[:count], env.receipts[:count]
+			env.tcount = tcount
+			env.profit = profit
+			return nil, err
+		}
+	}
+	return logs, nil
+}
+
+// commitBundleTx executes a single transaction of a bundle.
+func (env *Work) commitBundleTx(tx *types.Transaction, bc *core.BlockChain, coinbase common.Address) error {
+	if tx.Protected() && !env.config.IsEIP155(env.header.Number) {
+		return fmt.Errorf("transaction %x replay protected before EIP155", tx.Hash())
+	}
+	if tx.IsEnvelope() {
+		if envelope, err := tx.Envelope(); err != nil || envelope.Epoch != env.header.Number.Uint64() {
+			return fmt.Errorf("envelope %x of foreign epoch", tx.Hash())
+		}
+	}
+	if tx.IsDecryptionKey() {
+		return fmt.Errorf("decryption key %x in bundle", tx.Hash())
+	}
+	env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
+	if err, _ := env.commitTransaction(tx, bc, coinbase, env.gasPool); err != nil {
+		return fmt.Errorf("transaction %x: %v", tx.Hash(), err)
+	}
+	env.tcount++
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/state"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/params"
+)
+
+var (
+	bundleTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	bundleTestAddr    = crypto.PubkeyToAddress(bundleTestKey.PublicKey)
+	bundleTestRevert  = common.HexToAddress("0xdeadbeef")
+	bundleTestFunds   = big.NewInt(1000000000000000000)
+	bundleTestAmount  = big.NewInt(1000)
+	bundleTestGasUsed = big.NewInt(21000)
+)
+
+// newBundleTestWork creates a mining environment for block 1 on top of a genesis
+// funding the test account and deploying a contract that always reverts.
+func newBundleTestWork(t *testing.T) *Work {
+	db, _ := fbcdb.NewMemDatabase()
+	genesis := (&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			bundleTestAddr:   {Balance: bundleTestFunds},
+			bundleTestRevert: {Balance: new(big.Int), Code: []byte{0x60, 0x00, 0x80, 0xfd}}, // PUSH1 0 DUP1 REVERT
+		},
+	}).MustCommit(db)
+
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	return &Work{
+		config: params.TestChainConfig,
+		signer: types.NewEIP155Signer(params.TestChainConfig.ChainId),
+		state:  statedb,
+		header: &types.Header{
+			ParentHash: genesis.Hash(),
+			Number:     big.NewInt(1),
+			Difficulty: big.NewInt(1),
+			GasLimit:   genesis.GasLimit(),
+			GasUsed:    new(big.Int),
+			Time:       big.NewInt(100),
+		},
+		profit: new(big.Int),
+	}
+}
+
+// bundleTestTx creates a signed transaction of the test account.
+func bundleTestTx(t *testing.T, work *Work, nonce uint64, to common.Address, gas int64) *types.Transaction {
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, bundleTestAmount, big.NewInt(gas), big.NewInt(1), nil), work.signer, bundleTestKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that bundles are included whole if all their transactions succeed, and
+// left out entirely, without side effects, if any of them fails or reverts.
+func TestCommitBundle(t *testing.T) {
+	work := newBundleTestWork(t)
+	recipient := common.HexToAddress("0xc0ffee")
+
+	// A bundle of plain transfers should be included in order
+	ok := &Bundle{Txs: types.Transactions{
+		bundleTestTx(t, work, 0, recipient, 21000),
+		bundleTestTx(t, work, 1, recipient, 21000),
+	}, BlockNumber: 1}
+	if _, err := work.commitBundle(ok, nil, common.Address{}); err != nil {
+		t.Fatalf("failed to commit valid bundle: %v", err)
+	}
+	if len(work.txs) != 2 || work.tcount != 2 || work.txs[0] != ok.Txs[0] || work.txs[1] != ok.Txs[1] {
+		t.Fatalf("bundle transactions mismatch: have %d/%d, want 2", len(work.txs), work.tcount)
+	}
+	balance := work.state.GetBalance(recipient)
+	if want := new(big.Int).Mul(bundleTestAmount, big.NewInt(2)); balance.Cmp(want) != 0 {
+		t.Fatalf("recipient balance mismatch: have %v, want %v", balance, want)
+	}
+	gasUsed := new(big.Int).Set(work.header.GasUsed)
+	if want := new(big.Int).Mul(bundleTestGasUsed, big.NewInt(2)); gasUsed.Cmp(want) != 0 {
+		t.Fatalf("gas used mismatch: have %v, want %v", gasUsed, want)
+	}
+	gasLeft := new(big.Int).Set((*big.Int)(work.gasPool))
+	fees := work.fees()
+	if fees.Cmp(gasUsed) != 0 {
+		t.Fatalf("fees mismatch: have %v, want %v", fees, gasUsed)
+	}
+
+	// Bundles with a reverting or an invalid transaction should leave no trace
+	failing := []*Bundle{
+		{Txs: types.Transactions{
+			bundleTestTx(t, work, 2, recipient, 21000),
+			bundleTestTx(t, work, 3, bundleTestRevert, 50000),
+		}, BlockNumber: 1},
+		{Txs: types.Transactions{
+			bundleTestTx(t, work, 2, recipient, 21000),
+			bundleTestTx(t, work, 4, recipient, 21000),
+		}, BlockNumber: 1},
+	}
+	for i, bundle := range failing {
+		if _, err := work.commitBundle(bundle, nil, common.Address{}); err == nil {
+			t.Errorf("bundle %d: committed failing bundle", i)
+		}
+		if len(work.txs) != 2 || len(work.receipts) != 2 || work.tcount != 2 {
+			t.Errorf("bundle %d: transactions left behind: have %d/%d/%d, want 2", i, len(work.txs), len(work.receipts), work.tcount)
+		}
+		if have := work.state.GetBalance(recipient); have.Cmp(balance) != 0 {
+			t.Errorf("bundle %d: recipient balance changed: have %v, want %v", i, have, balance)
+		}
+		if have := work.state.GetNonce(bundleTestAddr); have != 2 {
+			t.Errorf("bundle %d: sender nonce changed: have %d, want 2", i, have)
+		}
+		if work.header.GasUsed.Cmp(gasUsed) != 0 {
+			t.Errorf("bundle %d: gas used changed: have %v, want %v", i, work.header.GasUsed, gasUsed)
+		}
+		if have := (*big.Int)(work.gasPool); have.Cmp(gasLeft) != 0 {
+			t.Errorf("bundle %d: gas pool changed: have %v, want %v", i, have, gasLeft)
+		}
+		if have := work.fees(); have.Cmp(fees) != 0 {
+			t.Errorf("bundle %d: fees changed: have %v, want %v", i, have, fees)
+		}
+	}
+}
+
+// testBlockRetriever is a canonical chain of blocks by number.
+type testBlockRetriever map[uint64]*types.Block
+
+func (chain testBlockRetriever) GetHeaderByNumber(number uint64) *types.Header {
+	if block := chain[number]; block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+func (chain testBlockRetriever) GetBlockByNumber(number uint64) *types.Block {
+	return chain[number]
+}
+
+// Tests the bundle pool's admission, selection and finalization of bundles.
+func TestBundlePool(t *testing.T) {
+	work := newBundleTestWork(t)
+	var (
+		chain = make(testBlockRetriever)
+		pool  = newBundlePool(chain)
+
+		tx0 = bundleTestTx(t, work, 0, common.Address{}, 21000)
+		tx1 = bundleTestTx(t, work, 1, common.Address{}, 21000)
+		tx2 = bundleTestTx(t, work, 2, common.Address{}, 21000)
+	)
+	// Invalid bundles should be rejected
+	invalid := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{&Bundle{BlockNumber: 2}, ErrEmptyBundle},
+		{&Bundle{Txs: make(types.Transactions, maxBundleTxs+1), BlockNumber: 2}, ErrBundleTooLarge},
+		{&Bundle{Txs: types.Transactions{tx0}, BlockNumber: 1}, ErrBundleTarget},
+		{&Bundle{Txs: types.Transactions{tx0}, BlockNumber: 2, MinTimestamp: 20, MaxTimestamp: 10}, ErrBundleTimestamps},
+	}
+	for i, tt := range invalid {
+		if _, err := pool.Add(tt.bundle, 1); err != tt.err {
+			t.Errorf("bundle %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Valid bundles should be selected by target block and timestamp range
+	included := &Bundle{Txs: types.Transactions{tx0, tx1}, BlockNumber: 2}
+	expired := &Bundle{Txs: types.Transactions{tx1, tx2}, BlockNumber: 2, MinTimestamp: 50}
+	later := &Bundle{Txs: types.Transactions{tx2}, BlockNumber: 3, MaxTimestamp: 50}
+
+	hashes := make([]common.Hash, 3)
+	for i, bundle := range []*Bundle{included, expired, later} {
+		hash, err := pool.Add(bundle, 1)
+		if err != nil {
+			t.Fatalf("bundle %d: failed to add: %v", i, err)
+		}
+		hashes[i] = hash
+	}
+	if bundles := pool.Executable(2, 100); len(bundles) != 2 || bundles[0] != included || bundles[1] != expired {
+		t.Errorf("executable bundles mismatch at time 100: have %v", bundles)
+	}
+	if bundles := pool.Executable(2, 10); len(bundles) != 1 || bundles[0] != included {
+		t.Errorf("executable bundles mismatch at time 10: have %v", bundles)
+	}
+	if bundles := pool.Executable(3, 100); len(bundles) != 0 {
+		t.Errorf("executable bundles mismatch past maximum time: have %v", bundles)
+	}
+	// Sealing the target block should settle the bundles targeting it
+	pool.Failed(hashes[1], ErrBundleTarget)
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(2)}, types.Transactions{tx2, tx0, tx1}, nil, nil)
+	chain[2] = block
+	pool.Finalize(2)
+
+	if status := pool.Status(hashes[0]); status == nil || status.State != BundleIncluded || status.BlockHash != block.Hash() {
+		t.Errorf("included bundle status mismatch: have %+v", status)
+	}
+	if status := pool.Status(hashes[1]); status == nil || status.State != BundleExpired || status.Error != ErrBundleTarget.Error() {
+		t.Errorf("expired bundle status mismatch: have %+v", status)
+	}
+	if status := pool.Status(hashes[2]); status == nil || status.State != BundlePending {
+		t.Errorf("later bundle status mismatch: have %+v", status)
+	}
+	if _, err := pool.Add(included, 1); err != ErrBundleFinalized {
+		t.Errorf("resubmission error mismatch: have %v, want %v", err, ErrBundleFinalized)
+	}
+	// The same transactions may target another block as a separate bundle
+	if hash := (&Bundle{Txs: included.Txs, BlockNumber: 3}).Hash(); hash == hashes[0] {
+		t.Errorf("bundles for different blocks share hash %x", hash)
+	}
+	// Reorgs replacing the target block should settle the bundles again
+	reorged := types.NewBlock(&types.Header{Number: big.NewInt(2), Extra: []byte("reorg")}, types.Transactions{tx1, tx2}, nil, nil)
+	chain[2] = reorged
+	pool.Finalize(2)
+
+	if status := pool.Status(hashes[0]); status == nil || status.State != BundleExpired {
+		t.Errorf("reorged out bundle status mismatch: have %+v", status)
+	}
+	if status := pool.Status(hashes[1]); status == nil || status.State != BundleIncluded || status.BlockHash != reorged.Hash() {
+		t.Errorf("reorged in bundle status mismatch: have %+v", status)
+	}
+	// Reorgs dropping the target block should make the bundles pending again
+	delete(chain, 2)
+	pool.Finalize(1)
+
+	if status := pool.Status(hashes[0]); status == nil || status.State != BundlePending {
+		t.Errorf("dropped target bundle status mismatch: have %+v", status)
+	}
+	if bundles := pool.Executable(2, 100); len(bundles) != 2 {
+		t.Errorf("executable bundles mismatch after reorg: have %v", bundles)
+	}
+	chain[2] = block
+	pool.Finalize(2)
+	// Finalized statuses should be dropped after the retention period
+	pool.Finalize(2 + bundleStatusRetention + 1)
+	if status := pool.Status(hashes[0]); status != nil {
+		t.Errorf("stale bundle status retained: %+v", status)
+	}
+}
@@ -19,7 +19,9 @@ package miner
 
 import (
 	"fmt"
+	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/fairblock/go-fairblock/accounts"
 	"github.com/fairblock/go-fairblock/common"
@@ -163,6 +165,60 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetTxOrdering selects the policy ordering the pending transactions packed into
+// new blocks by name.
+func (self *Miner) SetTxOrdering(name string) error {
+	policy, err := LookupTxPolicy(name)
+	if err != nil {
+		return err
+	}
+	self.worker.setTxPolicy(policy)
+	return nil
+}
+
+// SendBundle submits a bundle of transactions for atomic inclusion in its target
+// block and returns its identifier. Bundles are kept local to the miner.
+func (self *Miner) SendBundle(bundle *Bundle) (common.Hash, error) {
+	head := self.fbc.BlockChain().CurrentBlock().NumberU64()
+	signer := types.MakeSigner(self.worker.config, new(big.Int).SetUint64(bundle.BlockNumber))
+	for i, tx := range bundle.Txs {
+		if _, err := types.Sender(signer, tx); err != nil {
+			return common.Hash{}, fmt.Errorf("bundle transaction %d: %v", i, err)
+		}
+	}
+	return self.worker.bundles.Add(bundle, head)
+}
+
+// BundleStatus returns the status of a submitted bundle, or nil if the bundle
+// is unknown to the miner.
+func (self *Miner) BundleStatus(hash common.Hash) *BundleStatus {
+	return self.worker.bundles.Status(hash)
+}
+
+// SetGasLimit sets the gas limit targets of mined blocks: the limit is raised
+// towards the floor and kept below the ceiling (0 = unbounded), moving at most
+// as fast as the protocol allows.
+func (self *Miner) SetGasLimit(floor, ceil uint64) error {
+	if floor < params.MinGasLimit.Uint64() {
+		return fmt.Errorf("gas floor %d below minimum gas limit %v", floor, params.MinGasLimit)
+	}
+	if ceil != 0 && ceil < floor {
+		return fmt.Errorf("gas ceiling %d below gas floor %d", ceil, floor)
+	}
+	var gasCeil *big.Int
+	if ceil != 0 {
+		gasCeil = new(big.Int).SetUint64(ceil)
+	}
+	self.worker.setGasLimit(new(big.Int).SetUint64(floor), gasCeil)
+	return nil
+}
+
+// SetRecommitInterval sets the base interval at which the block being sealed is
+// rebuilt with the latest pending transactions.
+func (self *Miner) SetRecommitInterval(interval time.Duration) {
+	self.worker.setRecommitInterval(interval)
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
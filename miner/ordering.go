@@ -0,0 +1,216 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+)
+
+// DefaultTxPolicy is the name of the transaction ordering policy used if none is
+// configured, packing the best paying transactions first.
+const DefaultTxPolicy = "price"
+
+// TxOrdering yields the pending transactions to pack into a block one by one.
+// Implementations must yield the transactions of every account in nonce order.
+type TxOrdering interface {
+	// Peek returns the next transaction to pack, or nil if none are left.
+	Peek() *types.Transaction
+
+	// Shift replaces the transaction returned by Peek with the next one from
+	// the same account.
+	Shift()
+
+	// Pop drops the transaction returned by Peek along with all subsequent ones
+	// from the same account, used if it cannot be executed.
+	Pop()
+}
+
+// TxPolicy creates the ordering of the pending transactions packed into a new
+// block. The pending map holds the nonce sorted transactions of every account
+// and is owned by the policy.
+type TxPolicy func(signer types.Signer, pending map[common.Address]types.Transactions) TxOrdering
+
+// txPolicies are the transaction ordering policies selectable by name.
+var txPolicies = map[string]TxPolicy{
+	"price":      PriceOrdering,
+	"fifo":       ArrivalOrdering,
+	"roundrobin": RoundRobinOrdering,
+}
+
+// RegisterTxPolicy makes a transaction ordering policy selectable by name. It is
+// not safe for concurrent use and meant to be called from init functions.
+func RegisterTxPolicy(name string, policy TxPolicy) {
+	if _, exists := txPolicies[name]; exists {
+		panic(fmt.Sprintf("transaction ordering policy %q already registered", name))
+	}
+	txPolicies[name] = policy
+}
+
+// TxPolicies returns the sorted names of all selectable ordering policies.
+func TxPolicies() []string {
+	names := make([]string, 0, len(txPolicies))
+	for name := range txPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupTxPolicy retrieves a transaction ordering policy by name.
+func LookupTxPolicy(name string) (TxPolicy, error) {
+	policy, ok := txPolicies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction ordering %q (available: %s)", name, strings.Join(TxPolicies(), ", "))
+	}
+	return policy, nil
+}
+
+// PriceOrdering packs the transactions with the highest gas price first.
+func PriceOrdering(signer types.Signer, pending map[common.Address]types.Transactions) TxOrdering {
+	return types.NewTransactionsByPriceAndNonce(signer, pending)
+}
+
+// ArrivalOrdering packs transactions first-come first-served, in the order they
+// were first seen by the local node. Ties are broken by gas price.
+func ArrivalOrdering(signer types.Signer, pending map[common.Address]types.Transactions) TxOrdering {
+	t := &headOrdering{txs: make(map[common.Address]types.Transactions), signer: signer}
+	t.heads.less = func(a, b *types.Transaction) bool {
+		if ta, tb := a.FirstSeen(), b.FirstSeen(); !ta.Equal(tb) {
+			return ta.Before(tb)
+		}
+		return a.GasPrice().Cmp(b.GasPrice()) > 0
+	}
+	for _, txs := range pending {
+		acc, _ := types.Sender(signer, txs[0])
+		t.heads.txs = append(t.heads.txs, txs[0])
+		t.txs[acc] = txs[1:]
+	}
+	heap.Init(&t.heads)
+	return t
+}
+
+// headOrdering orders the head transactions of all accounts by a comparator.
+type headOrdering struct {
+	txs    map[common.Address]types.Transactions // Per account nonce-sorted remaining transactions
+	heads  txHeap                                // Next transaction of each account
+	signer types.Signer                          // Signer to derive the accounts with
+}
+
+// Peek implements TxOrdering, returning the best head transaction.
+func (t *headOrdering) Peek() *types.Transaction {
+	if len(t.heads.txs) == 0 {
+		return nil
+	}
+	return t.heads.txs[0]
+}
+
+// Shift implements TxOrdering, replacing the best head with the next transaction
+// from the same account.
+func (t *headOrdering) Shift() {
+	acc, _ := types.Sender(t.signer, t.heads.txs[0])
+	if txs := t.txs[acc]; len(txs) > 0 {
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+// Pop implements TxOrdering, dropping the best head and its account.
+func (t *headOrdering) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// txHeap is a heap of transactions sorted by an arbitrary comparator.
+type txHeap struct {
+	txs  []*types.Transaction
+	less func(a, b *types.Transaction) bool
+}
+
+func (h txHeap) Len() int            { return len(h.txs) }
+func (h txHeap) Less(i, j int) bool  { return h.less(h.txs[i], h.txs[j]) }
+func (h txHeap) Swap(i, j int)       { h.txs[i], h.txs[j] = h.txs[j], h.txs[i] }
+func (h *txHeap) Push(x interface{}) { h.txs = append(h.txs, x.(*types.Transaction)) }
+
+func (h *txHeap) Pop() interface{} {
+	old := h.txs
+	n := len(old)
+	x := old[n-1]
+	h.txs = old[:n-1]
+	return x
+}
+
+// RoundRobinOrdering packs one transaction of every account in turn, so no single
+// sender can crowd out the others. Accounts take turns in the order their first
+// transaction was seen.
+func RoundRobinOrdering(signer types.Signer, pending map[common.Address]types.Transactions) TxOrdering {
+	t := &roundRobinOrdering{txs: make(map[common.Address]types.Transactions), signer: signer}
+	for _, txs := range pending {
+		acc, _ := types.Sender(signer, txs[0])
+		t.txs[acc] = txs
+		t.turns = append(t.turns, acc)
+	}
+	sort.Slice(t.turns, func(i, j int) bool {
+		ti, tj := t.txs[t.turns[i]][0].FirstSeen(), t.txs[t.turns[j]][0].FirstSeen()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return bytes.Compare(t.turns[i][:], t.turns[j][:]) < 0
+	})
+	return t
+}
+
+// roundRobinOrdering cycles through the accounts, yielding the next transaction
+// of each in turn.
+type roundRobinOrdering struct {
+	txs    map[common.Address]types.Transactions // Per account nonce-sorted remaining transactions
+	turns  []common.Address                      // Accounts in the order of their next turn
+	signer types.Signer                          // Signer to derive the accounts with
+}
+
+// Peek implements TxOrdering, returning the next transaction of the account whose
+// turn it is.
+func (t *roundRobinOrdering) Peek() *types.Transaction {
+	if len(t.turns) == 0 {
+		return nil
+	}
+	return t.txs[t.turns[0]][0]
+}
+
+// Shift implements TxOrdering, consuming the current transaction and passing the
+// turn to the next account.
+func (t *roundRobinOrdering) Shift() {
+	acc := t.turns[0]
+	if t.txs[acc] = t.txs[acc][1:]; len(t.txs[acc]) > 0 {
+		t.turns = append(t.turns[1:], acc)
+	} else {
+		t.Pop()
+	}
+}
+
+// Pop implements TxOrdering, dropping the account whose turn it is.
+func (t *roundRobinOrdering) Pop() {
+	delete(t.txs, t.turns[0])
+	t.turns = t.turns[1:]
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// orderingTestTx describes a transaction of the ordering tests: the account
+// sending it, its nonce and its gas price.
+type orderingTestTx struct {
+	account int
+	nonce   uint64
+	price   int64
+}
+
+// makeOrderingTestTxs signs the described transactions in order, ensuring every
+// one of them is seen strictly later than the previous. It returns the pending
+// transactions per account and the transactions in creation order.
+func makeOrderingTestTxs(t *testing.T, keys []*ecdsa.PrivateKey, specs []orderingTestTx) (map[common.Address]types.Transactions, []*types.Transaction) {
+	var (
+		signer  = types.HomesteadSigner{}
+		pending = make(map[common.Address]types.Transactions)
+		created []*types.Transaction
+	)
+	for _, spec := range specs {
+		tx, err := types.SignTx(types.NewTransaction(spec.nonce, common.Address{}, new(big.Int), big.NewInt(21000), big.NewInt(spec.price), nil), signer, keys[spec.account])
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		for !time.Now().After(tx.FirstSeen()) {
+		}
+		addr := crypto.PubkeyToAddress(keys[spec.account].PublicKey)
+		pending[addr] = append(pending[addr], tx)
+		created = append(created, tx)
+	}
+	return pending, created
+}
+
+// Tests that the ordering policies yield the pending transactions in their
+// characteristic order, and all of them in nonce order per account.
+func TestTxOrderings(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	specs := []orderingTestTx{
+		{0, 0, 1}, {1, 0, 2}, {0, 1, 1}, {2, 0, 5}, {1, 1, 2}, {0, 2, 1},
+	}
+	tests := []struct {
+		policy string
+		order  []int // Indices into the specs in the expected yield order
+	}{
+		{"price", []int{3, 1, 4, 0, 2, 5}},
+		{"fifo", []int{0, 1, 2, 3, 4, 5}},
+		{"roundrobin", []int{0, 1, 3, 2, 4, 5}},
+	}
+	for _, tt := range tests {
+		policy, err := LookupTxPolicy(tt.policy)
+		if err != nil {
+			t.Fatalf("%s: failed to look up policy: %v", tt.policy, err)
+		}
+		pending, created := makeOrderingTestTxs(t, keys, specs)
+		ordering := policy(types.HomesteadSigner{}, pending)
+
+		for i, index := range tt.order {
+			tx := ordering.Peek()
+			if tx == nil {
+				t.Fatalf("%s: ordering exhausted after %d transactions", tt.policy, i)
+			}
+			if tx != created[index] {
+				t.Errorf("%s: transaction %d mismatch: have %x (nonce %d), want spec %d", tt.policy, i, tx.Hash().Bytes()[:4], tx.Nonce(), index)
+			}
+			ordering.Shift()
+		}
+		if tx := ordering.Peek(); tx != nil {
+			t.Errorf("%s: excess transaction yielded: %x", tt.policy, tx.Hash())
+		}
+	}
+}
+
+// Tests that popping a transaction drops all subsequent ones of its account.
+func TestTxOrderingPop(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	specs := []orderingTestTx{{0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {1, 1, 1}}
+
+	for _, name := range TxPolicies() {
+		policy, _ := LookupTxPolicy(name)
+		pending, created := makeOrderingTestTxs(t, keys, specs)
+		ordering := policy(types.HomesteadSigner{}, pending)
+
+		// Drop whichever account comes first and ensure only the other remains
+		first := ordering.Peek()
+		ordering.Pop()
+
+		dropped, _ := types.Sender(types.HomesteadSigner{}, first)
+		var yielded int
+		for tx := ordering.Peek(); tx != nil; tx = ordering.Peek() {
+			if from, _ := types.Sender(types.HomesteadSigner{}, tx); from == dropped {
+				t.Errorf("%s: transaction of popped account yielded", name)
+			}
+			yielded++
+			ordering.Shift()
+		}
+		if yielded != len(created)/2 {
+			t.Errorf("%s: yielded transactions mismatch: have %d, want %d", name, yielded, len(created)/2)
+		}
+	}
+	if _, err := LookupTxPolicy("unknown"); err == nil {
+		t.Errorf("unknown policy looked up")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+const (
+	// DefaultRecommitInterval is the default interval at which the block being
+	// sealed is rebuilt with the latest pending transactions.
+	DefaultRecommitInterval = 3 * time.Second
+
+	// minRecommitInterval and maxRecommitInterval bound the adaptive interval.
+	minRecommitInterval = time.Second
+	maxRecommitInterval = 15 * time.Second
+
+	// recommitCostRatio is the minimum ratio between the recommit interval and
+	// the time a rebuild takes, so rebuilding never dominates the sealing window.
+	recommitCostRatio = 10
+)
+
+// recalcRecommit moves the current recommit interval a third of the way towards
+// the cost of the last rebuild, but never below the configured base interval.
+func recalcRecommit(base, current, elapsed time.Duration) time.Duration {
+	target := elapsed * recommitCostRatio
+	if target < base {
+		target = base
+	}
+	next := current + (target-current)/3
+	if next < minRecommitInterval {
+		next = minRecommitInterval
+	}
+	if next > maxRecommitInterval {
+		next = maxRecommitInterval
+	}
+	return next
+}
+
+// fees returns the amount the coinbase earns from the work's transactions. It is
+// measured on the coinbase balance rather than derived from gas prices, so fee
+// burns, treasury cuts, free decryption keys and direct payments are accounted.
+func (env *Work) fees() *big.Int {
+	return new(big.Int).Set(env.profit)
+}
+
+func (self *worker) setRecommitInterval(interval time.Duration) {
+	if interval < minRecommitInterval {
+		log.Warn("Sanitizing miner recommit interval", "provided", interval, "updated", minRecommitInterval)
+		interval = minRecommitInterval
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.recommitBase = interval
+}
+
+func (self *worker) recommitInterval() time.Duration {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.recommitBase
+}
+
+// recommit rebuilds the block being sealed on top of the same parent, and swaps
+// it in if it pays more fees. It returns the time the rebuild took, and whether
+// a rebuild was attempted at all.
+func (self *worker) recommit() (time.Duration, bool) {
+	if atomic.LoadInt32(&self.mining) == 0 {
+		return 0, false
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.uncleMu.Lock()
+	defer self.uncleMu.Unlock()
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+
+	// Only rebuild while the current block is still waiting to be sealed
+	current, parent := self.current, self.chain.CurrentBlock()
+	if current == nil || current.Block == nil || current.header.ParentHash != parent.Hash() {
+		return 0, false
+	}
+	tstart := time.Now()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Set(current.header.Number),
+		GasLimit:   new(big.Int).Set(current.header.GasLimit),
+		GasUsed:    new(big.Int),
+		Extra:      self.extra,
+		Time:       new(big.Int).Set(current.header.Time),
+		Coinbase:   self.coinbase,
+	}
+	work, err := self.buildWork(parent, header)
+	elapsed := time.Since(tstart)
+	if err != nil {
+		return elapsed, true
+	}
+	oldFees, newFees := current.fees(), work.fees()
+	if newFees.Cmp(oldFees) <= 0 {
+		log.Trace("Discarded rebuilt mining work", "number", work.Block.Number(), "fees", newFees, "current", oldFees, "elapsed", common.PrettyDuration(elapsed))
+		return elapsed, true
+	}
+	self.current = work
+
+	log.Info("Commit rebuilt mining work", "number", work.Block.Number(), "txs", work.tcount, "fees", newFees, "previous", oldFees, "elapsed", common.PrettyDuration(elapsed))
+	self.push(work)
+	return elapsed, true
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/params"
+)
+
+// Tests that the recommit interval follows the cost of the rebuilds within the
+// configured base and the hard bounds.
+func TestRecalcRecommit(t *testing.T) {
+	tests := []struct {
+		base, current, elapsed time.Duration
+		want                   time.Duration
+	}{
+		{3 * time.Second, 3 * time.Second, 10 * time.Millisecond, 3 * time.Second},  // Cheap rebuild: stay at the base
+		{3 * time.Second, 9 * time.Second, 10 * time.Millisecond, 7 * time.Second},  // Cheap rebuild: decay towards the base
+		{3 * time.Second, 3 * time.Second, 600 * time.Millisecond, 4 * time.Second}, // Expensive rebuild: back off
+		{3 * time.Second, 14 * time.Second, 5 * time.Second, maxRecommitInterval},   // Capped at the maximum
+		{time.Millisecond, time.Second, time.Millisecond, minRecommitInterval},      // Floored at the minimum
+	}
+	for i, tt := range tests {
+		if have := recalcRecommit(tt.base, tt.current, tt.elapsed); have != tt.want {
+			t.Errorf("test %d: interval mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that the block being sealed is only replaced by a rebuilt one if the
+// latter pays more fees.
+func TestRecommit(t *testing.T) {
+	engine := fbcash.NewFaker()
+	backend := newTestBackend(t, engine)
+	worker := newWorker(params.TestChainConfig, engine, common.Address{0xc0}, backend, new(event.TypeMux))
+	atomic.StoreInt32(&worker.mining, 1)
+
+	worker.commitNewWork()
+	empty := worker.current
+	if len(empty.txs) != 0 {
+		t.Fatalf("initial work contains %d transactions", len(empty.txs))
+	}
+	// Rebuilding without new transactions must keep the current work
+	if _, ok := worker.recommit(); !ok {
+		t.Fatalf("recommit not attempted")
+	}
+	if worker.current != empty {
+		t.Fatalf("work without extra fees swapped in")
+	}
+	// Rebuilding with a new fee paying transaction must swap it in
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(2), nil), signer, bundleTestKey)
+	if err := backend.txPool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if _, ok := worker.recommit(); !ok {
+		t.Fatalf("recommit not attempted")
+	}
+	current := worker.current
+	if current == empty || len(current.txs) != 1 || current.txs[0].Hash() != tx.Hash() {
+		t.Fatalf("rebuilt work not swapped in")
+	}
+	if current.header.Time.Cmp(empty.header.Time) != 0 || current.Block.NumberU64() != empty.Block.NumberU64() {
+		t.Errorf("rebuilt work header mismatch: number %d, time %v", current.Block.NumberU64(), current.header.Time)
+	}
+	// Nothing is rebuilt when not mining
+	atomic.StoreInt32(&worker.mining, 0)
+	if _, ok := worker.recommit(); ok {
+		t.Errorf("recommit attempted while not mining")
+	}
+}
+
+// Tests that the fees of a work are what the coinbase actually earns, not what
+// the transactions pay, if the chain diverts the fees elsewhere.
+func TestWorkFees(t *testing.T) {
+	coinbase := common.HexToAddress("0xc0")
+	treasury := common.HexToAddress("0x7ea5")
+
+	tests := []struct {
+		rewards *params.RewardsConfig
+		fees    *big.Int
+	}{
+		{nil, bundleTestGasUsed},
+		{&params.RewardsConfig{FeeSink: params.FeeSinkCoinbase}, bundleTestGasUsed},
+		{&params.RewardsConfig{FeeSink: params.FeeSinkBurn}, new(big.Int)},
+		{&params.RewardsConfig{FeeSink: params.FeeSinkTreasury, Treasury: treasury}, new(big.Int)},
+		{&params.RewardsConfig{FeeSink: params.FeeSinkTreasury, Treasury: coinbase}, bundleTestGasUsed},
+	}
+	for i, tt := range tests {
+		work := newBundleTestWork(t)
+		config := *params.TestChainConfig
+		config.Rewards = tt.rewards
+		work.config = &config
+
+		work.state.Prepare(common.Hash{}, common.Hash{}, 0)
+		if err, _ := work.commitTransaction(bundleTestTx(t, work, 0, common.Address{1}, 21000), nil, coinbase, new(core.GasPool).AddGas(work.header.GasLimit)); err != nil {
+			t.Fatalf("test %d: failed to commit transaction: %v", i, err)
+		}
+		if fees := work.fees(); fees.Cmp(tt.fees) != 0 {
+			t.Errorf("test %d: fees mismatch: have %v, want %v", i, fees, tt.fees)
+		}
+	}
+}
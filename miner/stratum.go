@@ -0,0 +1,642 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/consensus"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// DefaultStratumDifficulty is the default difficulty of the shares submitted to
+// the Stratum server.
+const DefaultStratumDifficulty = 1 << 32
+
+const (
+	stratumMaxSessions    = 1024                 // Maximum number of concurrently connected miners
+	stratumMaxRequest     = 4096                 // Maximum size of a single request line
+	stratumMaxWorkerName  = 64                   // Maximum length of a worker name
+	stratumMaxWorkers     = 4096                 // Maximum number of tracked worker names
+	stratumMaxAuthorize   = 16                   // Maximum number of authorizations per connection
+	stratumIdleTimeout    = 10 * time.Minute     // Time after which silent connections are dropped
+	stratumWriteTimeout   = 10 * time.Second     // Time allowed to push a message to a miner
+	stratumJobExpiry      = 7 * 12 * time.Second // Time after which shares of old work are stale
+	stratumHashrateWindow = 10 * time.Minute     // Period over which worker hashrates are estimated
+)
+
+// Stratum error codes reported to miners, as used by existing pool software.
+var (
+	errStratumUnknown       = &stratumError{20, "other/unknown"}
+	errStratumStale         = &stratumError{21, "job not found"}
+	errStratumDuplicate     = &stratumError{22, "duplicate share"}
+	errStratumLowDifficulty = &stratumError{23, "low difficulty share"}
+	errStratumUnauthorized  = &stratumError{24, "unauthorized worker"}
+	errStratumUnsubscribed  = &stratumError{25, "not subscribed"}
+	errStratumNoMethod      = &stratumError{-32601, "method not found"}
+	errStratumInvalidParams = &stratumError{-32602, "invalid params"}
+)
+
+// stratumMaxTarget is the share target of difficulty 1, 2^256-1.
+var stratumMaxTarget = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+
+// errStratumClosed is returned by Serve once the Stratum server is closed.
+var errStratumClosed = errors.New("stratum server closed")
+
+// ShareVerifier is a consensus engine able to verify partial proof-of-work
+// solutions against a difficulty lower than the block's.
+type ShareVerifier interface {
+	consensus.Engine
+
+	// VerifyShare checks whether the header's seal satisfies the given difficulty.
+	VerifyShare(header *types.Header, difficulty *big.Int) error
+}
+
+// StratumWorkerStats is the share accounting of a single Stratum worker.
+type StratumWorkerStats struct {
+	Name      string    // Name the worker authorized with
+	Hashrate  uint64    // Hashrate estimated from the accepted shares, in hashes per second
+	Accepted  uint64    // Number of valid shares submitted
+	Rejected  uint64    // Number of invalid or duplicate shares submitted
+	Stale     uint64    // Number of shares submitted for expired work
+	Blocks    uint64    // Number of shares that were valid block solutions
+	LastShare time.Time // Time of the last accepted share
+}
+
+// stratumShare is an accepted share, remembered to estimate hashrates.
+type stratumShare struct {
+	time       time.Time
+	difficulty *big.Int
+}
+
+// stratumWorker tracks the shares of a named worker across its connections.
+type stratumWorker struct {
+	stats    StratumWorkerStats
+	shares   []stratumShare // Accepted shares within the hashrate window
+	since    time.Time      // Time the worker was first seen
+	sessions int            // Number of connections authorized as the worker
+}
+
+// hashrate estimates the worker's hashrate from its recent shares, each of them
+// being worth its difficulty in hashes on average.
+func (w *stratumWorker) hashrate(now time.Time) uint64 {
+	for len(w.shares) > 0 && now.Sub(w.shares[0].time) > stratumHashrateWindow {
+		w.shares = w.shares[1:]
+	}
+	window := now.Sub(w.since)
+	if window > stratumHashrateWindow {
+		window = stratumHashrateWindow
+	}
+	if window < time.Second {
+		window = time.Second
+	}
+	hashes := new(big.Int)
+	for _, share := range w.shares {
+		hashes.Add(hashes, share.difficulty)
+	}
+	return hashes.Div(hashes, big.NewInt(int64(window/time.Second))).Uint64()
+}
+
+// stratumJob is a work package handed out to the connected miners.
+type stratumJob struct {
+	work   *Work
+	nonces map[types.BlockNonce]struct{} // Nonces submitted for the job, to reject duplicates
+}
+
+// stratumSession is a single miner connection.
+type stratumSession struct {
+	conn   net.Conn
+	writer *bufio.Writer
+	lock   sync.Mutex // Serializes writes to the connection
+
+	subscribed bool   // Whether the miner asked for work notifications (guarded by server lock)
+	worker     string // Name of the authorized worker (guarded by server lock)
+	authorized int    // Number of authorization attempts (guarded by server lock)
+}
+
+// send writes a single newline delimited JSON message to the miner.
+func (s *stratumSession) send(msg interface{}) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	if _, err := s.writer.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	return s.writer.Flush()
+}
+
+// stratumRequest is a JSON-RPC request sent by a miner.
+type stratumRequest struct {
+	Id     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// stratumResponse is a JSON-RPC response sent to a miner.
+type stratumResponse struct {
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *stratumError   `json:"error,omitempty"`
+}
+
+// stratumNotification is a JSON-RPC notification pushed to a miner.
+type stratumNotification struct {
+	Version string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// stratumError is a JSON-RPC error reported to a miner.
+type stratumError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// StratumServer is a mining agent handing out work to remote miners over the
+// Stratum protocol: newline delimited JSON-RPC over TCP. Unlike the polling
+// RemoteAgent, new work is pushed to the miners as soon as it is available.
+// Miners submit shares at the configured share difficulty, which are verified
+// and accounted per worker; shares also solving the block are sealed.
+//
+// The supported methods are:
+//
+//	mining.subscribe([agent])                         -> true, followed by the current job
+//	mining.authorize(worker, password)                -> true
+//	mining.submit(worker, headerHash, nonce, mixHash) -> true
+//
+// and jobs are pushed as mining.notify(headerHash, seedHash, shareTarget, clean).
+//
+// If a password is configured, workers must authorize with it. Otherwise any
+// credentials are accepted, but every connection may only authorize a limited
+// number of times and only a limited number of worker names are tracked.
+type StratumServer struct {
+	chain      consensus.ChainReader
+	engine     ShareVerifier
+	difficulty *big.Int // Difficulty of the shares accepted from the miners
+	password   string   // Password the workers must authorize with (empty = any)
+
+	quitCh   chan struct{}
+	workCh   chan *Work
+	returnCh chan<- *Result
+
+	listener net.Listener
+	current  *Work
+	jobs     map[common.Hash]*stratumJob
+	sessions map[*stratumSession]struct{}
+	workers  map[string]*stratumWorker
+	closed   bool
+	lock     sync.Mutex // Protects the fields above from concurrent access
+
+	running int32 // running indicates whether the agent is active. Call atomically
+}
+
+// NewStratumServer creates a Stratum mining agent accepting shares of the given
+// difficulty, verified by the engine, from workers authorizing with the password.
+func NewStratumServer(chain consensus.ChainReader, engine ShareVerifier, difficulty *big.Int, password string) *StratumServer {
+	return &StratumServer{
+		chain:      chain,
+		engine:     engine,
+		difficulty: new(big.Int).Set(difficulty),
+		password:   password,
+		jobs:       make(map[common.Hash]*stratumJob),
+		sessions:   make(map[*stratumSession]struct{}),
+		workers:    make(map[string]*stratumWorker),
+	}
+}
+
+func (s *StratumServer) Work() chan<- *Work {
+	return s.workCh
+}
+
+func (s *StratumServer) SetReturnCh(returnCh chan<- *Result) {
+	s.returnCh = returnCh
+}
+
+func (s *StratumServer) Start() {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	s.quitCh = make(chan struct{})
+	s.workCh = make(chan *Work, 1)
+	go s.loop(s.workCh, s.quitCh)
+}
+
+func (s *StratumServer) Stop() {
+	if !atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		return
+	}
+	close(s.quitCh)
+	close(s.workCh)
+}
+
+// GetHashRate returns the estimated hashrate of all the workers combined.
+func (s *StratumServer) GetHashRate() (tot int64) {
+	for _, stats := range s.Workers() {
+		tot += int64(stats.Hashrate)
+	}
+	return tot
+}
+
+// Workers returns the share accounting of the known workers, sorted by name.
+func (s *StratumServer) Workers() []StratumWorkerStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	stats := make([]StratumWorkerStats, 0, len(s.workers))
+	for _, worker := range s.workers {
+		worker.stats.Hashrate = worker.hashrate(now)
+		stats = append(stats, worker.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Serve accepts miner connections on the listener until the server is closed.
+func (s *StratumServer) Serve(listener net.Listener) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		listener.Close()
+		return errStratumClosed
+	}
+	s.listener = listener
+	s.lock.Unlock()
+
+	log.Info("Stratum endpoint opened", "addr", listener.Addr(), "difficulty", s.difficulty)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return errStratumClosed
+			}
+			if tempErr, ok := err.(net.Error); ok && tempErr.Temporary() {
+				log.Debug("Temporary Stratum accept error", "err", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			return err
+		}
+		session := &stratumSession{conn: conn, writer: bufio.NewWriter(conn)}
+
+		s.lock.Lock()
+		if len(s.sessions) >= stratumMaxSessions {
+			s.lock.Unlock()
+			log.Debug("Rejecting Stratum connection, too many miners", "addr", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		s.sessions[session] = struct{}{}
+		s.lock.Unlock()
+
+		go s.handle(session)
+	}
+}
+
+// Close stops accepting miner connections and drops the connected ones.
+func (s *StratumServer) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.listener != nil {
+		s.listener.Close()
+		log.Info("Stratum endpoint closed", "addr", s.listener.Addr())
+	}
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+}
+
+// handle serves the requests of a single miner until it disconnects.
+func (s *StratumServer) handle(session *stratumSession) {
+	defer s.drop(session)
+
+	log.Debug("Stratum miner connected", "addr", session.conn.RemoteAddr())
+	reader := bufio.NewReaderSize(session.conn, stratumMaxRequest)
+	for {
+		session.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+		if isPrefix {
+			log.Debug("Oversized Stratum request", "addr", session.conn.RemoteAddr())
+			return
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			log.Debug("Malformed Stratum request", "addr", session.conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, serr := s.dispatch(session, &req)
+		res := &stratumResponse{Version: "2.0", Id: req.Id, Result: result, Error: serr}
+		if serr != nil {
+			res.Result = nil
+		}
+		if err := session.send(res); err != nil {
+			return
+		}
+		if req.Method == "mining.subscribe" && serr == nil {
+			s.lock.Lock()
+			work := s.current
+			s.lock.Unlock()
+			if work != nil {
+				if err := session.send(s.notification(work, true)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// drop disconnects a miner and releases its worker.
+func (s *StratumServer) drop(session *stratumSession) {
+	session.conn.Close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if session.worker != "" {
+		s.workers[session.worker].sessions--
+	}
+	delete(s.sessions, session)
+	log.Debug("Stratum miner disconnected", "addr", session.conn.RemoteAddr())
+}
+
+// dispatch executes a single miner request.
+func (s *StratumServer) dispatch(session *stratumSession, req *stratumRequest) (interface{}, *stratumError) {
+	switch req.Method {
+	case "mining.subscribe":
+		s.lock.Lock()
+		session.subscribed = true
+		s.lock.Unlock()
+		return true, nil
+
+	case "mining.authorize":
+		var name, password string
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &name) != nil || name == "" || len(name) > stratumMaxWorkerName {
+			return nil, errStratumInvalidParams
+		}
+		if len(req.Params) > 1 && json.Unmarshal(req.Params[1], &password) != nil {
+			return nil, errStratumInvalidParams
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if session.authorized++; session.authorized > stratumMaxAuthorize {
+			return nil, errStratumUnauthorized
+		}
+		if s.password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(s.password)) != 1 {
+			log.Debug("Stratum worker failed to authorize", "worker", name, "addr", session.conn.RemoteAddr())
+			return nil, errStratumUnauthorized
+		}
+		worker := s.workers[name]
+		if worker == nil {
+			if len(s.workers) >= stratumMaxWorkers && !s.evictWorker(time.Now()) {
+				return nil, errStratumUnauthorized
+			}
+			worker = &stratumWorker{stats: StratumWorkerStats{Name: name}, since: time.Now()}
+			s.workers[name] = worker
+		}
+		if session.worker != "" {
+			s.workers[session.worker].sessions--
+		}
+		worker.sessions++
+		session.worker = name
+		return true, nil
+
+	case "mining.submit":
+		var (
+			name      string
+			hash, mix common.Hash
+			nonce     hexutil.Bytes
+		)
+		if len(req.Params) < 4 || json.Unmarshal(req.Params[0], &name) != nil || json.Unmarshal(req.Params[1], &hash) != nil ||
+			json.Unmarshal(req.Params[2], &nonce) != nil || json.Unmarshal(req.Params[3], &mix) != nil || len(nonce) != len(types.BlockNonce{}) {
+			return nil, errStratumInvalidParams
+		}
+		s.lock.Lock()
+		subscribed, worker := session.subscribed, session.worker
+		s.lock.Unlock()
+
+		if !subscribed {
+			return nil, errStratumUnsubscribed
+		}
+		if worker == "" || worker != name {
+			return nil, errStratumUnauthorized
+		}
+		var blockNonce types.BlockNonce
+		copy(blockNonce[:], nonce)
+		if err := s.submit(worker, hash, blockNonce, mix); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	default:
+		return nil, errStratumNoMethod
+	}
+}
+
+// evictWorker drops a disconnected worker without shares in the hashrate window
+// to make room for a new one, reporting whether one was found. The caller must
+// hold the server lock.
+func (s *StratumServer) evictWorker(now time.Time) bool {
+	for name, worker := range s.workers {
+		if worker.sessions == 0 && now.Sub(worker.stats.LastShare) > stratumHashrateWindow {
+			delete(s.workers, name)
+			return true
+		}
+	}
+	return false
+}
+
+// submit verifies a share of a worker, sealing the block if the share solves it.
+func (s *StratumServer) submit(name string, hash common.Hash, nonce types.BlockNonce, mix common.Hash) *stratumError {
+	// Make sure the work submitted is present and the share is new
+	s.lock.Lock()
+	worker, job := s.workers[name], s.jobs[hash]
+	if job == nil {
+		worker.stats.Stale++
+		s.lock.Unlock()
+		return errStratumStale
+	}
+	if _, ok := job.nonces[nonce]; ok {
+		worker.stats.Rejected++
+		s.lock.Unlock()
+		return errStratumDuplicate
+	}
+	s.lock.Unlock()
+
+	// Verify the share, and the block solution if the share turns out to be one
+	work := job.work
+	header := work.Block.Header()
+	header.Nonce, header.MixDigest = nonce, mix
+
+	difficulty := s.shareDifficulty(header.Difficulty)
+	if err := s.engine.VerifyShare(header, difficulty); err != nil {
+		log.Debug("Invalid Stratum share submitted", "worker", name, "hash", hash, "err", err)
+
+		s.lock.Lock()
+		worker.stats.Rejected++
+		s.lock.Unlock()
+		return errStratumLowDifficulty
+	}
+	solved := difficulty.Cmp(header.Difficulty) == 0 || s.engine.VerifySeal(s.chain, header) == nil
+
+	// Only record verified shares, invalid ones must not block honest resubmits
+	s.lock.Lock()
+	if _, ok := job.nonces[nonce]; ok {
+		worker.stats.Rejected++
+		s.lock.Unlock()
+		return errStratumDuplicate // Submitted concurrently
+	}
+	job.nonces[nonce] = struct{}{}
+
+	now := time.Now()
+	worker.stats.Accepted++
+	worker.stats.LastShare = now
+	worker.shares = append(worker.shares, stratumShare{now, difficulty})
+	if solved {
+		if _, ok := s.jobs[hash]; !ok {
+			solved = false // Another share solved the block concurrently
+		} else {
+			worker.stats.Blocks++
+			delete(s.jobs, hash)
+		}
+	}
+	returnCh := s.returnCh
+	s.lock.Unlock()
+
+	if solved {
+		log.Info("Stratum worker solved block", "worker", name, "number", header.Number, "hash", hash)
+		returnCh <- &Result{work, work.Block.WithSeal(header)}
+	}
+	return nil
+}
+
+// shareDifficulty returns the difficulty of the shares of a block, which never
+// exceeds the block's own difficulty.
+func (s *StratumServer) shareDifficulty(block *big.Int) *big.Int {
+	if s.difficulty.Cmp(block) > 0 {
+		return block
+	}
+	return s.difficulty
+}
+
+// notification creates the job notification of a work package.
+func (s *StratumServer) notification(work *Work, clean bool) *stratumNotification {
+	block := work.Block
+
+	target := new(big.Int).Div(stratumMaxTarget, s.shareDifficulty(block.Difficulty()))
+
+	return &stratumNotification{
+		Version: "2.0",
+		Method:  "mining.notify",
+		Params: []interface{}{
+			block.HashNoNonce(),
+			common.BytesToHash(fbcash.SeedHash(block.NumberU64())),
+			common.BytesToHash(target.Bytes()),
+			clean,
+		},
+	}
+}
+
+// loop hands out new work to the subscribed miners and expires old work and
+// workers until the agent is stopped.
+//
+// Note, the work and quit channels are passed as parameters because Start()
+// recreates them, so the loop code cannot assume data stability in the fields.
+func (s *StratumServer) loop(workCh chan *Work, quitCh chan struct{}) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quitCh:
+			return
+
+		case work := <-workCh:
+			if work == nil {
+				continue
+			}
+			s.lock.Lock()
+			s.current = work
+			s.jobs[work.Block.HashNoNonce()] = &stratumJob{work: work, nonces: make(map[types.BlockNonce]struct{})}
+
+			var sessions []*stratumSession
+			for session := range s.sessions {
+				if session.subscribed {
+					sessions = append(sessions, session)
+				}
+			}
+			s.lock.Unlock()
+
+			notification := s.notification(work, true)
+			for _, session := range sessions {
+				go func(session *stratumSession) {
+					if err := session.send(notification); err != nil {
+						session.conn.Close()
+					}
+				}(session)
+			}
+
+		case <-ticker.C:
+			s.lock.Lock()
+			for hash, job := range s.jobs {
+				if job.work != s.current && time.Since(job.work.createdAt) > stratumJobExpiry {
+					delete(s.jobs, hash)
+				}
+			}
+			now := time.Now()
+			for name, worker := range s.workers {
+				if worker.sessions == 0 && worker.hashrate(now) == 0 && now.Sub(worker.since) > stratumHashrateWindow {
+					delete(s.workers, name)
+				}
+			}
+			s.lock.Unlock()
+		}
+	}
+}
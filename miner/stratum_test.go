@@ -0,0 +1,267 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core/types"
+)
+
+// stratumTestMiner is a fake remote miner speaking Stratum to the server.
+type stratumTestMiner struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+	jobs   []common.Hash // Header hashes of the notified jobs
+}
+
+func newStratumTestMiner(t *testing.T, addr net.Addr) *stratumTestMiner {
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	return &stratumTestMiner{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// read retrieves the next message pushed by the server, recording jobs.
+func (m *stratumTestMiner) read() map[string]json.RawMessage {
+	m.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := m.reader.ReadBytes('\n')
+	if err != nil {
+		m.t.Fatalf("failed to read stratum message: %v", err)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		m.t.Fatalf("failed to decode stratum message %s: %v", line, err)
+	}
+	if method, ok := msg["method"]; ok && string(method) == `"mining.notify"` {
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg["params"], &params); err != nil || len(params) != 4 {
+			m.t.Fatalf("malformed job notification: %s", line)
+		}
+		var hash common.Hash
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			m.t.Fatalf("malformed job header hash: %v", err)
+		}
+		m.jobs = append(m.jobs, hash)
+	}
+	return msg
+}
+
+// call sends a request and waits for its response, returning the error code
+// reported by the server, zero on success.
+func (m *stratumTestMiner) call(method string, params ...interface{}) int {
+	m.id++
+	blob, _ := json.Marshal(map[string]interface{}{"id": m.id, "method": method, "params": params})
+	if _, err := m.conn.Write(append(blob, '\n')); err != nil {
+		m.t.Fatalf("failed to send %s: %v", method, err)
+	}
+	for {
+		msg := m.read()
+		if id, ok := msg["id"]; !ok || string(id) != string(mustMarshal(m.id)) {
+			continue
+		}
+		if raw, ok := msg["error"]; ok {
+			var serr stratumError
+			if err := json.Unmarshal(raw, &serr); err != nil {
+				m.t.Fatalf("malformed error for %s: %s", method, raw)
+			}
+			return serr.Code
+		}
+		if string(msg["result"]) != "true" {
+			m.t.Fatalf("unexpected result for %s: %s", method, msg["result"])
+		}
+		return 0
+	}
+}
+
+// waitJob waits until the server notified the job with the given header hash.
+func (m *stratumTestMiner) waitJob(hash common.Hash) {
+	for {
+		for _, job := range m.jobs {
+			if job == hash {
+				return
+			}
+		}
+		m.read()
+	}
+}
+
+func mustMarshal(v interface{}) []byte {
+	blob, _ := json.Marshal(v)
+	return blob
+}
+
+// newStratumTestWork creates a work package for a block of the given difficulty.
+func newStratumTestWork(difficulty int64, extra string) *Work {
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(difficulty), Extra: []byte(extra)}
+	return &Work{Block: types.NewBlockWithHeader(header), createdAt: time.Now()}
+}
+
+// Tests that a Stratum miner is pushed work, gets its shares verified and
+// accounted, and that shares solving the block seal it.
+func TestStratumServer(t *testing.T) {
+	engine := fbcash.NewTester()
+	results := make(chan *Result, 1)
+
+	server := NewStratumServer(nil, engine, big.NewInt(1), "")
+	server.SetReturnCh(results)
+	server.Start()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	miner := newStratumTestMiner(t, listener.Addr())
+	defer miner.conn.Close()
+
+	if code := miner.call("mining.submit", "rig", common.Hash{}, hexutil.Bytes(make([]byte, 8)), common.Hash{}); code != errStratumUnsubscribed.Code {
+		t.Fatalf("unsubscribed submission error mismatch: have %d, want %d", code, errStratumUnsubscribed.Code)
+	}
+	if code := miner.call("mining.subscribe", "test/1.0"); code != 0 {
+		t.Fatalf("failed to subscribe: %d", code)
+	}
+	if code := miner.call("mining.submit", "rig", common.Hash{}, hexutil.Bytes(make([]byte, 8)), common.Hash{}); code != errStratumUnauthorized.Code {
+		t.Fatalf("unauthorized submission error mismatch: have %d, want %d", code, errStratumUnauthorized.Code)
+	}
+	if code := miner.call("mining.authorize", "rig", "x"); code != 0 {
+		t.Fatalf("failed to authorize: %d", code)
+	}
+	// Push a work package too hard to solve and submit shares for it
+	work := newStratumTestWork(1<<40, "share")
+	server.Work() <- work
+	hash := work.Block.HashNoNonce()
+	miner.waitJob(hash)
+
+	submit := func(hash common.Hash, nonce uint64, mix common.Hash) int {
+		blockNonce := types.EncodeNonce(nonce)
+		return miner.call("mining.submit", "rig", hash, hexutil.Bytes(blockNonce[:]), mix)
+	}
+	mix, _ := engine.Hashimoto(work.Block.Header(), 0)
+	if code := submit(hash, 0, mix); code != 0 {
+		t.Fatalf("valid share rejected: %d", code)
+	}
+	if code := submit(hash, 0, mix); code != errStratumDuplicate.Code {
+		t.Errorf("duplicate share error mismatch: have %d, want %d", code, errStratumDuplicate.Code)
+	}
+	for i := 0; i < 2; i++ {
+		// Invalid shares must not be recorded, or resubmits would be duplicates
+		if code := submit(hash, 1, mix); code != errStratumLowDifficulty.Code {
+			t.Errorf("invalid share %d error mismatch: have %d, want %d", i, code, errStratumLowDifficulty.Code)
+		}
+	}
+	if code := submit(common.Hash{1}, 0, mix); code != errStratumStale.Code {
+		t.Errorf("stale share error mismatch: have %d, want %d", code, errStratumStale.Code)
+	}
+	select {
+	case <-results:
+		t.Fatalf("share sealed block above its difficulty")
+	default:
+	}
+	// Push a work package easy enough to solve and submit a solution
+	work = newStratumTestWork(2, "block")
+	server.Work() <- work
+	hash = work.Block.HashNoNonce()
+	miner.waitJob(hash)
+
+	var nonce uint64
+	target := new(big.Int).Div(stratumMaxTarget, work.Block.Difficulty())
+	for ; ; nonce++ {
+		if mix, result := engine.Hashimoto(work.Block.Header(), nonce); result.Cmp(target) <= 0 {
+			if code := submit(hash, nonce, mix); code != 0 {
+				t.Fatalf("block solution rejected: %d", code)
+			}
+			break
+		}
+	}
+	select {
+	case result := <-results:
+		if result.Block.Nonce() != nonce || result.Block.HashNoNonce() != hash {
+			t.Errorf("sealed block mismatch: have nonce %d hash %x, want nonce %d hash %x", result.Block.Nonce(), result.Block.HashNoNonce(), nonce, hash)
+		}
+		if err := engine.VerifySeal(nil, result.Block.Header()); err != nil {
+			t.Errorf("sealed block invalid: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block solution not sealed")
+	}
+	// Check the share accounting of the worker
+	workers := server.Workers()
+	if len(workers) != 1 {
+		t.Fatalf("worker count mismatch: have %d, want 1", len(workers))
+	}
+	stats := workers[0]
+	if stats.Name != "rig" || stats.Accepted != 2 || stats.Rejected != 3 || stats.Stale != 1 || stats.Blocks != 1 {
+		t.Errorf("worker stats mismatch: have %+v", stats)
+	}
+	if stats.Hashrate == 0 || server.GetHashRate() != int64(stats.Hashrate) {
+		t.Errorf("hashrate mismatch: worker %d, total %d", stats.Hashrate, server.GetHashRate())
+	}
+}
+
+// Tests that Stratum workers must authorize with the configured password, and
+// that connections can't authorize an unbounded number of times.
+func TestStratumAuthorization(t *testing.T) {
+	server := NewStratumServer(nil, fbcash.NewTester(), big.NewInt(1), "secret")
+	server.Start()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	miner := newStratumTestMiner(t, listener.Addr())
+	defer miner.conn.Close()
+
+	if code := miner.call("mining.authorize", "rig"); code != errStratumUnauthorized.Code {
+		t.Fatalf("missing password error mismatch: have %d, want %d", code, errStratumUnauthorized.Code)
+	}
+	if code := miner.call("mining.authorize", "rig", "wrong"); code != errStratumUnauthorized.Code {
+		t.Fatalf("wrong password error mismatch: have %d, want %d", code, errStratumUnauthorized.Code)
+	}
+	if code := miner.call("mining.authorize", "rig", "secret"); code != 0 {
+		t.Fatalf("failed to authorize: %d", code)
+	}
+	for i := 3; i < stratumMaxAuthorize; i++ {
+		if code := miner.call("mining.authorize", "rig", "secret"); code != 0 {
+			t.Fatalf("reauthorization %d failed: %d", i, code)
+		}
+	}
+	if code := miner.call("mining.authorize", "rig", "secret"); code != errStratumUnauthorized.Code {
+		t.Fatalf("excess authorization error mismatch: have %d, want %d", code, errStratumUnauthorized.Code)
+	}
+	if workers := server.Workers(); len(workers) != 1 || workers[0].Name != "rig" {
+		t.Fatalf("worker mismatch: have %+v", workers)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// errNoTemplate is returned if the worker has no block under construction yet.
+var errNoTemplate = errors.New("no block template available yet")
+
+// BlockTemplate is the unsealed block the miner is currently working on, along
+// with the data external block builders need to complete and seal it.
+type BlockTemplate struct {
+	Block    *types.Block   // Finalized but unsealed block, with its state and receipts roots
+	Receipts types.Receipts // Receipts of the block's transactions, in order
+	Fees     *big.Int       // Amount the coinbase earns from the block's transactions
+}
+
+// template returns the block currently under construction.
+func (self *worker) template() (*BlockTemplate, error) {
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+
+	if self.current == nil || self.current.Block == nil {
+		return nil, errNoTemplate
+	}
+	work, block := self.current, self.current.Block
+
+	// Transactions arriving while not mining extend the pending block in place,
+	// rebuild it to keep the template current. The pending state already holds
+	// the rewards credited when the work was finalized.
+	if atomic.LoadInt32(&self.mining) == 0 {
+		header := types.CopyHeader(work.header)
+		header.Root = work.state.IntermediateRoot(self.config.IsEIP158(header.Number))
+		block = types.NewBlock(header, work.txs, block.Uncles(), work.receipts)
+	}
+	receipts := make(types.Receipts, len(work.receipts))
+	copy(receipts, work.receipts)
+
+	return &BlockTemplate{Block: block, Receipts: receipts, Fees: work.fees()}, nil
+}
+
+// BlockTemplate returns the unsealed block the miner is currently working on.
+func (self *Miner) BlockTemplate() (*BlockTemplate, error) {
+	return self.worker.template()
+}
+
+// SubmitBlock verifies the seal of an externally built block, imports it into
+// the chain and broadcasts it to the network.
+func (self *Miner) SubmitBlock(block *types.Block) error {
+	chain := self.fbc.BlockChain()
+	if err := self.engine.VerifySeal(chain, block.Header()); err != nil {
+		return err
+	}
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		return err
+	}
+	log.Info("Imported submitted block", "number", block.Number(), "hash", block.Hash(), "txs", len(block.Transactions()))
+
+	// Broadcast the block and wait for its confirmation like a locally mined one
+	self.mux.Post(core.NewMinedBlockEvent{Block: block})
+	self.worker.unconfirmed.Insert(block.NumberU64(), block.Hash())
+	return nil
+}
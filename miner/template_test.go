@@ -0,0 +1,135 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/consensus"
+	"github.com/fairblock/go-fairblock/consensus/fbcash"
+	"github.com/fairblock/go-fairblock/core"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/core/vm"
+	"github.com/fairblock/go-fairblock/event"
+	"github.com/fairblock/go-fairblock/fbcdb"
+	"github.com/fairblock/go-fairblock/params"
+)
+
+// testBackend implements Backend on top of an in-memory chain funding the
+// bundle test account.
+type testBackend struct {
+	db     fbcdb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+	accman *accounts.Manager
+}
+
+func newTestBackend(t *testing.T, engine consensus.Engine) *testBackend {
+	db, _ := fbcdb.NewMemDatabase()
+	(&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{bundleTestAddr: {Balance: bundleTestFunds}},
+	}).MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	return &testBackend{
+		db:     db,
+		chain:  chain,
+		txPool: core.NewTxPool(config, params.TestChainConfig, chain),
+		accman: accounts.NewManager(),
+	}
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return b.accman }
+func (b *testBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool              { return b.txPool }
+func (b *testBackend) ChainDb() fbcdb.Database           { return b.db }
+
+// Tests that the block template reflects the pending block, and that a block
+// sealed from it is imported when submitted back.
+func TestBlockTemplate(t *testing.T) {
+	engine := fbcash.NewFaker()
+	backend := newTestBackend(t, engine)
+	miner := New(backend, params.TestChainConfig, new(event.TypeMux), engine)
+
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainId)
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(2), nil), signer, bundleTestKey)
+	if err := backend.txPool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Wait for the transaction to make it into the pending block
+	var template *BlockTemplate
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		var err error
+		if template, err = miner.BlockTemplate(); err == nil && len(template.Block.Transactions()) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transaction not included in block template")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	block := template.Block
+	if block.NumberU64() != 1 || block.Transactions()[0].Hash() != tx.Hash() {
+		t.Fatalf("template block mismatch: number %d, txs %v", block.NumberU64(), block.Transactions())
+	}
+	if len(template.Receipts) != 1 || types.DeriveSha(template.Receipts) != block.ReceiptHash() {
+		t.Errorf("template receipts mismatch the receipts root")
+	}
+	if want := big.NewInt(21000 * 2); template.Fees.Cmp(want) != 0 {
+		t.Errorf("template fees mismatch: have %v, want %v", template.Fees, want)
+	}
+	// Seal the template and submit it back
+	sealed, err := engine.Seal(backend.chain, block, nil)
+	if err != nil {
+		t.Fatalf("failed to seal template: %v", err)
+	}
+	if err := miner.SubmitBlock(sealed); err != nil {
+		t.Fatalf("failed to submit sealed block: %v", err)
+	}
+	if head := backend.chain.CurrentBlock(); head.Hash() != sealed.Hash() {
+		t.Errorf("chain head mismatch: have %x, want %x", head.Hash(), sealed.Hash())
+	}
+}
+
+// Tests that blocks with an invalid seal are rejected on submission.
+func TestSubmitBlockInvalidSeal(t *testing.T) {
+	engine := fbcash.NewFakeFailer(1)
+	backend := newTestBackend(t, engine)
+	miner := New(backend, params.TestChainConfig, new(event.TypeMux), engine)
+
+	template, err := miner.BlockTemplate()
+	if err != nil {
+		t.Fatalf("failed to retrieve block template: %v", err)
+	}
+	if err := miner.SubmitBlock(template.Block); err == nil {
+		t.Fatalf("block with invalid seal accepted")
+	}
+	if head := backend.chain.CurrentBlock(); head.NumberU64() != 0 {
+		t.Errorf("chain head advanced to %d", head.NumberU64())
+	}
+}
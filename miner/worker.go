@@ -71,12 +71,14 @@ type Work struct {
 	family    *set.Set       // family set (used for checking uncle invalidity)
 	uncles    *set.Set       // uncle set
 	tcount    int            // tx count in cycle
+	gasPool   *core.GasPool  // available gas used to pack transactions
 
 	Block *types.Block // the new block
 
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+	profit   *big.Int // coinbase balance gained by executing the transactions
 
 	createdAt time.Time
 }
@@ -111,8 +113,12 @@ type worker struct {
 	proc    core.Validator
 	chainDb fbcdb.Database
 
-	coinbase common.Address
-	extra    []byte
+	coinbase     common.Address
+	extra        []byte
+	txPolicy     TxPolicy      // Ordering of the pending transactions packed into blocks
+	gasFloor     *big.Int      // Gas limit the mined blocks are raised towards
+	gasCeil      *big.Int      // Gas limit the mined blocks may not exceed (nil = unbounded)
+	recommitBase time.Duration // Interval at which the pending block is rebuilt while sealing
 
 	currentMu sync.Mutex
 	current   *Work
@@ -121,6 +127,7 @@ type worker struct {
 	possibleUncles map[common.Hash]*types.Block
 
 	unconfirmed *unconfirmedBlocks // set of locally mined blocks pending canonicalness confirmations
+	bundles     *bundlePool        // bundles submitted directly to the miner
 
 	// atomic status counters
 	mining int32
@@ -142,8 +149,12 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		proc:           fbc.BlockChain().Validator(),
 		possibleUncles: make(map[common.Hash]*types.Block),
 		coinbase:       coinbase,
+		txPolicy:       PriceOrdering,
+		gasFloor:       params.TargetGasLimit,
+		recommitBase:   DefaultRecommitInterval,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(fbc.BlockChain(), miningLogAtDepth),
+		bundles:        newBundlePool(fbc.BlockChain()),
 	}
 	// Subscribe TxPreEvent for tx pool
 	worker.txSub = fbc.TxPool().SubscribeTxPreEvent(worker.txCh)
@@ -170,6 +181,18 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setTxPolicy(policy TxPolicy) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.txPolicy = policy
+}
+
+func (self *worker) setGasLimit(floor, ceil *big.Int) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.gasFloor, self.gasCeil = floor, ceil
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
@@ -245,13 +268,35 @@ func (self *worker) update() {
 	defer self.chainHeadSub.Unsubscribe()
 	defer self.chainSideSub.Unsubscribe()
 
+	// Rebuild the block being sealed periodically, adapting the interval to
+	// the time each rebuild takes
+	interval := self.recommitInterval()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
 	for {
 		// A real event arrived, process interesting content
 		select {
 		// Handle ChainHeadEvent
-		case <-self.chainHeadCh:
+		case ev := <-self.chainHeadCh:
+			self.bundles.Finalize(ev.Block.NumberU64())
 			self.commitNewWork()
 
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(interval)
+
+		// Handle recommit timer
+		case <-timer.C:
+			if elapsed, ok := self.recommit(); ok {
+				interval = recalcRecommit(self.recommitInterval(), interval, elapsed)
+			}
+			timer.Reset(interval)
+
 		// Handle ChainSideEvent
 		case ev := <-self.chainSideCh:
 			self.uncleMu.Lock()
@@ -274,6 +319,9 @@ func (self *worker) update() {
 				if self.config.Clique != nil && self.config.Clique.Period == 0 {
 					self.commitNewWork()
 				}
+				if self.config.IBFT != nil && self.config.IBFT.Period == 0 {
+					self.commitNewWork()
+				}
 			}
 
 		// System stopped
@@ -354,11 +402,11 @@ func (self *worker) push(work *Work) {
 	}
 }
 
-// makeCurrent creates a new environment for the current cycle.
-func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error {
+// makeWork creates a new environment for a block on top of parent.
+func (self *worker) makeWork(parent *types.Block, header *types.Header) (*Work, error) {
 	state, err := self.chain.StateAt(parent.Root())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	work := &Work{
 		config:    self.config,
@@ -368,6 +416,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		family:    set.New(),
 		uncles:    set.New(),
 		header:    header,
+		profit:    new(big.Int),
 		createdAt: time.Now(),
 	}
 
@@ -382,8 +431,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 
 	// Keep track of transactions which return errors so they can be removed
 	work.tcount = 0
-	self.current = work
-	return nil
+	return work, nil
 }
 
 func (self *worker) commitNewWork() {
@@ -412,7 +460,7 @@ func (self *worker) commitNewWork() {
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent),
+		GasLimit:   core.CalcGasLimit(parent, self.gasFloor, self.gasCeil),
 		GasUsed:    new(big.Int),
 		Extra:      self.extra,
 		Time:       big.NewInt(tstamp),
@@ -421,9 +469,27 @@ func (self *worker) commitNewWork() {
 	if atomic.LoadInt32(&self.mining) == 1 {
 		header.Coinbase = self.coinbase
 	}
+	work, err := self.buildWork(parent, header)
+	if err != nil {
+		return
+	}
+	self.current = work
+
+	// We only care about logging if we're actually mining.
+	if atomic.LoadInt32(&self.mining) == 1 {
+		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(work.Block.Uncles()), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
+	}
+	self.push(work)
+}
+
+// buildWork assembles and finalizes a new block on top of parent, filling it
+// with the pending transactions, bundles and uncles. The caller must hold the
+// worker's locks.
+func (self *worker) buildWork(parent *types.Block, header *types.Header) (*Work, error) {
 	if err := self.engine.Prepare(self.chain, header); err != nil {
 		log.Error("Failed to prepare header for mining", "err", err)
-		return
+		return nil, err
 	}
 	// If we are care about TheDAO hard-fork check whfbcer to override the extra-data or not
 	if daoBlock := self.config.DAOForkBlock; daoBlock != nil {
@@ -439,23 +505,41 @@ func (self *worker) commitNewWork() {
 		}
 	}
 	// Could potentially happen if starting to mine in an odd state.
-	err := self.makeCurrent(parent, header)
+	work, err := self.makeWork(parent, header)
 	if err != nil {
 		log.Error("Failed to create mining context", "err", err)
-		return
+		return nil, err
 	}
-	// Create the current work task and check any fork transitions needed
-	work := self.current
+	// Check any fork transitions needed
 	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(work.state)
 	}
 	pending, err := self.fbc.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
-		return
+		return nil, err
+	}
+	// Commit the leading decryption keys of each account first, so the envelopes
+	// they unlock execute before any transaction ordered knowing their content
+	reveals := make(map[common.Address]types.Transactions)
+	for addr, list := range pending {
+		n := 0
+		for n < len(list) && list[n].IsDecryptionKey() {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		reveals[addr] = list[:n]
+		if n == len(list) {
+			delete(pending, addr)
+		} else {
+			pending[addr] = list[n:]
+		}
 	}
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitTransactions(self.mux, types.NewTransactionsByPriceAndNonce(work.signer, reveals), self.chain, self.coinbase)
+	self.commitBundles(work)
+	work.commitTransactions(self.mux, self.txPolicy(work.signer, pending), self.chain, self.coinbase)
 
 	// compute uncles for the new block.
 	var (
@@ -482,14 +566,32 @@ func (self *worker) commitNewWork() {
 	// Create the new block to seal with the consensus engine
 	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, uncles, work.receipts); err != nil {
 		log.Error("Failed to finalize block for sealing", "err", err)
-		return
+		return nil, err
 	}
-	// We only care about logging if we're actually mining.
-	if atomic.LoadInt32(&self.mining) == 1 {
-		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
-		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
+	return work, nil
+}
+
+// commitBundles simulates the bundles targeting the work's block on top of its
+// pending state, including the ones that execute without any revert.
+func (self *worker) commitBundles(work *Work) {
+	var coalescedLogs []*types.Log
+	for _, bundle := range self.bundles.Executable(work.header.Number.Uint64(), work.header.Time.Uint64()) {
+		logs, err := work.commitBundle(bundle, self.chain, self.coinbase)
+		if err != nil {
+			log.Debug("Bundle left out of block", "hash", bundle.Hash(), "number", work.header.Number, "err", err)
+			self.bundles.Failed(bundle.Hash(), err)
+			continue
+		}
+		coalescedLogs = append(coalescedLogs, logs...)
+	}
+	if len(coalescedLogs) > 0 {
+		cpy := make([]*types.Log, len(coalescedLogs))
+		for i, l := range coalescedLogs {
+			cpy[i] = new(types.Log)
+			*cpy[i] = *l
+		}
+		go self.mux.Post(core.PendingLogsEvent{Logs: cpy})
 	}
-	self.push(work)
 }
 
 func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
@@ -507,8 +609,11 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	return nil
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
-	gp := new(core.GasPool).AddGas(env.header.GasLimit)
+func (env *Work) commitTransactions(mux *event.TypeMux, txs TxOrdering, bc *core.BlockChain, coinbase common.Address) {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	gp := env.gasPool
 
 	var coalescedLogs []*types.Log
 
@@ -531,6 +636,23 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			txs.Pop()
 			continue
 		}
+		// Envelopes are only decrypted if included in their own epoch, wait for it.
+		// Envelopes of passed epochs are included undecrypted to free their nonce.
+		if tx.IsEnvelope() {
+			if envelope, err := tx.Envelope(); err != nil || envelope.Epoch > env.header.Number.Uint64() {
+				log.Trace("Ignoring envelope of future epoch", "hash", tx.Hash(), "number", env.header.Number)
+
+				txs.Pop()
+				continue
+			}
+		}
+		// Decryption keys must lead the block, consensus rejects them afterwards
+		if tx.IsDecryptionKey() && len(env.txs) > 0 && !env.txs[len(env.txs)-1].IsDecryptionKey() {
+			log.Trace("Ignoring decryption key after plain transactions", "hash", tx.Hash())
+
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
 
@@ -587,12 +709,15 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 
 func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) (error, []*types.Log) {
 	snap := env.state.Snapshot()
+	balance := env.state.GetBalance(coinbase)
 
 	receipt, _, err := core.ApplyTransaction(env.config, bc, &coinbase, gp, env.state, env.header, tx, env.header.GasUsed, vm.Config{})
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
 		return err, nil
 	}
+	// Track what the coinbase actually earned, after any fee burn or treasury cut
+	env.profit.Add(env.profit, new(big.Int).Sub(env.state.GetBalance(coinbase), balance))
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
 
@@ -24,15 +24,20 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/external"
+	"github.com/fairblock/go-fairblock/accounts/hdwallet"
 	"github.com/fairblock/go-fairblock/accounts/keystore"
 	"github.com/fairblock/go-fairblock/accounts/usbwallet"
+	"github.com/fairblock/go-fairblock/accounts/watchonly"
 	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/crypto"
 	"github.com/fairblock/go-fairblock/log"
 	"github.com/fairblock/go-fairblock/p2p"
 	"github.com/fairblock/go-fairblock/p2p/discover"
+	"github.com/fairblock/go-fairblock/rpc"
 )
 
 const (
@@ -41,6 +46,8 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirHDWallets       = "hdwallets"          // Path within the datadir to the mnemonic wallets
+	datadirWatchOnly       = "watchonly.json"     // Path within the datadir to the watch-only addresses
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -85,6 +92,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint of an external signer holding account keys
+	// outside of the node. Its accounts are available alongside the local ones.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -105,6 +116,16 @@ type Config struct {
 	// useless for custom HTTP clients.
 	HTTPCors []string `toml:",omitempty"`
 
+	// HTTPVirtualHosts is the list of virtual hostnames which are allowed on incoming
+	// requests. This is by default {'localhost'}. Using this prevents attacks like
+	// DNS rebinding, which bypasses SOP by simply masquerading as being within the
+	// same origin. These attacks do not utilize CORS, since they are not cross-domain.
+	// By explicitly checking the Host-header, the server will not allow requests
+	// made against the server with a malicious host domain. Requests using an IP
+	// address directly are not affected. Entries may be "*" to accept any host or
+	// "*.domain" to accept all subdomains of a domain.
+	HTTPVirtualHosts []string `toml:",omitempty"`
+
 	// HTTPModules is a list of API modules to expose via the HTTP RPC interface.
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
@@ -129,6 +150,24 @@ type Config struct {
 	// exposed.
 	WSModules []string `toml:",omitempty"`
 
+	// RPCRateLimit configures the per client call quotas enforced on the HTTP and
+	// websocket RPC interfaces, limiting calls by namespace or method name.
+	RPCRateLimit rpc.RateLimitConfig
+
+	// RPCBatchItemLimit is the maximum number of requests permitted in a single
+	// batch on the HTTP and websocket RPC interfaces. Zero disables the limit.
+	RPCBatchItemLimit int `toml:",omitempty"`
+
+	// RPCResponseMaxSize is the maximum number of bytes returned for a single
+	// request or batch on the HTTP and websocket RPC interfaces. Responses above
+	// the limit are replaced by errors. Zero disables the limit.
+	RPCResponseMaxSize int `toml:",omitempty"`
+
+	// RPCExecutionTimeout is the maximum time a method call may execute on the HTTP
+	// and websocket RPC interfaces before it is cancelled and answered with a
+	// timeout error. Zero disables the timeout.
+	RPCExecutionTimeout time.Duration `toml:",omitempty"`
+
 	// WSExposeAll exposes all API modules via the WebSocket RPC interface rather
 	// than just the public ones.
 	//
@@ -393,8 +432,8 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	var ephemeral string
 	if keydir == "" {
 		// There is no datadir.
-		keydir, err = ioutil.TempDir("", "go-fairblock-keystore")
-		ephemeral = keydir
+		ephemeral, err = ioutil.TempDir("", "go-fairblock-keystore")
+		keydir = filepath.Join(ephemeral, datadirDefaultKeyStore)
 	}
 
 	if err != nil {
@@ -403,9 +442,16 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	if err := os.MkdirAll(keydir, 0700); err != nil {
 		return nil, "", err
 	}
+	// The wallets not stored as key files live next to the keystore
+	walletdir := conf.DataDir
+	if walletdir == "" {
+		walletdir = filepath.Dir(keydir)
+	}
 	// Assemble the account manager and supported backends
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
+		hdwallet.NewHub(filepath.Join(walletdir, datadirHDWallets), scryptN, scryptP),
+		watchonly.NewBackend(filepath.Join(walletdir, datadirWatchOnly)),
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
@@ -421,5 +467,12 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 			backends = append(backends, trezorhub)
 		}
 	}
+	if conf.ExternalSigner != "" {
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
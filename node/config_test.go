@@ -21,9 +21,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 
+	"github.com/fairblock/go-fairblock/accounts/watchonly"
+	"github.com/fairblock/go-fairblock/common"
 	"github.com/fairblock/go-fairblock/crypto"
 	"github.com/fairblock/go-fairblock/p2p"
 )
@@ -148,3 +151,33 @@ func TestNodeKeyPersistency(t *testing.T) {
 		t.Fatalf("ephemeral node key persisted to disk")
 	}
 }
+
+// Tests that the wallets not stored as key files are kept in the datadir next to
+// the keystore instead of within it.
+func TestWalletsOutsideKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	am, _, err := makeAccountManager(&Config{DataDir: dir})
+	if err != nil {
+		t.Fatalf("failed to create account manager: %v", err)
+	}
+	defer am.Close()
+
+	backends := am.Backends(reflect.TypeOf(&watchonly.Backend{}))
+	if len(backends) != 1 {
+		t.Fatalf("watch-only backend count mismatch: have %d, want 1", len(backends))
+	}
+	if _, err := backends[0].(*watchonly.Backend).Add(common.Address{1}, "test"); err != nil {
+		t.Fatalf("failed to add watch-only address: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, datadirWatchOnly)); err != nil {
+		t.Errorf("watch-only addresses not stored in the datadir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, datadirDefaultKeyStore, datadirWatchOnly)); err == nil {
+		t.Errorf("watch-only addresses stored in the keystore")
+	}
+}
@@ -31,15 +31,21 @@ const (
 	DefaultHTTPPort = 9565        // Default TCP port for the HTTP RPC server
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 8546        // Default TCP port for the websocket RPC server
+
+	DefaultRPCBatchItemLimit  = 1000             // Default maximum number of requests in an RPC batch
+	DefaultRPCResponseMaxSize = 25 * 1000 * 1000 // Default maximum response size of an RPC request or batch
 )
 
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
-	DataDir:     DefaultDataDir(),
-	HTTPPort:    DefaultHTTPPort,
-	HTTPModules: []string{"net", "web3"},
-	WSPort:      DefaultWSPort,
-	WSModules:   []string{"net", "web3"},
+	DataDir:            DefaultDataDir(),
+	HTTPPort:           DefaultHTTPPort,
+	HTTPModules:        []string{"net", "web3"},
+	HTTPVirtualHosts:   []string{"localhost"},
+	WSPort:             DefaultWSPort,
+	WSModules:          []string{"net", "web3"},
+	RPCBatchItemLimit:  DefaultRPCBatchItemLimit,
+	RPCResponseMaxSize: DefaultRPCResponseMaxSize,
 	P2P: p2p.Config{
 		ListenAddr:      ":19565",
 		DiscoveryV5Addr: ":30304",
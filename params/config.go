@@ -17,12 +17,18 @@
 package params
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
 )
 
+// ErrRewardsChanged is returned if the block rewards of a chain are changed
+// after its genesis block.
+var ErrRewardsChanged = errors.New("block rewards can't be changed after genesis")
+
 var (
 	MainnetGenesisHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3") // Mainnet genesis hash to enforce below configs on
 	TestnetGenesisHash = common.HexToHash("0x41941023680923e0fe4d74a34bdac8141f2540e3ae90623718e47d66d1ca4a2d") // Testnet genesis hash to enforce below configs on
@@ -82,16 +88,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllFbcashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(FbcashConfig), nil}
+	AllFbcashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(FbcashConfig), nil, nil, nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Fairblock core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(FbcashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(FbcashConfig), nil, nil, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -120,6 +126,13 @@ type ChainConfig struct {
 	// Various consensus engines
 	Fbcash *FbcashConfig `json:"fbcash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+	IBFT   *IBFTConfig   `json:"ibft,omitempty"`
+
+	// Threshold encrypted mempool (nil = transactions are public)
+	Fairblock *FairblockConfig `json:"fairblock,omitempty"`
+
+	// Block rewards and transaction fee destination (nil = defaults)
+	Rewards *RewardsConfig `json:"rewards,omitempty"`
 }
 
 // FbcashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -141,6 +154,202 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// IBFTConfig is the consensus engine configs for Byzantine fault tolerant
+// proof-of-authority based sealing with immediate finality.
+type IBFTConfig struct {
+	Period         uint64 `json:"period"`         // Number of seconds between blocks to enforce
+	Epoch          uint64 `json:"epoch"`          // Epoch length to reset votes and checkpoint
+	RequestTimeout uint64 `json:"requestTimeout"` // Milliseconds after which a consensus round is abandoned (0 = default)
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *IBFTConfig) String() string {
+	return "ibft"
+}
+
+// FairblockConfig is the configuration of the threshold encrypted mempool. The
+// master public key is shared by a committee of key holders, any threshold of
+// which can reveal the decryption key of an epoch.
+type FairblockConfig struct {
+	MasterPublicKey  hexutil.Bytes   `json:"masterPublicKey"`  // Marshalled G2 master public key envelopes are encrypted to
+	Threshold        uint64          `json:"threshold"`        // Number of key shares needed to reveal a decryption key
+	VerificationKeys []hexutil.Bytes `json:"verificationKeys"` // Marshalled G2 verification keys of the key holders, by index
+	RevealWindow     uint64          `json:"revealWindow"`     // Number of blocks after an epoch is final its key may be revealed in (0 = default)
+	Confirmations    uint64          `json:"confirmations"`    // Number of blocks on top of an epoch making it final (0 = default)
+}
+
+// Window returns the number of blocks after an epoch is final during which its
+// decryption keys may still be revealed.
+func (c *FairblockConfig) Window() uint64 {
+	if c.RevealWindow == 0 {
+		return DefaultRevealWindow
+	}
+	return c.RevealWindow
+}
+
+// FinalityDepth returns the number of blocks that must be built on top of the
+// block of an epoch before the key holders release its decryption key shares.
+// Blocks sealed by ibft are final as soon as they are imported.
+func (c *ChainConfig) FinalityDepth() uint64 {
+	if c.IBFT != nil {
+		return 0
+	}
+	if c.Fairblock == nil || c.Fairblock.Confirmations == 0 {
+		return DefaultRevealDepth
+	}
+	return c.Fairblock.Confirmations
+}
+
+// String implements the stringer interface, returning the encrypted mempool details.
+func (c *FairblockConfig) String() string {
+	return fmt.Sprintf("fairblock(%d/%d)", c.Threshold, len(c.VerificationKeys))
+}
+
+// Destinations of the transaction fees.
+const (
+	FeeSinkCoinbase = "coinbase" // Fees are paid to the block's coinbase
+	FeeSinkBurn     = "burn"     // Fees are destroyed
+	FeeSinkTreasury = "treasury" // Fees are paid to a fixed treasury account
+)
+
+// RewardsConfig is the configuration of the block rewards and of the destination
+// of the transaction fees. Unset fields keep their default values.
+type RewardsConfig struct {
+	FrontierReward  *big.Int       `json:"frontierReward,omitempty"`  // Base block reward in wei before Byzantium (nil = default)
+	ByzantiumReward *big.Int       `json:"byzantiumReward,omitempty"` // Base block reward in wei from Byzantium on (nil = default)
+	HalvingInterval uint64         `json:"halvingInterval,omitempty"` // Number of blocks between halvings of the base reward (0 = never)
+	UncleDivisor    uint64         `json:"uncleDivisor,omitempty"`    // Divisor of the base reward paid per uncle depth (0 = default)
+	NephewDivisor   uint64         `json:"nephewDivisor,omitempty"`   // Divisor of the base reward paid for including an uncle (0 = default)
+	FeeSink         string         `json:"feeSink,omitempty"`         // Destination of the transaction fees ("" = coinbase)
+	Treasury        common.Address `json:"treasury,omitempty"`        // Account receiving the fees with the treasury sink
+}
+
+// Validate checks that the fee sink is known and has a treasury if needed.
+func (c *RewardsConfig) Validate() error {
+	switch c.FeeSink {
+	case "", FeeSinkCoinbase, FeeSinkBurn:
+		return nil
+	case FeeSinkTreasury:
+		if c.Treasury == (common.Address{}) {
+			return errors.New("treasury fee sink without treasury account")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown fee sink %q", c.FeeSink)
+	}
+}
+
+// equal reports whether two reward configurations pay the same rewards and fees.
+// A nil configuration only equals another nil one.
+func (c *RewardsConfig) equal(o *RewardsConfig) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	return configNumEqual(c.FrontierReward, o.FrontierReward) &&
+		configNumEqual(c.ByzantiumReward, o.ByzantiumReward) &&
+		c.HalvingInterval == o.HalvingInterval &&
+		c.UncleDivisor == o.UncleDivisor &&
+		c.NephewDivisor == o.NephewDivisor &&
+		c.FeeSink == o.FeeSink &&
+		c.Treasury == o.Treasury
+}
+
+// String implements the stringer interface, returning the reward details.
+func (c *RewardsConfig) String() string {
+	if c == nil {
+		return "rewards(default)"
+	}
+	sink := c.FeeSink
+	if sink == "" {
+		sink = FeeSinkCoinbase
+	}
+	return fmt.Sprintf("rewards(halving: %d, fees: %s)", c.HalvingInterval, sink)
+}
+
+// BlockReward returns the base reward for mining the block with the given number,
+// after any halvings.
+func (c *ChainConfig) BlockReward(num *big.Int) *big.Int {
+	reward := FrontierBlockReward
+	if c.IsByzantium(num) {
+		reward = ByzantiumBlockReward
+	}
+	if c.Rewards == nil {
+		return new(big.Int).Set(reward)
+	}
+	if scheduled := c.scheduledReward(num); scheduled != nil {
+		reward = scheduled
+	}
+	return c.halveReward(reward, num)
+}
+
+// ScheduledBlockReward returns the base reward for sealing the block with the
+// given number, after any halvings, counting only the rewards explicitly set in
+// the configuration. It is meant for consensus engines that pay no reward by
+// default, such as proof-of-authority ones.
+func (c *ChainConfig) ScheduledBlockReward(num *big.Int) *big.Int {
+	if c.Rewards == nil {
+		return new(big.Int)
+	}
+	reward := c.scheduledReward(num)
+	if reward == nil {
+		return new(big.Int)
+	}
+	return c.halveReward(reward, num)
+}
+
+// scheduledReward returns the configured base reward for the fork active at the
+// given block, or nil if the configuration keeps the default.
+func (c *ChainConfig) scheduledReward(num *big.Int) *big.Int {
+	if c.IsByzantium(num) {
+		return c.Rewards.ByzantiumReward
+	}
+	return c.Rewards.FrontierReward
+}
+
+// halveReward applies the configured halvings to the base reward of the block
+// with the given number.
+func (c *ChainConfig) halveReward(reward *big.Int, num *big.Int) *big.Int {
+	if c.Rewards == nil || c.Rewards.HalvingInterval == 0 {
+		return new(big.Int).Set(reward)
+	}
+	halvings := new(big.Int).Div(num, new(big.Int).SetUint64(c.Rewards.HalvingInterval))
+	if halvings.BitLen() > 8 {
+		return new(big.Int)
+	}
+	return new(big.Int).Rsh(reward, uint(halvings.Uint64()))
+}
+
+// UncleRewardDivisors returns the divisors of the base reward paid per depth to
+// the miner of an uncle and paid to the miner including it.
+func (c *ChainConfig) UncleRewardDivisors() (uncle *big.Int, nephew *big.Int) {
+	uncle, nephew = UncleRewardDivisor, NephewRewardDivisor
+	if c.Rewards != nil {
+		if c.Rewards.UncleDivisor != 0 {
+			uncle = new(big.Int).SetUint64(c.Rewards.UncleDivisor)
+		}
+		if c.Rewards.NephewDivisor != 0 {
+			nephew = new(big.Int).SetUint64(c.Rewards.NephewDivisor)
+		}
+	}
+	return uncle, nephew
+}
+
+// FeeRecipient returns the account credited with the transaction fees of a block
+// mined by coinbase, or false if the fees are burnt.
+func (c *ChainConfig) FeeRecipient(coinbase common.Address) (common.Address, bool) {
+	if c.Rewards == nil {
+		return coinbase, true
+	}
+	switch c.Rewards.FeeSink {
+	case FeeSinkBurn:
+		return common.Address{}, false
+	case FeeSinkTreasury:
+		return c.Rewards.Treasury, true
+	default:
+		return coinbase, true
+	}
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -149,6 +358,8 @@ func (c *ChainConfig) String() string {
 		engine = c.Fbcash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.IBFT != nil:
+		engine = c.IBFT
 	default:
 		engine = "unknown"
 	}
@@ -254,6 +465,22 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	return nil
 }
 
+// CheckRewards checks whether the block rewards of a chain with the given head
+// may be replaced by the valid ones of newcfg. Unlike forks, rewards cannot be
+// rescheduled by rewinding: the past blocks could only be verified with the
+// replaced configuration, so any change after the genesis block is rejected.
+func (c *ChainConfig) CheckRewards(newcfg *ChainConfig, height uint64) error {
+	if newcfg.Rewards != nil {
+		if err := newcfg.Rewards.Validate(); err != nil {
+			return fmt.Errorf("invalid rewards config: %v", err)
+		}
+	}
+	if height > 0 && !c.Rewards.equal(newcfg.Rewards) {
+		return fmt.Errorf("%v: have %v, want %v", ErrRewardsChanged, c.Rewards, newcfg.Rewards)
+	}
+	return nil
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
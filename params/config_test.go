@@ -79,3 +79,48 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckRewards(t *testing.T) {
+	tests := []struct {
+		stored, new *ChainConfig
+		head        uint64
+		ok          bool
+	}{
+		{stored: &ChainConfig{}, new: &ChainConfig{}, head: 100, ok: true},
+		{stored: &ChainConfig{}, new: &ChainConfig{Rewards: &RewardsConfig{FeeSink: FeeSinkBurn}}, head: 0, ok: true},
+		{stored: &ChainConfig{}, new: &ChainConfig{Rewards: &RewardsConfig{FeeSink: FeeSinkBurn}}, head: 1, ok: false},
+		{stored: &ChainConfig{}, new: &ChainConfig{Rewards: &RewardsConfig{FeeSink: "miner"}}, head: 0, ok: false},
+		{stored: &ChainConfig{Rewards: &RewardsConfig{FeeSink: FeeSinkTreasury}}, new: &ChainConfig{Rewards: &RewardsConfig{FeeSink: FeeSinkTreasury}}, head: 10, ok: false},
+		{stored: &ChainConfig{Rewards: &RewardsConfig{HalvingInterval: 100}}, new: &ChainConfig{Rewards: &RewardsConfig{HalvingInterval: 100}}, head: 3, ok: true},
+		{stored: &ChainConfig{Rewards: &RewardsConfig{HalvingInterval: 100}}, new: &ChainConfig{Rewards: &RewardsConfig{HalvingInterval: 200}}, head: 3, ok: false},
+		{stored: &ChainConfig{Rewards: &RewardsConfig{HalvingInterval: 100}}, new: &ChainConfig{}, head: 3, ok: false},
+	}
+	for i, tt := range tests {
+		if err := tt.stored.CheckRewards(tt.new, tt.head); (err == nil) != tt.ok {
+			t.Errorf("test %d: error mismatch: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+}
+
+func TestScheduledBlockReward(t *testing.T) {
+	tests := []struct {
+		config   *ChainConfig
+		number   int64
+		reward   *big.Int
+		fallback *big.Int
+	}{
+		{&ChainConfig{}, 1, new(big.Int), FrontierBlockReward},
+		{&ChainConfig{Rewards: &RewardsConfig{FeeSink: FeeSinkBurn}}, 1, new(big.Int), FrontierBlockReward},
+		{&ChainConfig{Rewards: &RewardsConfig{FrontierReward: big.NewInt(8)}}, 1, big.NewInt(8), big.NewInt(8)},
+		{&ChainConfig{Rewards: &RewardsConfig{FrontierReward: big.NewInt(8), HalvingInterval: 10}}, 25, big.NewInt(2), big.NewInt(2)},
+		{&ChainConfig{ByzantiumBlock: big.NewInt(0), Rewards: &RewardsConfig{FrontierReward: big.NewInt(8)}}, 1, new(big.Int), ByzantiumBlockReward},
+	}
+	for i, tt := range tests {
+		if reward := tt.config.ScheduledBlockReward(big.NewInt(tt.number)); reward.Cmp(tt.reward) != 0 {
+			t.Errorf("test %d: scheduled reward mismatch: have %v, want %v", i, reward, tt.reward)
+		}
+		if reward := tt.config.BlockReward(big.NewInt(tt.number)); reward.Cmp(tt.fallback) != 0 {
+			t.Errorf("test %d: block reward mismatch: have %v, want %v", i, reward, tt.fallback)
+		}
+	}
+}
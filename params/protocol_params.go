@@ -20,6 +20,8 @@ import "math/big"
 
 const (
 	MaximumExtraDataSize  uint64 = 32    // Maximum size extra data may be after Genesis.
+	DefaultRevealWindow   uint64 = 128   // Blocks after an epoch is final its decryption keys may be revealed in.
+	DefaultRevealDepth    uint64 = 12    // Blocks on top of an epoch before its decryption key shares are released.
 	ExpByteGas            uint64 = 10    // Times ceil(log256(exponent)) for the EXP instruction.
 	SloadGas              uint64 = 50    // Multiplied by the number of 32-byte words that are copied (round up) for any *COPY operation and added.
 	CallValueTransferGas  uint64 = 9000  // Paid for CALL when the value transfer is non-zero.
@@ -80,4 +82,9 @@ var (
 	GenesisDifficulty      = big.NewInt(131072)                // Difficulty of the Genesis block.
 	MinimumDifficulty      = big.NewInt(131072)                // The minimum that the difficulty may ever be.
 	DurationLimit          = big.NewInt(13)                    // The decision boundary on the blocktime duration used to determine whfbcer difficulty should go up or not.
+
+	FrontierBlockReward  = big.NewInt(0)     // Block reward in wei for successfully mining a block
+	ByzantiumBlockReward = big.NewInt(3e+18) // Block reward in wei for successfully mining a block upward from Byzantium
+	UncleRewardDivisor   = big.NewInt(8)     // Divisor of the block reward paid per depth to the miner of an uncle
+	NephewRewardDivisor  = big.NewInt(32)    // Divisor of the block reward paid to the miner for including an uncle
 )
@@ -125,13 +125,21 @@ type Client struct {
 	sendDone    chan error                     // signals write completion, releases write lock
 	respWait    map[string]*requestOp          // active requests
 	subs        map[string]*ClientSubscription // active subscriptions
+
+	// for reconnecting mode
+	reconnectLock sync.Mutex
+	reconnectCfg  *ReconnectConfig               // reconnection settings, nil if disabled
+	redialDone    chan redialResult              // results of background reconnection attempts
+	resubDone     chan uint64                    // signals the end of a resubscription run
+	orphans       map[*ClientSubscription]uint64 // subscriptions awaiting resubscription, by outage
 }
 
 type requestOp struct {
-	ids  []json.RawMessage
-	err  error
-	resp chan *jsonrpcMessage // receives up to len(ids) responses
-	sub  *ClientSubscription  // only set for FbcSubscribe requests
+	ids   []json.RawMessage
+	err   error
+	resp  chan *jsonrpcMessage // receives up to len(ids) responses
+	sub   *ClientSubscription  // only set for FbcSubscribe requests
+	resub bool                 // whether the request re-establishes sub after reconnecting
 }
 
 func (op *requestOp) wait(ctx context.Context) (*jsonrpcMessage, error) {
@@ -198,6 +206,9 @@ func newClient(initctx context.Context, connectFunc func(context.Context) (net.C
 		sendDone:    make(chan error, 1),
 		respWait:    make(map[string]*requestOp),
 		subs:        make(map[string]*ClientSubscription),
+		redialDone:  make(chan redialResult),
+		resubDone:   make(chan uint64),
+		orphans:     make(map[*ClientSubscription]uint64),
 	}
 	if !isHTTP {
 		go c.dispatch(conn)
@@ -393,6 +404,7 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 		resp: make(chan *jsonrpcMessage),
 		sub:  newClientSubscription(c, namespace, chanVal),
 	}
+	op.sub.params = msg.Params
 
 	// Send the subscription request.
 	// The arrival and validity of the response is signaled on sub.quit.
@@ -479,10 +491,30 @@ func (c *Client) dispatch(conn net.Conn) {
 		lastOp        *requestOp    // tracks last send operation
 		requestOpLock = c.requestOp // nil while the send lock is held
 		reading       = true        // if true, a read loop is running
+
+		redialing   bool     // if true, a background reconnection is running
+		pendingConn net.Conn // reconnected conn waiting for the send lock to be released
+		outages     uint64   // number of connection losses in reconnecting mode
+		resubbing   bool     // if true, a resubscription run is active
 	)
+	// install starts reading from a new connection and re-establishes the
+	// subscriptions lost with the previous one.
+	install := func(newconn net.Conn) {
+		go c.read(newconn)
+		reading = true
+		conn = newconn
+
+		if len(c.orphans) > 0 && !resubbing {
+			resubbing = true
+			go c.resubscribe(c.orphanList(), outages)
+		}
+	}
 	defer close(c.didQuit)
 	defer func() {
 		c.closeRequestOps(ErrClientQuit)
+		if pendingConn != nil {
+			pendingConn.Close()
+		}
 		conn.Close()
 		if reading {
 			// Empty read channels until read is dead.
@@ -525,10 +557,24 @@ func (c *Client) dispatch(conn net.Conn) {
 
 		case err := <-c.readErr:
 			log.Debug(fmt.Sprintf("<-readErr: %v", err))
-			c.closeRequestOps(err)
 			conn.Close()
 			reading = false
 
+			config := c.reconnectConfig()
+			if config == nil {
+				c.closeRequestOps(err)
+				continue
+			}
+			// In reconnecting mode, only fail the pending calls and keep the
+			// subscriptions around until the connection is re-established.
+			c.closePendingOps(err)
+			outages++
+			c.orphanSubscriptions(outages)
+			if !redialing {
+				redialing = true
+				go c.redial(*config)
+			}
+
 		case newconn := <-c.reconnected:
 			log.Debug(fmt.Sprintf("<-reconnected: (reading=%t) %v", reading, conn.RemoteAddr()))
 			if reading {
@@ -536,9 +582,33 @@ func (c *Client) dispatch(conn net.Conn) {
 				conn.Close()
 				<-c.readErr
 			}
-			go c.read(newconn)
-			reading = true
-			conn = newconn
+			install(newconn)
+
+		case res := <-c.redialDone:
+			redialing = false
+			switch {
+			case res.err != nil:
+				log.Debug(fmt.Sprintf("<-redialDone: giving up: %v", res.err))
+				if !reading {
+					c.closeOrphans(res.err)
+				}
+			case reading:
+				// A write has re-established the connection in the meantime.
+				res.conn.Close()
+			case requestOpLock == nil:
+				// The write connection may only be replaced while nobody is sending.
+				pendingConn = res.conn
+			default:
+				c.writeConn = res.conn
+				install(res.conn)
+			}
+
+		case outage := <-c.resubDone:
+			resubbing = false
+			if reading && c.hasOrphansSince(outage) {
+				resubbing = true
+				go c.resubscribe(c.orphanList(), outages)
+			}
 
 		// Send path.
 		case op := <-requestOpLock:
@@ -558,6 +628,16 @@ func (c *Client) dispatch(conn net.Conn) {
 					delete(c.respWait, string(id))
 				}
 			}
+			// Install any connection re-established while the send lock was held.
+			if pendingConn != nil {
+				if reading {
+					pendingConn.Close()
+				} else {
+					c.writeConn = pendingConn
+					install(pendingConn)
+				}
+				pendingConn = nil
+			}
 			// Listen for send ops again.
 			requestOpLock = c.requestOp
 			lastOp = nil
@@ -567,6 +647,17 @@ func (c *Client) dispatch(conn net.Conn) {
 
 // closeRequestOps unblocks pending send ops and active subscriptions.
 func (c *Client) closeRequestOps(err error) {
+	c.closePendingOps(err)
+
+	for id, sub := range c.subs {
+		delete(c.subs, id)
+		sub.quitWithError(err, false)
+	}
+	c.closeOrphans(err)
+}
+
+// closePendingOps unblocks pending send ops.
+func (c *Client) closePendingOps(err error) {
 	didClose := make(map[*requestOp]bool)
 
 	for id, op := range c.respWait {
@@ -579,10 +670,6 @@ func (c *Client) closeRequestOps(err error) {
 			didClose[op] = true
 		}
 	}
-	for id, sub := range c.subs {
-		delete(c.subs, id)
-		sub.quitWithError(err, false)
-	}
 }
 
 func (c *Client) handleNotification(msg *jsonrpcMessage) {
@@ -619,6 +706,10 @@ func (c *Client) handleResponse(msg *jsonrpcMessage) {
 	// indicates success. FbcSubscribe gets unblocked in either case through
 	// the op.resp channel.
 	defer close(op.resp)
+	if op.resub {
+		c.handleResubscribe(op, msg)
+		return
+	}
 	if msg.Error != nil {
 		op.err = msg.Error
 		return
@@ -668,9 +759,15 @@ type ClientSubscription struct {
 	etype     reflect.Type
 	channel   reflect.Value
 	namespace string
-	subid     string
+	params    json.RawMessage // subscribe call parameters, for resubscribing
 	in        chan json.RawMessage
 
+	idLock sync.Mutex // protects subid, which changes on resubscription
+	subid  string
+
+	lost time.Time            // time the subscription was orphaned, owned by dispatch
+	gaps chan SubscriptionGap // notifications about resubscriptions
+
 	quitOnce sync.Once     // ensures quit is closed once
 	quit     chan struct{} // quit is closed when the subscription exits
 	errOnce  sync.Once     // ensures err is closed once
@@ -686,6 +783,7 @@ func newClientSubscription(c *Client, namespace string, channel reflect.Value) *
 		quit:      make(chan struct{}),
 		err:       make(chan error, 1),
 		in:        make(chan json.RawMessage),
+		gaps:      make(chan SubscriptionGap, 1),
 	}
 	return sub
 }
@@ -698,6 +796,9 @@ func newClientSubscription(c *Client, namespace string, channel reflect.Value) *
 // on the underlying client and no other error has occurred.
 //
 // The error channel is closed when Unsubscribe is called on the subscription.
+//
+// In reconnecting mode (see EnableReconnect), losing the connection doesn't end the
+// subscription, it's re-established instead and reported on the Gaps channel.
 func (sub *ClientSubscription) Err() <-chan error {
 	return sub.err
 }
@@ -786,6 +887,10 @@ func (sub *ClientSubscription) unmarshal(result json.RawMessage) (interface{}, e
 }
 
 func (sub *ClientSubscription) requestUnsubscribe() error {
+	sub.idLock.Lock()
+	subid := sub.subid
+	sub.idLock.Unlock()
+
 	var result interface{}
-	return sub.client.Call(&result, sub.namespace+unsubscribeMethodSuffix, sub.subid)
+	return sub.client.Call(&result, sub.namespace+unsubscribeMethodSuffix, subid)
 }
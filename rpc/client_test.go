@@ -49,6 +49,28 @@ func TestClientRequest(t *testing.T) {
 	}
 }
 
+func TestClientErrorData(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var resp string
+	err := client.Call(&resp, "service_dataErr")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if err.Error() != "failed with data" {
+		t.Errorf("wrong error message: %q", err)
+	}
+	if code := err.(Error).ErrorCode(); code != 3 {
+		t.Errorf("wrong error code: have %d, want 3", code)
+	}
+	if data := err.(DataError).ErrorData(); data != "0xdeadbeef" {
+		t.Errorf("wrong error data: have %v, want 0xdeadbeef", data)
+	}
+}
+
 func TestClientBatchRequest(t *testing.T) {
 	server := newTestServer("service", new(Service))
 	defer server.Stop()
@@ -52,3 +52,42 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestHTTPVirtualHosts(t *testing.T) {
+	vhosts := []string{"localhost", "*.example.com", "Mixed.Case.org"}
+	handler := newVHostHandler(vhosts, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	tests := []struct {
+		host string
+		code int
+	}{
+		{"", http.StatusOK},
+		{"localhost", http.StatusOK},
+		{"localhost:9565", http.StatusOK},
+		{"127.0.0.1:9565", http.StatusOK},
+		{"[::1]:9565", http.StatusOK},
+		{"rpc.example.com", http.StatusOK},
+		{"deep.rpc.example.com:80", http.StatusOK},
+		{"mixed.case.org", http.StatusOK},
+		{"example.com", http.StatusForbidden},
+		{"evil.com", http.StatusForbidden},
+		{"localhost.evil.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest("POST", "http://url.com", nil)
+		request.Host = tt.host
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tt.code {
+			t.Errorf("host %q: response code mismatch: have %d, want %d", tt.host, recorder.Code, tt.code)
+		}
+	}
+	// Wildcard whitelist accepts all hosts
+	handler = newVHostHandler([]string{"*"}, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest("POST", "http://evil.com", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("wildcard: response code mismatch: have %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
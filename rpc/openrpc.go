@@ -0,0 +1,351 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fairblock/go-fairblock/common/hexutil"
+)
+
+// openRPCVersion is the version of the OpenRPC specification the generated
+// documents conform to.
+const openRPCVersion = "1.2.6"
+
+// OpenRPCDocument is an OpenRPC service description, see https://spec.open-rpc.org.
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+// OpenRPCInfo contains the metadata about the described API.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a single method offered by the API. Subscriptions are
+// listed as methods named after the subscription, with Subscription set. They
+// are invoked through the <namespace>_subscribe method with the subscription name
+// as the first parameter, which is how their parameter list is described.
+type OpenRPCMethod struct {
+	Name         string                     `json:"name"`
+	Summary      string                     `json:"summary,omitempty"`
+	Params       []OpenRPCContentDescriptor `json:"params"`
+	Result       OpenRPCContentDescriptor   `json:"result"`
+	Subscription bool                       `json:"x-subscription,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a method parameter or result.
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+// OpenRPCComponents holds the reusable schemas referenced by the methods.
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas,omitempty"`
+}
+
+// JSONSchema is the subset of JSON schema used to describe Go types.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+const (
+	hexBytesPattern  = "^0x([0-9a-fA-F]{2})*$"
+	hexNumberPattern = "^0x(0|[1-9a-fA-F][0-9a-fA-F]*)$"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	blockNumberType = reflect.TypeOf(BlockNumber(0))
+
+	// knownSchemas are the hand written schemas of types whose JSON encoding
+	// can't be derived from their Go definition.
+	knownSchemas = map[reflect.Type]*JSONSchema{
+		reflect.TypeOf(hexutil.Big{}):     {Title: "hexutil.Big", Type: "string", Pattern: hexNumberPattern},
+		reflect.TypeOf(hexutil.Uint64(0)): {Title: "hexutil.Uint64", Type: "string", Pattern: hexNumberPattern},
+		reflect.TypeOf(hexutil.Uint(0)):   {Title: "hexutil.Uint", Type: "string", Pattern: hexNumberPattern},
+		reflect.TypeOf(hexutil.Bytes{}):   {Title: "hexutil.Bytes", Type: "string", Pattern: hexBytesPattern},
+		bigIntType:                        {Title: "big.Int", Type: "integer"},
+		blockNumberType: {
+			Title: "rpc.BlockNumber",
+			OneOf: []*JSONSchema{
+				{Type: "string", Enum: []string{"earliest", "latest", "pending"}},
+				{Type: "string", Pattern: hexNumberPattern},
+			},
+		},
+	}
+)
+
+// Discover returns the OpenRPC document describing all the methods and
+// subscriptions registered on the server.
+func (s *RPCService) Discover() *OpenRPCDocument {
+	return s.server.openRPC()
+}
+
+// openRPC generates the OpenRPC document of the registered services.
+func (s *Server) openRPC() *OpenRPCDocument {
+	builder := &schemaBuilder{schemas: make(map[string]*JSONSchema), names: make(map[reflect.Type]string)}
+	doc := &OpenRPCDocument{
+		OpenRPC: openRPCVersion,
+		Info:    OpenRPCInfo{Title: "go-fairblock JSON-RPC API", Version: "1.0"},
+		Methods: []OpenRPCMethod{},
+	}
+	// Describe the methods in a fixed order, as the component names assigned to
+	// colliding types depend on the order the types are seen in
+	namespaces := make([]string, 0, len(s.services))
+	for namespace := range s.services {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		svc := s.services[namespace]
+		for _, name := range sortedCallbacks(svc.callbacks) {
+			doc.Methods = append(doc.Methods, builder.method(namespace+serviceMethodSeparator+name, svc.callbacks[name]))
+		}
+		for _, name := range sortedCallbacks(svc.subscriptions) {
+			doc.Methods = append(doc.Methods, builder.subscription(namespace, name, svc.subscriptions[name]))
+		}
+	}
+	sort.SliceStable(doc.Methods, func(i, j int) bool { return doc.Methods[i].Name < doc.Methods[j].Name })
+
+	doc.Components.Schemas = builder.schemas
+	return doc
+}
+
+// sortedCallbacks returns the names of the callbacks in alphabetical order.
+func sortedCallbacks(callbacks map[string]*callback) []string {
+	names := make([]string, 0, len(callbacks))
+	for name := range callbacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemaBuilder derives JSON schemas from Go types, collecting named struct
+// types as reusable components.
+type schemaBuilder struct {
+	schemas map[string]*JSONSchema
+	names   map[reflect.Type]string // Component names assigned to the named structs
+}
+
+// method describes a regular RPC method callback.
+func (b *schemaBuilder) method(name string, callb *callback) OpenRPCMethod {
+	method := OpenRPCMethod{Name: name, Params: b.params(callb.argTypes, 0)}
+
+	mtype := callb.method.Type
+	if mtype.NumOut() == 0 || callb.errPos == 0 {
+		method.Result = OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{Type: "null"}}
+	} else {
+		method.Result = OpenRPCContentDescriptor{Name: "result", Schema: b.result(mtype.Out(0))}
+	}
+	return method
+}
+
+// subscription describes a subscription callback.
+func (b *schemaBuilder) subscription(namespace, name string, callb *callback) OpenRPCMethod {
+	method := OpenRPCMethod{
+		Name:         namespace + serviceMethodSeparator + name,
+		Summary:      fmt.Sprintf("Subscription, created via %s%s, cancelled via %s%s", namespace, subscribeMethodSuffix, namespace, unsubscribeMethodSuffix),
+		Subscription: true,
+	}
+	method.Params = append([]OpenRPCContentDescriptor{{
+		Name:     "subscription",
+		Required: true,
+		Schema:   &JSONSchema{Type: "string", Enum: []string{name}},
+	}}, b.params(callb.argTypes, 1)...)
+	method.Result = OpenRPCContentDescriptor{Name: "subscriptionID", Schema: &JSONSchema{Title: "rpc.ID", Type: "string", Pattern: hexNumberPattern}}
+
+	return method
+}
+
+// params describes a list of method arguments. Trailing pointer arguments are
+// optional as they may be omitted by callers.
+func (b *schemaBuilder) params(types []reflect.Type, offset int) []OpenRPCContentDescriptor {
+	params := make([]OpenRPCContentDescriptor, len(types))
+	optional := true
+	for i := len(types) - 1; i >= 0; i-- {
+		if types[i].Kind() != reflect.Ptr {
+			optional = false
+		}
+		params[i] = OpenRPCContentDescriptor{
+			Name:     fmt.Sprintf("param%d", i+offset),
+			Required: !optional,
+			Schema:   b.schema(types[i]),
+		}
+	}
+	return params
+}
+
+// result describes the return value of a method, which is encoded just like any
+// other value, with the exception of big integers being encoded in hex.
+func (b *schemaBuilder) result(t reflect.Type) *JSONSchema {
+	if isHexNum(t) {
+		return &JSONSchema{Title: "big.Int", Type: "string", Pattern: hexNumberPattern}
+	}
+	return b.schema(t)
+}
+
+// schema derives the JSON schema of a Go type.
+func (b *schemaBuilder) schema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if known, ok := knownSchemas[t]; ok {
+		schema := *known
+		return &schema
+	}
+	// Types with custom encodings are strings if text based, unknown otherwise
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		schema := &JSONSchema{Title: typeName(t), Type: "string"}
+		if t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 {
+			schema.Pattern = fmt.Sprintf("^0x[0-9a-fA-F]{%d}$", 2*t.Len())
+		}
+		return schema
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &JSONSchema{Title: typeName(t)}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Title: "base64", Type: "string"}
+		}
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Array:
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	// Interfaces and anything else can hold arbitrary values
+	return &JSONSchema{}
+}
+
+// structSchema derives the schema of a struct type. Named structs are stored as
+// components and referenced, which also terminates recursive type definitions.
+func (b *schemaBuilder) structSchema(t reflect.Type) *JSONSchema {
+	if typeName(t) == "" {
+		return &JSONSchema{Type: "object", Properties: b.properties(t)}
+	}
+	name, ok := b.names[t]
+	if !ok {
+		name = b.componentName(t)
+		b.names[t] = name
+
+		schema := &JSONSchema{Title: typeName(t), Type: "object"}
+		b.schemas[name] = schema
+		schema.Properties = b.properties(t)
+	}
+	return &JSONSchema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName picks an unused component name for a named type. Types from
+// different packages with the same base name, e.g. text/template.Template and
+// html/template.Template, get a numeric suffix in the order they are seen,
+// which is fixed by describing the methods in alphabetical order.
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := typeName(t)
+	for i := 2; ; i++ {
+		if _, ok := b.schemas[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", typeName(t), i)
+	}
+}
+
+// properties derives the schemas of the fields of a struct as encoded by the
+// encoding/json package, flattening embedded structs.
+func (b *schemaBuilder) properties(t reflect.Type) map[string]*JSONSchema {
+	props := make(map[string]*JSONSchema)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for name, prop := range b.properties(ft) {
+					if _, ok := props[name]; !ok {
+						props[name] = prop
+					}
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = b.schema(field.Type)
+	}
+	return props
+}
+
+// typeName returns the package qualified name of a type, or an empty string for
+// unnamed types.
+func typeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return ""
+	}
+	if t.PkgPath() == "" {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	htmltemplate "html/template"
+	"math/big"
+	"reflect"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/fairblock/go-fairblock/common/hexutil"
+)
+
+type DiscoverAddress [20]byte
+
+func (a DiscoverAddress) MarshalText() ([]byte, error) { return hexutil.Bytes(a[:]).MarshalText() }
+
+type DiscoverCallArgs struct {
+	From  DiscoverAddress `json:"from"`
+	Value *hexutil.Big    `json:"value"`
+	Data  hexutil.Bytes   `json:"data,omitempty"`
+	Skip  string          `json:"-"`
+	Next  *DiscoverCallArgs
+}
+
+type DiscoverService struct{}
+
+func (s *DiscoverService) Call(args DiscoverCallArgs, number BlockNumber, limit *hexutil.Uint64) (hexutil.Bytes, error) {
+	return nil, nil
+}
+
+func (s *DiscoverService) Balance(ctx context.Context, addr DiscoverAddress) *big.Int {
+	return nil
+}
+
+func (s *DiscoverService) Ping() {}
+
+func (s *DiscoverService) Heads(ctx context.Context, full bool) (*Subscription, error) {
+	return nil, nil
+}
+
+type DiscoverTextService struct{}
+
+func (s *DiscoverTextService) Parse(tmpl texttemplate.Template) {}
+
+type DiscoverHTMLService struct{}
+
+func (s *DiscoverHTMLService) Parse(tmpl htmltemplate.Template) {}
+
+func TestDiscover(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("disc", new(DiscoverService)); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var doc OpenRPCDocument
+	if err := client.Call(&doc, "rpc_discover"); err != nil {
+		t.Fatalf("failed to discover: %v", err)
+	}
+	if doc.OpenRPC != openRPCVersion {
+		t.Errorf("version mismatch: have %s, want %s", doc.OpenRPC, openRPCVersion)
+	}
+	methods := make(map[string]OpenRPCMethod)
+	var names []string
+	for _, method := range doc.Methods {
+		methods[method.Name] = method
+		names = append(names, method.Name)
+	}
+	want := []string{"disc_balance", "disc_call", "disc_heads", "disc_ping", "rpc_discover", "rpc_modules"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("method list mismatch: have %v, want %v", names, want)
+	}
+	// Check parameter and result schemas derived from the Go types
+	call := methods["disc_call"]
+	if len(call.Params) != 3 {
+		t.Fatalf("disc_call: parameter count mismatch: have %d, want 3", len(call.Params))
+	}
+	if ref := call.Params[0].Schema.Ref; ref != "#/components/schemas/rpc.DiscoverCallArgs" {
+		t.Errorf("disc_call: struct parameter reference mismatch: have %q", ref)
+	}
+	if schema := call.Params[1].Schema; schema.Title != "rpc.BlockNumber" || len(schema.OneOf) != 2 {
+		t.Errorf("disc_call: block number schema mismatch: have %+v", schema)
+	}
+	if !call.Params[0].Required || !call.Params[1].Required || call.Params[2].Required {
+		t.Errorf("disc_call: required flags mismatch: have %v %v %v", call.Params[0].Required, call.Params[1].Required, call.Params[2].Required)
+	}
+	if schema := call.Result.Schema; schema.Title != "hexutil.Bytes" || schema.Pattern != hexBytesPattern {
+		t.Errorf("disc_call: result schema mismatch: have %+v", schema)
+	}
+	if schema := methods["disc_balance"].Params[0].Schema; schema.Type != "string" || schema.Pattern != "^0x[0-9a-fA-F]{40}$" {
+		t.Errorf("disc_balance: address schema mismatch: have %+v", schema)
+	}
+	if schema := methods["disc_balance"].Result.Schema; schema.Type != "string" || schema.Pattern != hexNumberPattern {
+		t.Errorf("disc_balance: big int result schema mismatch: have %+v", schema)
+	}
+	if schema := methods["disc_ping"].Result.Schema; schema.Type != "null" {
+		t.Errorf("disc_ping: result schema mismatch: have %+v", schema)
+	}
+	// Check the struct component, including the recursive field
+	args := doc.Components.Schemas["rpc.DiscoverCallArgs"]
+	if args == nil {
+		t.Fatalf("struct component missing, have %v", doc.Components.Schemas)
+	}
+	props := []string{"Next", "data", "from", "value"}
+	var have []string
+	for name := range args.Properties {
+		have = append(have, name)
+	}
+	if len(have) != len(props) {
+		t.Errorf("struct properties mismatch: have %v, want %v", have, props)
+	}
+	if schema := args.Properties["value"]; schema == nil || schema.Title != "hexutil.Big" {
+		t.Errorf("struct value property mismatch: have %+v", schema)
+	}
+	if schema := args.Properties["Next"]; schema == nil || schema.Ref != "#/components/schemas/rpc.DiscoverCallArgs" {
+		t.Errorf("recursive struct property mismatch: have %+v", schema)
+	}
+	// Check the subscription description
+	heads := methods["disc_heads"]
+	if !heads.Subscription {
+		t.Errorf("disc_heads: not marked as subscription")
+	}
+	if len(heads.Params) != 2 || !reflect.DeepEqual(heads.Params[0].Schema.Enum, []string{"heads"}) || heads.Params[1].Schema.Type != "boolean" {
+		t.Errorf("disc_heads: parameter mismatch: have %+v", heads.Params)
+	}
+}
+
+// Tests that named structs from different packages with the same base name are
+// stored as distinct components.
+func TestDiscoverComponentNames(t *testing.T) {
+	b := &schemaBuilder{schemas: make(map[string]*JSONSchema), names: make(map[reflect.Type]string)}
+
+	text := b.schema(reflect.TypeOf(texttemplate.Template{}))
+	html := b.schema(reflect.TypeOf(htmltemplate.Template{}))
+	if text.Ref != "#/components/schemas/template.Template" {
+		t.Errorf("first component reference mismatch: have %q", text.Ref)
+	}
+	if html.Ref != "#/components/schemas/template.Template_2" {
+		t.Errorf("colliding component reference mismatch: have %q", html.Ref)
+	}
+	if again := b.schema(reflect.TypeOf(&htmltemplate.Template{})); again.Ref != html.Ref {
+		t.Errorf("component reference not stable: have %q, want %q", again.Ref, html.Ref)
+	}
+	if _, ok := b.schemas["template.Template"].Properties["Tree"]; ok {
+		t.Errorf("text/template component overwritten by html/template")
+	}
+	if _, ok := b.schemas["template.Template_2"].Properties["Tree"]; !ok {
+		t.Errorf("html/template component missing its fields")
+	}
+}
+
+// Tests that the discovered document, including the names of the components of
+// colliding types, is the same on every call.
+func TestDiscoverStable(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("text", new(DiscoverTextService)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("html", new(DiscoverHTMLService)); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var first json.RawMessage
+	if err := client.Call(&first, "rpc_discover"); err != nil {
+		t.Fatalf("failed to discover: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		var doc json.RawMessage
+		if err := client.Call(&doc, "rpc_discover"); err != nil {
+			t.Fatalf("failed to discover: %v", err)
+		}
+		if !bytes.Equal(doc, first) {
+			t.Fatalf("discovery %d mismatch:\nhave %s\nwant %s", i, doc, first)
+		}
+	}
+	var doc OpenRPCDocument
+	if err := json.Unmarshal(first, &doc); err != nil {
+		t.Fatal(err)
+	}
+	refs := make(map[string]string)
+	for _, method := range doc.Methods {
+		if len(method.Params) == 1 {
+			refs[method.Name] = method.Params[0].Schema.Ref
+		}
+	}
+	want := map[string]string{
+		"html_parse": "#/components/schemas/template.Template",
+		"text_parse": "#/components/schemas/template.Template_2",
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("component references mismatch: have %v, want %v", refs, want)
+	}
+}
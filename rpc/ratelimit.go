@@ -0,0 +1,294 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fairblock/go-fairblock/metrics"
+)
+
+const (
+	// RateLimitKeyByIP identifies clients by their remote IP address.
+	RateLimitKeyByIP = "ip"
+
+	// RateLimitKeyByAuth identifies clients by the credentials sent in the HTTP
+	// Authorization header, falling back to their remote IP address if none were
+	// sent. The credentials are not verified by the node, so this mode is meant
+	// to be used behind an authenticating proxy.
+	RateLimitKeyByAuth = "auth"
+
+	// maxIdleQuotas is the number of tracked client quotas above which idle ones
+	// (full token bucket, no calls in flight) are dropped.
+	maxIdleQuotas = 4096
+)
+
+var (
+	rateLimitRejectMeter   = metrics.NewMeter("rpc/ratelimit/rejected/rate")
+	concurrencyRejectMeter = metrics.NewMeter("rpc/ratelimit/rejected/concurrency")
+)
+
+// RateLimitConfig defines the call quotas a Server enforces on its clients.
+//
+// Every call is matched against the most specific rule available: a rule for the
+// fully qualified method name (e.g. "fbc_getLogs") takes precedence over a rule
+// for its namespace (e.g. "debug" or "debug_*"), which in turn takes precedence
+// over the catch-all "*" rule. Calls matching no rule are not limited. Quotas are
+// tracked separately for every client and every rule.
+//
+// Calls rejected due to a quota are answered with a JSON-RPC error of code -32005.
+type RateLimitConfig struct {
+	// KeyBy selects how clients are told apart, either "ip" (default) or "auth".
+	// With "auth", authenticated calls are only charged to the quota of the sent
+	// credentials, so clients sharing an address don't share their limits.
+	KeyBy string `toml:",omitempty"`
+
+	// Rules is the list of per namespace and per method limits.
+	Rules []RateLimitRule `toml:",omitempty"`
+}
+
+// RateLimitRule limits the calls made to a set of RPC methods.
+type RateLimitRule struct {
+	// Method is the pattern of methods the rule applies to: "*", a namespace
+	// ("debug" or "debug_*") or a fully qualified method name ("fbc_getLogs").
+	// Subscriptions are matched by namespace and subscription name ("fbc_logs").
+	Method string
+
+	// Rate is the number of calls per second permitted in the long run. Zero
+	// disables rate limiting for the matched methods.
+	Rate float64 `toml:",omitempty"`
+
+	// Burst is the number of calls permitted in a quick succession above the rate.
+	// It defaults to the rate rounded up (minimum 1) if unset.
+	Burst int `toml:",omitempty"`
+
+	// MaxConcurrent is the number of calls permitted to execute simultaneously.
+	// Zero disables the concurrency cap for the matched methods.
+	MaxConcurrent int `toml:",omitempty"`
+}
+
+// clientInfoKey is the context key under which the connection details of the
+// remote client are stored.
+type clientInfoKey struct{}
+
+// clientInfo contains the details about the remote end of a connection used for
+// telling clients apart.
+type clientInfo struct {
+	ip   string // remote IP address, empty for local transports
+	auth string // authentication identity, empty if none was sent
+}
+
+// withHTTPClientInfo derives a context containing the client details of an HTTP
+// request (also used for the websocket upgrade request).
+func withHTTPClientInfo(ctx context.Context, r *http.Request) context.Context {
+	info := clientInfo{ip: r.RemoteAddr}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		info.ip = host
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		info.auth = "basic:" + user
+	} else if auth := r.Header.Get("Authorization"); auth != "" {
+		info.auth = auth
+	}
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// clientQuota is the token bucket and in-flight call counter of a single client
+// for a single rule.
+type clientQuota struct {
+	tokens float64   // calls currently permitted by the bucket
+	last   time.Time // last time the bucket was refilled
+	active int       // calls currently executing
+}
+
+// quotaKey identifies a client quota.
+type quotaKey struct {
+	rule   *RateLimitRule
+	client string
+}
+
+// rateLimiter enforces a RateLimitConfig.
+type rateLimiter struct {
+	keyBy string
+	rules map[string]*RateLimitRule // rules indexed by normalized method pattern
+
+	lock   sync.Mutex
+	quotas map[quotaKey]*clientQuota
+}
+
+// newRateLimiter validates a rate limit configuration and creates a limiter for
+// it. Nil is returned if the configuration contains no rules.
+func newRateLimiter(config RateLimitConfig) (*rateLimiter, error) {
+	switch config.KeyBy {
+	case "", RateLimitKeyByIP, RateLimitKeyByAuth:
+	default:
+		return nil, fmt.Errorf("invalid rate limit client key %q, must be %q or %q", config.KeyBy, RateLimitKeyByIP, RateLimitKeyByAuth)
+	}
+	if len(config.Rules) == 0 {
+		return nil, nil
+	}
+	limiter := &rateLimiter{
+		keyBy:  config.KeyBy,
+		rules:  make(map[string]*RateLimitRule),
+		quotas: make(map[quotaKey]*clientQuota),
+	}
+	for i := range config.Rules {
+		rule := config.Rules[i]
+		if rule.Rate < 0 || rule.Burst < 0 || rule.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("invalid rate limit rule for %q: negative limit", rule.Method)
+		}
+		if rule.Rate > 0 && rule.Burst == 0 {
+			rule.Burst = int(rule.Rate)
+			if float64(rule.Burst) < rule.Rate {
+				rule.Burst++
+			}
+		}
+		pattern := strings.TrimSuffix(rule.Method, serviceMethodSeparator+"*")
+		if pattern == "" {
+			return nil, fmt.Errorf("rate limit rule #%d has no method pattern", i)
+		}
+		if _, exist := limiter.rules[pattern]; exist {
+			return nil, fmt.Errorf("duplicate rate limit rule for %q", rule.Method)
+		}
+		limiter.rules[pattern] = &rule
+	}
+	return limiter, nil
+}
+
+// match returns the most specific rule applicable to a method, or nil if the
+// method isn't limited.
+func (l *rateLimiter) match(namespace, method string) *RateLimitRule {
+	if rule, ok := l.rules[namespace+serviceMethodSeparator+method]; ok {
+		return rule
+	}
+	if rule, ok := l.rules[namespace]; ok {
+		return rule
+	}
+	return l.rules["*"]
+}
+
+// clientKey derives the identity quotas are tracked by from the request context.
+func (l *rateLimiter) clientKey(ctx context.Context) string {
+	info, _ := ctx.Value(clientInfoKey{}).(clientInfo)
+
+	switch {
+	case l.keyBy == RateLimitKeyByAuth && info.auth != "":
+		return "auth:" + info.auth
+	case info.ip != "":
+		return "ip:" + info.ip
+	default:
+		return "local"
+	}
+}
+
+// acquire checks whether a call to the given method is permitted for the client
+// making the request. If so, it returns a function that must be invoked once the
+// call has finished executing, otherwise the error to return to the client.
+func (l *rateLimiter) acquire(ctx context.Context, namespace, method string) (func(), Error) {
+	rule := l.match(namespace, method)
+	if rule == nil || (rule.Rate == 0 && rule.MaxConcurrent == 0) {
+		return func() {}, nil
+	}
+	key := quotaKey{rule: rule, client: l.clientKey(ctx)}
+	name := namespace + serviceMethodSeparator + method
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	quota := l.quotas[key]
+	if quota == nil {
+		l.evict(now)
+		quota = &clientQuota{tokens: float64(rule.Burst), last: now}
+		l.quotas[key] = quota
+	}
+	if rule.MaxConcurrent > 0 && quota.active >= rule.MaxConcurrent {
+		concurrencyRejectMeter.Mark(1)
+		markRejected(name)
+		return nil, &limitExceededError{fmt.Sprintf("too many concurrent calls to %s, limit is %d", name, rule.MaxConcurrent)}
+	}
+	if rule.Rate > 0 {
+		quota.refill(rule, now)
+		if quota.tokens < 1 {
+			rateLimitRejectMeter.Mark(1)
+			markRejected(name)
+			return nil, &limitExceededError{fmt.Sprintf("rate limit exceeded for %s, limit is %v calls per second", name, rule.Rate)}
+		}
+		quota.tokens--
+	}
+	quota.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.lock.Lock()
+			quota.active--
+			l.lock.Unlock()
+		})
+	}, nil
+}
+
+// markRejected bumps the rejection meter of a single method.
+func markRejected(name string) {
+	if !metrics.Enabled {
+		return
+	}
+	metrics.NewMeter("rpc/ratelimit/rejected/method/" + name).Mark(1)
+}
+
+// evict drops the quotas of clients which are idle if the number of tracked
+// quotas grew too large. The caller must hold the limiter lock.
+func (l *rateLimiter) evict(now time.Time) {
+	if len(l.quotas) < maxIdleQuotas {
+		return
+	}
+	for key, quota := range l.quotas {
+		if quota.active > 0 {
+			continue
+		}
+		quota.refill(key.rule, now)
+		if quota.tokens >= float64(key.rule.Burst) {
+			delete(l.quotas, key)
+		}
+	}
+}
+
+// refill adds the tokens accumulated since the last refill to the bucket.
+func (q *clientQuota) refill(rule *RateLimitRule, now time.Time) {
+	q.tokens += now.Sub(q.last).Seconds() * rule.Rate
+	if q.tokens > float64(rule.Burst) {
+		q.tokens = float64(rule.Burst)
+	}
+	q.last = now
+}
+
+// SetRateLimits configures the call quotas enforced on the clients of the server.
+// It must be called before the server starts serving requests.
+func (s *Server) SetRateLimits(config RateLimitConfig) error {
+	limiter, err := newRateLimiter(config)
+	if err != nil {
+		return err
+	}
+	s.limiter = limiter
+	return nil
+}
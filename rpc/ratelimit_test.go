@@ -0,0 +1,188 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimitRuleMatching(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimitConfig{
+		Rules: []RateLimitRule{
+			{Method: "*", Rate: 100},
+			{Method: "debug_*", Rate: 10},
+			{Method: "fbc", Rate: 20},
+			{Method: "fbc_getLogs", Rate: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	tests := []struct {
+		namespace, method string
+		rate              float64
+	}{
+		{"fbc", "getLogs", 1},
+		{"fbc", "blockNumber", 20},
+		{"debug", "traceTransaction", 10},
+		{"net", "version", 100},
+	}
+	for _, tt := range tests {
+		if rule := limiter.match(tt.namespace, tt.method); rule == nil || rule.Rate != tt.rate {
+			t.Errorf("%s_%s: matched rule mismatch: have %v, want rate %v", tt.namespace, tt.method, rule, tt.rate)
+		}
+	}
+}
+
+func TestRateLimitInvalidConfig(t *testing.T) {
+	configs := []RateLimitConfig{
+		{KeyBy: "cookie"},
+		{Rules: []RateLimitRule{{Method: "", Rate: 1}}},
+		{Rules: []RateLimitRule{{Method: "fbc", Rate: -1}}},
+		{Rules: []RateLimitRule{{Method: "fbc", Rate: 1}, {Method: "fbc_*", Rate: 2}}},
+	}
+	for i, config := range configs {
+		if _, err := newRateLimiter(config); err == nil {
+			t.Errorf("config %d: expected error, got none", i)
+		}
+	}
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetRateLimits(RateLimitConfig{
+		Rules: []RateLimitRule{{Method: "test_echo", Rate: 0.001, Burst: 2}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	call := func(method string) *jsonError {
+		request := map[string]interface{}{
+			"id":      1,
+			"method":  method,
+			"version": "2.0",
+			"params":  []interface{}{"s", 1, &Args{"a"}},
+		}
+		if err := out.Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		var response jsonrpcMessage
+		if err := in.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Error
+	}
+	for i := 0; i < 2; i++ {
+		if err := call("test_echo"); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if err := call("test_echo"); err == nil || err.Code != -32005 {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	// Other methods must not be affected by the quota
+	if err := call("test_echoWithCtx"); err != nil {
+		t.Fatalf("unlimited method rejected: %v", err)
+	}
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimitConfig{
+		Rules: []RateLimitRule{{Method: "debug", MaxConcurrent: 1}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	ctx := context.Background()
+
+	release, err := limiter.acquire(ctx, "debug", "traceBlock")
+	if err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	if _, err := limiter.acquire(ctx, "debug", "traceTransaction"); err == nil {
+		t.Fatalf("concurrent call accepted")
+	}
+	release()
+	release() // releasing twice must not free up a second slot
+
+	if _, err := limiter.acquire(ctx, "debug", "traceTransaction"); err != nil {
+		t.Fatalf("call rejected after release: %v", err)
+	}
+	if _, err := limiter.acquire(ctx, "debug", "traceTransaction"); err == nil {
+		t.Fatalf("concurrent call accepted after double release")
+	}
+}
+
+func TestRateLimitClientKeys(t *testing.T) {
+	newCtx := func(remote, auth string) context.Context {
+		r := httptest.NewRequest("POST", "http://localhost", nil)
+		r.RemoteAddr = remote
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		return withHTTPClientInfo(context.Background(), r)
+	}
+	rules := []RateLimitRule{{Method: "*", Rate: 0.001, Burst: 1}}
+
+	// Clients keyed by IP address share the quota across ports and credentials
+	limiter, _ := newRateLimiter(RateLimitConfig{KeyBy: RateLimitKeyByIP, Rules: rules})
+	if _, err := limiter.acquire(newCtx("10.0.0.1:1000", "token-a"), "fbc", "call"); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.1:2000", "token-b"), "fbc", "call"); err == nil {
+		t.Fatalf("same IP got a separate quota")
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.2:1000", "token-a"), "fbc", "call"); err != nil {
+		t.Fatalf("different IP shares the quota: %v", err)
+	}
+	// Clients keyed by auth identity share the quota across addresses, but not
+	// with other identities behind the same address
+	limiter, _ = newRateLimiter(RateLimitConfig{KeyBy: RateLimitKeyByAuth, Rules: rules})
+	if _, err := limiter.acquire(newCtx("10.0.0.1:1000", "token-a"), "fbc", "call"); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.2:1000", "token-a"), "fbc", "call"); err == nil {
+		t.Fatalf("same identity got a separate quota")
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.2:1000", "token-b"), "fbc", "call"); err != nil {
+		t.Fatalf("different identity shares the quota: %v", err)
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.1:1000", "token-c"), "fbc", "call"); err != nil {
+		t.Fatalf("identity charged to the quota of its address: %v", err)
+	}
+	// Unauthenticated clients fall back to their IP address
+	if _, err := limiter.acquire(newCtx("10.0.0.3:1000", ""), "fbc", "call"); err != nil {
+		t.Fatalf("first unauthenticated call rejected: %v", err)
+	}
+	if _, err := limiter.acquire(newCtx("10.0.0.3:2000", ""), "fbc", "call"); err == nil {
+		t.Fatalf("same unauthenticated IP got a separate quota")
+	}
+}
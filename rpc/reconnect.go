@@ -0,0 +1,269 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/fairblock/go-fairblock/log"
+)
+
+const (
+	defaultMinReconnectBackoff = 500 * time.Millisecond // used if ReconnectConfig.MinBackoff is unset
+	defaultMaxReconnectBackoff = 30 * time.Second       // used if ReconnectConfig.MaxBackoff is unset
+)
+
+var errReconnectHTTP = errors.New("reconnecting mode is not supported over HTTP")
+
+// ReconnectConfig are the settings of the reconnecting mode of a Client.
+type ReconnectConfig struct {
+	MinBackoff  time.Duration // Delay before the first reconnection attempt, doubled on every failure
+	MaxBackoff  time.Duration // Maximum delay between reconnection attempts
+	MaxAttempts int           // Number of attempts before giving up, zero for unlimited
+}
+
+// SubscriptionGap is delivered on the Gaps channel of a subscription when it was
+// re-established after the connection to the server was lost. Notifications sent
+// by the server in between were missed and might need to be backfilled.
+type SubscriptionGap struct {
+	Lost     time.Time // Time the connection carrying the subscription was lost
+	Restored time.Time // Time the subscription was re-established
+}
+
+// redialResult is the outcome of a background reconnection.
+type redialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// EnableReconnect switches the client into reconnecting mode, which is available
+// for websocket, IPC and in-process connections.
+//
+// By default, all active subscriptions are ended with an error if the connection
+// to the server is lost. In reconnecting mode, the client instead re-establishes
+// the connection in the background, waiting an exponentially increasing backoff
+// time between attempts, and transparently re-creates all active subscriptions
+// with their original arguments. Every re-created subscription reports the time
+// span it was interrupted on its Gaps channel. Calls in flight while the connection
+// is lost still fail with an error. Subscriptions only end with an error if the
+// server rejects the resubscription or the client gives up reconnecting.
+func (c *Client) EnableReconnect(config ReconnectConfig) error {
+	if c.isHTTP {
+		return errReconnectHTTP
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = defaultMinReconnectBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxReconnectBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = config.MinBackoff
+	}
+	c.reconnectLock.Lock()
+	defer c.reconnectLock.Unlock()
+
+	c.reconnectCfg = &config
+	return nil
+}
+
+// reconnectConfig returns the reconnecting mode settings, or nil if disabled.
+func (c *Client) reconnectConfig() *ReconnectConfig {
+	c.reconnectLock.Lock()
+	defer c.reconnectLock.Unlock()
+
+	return c.reconnectCfg
+}
+
+// redial tries to establish a new connection to the server, backing off between
+// failed attempts. The outcome is reported to the dispatch loop.
+func (c *Client) redial(config ReconnectConfig) {
+	var (
+		backoff = config.MinBackoff
+		res     redialResult
+	)
+	for attempt := 1; config.MaxAttempts == 0 || attempt <= config.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-c.didQuit:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+		res.conn, res.err = c.connectFunc(ctx)
+		cancel()
+
+		if res.err == nil {
+			break
+		}
+		log.Debug(fmt.Sprintf("reconnect attempt %d failed: %v", attempt, res.err))
+		if backoff *= 2; backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+	select {
+	case c.redialDone <- res:
+	case <-c.didQuit:
+		if res.conn != nil {
+			res.conn.Close()
+		}
+	}
+}
+
+// orphanSubscriptions moves all active subscriptions into the set of orphans to
+// be re-established after reconnecting. It's called by the dispatch loop when
+// the connection is lost.
+func (c *Client) orphanSubscriptions(outage uint64) {
+	now := time.Now()
+	for id, sub := range c.subs {
+		delete(c.subs, id)
+		sub.lost = now
+		c.orphans[sub] = outage
+	}
+}
+
+// orphanList returns the orphaned subscriptions which haven't been unsubscribed
+// in the meantime, dropping the ones which have.
+func (c *Client) orphanList() []*ClientSubscription {
+	subs := make([]*ClientSubscription, 0, len(c.orphans))
+	for sub := range c.orphans {
+		select {
+		case <-sub.quit:
+			delete(c.orphans, sub)
+		default:
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// hasOrphansSince reports whether any subscription was orphaned after the given
+// outage.
+func (c *Client) hasOrphansSince(outage uint64) bool {
+	for _, lost := range c.orphans {
+		if lost > outage {
+			return true
+		}
+	}
+	return false
+}
+
+// closeOrphans ends all subscriptions awaiting resubscription with an error.
+func (c *Client) closeOrphans(err error) {
+	for sub := range c.orphans {
+		delete(c.orphans, sub)
+		sub.quitWithError(err, false)
+	}
+}
+
+// resubscribe re-creates the given subscriptions on the server. The responses
+// are processed by the dispatch loop, which is notified once all requests have
+// been made.
+func (c *Client) resubscribe(subs []*ClientSubscription, outage uint64) {
+	for _, sub := range subs {
+		msg := &jsonrpcMessage{Version: "2.0", ID: c.nextID(), Method: sub.namespace + subscribeMethodSuffix, Params: sub.params}
+		op := &requestOp{
+			ids:   []json.RawMessage{msg.ID},
+			resp:  make(chan *jsonrpcMessage),
+			sub:   sub,
+			resub: true,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+		err := c.send(ctx, op, msg)
+		if err == nil {
+			_, err = op.wait(ctx)
+		}
+		cancel()
+
+		if err == ErrClientQuit {
+			return
+		}
+		if err != nil {
+			log.Debug(fmt.Sprintf("resubscription to %s failed: %v", sub.namespace, err))
+		}
+	}
+	select {
+	case c.resubDone <- outage:
+	case <-c.didQuit:
+	}
+}
+
+// handleResubscribe processes the server response to a resubscription request.
+// It's called by the dispatch loop.
+func (c *Client) handleResubscribe(op *requestOp, msg *jsonrpcMessage) {
+	sub := op.sub
+	if _, ok := c.orphans[sub]; !ok {
+		// The subscription was ended while the request was in flight, make sure
+		// the server side doesn't linger around.
+		var subid string
+		if msg.Error == nil && json.Unmarshal(msg.Result, &subid) == nil {
+			go func() {
+				var result interface{}
+				c.Call(&result, sub.namespace+unsubscribeMethodSuffix, subid)
+			}()
+		}
+		return
+	}
+	delete(c.orphans, sub)
+
+	if msg.Error != nil {
+		op.err = msg.Error
+		sub.quitWithError(msg.Error, false)
+		return
+	}
+	var subid string
+	if op.err = json.Unmarshal(msg.Result, &subid); op.err != nil {
+		sub.quitWithError(op.err, false)
+		return
+	}
+	sub.idLock.Lock()
+	sub.subid = subid
+	sub.idLock.Unlock()
+
+	c.subs[subid] = sub
+	sub.reportGap(SubscriptionGap{Lost: sub.lost, Restored: time.Now()})
+}
+
+// Gaps returns a channel which receives a notification whenever the subscription
+// was re-established by a client in reconnecting mode. Only the latest gap is
+// buffered: if the channel isn't read in time, consecutive gaps are merged.
+func (sub *ClientSubscription) Gaps() <-chan SubscriptionGap {
+	return sub.gaps
+}
+
+// reportGap delivers a gap notification without blocking, merging it with any
+// notification still pending in the channel.
+func (sub *ClientSubscription) reportGap(gap SubscriptionGap) {
+	select {
+	case sub.gaps <- gap:
+		return
+	default:
+	}
+	select {
+	case prev := <-sub.gaps:
+		gap.Lost = prev.Lost
+	default:
+	}
+	select {
+	case sub.gaps <- gap:
+	default:
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TickerService streams increasing numbers to its subscribers.
+type TickerService struct{}
+
+func (s *TickerService) Ticker(ctx context.Context, start int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := start; ; i++ {
+			select {
+			case <-time.After(5 * time.Millisecond):
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+			if err := notifier.Notify(sub.ID, i); err != nil {
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// flakeyDialer connects clients to an in-process server, allowing the tests to
+// drop the live connection and to refuse new ones.
+type flakeyDialer struct {
+	server *Server
+
+	lock    sync.Mutex
+	conn    net.Conn
+	refuse  int // number of dial attempts to refuse
+	refused int // number of dial attempts refused
+}
+
+func (d *flakeyDialer) dial(context.Context) (net.Conn, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.refuse != 0 {
+		if d.refuse > 0 {
+			d.refuse--
+		}
+		d.refused++
+		return nil, errors.New("connection refused")
+	}
+	p1, p2 := net.Pipe()
+	go d.server.ServeCodec(NewJSONCodec(p1), OptionMethodInvocation|OptionSubscriptions)
+	d.conn = p2
+	return p2, nil
+}
+
+// drop kills the live connection, refusing the given number of redials (-1 for
+// refusing all of them).
+func (d *flakeyDialer) drop(refuse int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.refuse = refuse
+	d.conn.Close()
+}
+
+func newFlakeyClient(t *testing.T) (*Client, *flakeyDialer) {
+	dialer := &flakeyDialer{server: newTestServer("fbc", new(TickerService))}
+	client, err := newClient(context.Background(), dialer.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, dialer
+}
+
+func TestClientReconnectResubscribe(t *testing.T) {
+	client, dialer := newFlakeyClient(t)
+	defer client.Close()
+
+	if err := client.EnableReconnect(ReconnectConfig{MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan int, 100)
+	sub, err := client.FbcSubscribe(context.Background(), ch, "ticker", 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		if val := <-ch; val != i {
+			t.Fatalf("value mismatch: have %d, want %d", val, i)
+		}
+	}
+	// Drop the connection and refuse a few reconnection attempts
+	lost := time.Now()
+	dialer.drop(3)
+
+	select {
+	case gap := <-sub.Gaps():
+		if gap.Lost.Before(lost) || gap.Restored.Before(gap.Lost) {
+			t.Errorf("invalid gap: lost %v, restored %v, dropped at %v", gap.Lost, gap.Restored, lost)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not re-established")
+	}
+	dialer.lock.Lock()
+	if dialer.refused != 3 {
+		t.Errorf("refused dial count mismatch: have %d, want %d", dialer.refused, 3)
+	}
+	dialer.lock.Unlock()
+
+	// The resubscription uses the original arguments, so the ticker restarts
+	for {
+		select {
+		case val := <-ch:
+			if val == 0 {
+				// Calls must work on the restored connection too
+				var modules map[string]string
+				if err := client.Call(&modules, "rpc_modules"); err != nil {
+					t.Fatalf("call failed after reconnecting: %v", err)
+				}
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notifications after resubscribing")
+		}
+	}
+}
+
+func TestClientReconnectGiveUp(t *testing.T) {
+	client, dialer := newFlakeyClient(t)
+	defer client.Close()
+
+	if err := client.EnableReconnect(ReconnectConfig{MinBackoff: time.Millisecond, MaxAttempts: 3}); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan int, 100)
+	sub, err := client.FbcSubscribe(context.Background(), ch, "ticker", 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	<-ch
+	dialer.drop(-1)
+
+	select {
+	case err := <-sub.Err():
+		if err == nil {
+			t.Fatalf("expected error after giving up reconnecting")
+		}
+	case <-sub.Gaps():
+		t.Fatalf("subscription re-established despite refused connections")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not ended after giving up reconnecting")
+	}
+	dialer.lock.Lock()
+	if dialer.refused != 3 {
+		t.Errorf("refused dial count mismatch: have %d, want %d", dialer.refused, 3)
+	}
+	dialer.lock.Unlock()
+}
+
+func TestClientNoReconnect(t *testing.T) {
+	client, dialer := newFlakeyClient(t)
+	defer client.Close()
+
+	ch := make(chan int, 100)
+	sub, err := client.FbcSubscribe(context.Background(), ch, "ticker", 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	<-ch
+	dialer.drop(0)
+
+	select {
+	case err := <-sub.Err():
+		if err == nil {
+			t.Fatalf("expected error after connection loss")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not ended after connection loss")
+	}
+}
+
+func TestClientReconnectHTTP(t *testing.T) {
+	client, err := DialHTTP("http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.EnableReconnect(ReconnectConfig{}); err != errReconnectHTTP {
+		t.Fatalf("error mismatch: have %v, want %v", err, errReconnectHTTP)
+	}
+}
@@ -18,12 +18,14 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/fairblock/go-fairblock/log"
 	"gopkg.in/fatih/set.v0"
@@ -31,6 +33,11 @@ import (
 
 const MetadataApi = "rpc"
 
+// maxDetachedCalls is the maximum number of calls of methods without a context
+// run in the background, where they can be abandoned once they time out. Past
+// it, such calls are run to completion before their timeout is reported.
+const maxDetachedCalls = 64
+
 // CodecOption specifies which type of messages this codec supports
 type CodecOption int
 
@@ -125,7 +132,7 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 // If singleShot is true it will process a single request, otherwise it will handle
 // requests until the codec returns an error when reading a request (in most cases
 // an EOF). It executes requests in parallel when singleShot is false.
-func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecOption) error {
+func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot bool, options CodecOption) error {
 	var pend sync.WaitGroup
 
 	defer func() {
@@ -140,7 +147,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		s.codecsMu.Unlock()
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// if the codec supports notification include a notifier that callbacks can use
@@ -186,6 +193,17 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			}
 			return nil
 		}
+		// reject batches exceeding the configured item limit as a whole
+		if batch && s.maxBatchItems > 0 && len(reqs) > s.maxBatchItems {
+			err := &invalidRequestError{fmt.Sprintf("batch too large (%d>%d)", len(reqs), s.maxBatchItems)}
+			if err := codec.Write(codec.CreateErrorResponse(nil, err)); err != nil {
+				log.Debug("Failed to write batch rejection", "err", err)
+			}
+			if singleShot {
+				return nil
+			}
+			continue
+		}
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -210,19 +228,36 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	return nil
 }
 
+// SetBatchLimits sets the maximum number of requests permitted in a batch and the
+// maximum size in bytes of the responses sent back for a single request or batch.
+// Zero values disable the respective limit. It must be called before the server
+// starts serving requests.
+func (s *Server) SetBatchLimits(itemLimit, maxResponseSize int) {
+	s.maxBatchItems = itemLimit
+	s.maxResponseSize = maxResponseSize
+}
+
+// SetExecutionTimeout sets the time a single method call may execute before it
+// is answered with a timeout error. The context passed to the method is cancelled
+// when the timeout expires. Zero disables the timeout. Subscriptions are not
+// affected. It must be called before the server starts serving requests.
+func (s *Server) SetExecutionTimeout(timeout time.Duration) {
+	s.execTimeout = timeout
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes the
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(codec, false, options)
+	s.serveRequest(context.Background(), codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
 // close the codec unless a non-recoverable error has occurred. Note, this method will return after
 // a single request has been processed!
 func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
-	s.serveRequest(codec, true, options)
+	s.serveRequest(context.Background(), codec, true, options)
 }
 
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
@@ -276,7 +311,18 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	// enforce the client quotas before executing anything
+	release := func() {}
+	if s.limiter != nil {
+		var err Error
+		if release, err = s.limiter.acquire(ctx, req.svcname, req.method); err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
+		defer release()
+
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
@@ -293,11 +339,17 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 
 	// regular RPC call, prepare arguments
 	if len(req.args) != len(req.callb.argTypes) {
+		release()
 		rpcErr := &invalidParamsError{fmt.Sprintf("%s%s%s expects %d parameters, got %d",
 			req.svcname, serviceMethodSeparator, req.callb.method.Name,
 			len(req.callb.argTypes), len(req.args))}
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
+	if s.execTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.execTimeout)
+		defer cancel()
+	}
 
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
@@ -308,7 +360,10 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	reply, err := s.call(ctx, req.callb, arguments, release)
+	if err != nil {
+		return codec.CreateErrorResponse(&req.id, err), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -316,13 +371,87 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+
+			// Retain the error code and any data attached by the callback
+			var rerr Error = &callbackError{e.Error()}
+			if ec, ok := e.(Error); ok {
+				rerr = ec
+			}
+			if de, ok := e.(DataError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, rerr, de.ErrorData()), nil
+			}
+			return codec.CreateErrorResponse(&req.id, rerr), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// call invokes a method callback with the given arguments, invoking done once the
+// method returns. If an execution timeout is configured, methods taking a context
+// are expected to return once it expires and are waited for, so no work is left
+// running. Methods without a context can't be interrupted: they are abandoned
+// with an error when the timeout expires, leaving them to finish in the
+// background, but only up to maxDetachedCalls at a time.
+func (s *Server) call(ctx context.Context, callb *callback, arguments []reflect.Value, done func()) ([]reflect.Value, Error) {
+	if s.execTimeout == 0 {
+		defer done()
+		return callb.method.Func.Call(arguments), nil
+	}
+	if callb.hasCtx || atomic.AddInt32(&s.detached, 1) > maxDetachedCalls {
+		if !callb.hasCtx {
+			atomic.AddInt32(&s.detached, -1)
+		}
+		reply := callb.method.Func.Call(arguments)
+		done()
+		if err := ctx.Err(); err != nil {
+			return nil, s.contextError(err)
+		}
+		return reply, nil
+	}
+	result := make(chan []reflect.Value, 1)
+	go func() {
+		defer atomic.AddInt32(&s.detached, -1)
+		defer done()
+		result <- callb.method.Func.Call(arguments)
+	}()
+	select {
+	case reply := <-result:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, s.contextError(ctx.Err())
+	}
+}
+
+// contextError converts the error of an expired call context into an RPC error.
+func (s *Server) contextError(err error) Error {
+	if err == context.DeadlineExceeded {
+		return &timeoutError{s.execTimeout}
+	}
+	return &callbackError{err.Error()}
+}
+
+// limitResponse checks whether adding the given response to the ones already
+// written to the client would exceed the response size limit. If so, an error
+// response is returned in its place. The size of the response is added to used.
+// Responses within the limit are returned encoded, so they aren't marshalled
+// a second time by the codec.
+func (s *Server) limitResponse(codec ServerCodec, req *serverRequest, response interface{}, used *int) interface{} {
+	if s.maxResponseSize == 0 {
+		return response
+	}
+	blob, err := json.Marshal(response)
+	if err != nil {
+		// leave it to the codec to report the encoding failure
+		return response
+	}
+	if *used+len(blob) > s.maxResponseSize {
+		*used = s.maxResponseSize
+		return codec.CreateErrorResponse(&req.id, &responseTooLargeError{s.maxResponseSize})
+	}
+	*used += len(blob)
+	return json.RawMessage(blob)
+}
+
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
@@ -332,6 +461,8 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	var used int
+	response = s.limitResponse(codec, req, response, &used)
 
 	if err := codec.Write(response); err != nil {
 		log.Error(fmt.Sprintf("%v\n", err))
@@ -348,8 +479,16 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 // It will only write the response back when the last request is processed.
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
-	var callbacks []func()
+	var (
+		callbacks []func()
+		used      int
+	)
 	for i, req := range requests {
+		// stop executing requests once the response size limit has been reached
+		if s.maxResponseSize > 0 && used >= s.maxResponseSize {
+			responses[i] = codec.CreateErrorResponse(&req.id, &responseTooLargeError{s.maxResponseSize})
+			continue
+		}
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
@@ -358,6 +497,7 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 				callbacks = append(callbacks, callback)
 			}
 		}
+		responses[i] = s.limitResponse(codec, req, responses[i], &used)
 	}
 
 	if err := codec.Write(responses); err != nil {
@@ -410,7 +550,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		if r.isPubSub { // fbc_subscribe, r.method contains the subscription method name
 			if callb, ok := svc.subscriptions[r.method]; ok {
-				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+				requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: r.method, callb: callb}
 				if r.params != nil && len(callb.argTypes) > 0 {
 					argTypes := []reflect.Type{reflect.TypeOf("")}
 					argTypes = append(argTypes, callb.argTypes...)
@@ -427,7 +567,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		}
 
 		if callb, ok := svc.callbacks[r.method]; ok { // lookup RPC method
-			requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+			requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: r.method, callb: callb}
 			if r.params != nil && len(callb.argTypes) > 0 {
 				if args, err := codec.ParseRequestArguments(callb.argTypes, r.params); err == nil {
 					requests[i].args = args
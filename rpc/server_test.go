@@ -19,8 +19,11 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -48,7 +51,13 @@ func (s *Service) EchoWithCtx(ctx context.Context, str string, i int, args *Args
 	return Result{str, i, args}
 }
 
+// sleeping counts the Sleep calls that haven't returned yet.
+var sleeping int32
+
 func (s *Service) Sleep(ctx context.Context, duration time.Duration) {
+	atomic.AddInt32(&sleeping, 1)
+	defer atomic.AddInt32(&sleeping, -1)
+
 	select {
 	case <-time.After(duration):
 	case <-ctx.Done():
@@ -59,6 +68,17 @@ func (s *Service) Rets() (string, error) {
 	return "", nil
 }
 
+// dataError is an error carrying a custom error code and data.
+type dataError struct{}
+
+func (e *dataError) Error() string          { return "failed with data" }
+func (e *dataError) ErrorCode() int         { return 3 }
+func (e *dataError) ErrorData() interface{} { return "0xdeadbeef" }
+
+func (s *Service) DataErr() (string, error) {
+	return "", new(dataError)
+}
+
 func (s *Service) InvalidRets1() (error, string) {
 	return nil, ""
 }
@@ -92,8 +112,8 @@ func TestServerRegisterName(t *testing.T) {
 		t.Fatalf("Expected service calc to be registered")
 	}
 
-	if len(svc.callbacks) != 5 {
-		t.Errorf("Expected 5 callbacks for service 'calc', got %d", len(svc.callbacks))
+	if len(svc.callbacks) != 6 {
+		t.Errorf("Expected 6 callbacks for service 'calc', got %d", len(svc.callbacks))
 	}
 
 	if len(svc.subscriptions) != 1 {
@@ -160,3 +180,137 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+// testServerLimits starts serving a test service on a pipe connection, returning
+// the client end of the connection.
+func testServerLimits(t *testing.T, configure func(*Server)) (*json.Encoder, *json.Decoder, func()) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	configure(server)
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	return json.NewEncoder(clientConn), json.NewDecoder(clientConn), func() { clientConn.Close() }
+}
+
+func echoRequest(id int, str string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      id,
+		"method":  "test_echo",
+		"version": "2.0",
+		"params":  []interface{}{str, id, &Args{"abcde"}},
+	}
+}
+
+func TestServerBatchItemLimit(t *testing.T) {
+	out, in, closer := testServerLimits(t, func(s *Server) { s.SetBatchLimits(2, 0) })
+	defer closer()
+
+	// A batch within the limit is served
+	if err := out.Encode([]interface{}{echoRequest(1, "a"), echoRequest(2, "b")}); err != nil {
+		t.Fatal(err)
+	}
+	var responses []jsonrpcMessage
+	if err := in.Decode(&responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("response count mismatch: have %d, want %d", len(responses), 2)
+	}
+	for i, resp := range responses {
+		if resp.Error != nil {
+			t.Errorf("response %d: unexpected error: %v", i, resp.Error.Message)
+		}
+	}
+	// A batch exceeding the limit is rejected as a whole
+	if err := out.Encode([]interface{}{echoRequest(1, "a"), echoRequest(2, "b"), echoRequest(3, "c")}); err != nil {
+		t.Fatal(err)
+	}
+	var response jsonrpcMessage
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Code != -32600 {
+		t.Fatalf("expected batch too large error, got %+v", response)
+	}
+}
+
+func TestServerResponseSizeLimit(t *testing.T) {
+	payload := strings.Repeat("x", 400)
+	out, in, closer := testServerLimits(t, func(s *Server) { s.SetBatchLimits(0, 1000) })
+	defer closer()
+
+	// Responses within the limit are served, subsequent ones are replaced by errors
+	batch := []interface{}{echoRequest(1, payload), echoRequest(2, payload), echoRequest(3, payload), echoRequest(4, "")}
+	if err := out.Encode(batch); err != nil {
+		t.Fatal(err)
+	}
+	var responses []jsonrpcMessage
+	if err := in.Decode(&responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != len(batch) {
+		t.Fatalf("response count mismatch: have %d, want %d", len(responses), len(batch))
+	}
+	for i, resp := range responses {
+		switch {
+		case i < 2 && resp.Error != nil:
+			t.Errorf("response %d: unexpected error: %v", i, resp.Error.Message)
+		case i >= 2 && (resp.Error == nil || resp.Error.Code != -32003):
+			t.Errorf("response %d: expected response too large error, got %+v", i, resp)
+		}
+		if string(resp.ID) != fmt.Sprint(i+1) {
+			t.Errorf("response %d: id mismatch: have %s, want %d", i, resp.ID, i+1)
+		}
+	}
+	// Single responses above the limit are replaced by an error too
+	if err := out.Encode(echoRequest(5, payload+payload+payload)); err != nil {
+		t.Fatal(err)
+	}
+	var response jsonrpcMessage
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Code != -32003 {
+		t.Fatalf("expected response too large error, got %+v", response)
+	}
+}
+
+func TestServerExecutionTimeout(t *testing.T) {
+	out, in, closer := testServerLimits(t, func(s *Server) { s.SetExecutionTimeout(50 * time.Millisecond) })
+	defer closer()
+
+	call := func(duration time.Duration) *jsonError {
+		request := map[string]interface{}{
+			"id":      1,
+			"method":  "test_sleep",
+			"version": "2.0",
+			"params":  []interface{}{duration},
+		}
+		if err := out.Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		var response jsonrpcMessage
+		if err := in.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Error
+	}
+	if err := call(time.Millisecond); err != nil {
+		t.Fatalf("fast call failed: %v", err.Message)
+	}
+	start := time.Now()
+	if err := call(10 * time.Second); err == nil || err.Code != -32002 {
+		t.Fatalf("expected timeout error, got %+v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout not enforced, call took %v", elapsed)
+	}
+	// Methods taking a context must not be left running after the timeout
+	if n := atomic.LoadInt32(&sleeping); n != 0 {
+		t.Fatalf("timed out call left running: %d", n)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fairblock/go-fairblock/common/hexutil"
 	"gopkg.in/fatih/set.v0"
@@ -57,6 +58,7 @@ type service struct {
 type serverRequest struct {
 	id            interface{}
 	svcname       string
+	method        string
 	callb         *callback
 	args          []reflect.Value
 	isUnsubscribe bool
@@ -70,6 +72,12 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 // Server represents a RPC server
 type Server struct {
 	services serviceRegistry
+	limiter  *rateLimiter // optional per client call quotas
+
+	maxBatchItems   int           // maximum number of requests in a batch, 0 if unlimited
+	maxResponseSize int           // maximum response bytes of a request or batch, 0 if unlimited
+	execTimeout     time.Duration // maximum execution time of a method call, 0 if unlimited
+	detached        int32         // number of calls of methods without a context running in the background
 
 	run      int32
 	codecsMu sync.Mutex
@@ -92,6 +100,13 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError contains extra data to explain the error, sent along in the data
+// field of the JSON-RPC error response.
+type DataError interface {
+	Error() string          // returns the message
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
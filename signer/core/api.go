@@ -0,0 +1,233 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package core implements a standalone signer, which holds the account keys outside
+// of the node process and requires each signing request to be approved.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/accounts/usbwallet"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/crypto"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/rlp"
+)
+
+// ErrRequestDenied is returned if the user or the rule engine rejected a request.
+var ErrRequestDenied = errors.New("request denied")
+
+// ExternalAPI defines the signing API exposed to clients under the "account"
+// namespace.
+type ExternalAPI interface {
+	// List returns the addresses of the accounts the signer agreed to reveal.
+	List(ctx context.Context) ([]common.Address, error)
+
+	// SignTransaction signs the given transaction and returns it both as RLP and
+	// in its JSON representation.
+	SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error)
+
+	// Sign calculates a signature over keccak256("\x19Fairblock Signed Message:\n"
+	// + len(data) + data) with the key of the given account.
+	Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error)
+
+	// SignTypedData calculates a signature over the EIP-712 hash of the typed
+	// structured data with the key of the given account.
+	SignTypedData(ctx context.Context, addr common.Address, typedData TypedData) (hexutil.Bytes, error)
+}
+
+// UIClientAPI is the interface through which the signer asks for approval of the
+// incoming requests, whether interactively or by evaluating rules.
+type UIClientAPI interface {
+	// ApproveTx prompts the user to approve the signing of a transaction.
+	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
+
+	// ApproveSignData prompts the user to approve the signing of arbitrary data.
+	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+
+	// ApproveListing prompts the user to reveal the listed accounts.
+	ApproveListing(request *ListRequest) (ListResponse, error)
+
+	// OnApprovedTx notifies the UI about a transaction that was signed, which
+	// allows rules to keep track of e.g. the amount spent.
+	OnApprovedTx(result SignTxResult)
+
+	// ShowError displays an error message to the user.
+	ShowError(message string)
+
+	// ShowInfo displays an informational message to the user.
+	ShowInfo(message string)
+}
+
+// SignerAPI implements ExternalAPI on top of an account manager.
+type SignerAPI struct {
+	chainID *big.Int
+	am      *accounts.Manager
+	UI      UIClientAPI
+
+	// signLock serializes transaction approvals with the recording of the signed
+	// transactions, so rules see a consistent view of e.g. the amount spent.
+	signLock sync.Mutex
+}
+
+// NewSignerAPI creates a signer for the given chain, using the wallets of the
+// account manager and asking the UI for approval of every request.
+func NewSignerAPI(chainID *big.Int, am *accounts.Manager, ui UIClientAPI) *SignerAPI {
+	return &SignerAPI{chainID: chainID, am: am, UI: ui}
+}
+
+// StartAccountManager assembles an account manager backed by the keystore at the
+// given location and, unless disabled, by USB hardware wallets.
+func StartAccountManager(keydir string, lightKDF, noUSB bool) *accounts.Manager {
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if lightKDF {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	backends := []accounts.Backend{
+		keystore.NewKeyStore(keydir, scryptN, scryptP),
+	}
+	if !noUSB {
+		// Start a USB hub for Ledger hardware wallets
+		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
+		} else {
+			backends = append(backends, ledgerhub)
+		}
+		// Start a USB hub for Trezor hardware wallets
+		if trezorhub, err := usbwallet.NewTrezorHub(); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start Trezor hub, disabling: %v", err))
+		} else {
+			backends = append(backends, trezorhub)
+		}
+	}
+	return accounts.NewManager(backends...)
+}
+
+// List implements ExternalAPI, returning the accounts approved by the UI.
+func (api *SignerAPI) List(ctx context.Context) ([]common.Address, error) {
+	var accs []Account
+	for _, wallet := range api.am.Wallets() {
+		for _, acc := range wallet.Accounts() {
+			accs = append(accs, Account{Address: acc.Address, URL: acc.URL.String()})
+		}
+	}
+	resp, err := api.UI.ApproveListing(&ListRequest{Accounts: accs})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Accounts == nil {
+		return nil, ErrRequestDenied
+	}
+	addrs := make([]common.Address, 0, len(resp.Accounts))
+	for _, acc := range resp.Accounts {
+		addrs = append(addrs, acc.Address)
+	}
+	return addrs, nil
+}
+
+// SignTransaction implements ExternalAPI, signing the transaction if the UI
+// approves it. The transaction actually signed is the one returned by the UI.
+func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error) {
+	api.signLock.Lock()
+	defer api.signLock.Unlock()
+
+	resp, err := api.UI.ApproveTx(&SignTxRequest{Transaction: args})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: resp.Transaction.From}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := wallet.SignTxWithPassphrase(account, resp.Password, resp.Transaction.toTransaction(), api.chainID)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	result := SignTxResult{Raw: raw, Tx: signed}
+	api.UI.OnApprovedTx(result)
+
+	return &result, nil
+}
+
+// Sign implements ExternalAPI, signing the prefixed hash of the data if the UI
+// approves it. The V value of the signature is 27 or 28, as for fbc_sign.
+func (api *SignerAPI) Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	hash, msg := SignHash(data)
+	return api.sign(&SignDataRequest{Address: addr, Rawdata: data, Message: msg, Hash: hash})
+}
+
+// SignTypedData implements ExternalAPI, signing the EIP-712 hash of the typed
+// data if the UI approves it. The V value of the signature is 27 or 28.
+func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.Address, typedData TypedData) (hexutil.Bytes, error) {
+	hash, preimage, err := TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("EIP-712 %s message", typedData.PrimaryType)
+	if typedData.Domain.Name != "" {
+		msg += fmt.Sprintf(" for %q", typedData.Domain.Name)
+	}
+	return api.sign(&SignDataRequest{Address: addr, Rawdata: preimage, Message: msg, Hash: hash, TypedData: &typedData})
+}
+
+// sign asks the UI to approve the data signing request and signs its hash.
+func (api *SignerAPI) sign(req *SignDataRequest) (hexutil.Bytes, error) {
+	resp, err := api.UI.ApproveSignData(req)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Approved {
+		return nil, ErrRequestDenied
+	}
+	account := accounts.Account{Address: req.Address}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, resp.Password, req.Hash)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
+// SignHash is a helper function that calculates the hash signed for the given
+// data, returning it alongside the message it was calculated from:
+//
+//   keccak256("\x19Fairblock Signed Message:\n"${message length}${message})
+func SignHash(data []byte) ([]byte, string) {
+	msg := fmt.Sprintf("\x19Fairblock Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg)), msg
+}
@@ -0,0 +1,176 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/accounts"
+	"github.com/fairblock/go-fairblock/accounts/keystore"
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// scriptedUI approves or rejects all requests with a fixed password, optionally
+// modifying the transactions before approval.
+type scriptedUI struct {
+	approve  bool
+	password string
+	modify   func(*SendTxArgs)
+	signed   []SignTxResult
+}
+
+func (ui *scriptedUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	tx := request.Transaction
+	if ui.modify != nil {
+		ui.modify(&tx)
+	}
+	return SignTxResponse{Transaction: tx, Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *scriptedUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	return SignDataResponse{Approved: ui.approve, Password: ui.password}, nil
+}
+
+func (ui *scriptedUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	if !ui.approve {
+		return ListResponse{}, nil
+	}
+	return ListResponse{Accounts: request.Accounts}, nil
+}
+
+func (ui *scriptedUI) OnApprovedTx(result SignTxResult) { ui.signed = append(ui.signed, result) }
+func (ui *scriptedUI) ShowError(message string)         {}
+func (ui *scriptedUI) ShowInfo(message string)          {}
+
+func newTestSigner(t *testing.T) (*SignerAPI, *scriptedUI, common.Address, func()) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := &scriptedUI{approve: true, password: "secret"}
+	api := NewSignerAPI(big.NewInt(1337), accounts.NewManager(ks), ui)
+	return api, ui, account.Address, func() { os.RemoveAll(dir) }
+}
+
+func testTxArgs(from common.Address) SendTxArgs {
+	to := common.HexToAddress("0x000000000000000000000000000000000000dead")
+	return SendTxArgs{
+		From:     from,
+		To:       &to,
+		Gas:      hexutil.Big(*big.NewInt(21000)),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Value:    hexutil.Big(*big.NewInt(1000)),
+		Nonce:    3,
+	}
+}
+
+func TestSignerList(t *testing.T) {
+	api, ui, addr, teardown := newTestSigner(t)
+	defer teardown()
+
+	addrs, err := api.List(context.Background())
+	if err != nil {
+		t.Fatalf("listing failed: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != addr {
+		t.Fatalf("account list mismatch: have %v, want [%x]", addrs, addr)
+	}
+	ui.approve = false
+	if _, err := api.List(context.Background()); err != ErrRequestDenied {
+		t.Fatalf("error mismatch for rejected listing: have %v, want %v", err, ErrRequestDenied)
+	}
+}
+
+func TestSignerSignTransaction(t *testing.T) {
+	api, ui, addr, teardown := newTestSigner(t)
+	defer teardown()
+
+	res, err := api.SignTransaction(context.Background(), testTxArgs(addr))
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	if sender, err := types.Sender(signer, res.Tx); err != nil || sender != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", sender, err, addr)
+	}
+	if res.Tx.Nonce() != 3 || res.Tx.Value().Int64() != 1000 {
+		t.Fatalf("signed transaction mismatch: %v", res.Tx)
+	}
+	if len(ui.signed) != 1 || ui.signed[0].Tx.Hash() != res.Tx.Hash() {
+		t.Fatalf("UI not notified about the signed transaction")
+	}
+	// Transactions modified by the UI are signed as modified
+	ui.modify = func(args *SendTxArgs) { args.GasPrice = hexutil.Big(*big.NewInt(2)) }
+	if res, err = api.SignTransaction(context.Background(), testTxArgs(addr)); err != nil {
+		t.Fatalf("signing modified transaction failed: %v", err)
+	}
+	if res.Tx.GasPrice().Int64() != 2 {
+		t.Fatalf("gas price mismatch: have %v, want 2", res.Tx.GasPrice())
+	}
+	// Rejected requests and invalid passwords must fail
+	ui.modify = nil
+	ui.password = "wrong"
+	if _, err := api.SignTransaction(context.Background(), testTxArgs(addr)); err == nil {
+		t.Fatalf("transaction signed with invalid password")
+	}
+	ui.approve = false
+	if _, err := api.SignTransaction(context.Background(), testTxArgs(addr)); err != ErrRequestDenied {
+		t.Fatalf("error mismatch for rejected transaction: have %v, want %v", err, ErrRequestDenied)
+	}
+	if len(ui.signed) != 2 {
+		t.Fatalf("signed transaction count mismatch: have %d, want 2", len(ui.signed))
+	}
+}
+
+func TestSignerSign(t *testing.T) {
+	api, ui, addr, teardown := newTestSigner(t)
+	defer teardown()
+
+	data := hexutil.Bytes("hello")
+	sig, err := api.Sign(context.Background(), addr, data)
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("invalid V value: %d", sig[64])
+	}
+	sig[64] -= 27
+	hash, _ := SignHash(data)
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != addr {
+		t.Fatalf("signer mismatch: have %x, want %x", signer, addr)
+	}
+	ui.approve = false
+	if _, err := api.Sign(context.Background(), addr, data); err != ErrRequestDenied {
+		t.Fatalf("error mismatch for rejected signing: have %v, want %v", err, ErrRequestDenied)
+	}
+}
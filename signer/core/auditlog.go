@@ -0,0 +1,102 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"os"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// AuditLogger is an ExternalAPI wrapping another one, recording every request
+// and the decision taken on it.
+type AuditLogger struct {
+	log log.Logger
+	api ExternalAPI
+}
+
+// NewAuditLogger creates an audit log appending to the file at the given path.
+// As the log reveals the accounts and the signed data, the file is only made
+// accessible to its owner.
+func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// Tighten the permissions of logs created by earlier versions
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return nil, err
+	}
+	logger := log.New("api", "signer")
+	logger.SetHandler(log.StreamHandler(f, log.LogfmtFormat()))
+
+	logger.Info("Configured", "audit log", path)
+	return &AuditLogger{log: logger, api: api}, nil
+}
+
+// List implements ExternalAPI, logging the request and the revealed accounts.
+func (l *AuditLogger) List(ctx context.Context) ([]common.Address, error) {
+	l.log.Info("List", "type", "request")
+	res, err := l.api.List(ctx)
+	if err != nil {
+		l.log.Info("List", "type", "response", "approved", false, "err", err)
+		return res, err
+	}
+	l.log.Info("List", "type", "response", "approved", true, "accounts", res)
+	return res, err
+}
+
+// SignTransaction implements ExternalAPI, logging the request and the signed
+// transaction, which might differ from the requested one.
+func (l *AuditLogger) SignTransaction(ctx context.Context, args SendTxArgs) (*SignTxResult, error) {
+	l.log.Info("SignTransaction", "type", "request", "tx", args.String())
+	res, err := l.api.SignTransaction(ctx, args)
+	if err != nil {
+		l.log.Info("SignTransaction", "type", "response", "approved", false, "err", err)
+		return res, err
+	}
+	l.log.Info("SignTransaction", "type", "response", "approved", true, "hash", res.Tx.Hash(), "raw", res.Raw)
+	return res, err
+}
+
+// Sign implements ExternalAPI, logging the request and the signature.
+func (l *AuditLogger) Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	l.log.Info("Sign", "type", "request", "addr", addr, "data", data)
+	res, err := l.api.Sign(ctx, addr, data)
+	if err != nil {
+		l.log.Info("Sign", "type", "response", "approved", false, "err", err)
+		return res, err
+	}
+	l.log.Info("Sign", "type", "response", "approved", true, "signature", res)
+	return res, err
+}
+
+// SignTypedData implements ExternalAPI, logging the request and the signature.
+func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.Address, typedData TypedData) (hexutil.Bytes, error) {
+	l.log.Info("SignTypedData", "type", "request", "addr", addr, "primaryType", typedData.PrimaryType, "domain", typedData.Domain.Name)
+	res, err := l.api.SignTypedData(ctx, addr, typedData)
+	if err != nil {
+		l.log.Info("SignTypedData", "type", "response", "approved", false, "err", err)
+		return res, err
+	}
+	l.log.Info("SignTypedData", "type", "response", "approved", true, "signature", res)
+	return res, err
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// CommandlineUI is a UIClientAPI prompting the user on the terminal the signer
+// was started from. Requests are presented one at a time.
+type CommandlineUI struct {
+	in *bufio.Reader
+	mu sync.Mutex
+}
+
+// NewCommandlineUI creates a terminal based approval UI.
+func NewCommandlineUI() *CommandlineUI {
+	return &CommandlineUI{in: bufio.NewReader(os.Stdin)}
+}
+
+// readString reads a single line from the terminal.
+func (ui *CommandlineUI) readString() string {
+	text, err := ui.in.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
+
+// readPassword reads a password from the terminal without echoing it.
+func (ui *CommandlineUI) readPassword() string {
+	fmt.Printf("Enter password to approve:\n> ")
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return ""
+	}
+	return string(password)
+}
+
+// confirm asks the user a yes/no question, defaulting to no.
+func (ui *CommandlineUI) confirm() bool {
+	fmt.Printf("Approve? [y/N]:\n> ")
+	return strings.ToLower(ui.readString()) == "y"
+}
+
+// ApproveTx implements UIClientAPI, showing the transaction and prompting for
+// the password of the sender.
+func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	tx := request.Transaction
+	fmt.Printf("--------- Transaction request -------------\n")
+	if tx.To != nil {
+		fmt.Printf("to:       %v\n", tx.To.Hex())
+	} else {
+		fmt.Printf("to:       <contract creation>\n")
+	}
+	fmt.Printf("from:     %v\n", tx.From.Hex())
+	fmt.Printf("value:    %v wei\n", tx.Value.ToInt())
+	fmt.Printf("gas:      %v\n", tx.Gas.ToInt())
+	fmt.Printf("gasprice: %v wei\n", tx.GasPrice.ToInt())
+	fmt.Printf("nonce:    %d\n", uint64(tx.Nonce))
+	if len(tx.Data) > 0 {
+		fmt.Printf("data:     %v\n", tx.Data)
+	}
+	fmt.Printf("-------------------------------------------\n")
+	if !ui.confirm() {
+		return SignTxResponse{Transaction: tx, Approved: false}, nil
+	}
+	return SignTxResponse{Transaction: tx, Approved: true, Password: ui.readPassword()}, nil
+}
+
+// ApproveSignData implements UIClientAPI, showing the message and prompting for
+// the password of the signer.
+func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("--------- Sign data request --------------\n")
+	fmt.Printf("account:  %v\n", request.Address.Hex())
+	fmt.Printf("message:  %q\n", request.Message)
+	if request.TypedData != nil {
+		if blob, err := json.MarshalIndent(request.TypedData, "", "  "); err == nil {
+			fmt.Printf("typed data:\n%s\n", blob)
+		}
+	}
+	fmt.Printf("raw data: %v\n", request.Rawdata)
+	fmt.Printf("hash:     %v\n", request.Hash)
+	fmt.Printf("-------------------------------------------\n")
+	if !ui.confirm() {
+		return SignDataResponse{Approved: false}, nil
+	}
+	return SignDataResponse{Approved: true, Password: ui.readPassword()}, nil
+}
+
+// ApproveListing implements UIClientAPI, revealing either all or none of the
+// accounts.
+func (ui *CommandlineUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("--------- List account request ------------\n")
+	fmt.Printf("A request has been made to list all accounts:\n")
+	for _, account := range request.Accounts {
+		fmt.Printf("  [x] %v\n", account.Address.Hex())
+		fmt.Printf("    URL: %v\n", account.URL)
+	}
+	fmt.Printf("-------------------------------------------\n")
+	if !ui.confirm() {
+		return ListResponse{}, nil
+	}
+	return ListResponse{Accounts: request.Accounts}, nil
+}
+
+// OnApprovedTx implements UIClientAPI, displaying the signed transaction.
+func (ui *CommandlineUI) OnApprovedTx(result SignTxResult) {
+	fmt.Printf("Transaction signed: %v\n", result.Tx.Hash().Hex())
+}
+
+// ShowError implements UIClientAPI, printing the message to the terminal.
+func (ui *CommandlineUI) ShowError(message string) {
+	fmt.Printf("ERROR: %v\n", message)
+}
+
+// ShowInfo implements UIClientAPI, printing the message to the terminal.
+func (ui *CommandlineUI) ShowInfo(message string) {
+	fmt.Printf("INFO: %v\n", message)
+}
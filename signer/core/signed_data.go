@@ -0,0 +1,399 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/common/math"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// TypedData is a type to encapsulate EIP-712 typed structured data.
+//
+// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-712.md
+type TypedData struct {
+	Types       Types                  `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      TypedDataDomain        `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// Types maps the names of the struct types to their fields.
+type Types map[string][]Type
+
+// Type is a single field of a struct type.
+type Type struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain represents the domain part of an EIP-712 message, separating
+// the signatures of different applications and chains.
+type TypedDataDomain struct {
+	Name              string                `json:"name,omitempty"`
+	Version           string                `json:"version,omitempty"`
+	ChainId           *math.HexOrDecimal256 `json:"chainId,omitempty"`
+	VerifyingContract string                `json:"verifyingContract,omitempty"`
+	Salt              string                `json:"salt,omitempty"`
+}
+
+// domainType is the name of the struct type describing the domain.
+const domainType = "EIP712Domain"
+
+// maxTypedDataDepth limits the nesting of structs and arrays in a message.
+const maxTypedDataDepth = 32
+
+var (
+	typedArrayRegexp   = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+	typedIntegerRegexp = regexp.MustCompile(`^(u?)int([0-9]*)$`)
+	typedBytesRegexp   = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+// TypedDataAndHash calculates the hash to be signed for the typed data, which is
+//
+//   keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+//
+// It also returns the preimage, which is shown to users asked to approve it.
+func TypedDataAndHash(typedData TypedData) ([]byte, []byte, error) {
+	domainSeparator, err := typedData.HashStruct(domainType, typedData.Domain.Map())
+	if err != nil {
+		return nil, nil, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+	preimage := append([]byte("\x19\x01"), append(domainSeparator, messageHash...)...)
+	return crypto.Keccak256(preimage), preimage, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the chain id both as a JSON
+// number and as a decimal or hex string.
+func (domain *TypedDataDomain) UnmarshalJSON(input []byte) error {
+	type typedDataDomain TypedDataDomain
+	var dec struct {
+		typedDataDomain
+		ChainId interface{} `json:"chainId,omitempty"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*domain = TypedDataDomain(dec.typedDataDomain)
+	if dec.ChainId != nil {
+		chainID, err := parseInteger(dec.ChainId)
+		if err != nil {
+			return fmt.Errorf("invalid chain id: %v", err)
+		}
+		domain.ChainId = (*math.HexOrDecimal256)(chainID)
+	}
+	return nil
+}
+
+// Map returns the fields of the domain which are set, keyed by their names in
+// the EIP712Domain type.
+func (domain *TypedDataDomain) Map() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if domain.Name != "" {
+		fields["name"] = domain.Name
+	}
+	if domain.Version != "" {
+		fields["version"] = domain.Version
+	}
+	if domain.ChainId != nil {
+		fields["chainId"] = (*big.Int)(domain.ChainId)
+	}
+	if domain.VerifyingContract != "" {
+		fields["verifyingContract"] = domain.VerifyingContract
+	}
+	if domain.Salt != "" {
+		fields["salt"] = domain.Salt
+	}
+	return fields
+}
+
+// HashStruct calculates the hash of a struct of the given type:
+//
+//   keccak256(typeHash ‖ encodeData(data))
+func (typedData *TypedData) HashStruct(primaryType string, data map[string]interface{}) (hexutil.Bytes, error) {
+	encoded, err := typedData.EncodeData(primaryType, data, 1)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// Dependencies returns the struct types referenced by the given type, including
+// itself, in the order they were found.
+func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
+	primaryType = baseType(primaryType)
+	for _, dep := range found {
+		if dep == primaryType {
+			return found
+		}
+	}
+	if typedData.Types[primaryType] == nil {
+		return found
+	}
+	found = append(found, primaryType)
+	for _, field := range typedData.Types[primaryType] {
+		found = typedData.Dependencies(field.Type, found)
+	}
+	return found
+}
+
+// EncodeType returns the encoding of a struct type, which is its signature
+// followed by the signatures of all its dependencies in alphabetical order:
+//
+//   Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (typedData *TypedData) EncodeType(primaryType string) hexutil.Bytes {
+	deps := typedData.Dependencies(primaryType, nil)
+	if len(deps) > 1 {
+		sort.Strings(deps[1:])
+	}
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.Bytes()
+}
+
+// TypeHash returns the hash of the encoding of a struct type.
+func (typedData *TypedData) TypeHash(primaryType string) hexutil.Bytes {
+	return crypto.Keccak256(typedData.EncodeType(primaryType))
+}
+
+// EncodeData returns the type hash of a struct followed by the encodings of all
+// of its fields, each taking up 32 bytes. Nested structs and arrays are replaced
+// by their hashes.
+func (typedData *TypedData) EncodeData(primaryType string, data map[string]interface{}, depth int) (hexutil.Bytes, error) {
+	if depth > maxTypedDataDepth {
+		return nil, errors.New("typed data nested too deeply")
+	}
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	if len(data) > len(fields) {
+		return nil, fmt.Errorf("%s: %d values for %d fields", primaryType, len(data), len(fields))
+	}
+	buffer := bytes.NewBuffer(typedData.TypeHash(primaryType))
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing value for field %q", primaryType, field.Name)
+		}
+		encoded, err := typedData.encodeValue(field.Type, value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", primaryType, field.Name, err)
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeValue returns the 32 byte encoding of a field value.
+func (typedData *TypedData) encodeValue(encType string, value interface{}, depth int) ([]byte, error) {
+	// Arrays are encoded as the hash of the concatenated encodings of the items
+	if match := typedArrayRegexp.FindStringSubmatch(encType); match != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v for array type %s", value, encType)
+		}
+		if match[2] != "" {
+			if size, err := strconv.Atoi(match[2]); err != nil || size != len(items) {
+				return nil, fmt.Errorf("array length mismatch: have %d, want %s", len(items), match[2])
+			}
+		}
+		if depth+1 > maxTypedDataDepth {
+			return nil, errors.New("typed data nested too deeply")
+		}
+		var buffer bytes.Buffer
+		for i, item := range items {
+			encoded, err := typedData.encodeValue(match[1], item, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %v", i, err)
+			}
+			buffer.Write(encoded)
+		}
+		return crypto.Keccak256(buffer.Bytes()), nil
+	}
+	// Structs are encoded as their hash
+	if _, ok := typedData.Types[encType]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v for struct type %s", value, encType)
+		}
+		encoded, err := typedData.EncodeData(encType, fields, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	return encodePrimitiveValue(encType, value)
+}
+
+// encodePrimitiveValue returns the 32 byte encoding of an atomic or dynamic value.
+func encodePrimitiveValue(encType string, value interface{}) ([]byte, error) {
+	switch encType {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", value)
+		}
+		if b {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return make([]byte, 32), nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+	}
+	if match := typedBytesRegexp.FindStringSubmatch(encType); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		if size == 0 || size > 32 {
+			return nil, fmt.Errorf("invalid type %s", encType)
+		}
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != size {
+			return nil, fmt.Errorf("invalid length %d for %s", len(blob), encType)
+		}
+		return common.RightPadBytes(blob, 32), nil
+	}
+	if match := typedIntegerRegexp.FindStringSubmatch(encType); match != nil {
+		bits := 256
+		if match[2] != "" {
+			bits, _ = strconv.Atoi(match[2])
+		}
+		if bits == 0 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("invalid type %s", encType)
+		}
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		signed := match[1] == ""
+		switch {
+		case !signed && (n.Sign() < 0 || n.BitLen() > bits):
+			return nil, fmt.Errorf("integer %v out of range for %s", n, encType)
+		case signed && n.Sign() >= 0 && n.BitLen() > bits-1:
+			return nil, fmt.Errorf("integer %v out of range for %s", n, encType)
+		case signed && n.Sign() < 0 && new(big.Int).Add(n, common.Big1).BitLen() > bits-1:
+			return nil, fmt.Errorf("integer %v out of range for %s", n, encType)
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(n)), 32), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", encType)
+}
+
+// parseBytes converts a hex string or a byte slice into bytes.
+func parseBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case hexutil.Bytes:
+		return v, nil
+	case string:
+		blob, err := hexutil.Decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %q: %v", v, err)
+		}
+		return blob, nil
+	}
+	return nil, fmt.Errorf("invalid bytes %v", value)
+}
+
+// parseInteger converts a JSON number or a decimal or hex string into an integer.
+// Large numbers should be passed as strings, as JSON numbers lose precision.
+func parseInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case *math.HexOrDecimal256:
+		return (*big.Int)(v), nil
+	case json.Number:
+		return parseInteger(string(v))
+	case float64:
+		if v != float64(int64(v)) || v > 1<<53 || v < -(1<<53) {
+			return nil, fmt.Errorf("invalid integer %v, use a string for large numbers", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case string:
+		negative := strings.HasPrefix(v, "-")
+		n, ok := math.ParseBig256(strings.TrimPrefix(v, "-"))
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		if negative {
+			n.Neg(n)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid integer %v", value)
+}
+
+// baseType strips the array suffixes from a type name.
+func baseType(encType string) string {
+	for {
+		match := typedArrayRegexp.FindStringSubmatch(encType)
+		if match == nil {
+			return encType
+		}
+		encType = match[1]
+	}
+}
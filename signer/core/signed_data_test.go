@@ -0,0 +1,238 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/crypto"
+)
+
+// mailTypedData is the example message of the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func loadTypedData(t *testing.T, blob string) TypedData {
+	var typedData TypedData
+	if err := json.Unmarshal([]byte(blob), &typedData); err != nil {
+		t.Fatalf("failed to unmarshal typed data: %v", err)
+	}
+	return typedData
+}
+
+func TestTypedDataMailExample(t *testing.T) {
+	typedData := loadTypedData(t, mailTypedData)
+
+	if have, want := string(typedData.EncodeType("Mail")), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("type encoding mismatch:\nhave %s\nwant %s", have, want)
+	}
+	if have, want := typedData.TypeHash("Mail").String(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; have != want {
+		t.Errorf("type hash mismatch: have %s, want %s", have, want)
+	}
+	separator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	if have, want := separator.String(), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; have != want {
+		t.Errorf("domain separator mismatch: have %s, want %s", have, want)
+	}
+	message, err := typedData.HashStruct("Mail", typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to hash message: %v", err)
+	}
+	if have, want := message.String(), "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; have != want {
+		t.Errorf("message hash mismatch: have %s, want %s", have, want)
+	}
+	hash, _, err := TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatalf("failed to hash typed data: %v", err)
+	}
+	if have, want := hexutil.Encode(hash), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; have != want {
+		t.Errorf("typed data hash mismatch: have %s, want %s", have, want)
+	}
+	// Sign with the key of the example and compare against the specified signature
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	if addr := crypto.PubkeyToAddress(key.PublicKey); addr != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Fatalf("example key mismatch: have %x", addr)
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	wantR := hexutil.MustDecode("0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d")
+	wantS := hexutil.MustDecode("0x07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562")
+	if !bytes.Equal(sig[:32], wantR) || !bytes.Equal(sig[32:64], wantS) || sig[64]+27 != 28 {
+		t.Errorf("signature mismatch: have %x", sig)
+	}
+}
+
+func TestTypedDataArrays(t *testing.T) {
+	typedData := loadTypedData(t, `{
+		"types": {
+			"EIP712Domain": [{"name": "name", "type": "string"}],
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallets", "type": "address[]"}
+			],
+			"Group": [
+				{"name": "members", "type": "Person[]"},
+				{"name": "ids", "type": "uint8[2]"}
+			]
+		},
+		"primaryType": "Group",
+		"domain": {"name": "Groups"},
+		"message": {
+			"members": [
+				{"name": "Alice", "wallets": ["0x1111111111111111111111111111111111111111"]},
+				{"name": "Bob", "wallets": []}
+			],
+			"ids": [1, "0x02"]
+		}
+	}`)
+	if have, want := string(typedData.EncodeType("Group")), "Group(Person[] members,uint8[2] ids)Person(string name,address[] wallets)"; have != want {
+		t.Fatalf("type encoding mismatch:\nhave %s\nwant %s", have, want)
+	}
+	// Assemble the expected encoding by hand
+	word := func(b byte) []byte { return common.LeftPadBytes([]byte{b}, 32) }
+	person := func(name string, wallets ...common.Address) []byte {
+		var encWallets []byte
+		for _, wallet := range wallets {
+			encWallets = append(encWallets, common.LeftPadBytes(wallet.Bytes(), 32)...)
+		}
+		enc := append(crypto.Keccak256([]byte("Person(string name,address[] wallets)")), crypto.Keccak256([]byte(name))...)
+		return crypto.Keccak256(append(enc, crypto.Keccak256(encWallets)...))
+	}
+	members := append(person("Alice", common.HexToAddress("0x1111111111111111111111111111111111111111")), person("Bob")...)
+
+	want := crypto.Keccak256([]byte("Group(Person[] members,uint8[2] ids)Person(string name,address[] wallets)"))
+	want = append(want, crypto.Keccak256(members)...)
+	want = append(want, crypto.Keccak256(append(word(1), word(2)...))...)
+
+	have, err := typedData.EncodeData("Group", typedData.Message, 1)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("encoding mismatch:\nhave %x\nwant %x", []byte(have), want)
+	}
+}
+
+func TestTypedDataInvalid(t *testing.T) {
+	tests := []struct {
+		field, value, err string
+	}{
+		{"uint8", `256`, "out of range"},
+		{"uint8", `-1`, "out of range"},
+		{"int8", `"-129"`, "out of range"},
+		{"int8", `"-128"`, ""},
+		{"uint256", `1.5`, "invalid integer"},
+		{"address", `"0x1234"`, "invalid address"},
+		{"bytes4", `"0x0102"`, "invalid length"},
+		{"bytes33", `"0x01"`, "invalid type"},
+		{"bool", `"true"`, "invalid bool"},
+		{"uint8[2]", `[1]`, "array length mismatch"},
+		{"Unknown", `{}`, "unknown type"},
+	}
+	for _, tt := range tests {
+		typedData := loadTypedData(t, `{
+			"types": {
+				"EIP712Domain": [],
+				"Test": [{"name": "value", "type": "`+tt.field+`"}]
+			},
+			"primaryType": "Test",
+			"message": {"value": `+tt.value+`}
+		}`)
+		_, _, err := TypedDataAndHash(typedData)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s %s: unexpected error: %v", tt.field, tt.value, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s %s: error mismatch: have %v, want %q", tt.field, tt.value, err, tt.err)
+		}
+	}
+	// Chain ids may be given as strings too
+	var domain TypedDataDomain
+	if err := json.Unmarshal([]byte(`{"chainId": "0x539"}`), &domain); err != nil || domain.Map()["chainId"].(*big.Int).Int64() != 1337 {
+		t.Errorf("hex chain id mismatch: have %v (%v), want 1337", domain.ChainId, err)
+	}
+	// Values for undeclared fields must be rejected
+	typedData := loadTypedData(t, mailTypedData)
+	typedData.Message["extra"] = "value"
+	if _, _, err := TypedDataAndHash(typedData); err == nil {
+		t.Errorf("undeclared field accepted")
+	}
+}
+
+func TestSignerSignTypedData(t *testing.T) {
+	api, ui, addr, teardown := newTestSigner(t)
+	defer teardown()
+
+	typedData := loadTypedData(t, mailTypedData)
+	sig, err := api.SignTypedData(context.Background(), addr, typedData)
+	if err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	hash, _, _ := TypedDataAndHash(typedData)
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != addr {
+		t.Fatalf("signer mismatch: have %x, want %x", signer, addr)
+	}
+	ui.approve = false
+	if _, err := api.SignTypedData(context.Background(), addr, typedData); err != ErrRequestDenied {
+		t.Fatalf("error mismatch for rejected signing: have %v, want %v", err, ErrRequestDenied)
+	}
+}
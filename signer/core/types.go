@@ -0,0 +1,112 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+)
+
+// SendTxArgs represents a transaction to be signed. Contrary to the node API, the
+// signer has no access to the chain state, so all fields apart from the recipient
+// and the payload must be specified by the caller.
+type SendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Big     `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+func (args SendTxArgs) String() string {
+	to := "<contract creation>"
+	if args.To != nil {
+		to = args.To.Hex()
+	}
+	return fmt.Sprintf("from=%s to=%s value=%v gas=%v gasPrice=%v nonce=%d data=%d bytes",
+		args.From.Hex(), to, args.Value.ToInt(), args.Gas.ToInt(), args.GasPrice.ToInt(), uint64(args.Nonce), len(args.Data))
+}
+
+// toTransaction assembles the unsigned transaction described by the arguments.
+func (args *SendTxArgs) toTransaction() *types.Transaction {
+	var (
+		value    = new(big.Int).Set(args.Value.ToInt())
+		gas      = new(big.Int).Set(args.Gas.ToInt())
+		gasPrice = new(big.Int).Set(args.GasPrice.ToInt())
+	)
+	if args.To == nil {
+		return types.NewContractCreation(uint64(args.Nonce), value, gas, gasPrice, args.Data)
+	}
+	return types.NewTransaction(uint64(args.Nonce), *args.To, value, gas, gasPrice, args.Data)
+}
+
+// Account is an account known to the signer, as presented to the UI.
+type Account struct {
+	Address common.Address `json:"address"`
+	URL     string         `json:"url"`
+}
+
+// SignTxRequest contains the transaction to be approved by the UI.
+type SignTxRequest struct {
+	Transaction SendTxArgs `json:"transaction"`
+}
+
+// SignTxResponse is the UI's decision on a SignTxRequest. The UI may modify the
+// transaction (e.g. to lower the gas price) before approving it.
+type SignTxResponse struct {
+	Transaction SendTxArgs `json:"transaction"`
+	Approved    bool       `json:"approved"`
+	Password    string     `json:"password"`
+}
+
+// SignTxResult is the outcome of a successful signing, returned to the caller.
+type SignTxResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// SignDataRequest contains the data to be approved by the UI for signing.
+type SignDataRequest struct {
+	Address   common.Address `json:"address"`
+	Rawdata   hexutil.Bytes  `json:"raw_data"`
+	Message   string         `json:"message"`
+	Hash      hexutil.Bytes  `json:"hash"`
+	TypedData *TypedData     `json:"typed_data,omitempty"` // Set for EIP-712 requests
+}
+
+// SignDataResponse is the UI's decision on a SignDataRequest.
+type SignDataResponse struct {
+	Approved bool   `json:"approved"`
+	Password string `json:"password"`
+}
+
+// ListRequest contains the accounts the caller asked to see.
+type ListRequest struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// ListResponse contains the accounts the UI agreed to reveal, which may be a
+// subset of the requested ones.
+type ListResponse struct {
+	Accounts []Account `json:"accounts"`
+}
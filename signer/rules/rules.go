@@ -0,0 +1,313 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rules implements a signer UI which approves or rejects requests based on
+// user supplied JavaScript rules, deferring to another UI if the rules are silent.
+//
+// The rules file may define the functions ApproveTx, ApproveSignData and
+// ApproveListing, each receiving the request as a JSON object and returning
+// "Approve", "Reject" or anything else to defer the decision. A function named
+// OnApprovedTx is invoked with every signed transaction. The rules run in a fresh
+// interpreter for every request, are aborted if they run for longer than a second
+// and may only persist state through the storage object:
+//
+//   storage.put(key, value), storage.get(key)
+//
+// As JavaScript numbers can't represent wei amounts, the big object provides
+// arbitrary precision arithmetic on decimal or hex encoded strings:
+//
+//   big.add(x, y), big.sub(x, y), big.cmp(x, y)
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/math"
+	"github.com/fairblock/go-fairblock/log"
+	"github.com/fairblock/go-fairblock/signer/core"
+	"github.com/fairblock/go-fairblock/signer/storage"
+	"github.com/robertkrimen/otto"
+)
+
+var (
+	// errNoRule is returned if the rules don't define a function for a request.
+	errNoRule = errors.New("no rule defined")
+
+	// errRuleTimeout is returned if the rules run for longer than ruleTimeout.
+	errRuleTimeout = errors.New("rule execution timed out")
+)
+
+// ruleTimeout is the maximum time the rules may run for when loaded or invoked,
+// so that a buggy rule can't stall every request.
+const ruleTimeout = time.Second
+
+// decision is the outcome of evaluating a rule.
+type decision int
+
+const (
+	deferDecision decision = iota // The rules are silent, ask the next UI
+	approve                       // The rules approved the request
+	reject                        // The rules rejected the request (or failed)
+)
+
+// RulesetUI is a core.UIClientAPI evaluating JavaScript rules.
+type RulesetUI struct {
+	next        core.UIClientAPI // UI to ask if the rules don't decide
+	storage     storage.Storage  // Persistent storage available to the rules
+	credentials storage.Storage  // Account passwords, keyed by lowercase hex address
+	jsRules     string           // Source code of the rules
+}
+
+// NewRuleEvaluator creates a rule based UI. Requests the rules don't decide on are
+// forwarded to the next UI. Approved requests are signed with the password found
+// in the credentials storage for the account.
+func NewRuleEvaluator(next core.UIClientAPI, jsbackend, credentials storage.Storage) *RulesetUI {
+	return &RulesetUI{
+		next:        next,
+		storage:     jsbackend,
+		credentials: credentials,
+	}
+}
+
+// Init loads the given rules, failing if they can't be executed.
+func (r *RulesetUI) Init(javascriptRules string) error {
+	if _, err := r.newVM(javascriptRules); err != nil {
+		return err
+	}
+	r.jsRules = javascriptRules
+	return nil
+}
+
+// newVM creates an interpreter with the helper objects set up and the rules
+// loaded.
+func (r *RulesetUI) newVM(rules string) (*otto.Otto, error) {
+	vm := otto.New()
+
+	store, _ := vm.Object("({})")
+	store.Set("put", func(call otto.FunctionCall) otto.Value {
+		key, val := call.Argument(0).String(), call.Argument(1).String()
+		if err := r.storage.Put(key, val); err != nil {
+			panic(call.Otto.MakeCustomError("StorageError", err.Error()))
+		}
+		return otto.UndefinedValue()
+	})
+	store.Set("get", func(call otto.FunctionCall) otto.Value {
+		val, _ := otto.ToValue(r.storage.Get(call.Argument(0).String()))
+		return val
+	})
+	vm.Set("storage", store)
+
+	bigmath, _ := vm.Object("({})")
+	bigmath.Set("add", bigOp(func(x, y *big.Int) interface{} { return new(big.Int).Add(x, y).String() }))
+	bigmath.Set("sub", bigOp(func(x, y *big.Int) interface{} { return new(big.Int).Sub(x, y).String() }))
+	bigmath.Set("cmp", bigOp(func(x, y *big.Int) interface{} { return x.Cmp(y) }))
+	vm.Set("big", bigmath)
+
+	console, _ := vm.Object("({})")
+	console.Set("log", func(call otto.FunctionCall) otto.Value {
+		args := make([]string, len(call.ArgumentList))
+		for i, arg := range call.ArgumentList {
+			args[i] = arg.String()
+		}
+		log.Info("Rule log", "msg", strings.Join(args, " "))
+		return otto.UndefinedValue()
+	})
+	vm.Set("console", console)
+
+	if err := runWithTimeout(vm, func() error {
+		_, err := vm.Run(rules)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load rules: %v", err)
+	}
+	return vm, nil
+}
+
+// runWithTimeout runs fn on the interpreter, interrupting it if it doesn't
+// return within ruleTimeout.
+func runWithTimeout(vm *otto.Otto, fn func() error) (err error) {
+	vm.Interrupt = make(chan func(), 1)
+	timer := time.AfterFunc(ruleTimeout, func() {
+		vm.Interrupt <- func() { panic(errRuleTimeout) }
+	})
+	defer timer.Stop()
+
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught != errRuleTimeout {
+				panic(caught)
+			}
+			err = errRuleTimeout
+		}
+	}()
+	return fn()
+}
+
+// bigOp wraps a binary big integer operation into a JavaScript function.
+func bigOp(op func(x, y *big.Int) interface{}) func(otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		var args [2]*big.Int
+		for i := range args {
+			arg := call.Argument(i).String()
+			if arg == "" || arg == "undefined" {
+				arg = "0"
+			}
+			n, ok := math.ParseBig256(arg)
+			if !ok {
+				panic(call.Otto.MakeTypeError(fmt.Sprintf("invalid number %q", arg)))
+			}
+			args[i] = n
+		}
+		val, _ := otto.ToValue(op(args[0], args[1]))
+		return val
+	}
+}
+
+// execute invokes the given rule function with the JSON representation of the
+// argument, returning errNoRule if the rules don't define it.
+func (r *RulesetUI) execute(function string, arg interface{}) (otto.Value, error) {
+	vm, err := r.newVM(r.jsRules)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	if fn, err := vm.Get(function); err != nil || !fn.IsFunction() {
+		return otto.UndefinedValue(), errNoRule
+	}
+	blob, err := json.Marshal(arg)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	obj, err := vm.Object("(" + string(blob) + ")")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	result := otto.UndefinedValue()
+	err = runWithTimeout(vm, func() (err error) {
+		result, err = vm.Call(function, nil, obj)
+		return err
+	})
+	return result, err
+}
+
+// decide evaluates the given rule function. Failing rules reject the request.
+func (r *RulesetUI) decide(function string, arg interface{}) decision {
+	result, err := r.execute(function, arg)
+	switch {
+	case err == errNoRule:
+		return deferDecision
+	case err != nil:
+		log.Warn("Rule evaluation failed, rejecting", "rule", function, "err", err)
+		r.ShowError(fmt.Sprintf("rule %s failed: %v", function, err))
+		return reject
+	}
+	switch result.String() {
+	case "Approve":
+		log.Info("Request approved by rules", "rule", function)
+		return approve
+	case "Reject":
+		log.Info("Request rejected by rules", "rule", function)
+		return reject
+	}
+	return deferDecision
+}
+
+// password retrieves the stored password of an account.
+func (r *RulesetUI) password(addr common.Address) string {
+	if r.credentials == nil {
+		return ""
+	}
+	return r.credentials.Get(strings.ToLower(addr.Hex()))
+}
+
+// ApproveTx implements core.UIClientAPI.
+func (r *RulesetUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	switch r.decide("ApproveTx", request) {
+	case approve:
+		if password := r.password(request.Transaction.From); password != "" {
+			return core.SignTxResponse{Transaction: request.Transaction, Approved: true, Password: password}, nil
+		}
+		log.Warn("No credentials stored for rule approved account", "address", request.Transaction.From)
+	case reject:
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: false}, nil
+	}
+	if r.next == nil {
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: false}, nil
+	}
+	return r.next.ApproveTx(request)
+}
+
+// ApproveSignData implements core.UIClientAPI.
+func (r *RulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	switch r.decide("ApproveSignData", request) {
+	case approve:
+		if password := r.password(request.Address); password != "" {
+			return core.SignDataResponse{Approved: true, Password: password}, nil
+		}
+		log.Warn("No credentials stored for rule approved account", "address", request.Address)
+	case reject:
+		return core.SignDataResponse{Approved: false}, nil
+	}
+	if r.next == nil {
+		return core.SignDataResponse{Approved: false}, nil
+	}
+	return r.next.ApproveSignData(request)
+}
+
+// ApproveListing implements core.UIClientAPI.
+func (r *RulesetUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	switch r.decide("ApproveListing", request) {
+	case approve:
+		return core.ListResponse{Accounts: request.Accounts}, nil
+	case reject:
+		return core.ListResponse{}, nil
+	}
+	if r.next == nil {
+		return core.ListResponse{}, nil
+	}
+	return r.next.ApproveListing(request)
+}
+
+// OnApprovedTx implements core.UIClientAPI, letting the rules record the signed
+// transaction before notifying the next UI.
+func (r *RulesetUI) OnApprovedTx(result core.SignTxResult) {
+	if _, err := r.execute("OnApprovedTx", result); err != nil && err != errNoRule {
+		log.Error("Failed to record approved transaction", "err", err)
+		r.ShowError(fmt.Sprintf("rule OnApprovedTx failed: %v", err))
+	}
+	if r.next != nil {
+		r.next.OnApprovedTx(result)
+	}
+}
+
+// ShowError implements core.UIClientAPI, forwarding to the next UI.
+func (r *RulesetUI) ShowError(message string) {
+	if r.next != nil {
+		r.next.ShowError(message)
+	}
+}
+
+// ShowInfo implements core.UIClientAPI, forwarding to the next UI.
+func (r *RulesetUI) ShowInfo(message string) {
+	if r.next != nil {
+		r.next.ShowInfo(message)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/fairblock/go-fairblock/common"
+	"github.com/fairblock/go-fairblock/common/hexutil"
+	"github.com/fairblock/go-fairblock/core/types"
+	"github.com/fairblock/go-fairblock/signer/core"
+	"github.com/fairblock/go-fairblock/signer/storage"
+)
+
+// Approves transfers to a single recipient, up to 1 ether per day.
+const capRules = `
+var limit = "1000000000000000000";
+
+function spentKey() {
+	return "spent-" + new Date().toISOString().slice(0, 10);
+}
+
+function ApproveTx(r) {
+	if (r.transaction.to != "0x000000000000000000000000000000000000dead") {
+		return "Reject";
+	}
+	var spent = big.add(storage.get(spentKey()), r.transaction.value);
+	if (big.cmp(spent, limit) > 0) {
+		return "Reject";
+	}
+	return "Approve";
+}
+
+function OnApprovedTx(res) {
+	storage.put(spentKey(), big.add(storage.get(spentKey()), res.tx.value));
+}
+`
+
+// recordingUI is a fallback UI rejecting everything, counting the requests it got.
+type recordingUI struct {
+	txs, signs, listings int
+}
+
+func (ui *recordingUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	ui.txs++
+	return core.SignTxResponse{Transaction: request.Transaction}, nil
+}
+
+func (ui *recordingUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	ui.signs++
+	return core.SignDataResponse{}, nil
+}
+
+func (ui *recordingUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	ui.listings++
+	return core.ListResponse{}, nil
+}
+
+func (ui *recordingUI) OnApprovedTx(result core.SignTxResult) {}
+func (ui *recordingUI) ShowError(message string)              {}
+func (ui *recordingUI) ShowInfo(message string)               {}
+
+var (
+	sender    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	recipient = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	stranger  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+)
+
+func newRuleUI(t *testing.T, rules string, next core.UIClientAPI) (*RulesetUI, storage.Storage) {
+	jsStorage := storage.NewEphemeralStorage()
+	credentials := storage.NewEphemeralStorage()
+	credentials.Put(strings.ToLower(sender.Hex()), "secret")
+
+	ui := NewRuleEvaluator(next, jsStorage, credentials)
+	if err := ui.Init(rules); err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	return ui, jsStorage
+}
+
+func txRequest(from, to common.Address, value int64) *core.SignTxRequest {
+	return &core.SignTxRequest{Transaction: core.SendTxArgs{
+		From:  from,
+		To:    &to,
+		Value: hexutil.Big(*big.NewInt(value)),
+	}}
+}
+
+// approveAndRecord runs a transaction through the rules, recording it as signed
+// if approved.
+func approveAndRecord(t *testing.T, ui *RulesetUI, request *core.SignTxRequest) bool {
+	resp, err := ui.ApproveTx(request)
+	if err != nil {
+		t.Fatalf("approval failed: %v", err)
+	}
+	if resp.Approved {
+		if resp.Password != "secret" {
+			t.Fatalf("password mismatch: have %q, want %q", resp.Password, "secret")
+		}
+		tx := types.NewTransaction(0, *request.Transaction.To, request.Transaction.Value.ToInt(), big.NewInt(21000), big.NewInt(1), nil)
+		ui.OnApprovedTx(core.SignTxResult{Tx: tx})
+	}
+	return resp.Approved
+}
+
+func TestRulesDailyCap(t *testing.T) {
+	next := new(recordingUI)
+	ui, _ := newRuleUI(t, capRules, next)
+
+	ether := int64(1000000000000000000)
+	if !approveAndRecord(t, ui, txRequest(sender, recipient, ether/2)) {
+		t.Fatalf("transfer within the cap rejected")
+	}
+	if !approveAndRecord(t, ui, txRequest(sender, recipient, ether/2)) {
+		t.Fatalf("transfer reaching the cap rejected")
+	}
+	if approveAndRecord(t, ui, txRequest(sender, recipient, 1)) {
+		t.Fatalf("transfer exceeding the cap approved")
+	}
+	if approveAndRecord(t, ui, txRequest(sender, stranger, 1)) {
+		t.Fatalf("transfer to non-whitelisted recipient approved")
+	}
+	if next.txs != 0 {
+		t.Fatalf("decided requests forwarded to the next UI: %d", next.txs)
+	}
+}
+
+func TestRulesDeferToNextUI(t *testing.T) {
+	next := new(recordingUI)
+	ui, _ := newRuleUI(t, `function ApproveTx(r) { if (r.transaction.to == "0x000000000000000000000000000000000000dead") { return "Approve"; } }`, next)
+
+	// Requests without matching rules are forwarded
+	if approveAndRecord(t, ui, txRequest(sender, stranger, 1)) {
+		t.Fatalf("undecided transaction approved")
+	}
+	ui.ApproveListing(&core.ListRequest{})
+	ui.ApproveSignData(&core.SignDataRequest{Address: sender})
+	if next.txs != 1 || next.listings != 1 || next.signs != 1 {
+		t.Fatalf("forwarded request count mismatch: txs %d, listings %d, signs %d", next.txs, next.listings, next.signs)
+	}
+	// Approved requests without stored credentials are forwarded too
+	if approveAndRecord(t, ui, txRequest(stranger, recipient, 1)) {
+		t.Fatalf("transaction without credentials approved")
+	}
+	if next.txs != 2 {
+		t.Fatalf("transaction without credentials not forwarded")
+	}
+}
+
+func TestRulesFailureRejects(t *testing.T) {
+	next := new(recordingUI)
+	ui, _ := newRuleUI(t, `function ApproveTx(r) { return big.add("foo", 1) == "1" ? "Approve" : "Defer"; }`, next)
+
+	if approveAndRecord(t, ui, txRequest(sender, recipient, 1)) {
+		t.Fatalf("transaction approved despite failing rule")
+	}
+	if next.txs != 0 {
+		t.Fatalf("failing rule deferred to the next UI")
+	}
+}
+
+func TestRulesInvalidSource(t *testing.T) {
+	ui := NewRuleEvaluator(nil, storage.NewEphemeralStorage(), nil)
+	if err := ui.Init("function ApproveTx(r) {"); err == nil {
+		t.Fatalf("invalid rules accepted")
+	}
+}
+
+func TestRulesTimeout(t *testing.T) {
+	next := new(recordingUI)
+	ui, _ := newRuleUI(t, `function ApproveTx(r) { while (true) {} }`, next)
+
+	if approveAndRecord(t, ui, txRequest(sender, recipient, 1)) {
+		t.Fatalf("transaction approved despite looping rule")
+	}
+	if next.txs != 0 {
+		t.Fatalf("looping rule deferred to the next UI")
+	}
+	if err := NewRuleEvaluator(nil, storage.NewEphemeralStorage(), nil).Init("while (true) {}"); err == nil {
+		t.Fatalf("looping rules accepted")
+	}
+}
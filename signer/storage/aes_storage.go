@@ -0,0 +1,154 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/fairblock/go-fairblock/log"
+)
+
+// storedCredential is a value encrypted with AES-GCM, as persisted on disk.
+type storedCredential struct {
+	Iv         []byte `json:"iv"` // Nonce used to encrypt the value
+	CipherText []byte `json:"c"`  // Encrypted value, including the GCM tag
+}
+
+// AESEncryptedStorage is a storage persisted as a JSON object into a single file,
+// with every value encrypted by AES-GCM under a master key. The key of each entry
+// is authenticated as additional data, so values can't be swapped between keys.
+type AESEncryptedStorage struct {
+	path string
+	key  []byte
+	data map[string]storedCredential
+	lock sync.RWMutex
+}
+
+// NewAESEncryptedStorage opens the encrypted storage at the given path, creating
+// it on first write if it doesn't exist yet. The key must be 16, 24 or 32 bytes
+// long, selecting AES-128, AES-192 or AES-256.
+func NewAESEncryptedStorage(path string, key []byte) (*AESEncryptedStorage, error) {
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	s := &AESEncryptedStorage{path: path, key: key, data: make(map[string]storedCredential)}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		return s, nil
+	case err != nil:
+		return nil, err
+	case info.Mode().Perm()&0077 != 0:
+		return nil, fmt.Errorf("storage file %s is accessible by others (mode %v), expected 0600", path, info.Mode().Perm())
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) > 0 {
+		if err := json.Unmarshal(blob, &s.data); err != nil {
+			return nil, fmt.Errorf("invalid storage file %s: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Put implements Storage, encrypting the value and flushing the data to disk.
+func (s *AESEncryptedStorage) Put(key, value string) error {
+	iv, ciphertext, err := encrypt(s.key, []byte(value), []byte(key))
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prev, existed := s.data[key]
+	s.data[key] = storedCredential{Iv: iv, CipherText: ciphertext}
+
+	blob, err := json.MarshalIndent(s.data, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(s.path, blob, 0600)
+	}
+	if err != nil {
+		// Roll back so memory and disk don't diverge
+		if existed {
+			s.data[key] = prev
+		} else {
+			delete(s.data, key)
+		}
+		return fmt.Errorf("failed to persist signer storage: %v", err)
+	}
+	return nil
+}
+
+// Get implements Storage, decrypting the value stored under the key. Values that
+// fail to decrypt, e.g. because of a wrong master key, are treated as missing.
+func (s *AESEncryptedStorage) Get(key string) string {
+	s.lock.RLock()
+	cred, ok := s.data[key]
+	s.lock.RUnlock()
+
+	if !ok {
+		return ""
+	}
+	value, err := decrypt(s.key, cred.Iv, cred.CipherText, []byte(key))
+	if err != nil {
+		log.Warn("Failed to decrypt stored value", "key", key, "err", err)
+		return ""
+	}
+	return string(value)
+}
+
+// encrypt seals the plaintext with AES-GCM under a fresh random nonce,
+// authenticating the additional data along with it.
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aesgcm.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// decrypt opens an AES-GCM sealed ciphertext, verifying the additional data.
+func decrypt(key []byte, nonce []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesgcm.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAESEncryptedStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials.json")
+	key := bytes.Repeat([]byte{0x01}, 32)
+
+	store, err := NewAESEncryptedStorage(path, key)
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	if err := store.Put("alice", "secret"); err != nil {
+		t.Fatalf("failed to store value: %v", err)
+	}
+	// The value must not be persisted in plaintext
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(blob, []byte("secret")) {
+		t.Fatalf("plaintext value found on disk: %s", blob)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("storage file mode mismatch: have %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+	// Reopening with the right key recovers the value, a wrong key doesn't
+	if store, err = NewAESEncryptedStorage(path, key); err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	if value := store.Get("alice"); value != "secret" {
+		t.Fatalf("value mismatch: have %q, want %q", value, "secret")
+	}
+	wrong, err := NewAESEncryptedStorage(path, bytes.Repeat([]byte{0x02}, 32))
+	if err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	if value := wrong.Get("alice"); value != "" {
+		t.Fatalf("value decrypted with wrong key: %q", value)
+	}
+	// Values moved to another key must fail authentication
+	var data map[string]storedCredential
+	if err := json.Unmarshal(blob, &data); err != nil {
+		t.Fatal(err)
+	}
+	data["bob"] = data["alice"]
+	if blob, err = json.Marshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = NewAESEncryptedStorage(path, key); err != nil {
+		t.Fatalf("failed to reopen storage: %v", err)
+	}
+	if value := store.Get("bob"); value != "" {
+		t.Fatalf("swapped value decrypted: %q", value)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The go-fairblock Authors
+// This file is part of the go-fairblock library.
+//
+// The go-fairblock library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-fairblock library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-fairblock library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package storage implements the key-value stores used by the signer to persist
+// rule engine state and account credentials.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Storage is a simple string key-value store.
+type Storage interface {
+	// Put stores a value under the given key, overwriting any previous value.
+	Put(key, value string) error
+
+	// Get returns the value stored under the given key, or the empty string if
+	// no such value exists.
+	Get(key string) string
+}
+
+// EphemeralStorage is an in-memory storage that does not persist across restarts.
+type EphemeralStorage struct {
+	data map[string]string
+	lock sync.RWMutex
+}
+
+// NewEphemeralStorage creates an empty in-memory storage.
+func NewEphemeralStorage() *EphemeralStorage {
+	return &EphemeralStorage{data: make(map[string]string)}
+}
+
+// Put implements Storage, storing the value in memory.
+func (s *EphemeralStorage) Put(key, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data[key] = value
+	return nil
+}
+
+// Get implements Storage, retrieving the value from memory.
+func (s *EphemeralStorage) Get(key string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.data[key]
+}
+
+// JSONStorage is a storage persisted as a JSON object into a single file, which
+// is rewritten on every modification.
+type JSONStorage struct {
+	path string
+	data map[string]string
+	lock sync.RWMutex
+}
+
+// NewJSONStorage opens the JSON storage at the given path, creating it on first
+// write if it doesn't exist yet. As the file may contain credentials, it's
+// rejected if it's accessible by anyone else than its owner.
+func NewJSONStorage(path string) (*JSONStorage, error) {
+	s := &JSONStorage{path: path, data: make(map[string]string)}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		return s, nil
+	case err != nil:
+		return nil, err
+	case info.Mode().Perm()&0077 != 0:
+		return nil, fmt.Errorf("storage file %s is accessible by others (mode %v), expected 0600", path, info.Mode().Perm())
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) > 0 {
+		if err := json.Unmarshal(blob, &s.data); err != nil {
+			return nil, fmt.Errorf("invalid storage file %s: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Put implements Storage, storing the value and flushing the data to disk.
+func (s *JSONStorage) Put(key, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prev, existed := s.data[key]
+	s.data[key] = value
+
+	blob, err := json.MarshalIndent(s.data, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(s.path, blob, 0600)
+	}
+	if err != nil {
+		// Roll back so memory and disk don't diverge
+		if existed {
+			s.data[key] = prev
+		} else {
+			delete(s.data, key)
+		}
+		return fmt.Errorf("failed to persist signer storage: %v", err)
+	}
+	return nil
+}
+
+// Get implements Storage, retrieving the value from the in-memory copy.
+func (s *JSONStorage) Get(key string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.data[key]
+}